@@ -4,14 +4,23 @@ import (
 	"context"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/WalterPaes/go-rest-api-crud/configs"
+	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
 	"github.com/WalterPaes/go-rest-api-crud/internal/handlers"
+	"github.com/WalterPaes/go-rest-api-crud/internal/handlers/middlewares"
 	"github.com/WalterPaes/go-rest-api-crud/internal/repositories"
 	"github.com/WalterPaes/go-rest-api-crud/internal/services"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/hasher"
 	"github.com/WalterPaes/go-rest-api-crud/pkg/jwt"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/lockout"
 	"github.com/WalterPaes/go-rest-api-crud/pkg/logger"
 	"github.com/WalterPaes/go-rest-api-crud/pkg/mongodb"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/notifier"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/oidc"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/signer"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/validation"
 	"github.com/gin-gonic/gin"
 
 	docs "github.com/WalterPaes/go-rest-api-crud/docs"
@@ -36,11 +45,32 @@ func main() {
 	logger.Init(cfg.LogLevel, cfg.LogOutput)
 	logger.Info("Start Application")
 
-	jwtAuth := jwt.NewJwtAuth(cfg.JwtSecret, cfg.JwtExpTime)
-
 	dbClient := mongodb.NewMongoDBClient(context.Background(), cfg.MongoDBTimeout, cfg.MongoDBUri)
 
+	revokedTokenRepository := repositories.NewRevokedTokenRepository(dbClient, cfg.MongoDBDatabase, cfg.MongoDBRevokedTokenCollection)
+	if err := revokedTokenRepository.EnsureIndexes(context.Background()); err != nil {
+		log.Fatal(err)
+	}
+
+	keySet, err := jwt.LoadKeySet(jwt.KeySetConfig{
+		SigningMethod:  cfg.JwtSigningMethod,
+		Secret:         cfg.JwtSecret,
+		KeyID:          cfg.JwtKeyID,
+		PrivateKeyFile: cfg.JwtPrivateKeyFile,
+		PublicKeyFiles: cfg.JwtPublicKeyFiles,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	jwtAuth := jwt.NewJwtAuth(keySet, cfg.JwtExpTime, revokedTokenRepository)
+
 	r := gin.Default()
+	if err := r.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Fatal(err)
+	}
+	r.Use(middlewares.ClientInfo)
+
 	r.GET("/ping", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{
 			"message": "pong",
@@ -48,19 +78,233 @@ func main() {
 	})
 
 	userRepository := repositories.NewUserRepository(dbClient, cfg.MongoDBDatabase, cfg.MongoDBCollection)
-	userService := services.NewUserService(userRepository)
-	userHandler := handlers.NewUserHandler(userService)
+	if err := userRepository.EnsureIndexes(context.Background()); err != nil {
+		log.Fatal(err)
+	}
 
-	loginService := services.NewLoginService(userRepository, jwtAuth)
+	// the deleted users are kept for the retention so they can be restored
+	if cfg.UserPurgeInterval > 0 {
+		go services.NewUserPurger(userRepository, cfg.UserPurgeRetention).
+			Run(context.Background(), time.Minute*time.Duration(cfg.UserPurgeInterval))
+	}
+
+	refreshTokenRepository := repositories.NewRefreshTokenRepository(dbClient, cfg.MongoDBDatabase, cfg.MongoDBRefreshTokenCollection)
+	if err := refreshTokenRepository.EnsureIndexes(context.Background()); err != nil {
+		log.Fatal(err)
+	}
+
+	sessionRepository := repositories.NewSessionRepository(dbClient, cfg.MongoDBDatabase, cfg.MongoDBSessionCollection)
+	if err := sessionRepository.EnsureIndexes(context.Background()); err != nil {
+		log.Fatal(err)
+	}
+
+	userNotifier, err := notifier.New(cfg.NotifierType, cfg.NotifierFilePath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	linkSigner, err := signer.New(cfg.SigningSecret)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	passwordHasher, err := hasher.New(hasher.Config{
+		Algorithm:  cfg.PasswordHashAlgorithm,
+		BcryptCost: cfg.PasswordBcryptCost,
+		Argon2id: hasher.Argon2idParams{
+			Memory:      uint32(cfg.PasswordArgon2Memory),
+			Iterations:  uint32(cfg.PasswordArgon2Iterations),
+			Parallelism: uint8(cfg.PasswordArgon2Parallelism),
+			SaltLength:  hasher.DefaultArgon2idParams.SaltLength,
+			KeyLength:   hasher.DefaultArgon2idParams.KeyLength,
+		},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	passwordPolicy := &validation.PasswordPolicy{
+		MinLength:     cfg.PasswordMinLength,
+		MaxLength:     cfg.PasswordMaxLength,
+		RequireUpper:  cfg.PasswordRequireUpper,
+		RequireLower:  cfg.PasswordRequireLower,
+		RequireDigit:  cfg.PasswordRequireDigit,
+		RequireSymbol: cfg.PasswordRequireSymbol,
+	}
+	if cfg.PasswordDenyListFile != "" {
+		if err := passwordPolicy.LoadDenyList(cfg.PasswordDenyListFile); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	emailVerificationService := services.NewEmailVerificationService(
+		userRepository,
+		userNotifier,
+		linkSigner,
+		cfg.EmailVerificationTokenExpTime,
+		cfg.EmailVerificationURL,
+	)
+	emailVerificationHandler := handlers.NewEmailVerificationHandler(emailVerificationService)
+
+	userService := services.NewUserService(userRepository, refreshTokenRepository, jwtAuth, emailVerificationService, passwordHasher, passwordPolicy, cfg.PasswordHistorySize, linkSigner)
+	userHandler := handlers.NewUserHandler(userService, cfg.UsersMaxPerPage)
+
+	passwordResetTokenRepository := repositories.NewPasswordResetTokenRepository(dbClient, cfg.MongoDBDatabase, cfg.MongoDBPasswordResetTokenCollection)
+	if err := passwordResetTokenRepository.EnsureIndexes(context.Background()); err != nil {
+		log.Fatal(err)
+	}
+
+	passwordService := services.NewPasswordService(
+		userRepository,
+		passwordResetTokenRepository,
+		refreshTokenRepository,
+		jwtAuth,
+		userNotifier,
+		passwordHasher,
+		passwordPolicy,
+		cfg.PasswordHistorySize,
+		cfg.PasswordResetTokenExpTime,
+		cfg.PasswordResetURL,
+	)
+	passwordHandler := handlers.NewPasswordHandler(passwordService)
+
+	mfaService := services.NewMFAService(userRepository, linkSigner, cfg.MFAIssuer, cfg.MFAChallengeExpTime)
+	mfaHandler := handlers.NewMFAHandler(mfaService)
+
+	var loginAttemptStore lockout.Store
+	switch cfg.LoginAttemptStore {
+	case "memory":
+		loginAttemptStore = lockout.NewMemoryStore()
+	case "mongo":
+		loginAttemptRepository := repositories.NewLoginAttemptRepository(dbClient, cfg.MongoDBDatabase, cfg.MongoDBLoginAttemptCollection)
+		if err := loginAttemptRepository.EnsureIndexes(context.Background()); err != nil {
+			log.Fatal(err)
+		}
+		loginAttemptStore = loginAttemptRepository
+	default:
+		log.Fatalf("unknown login attempt store: %q", cfg.LoginAttemptStore)
+	}
+
+	loginPolicy := lockout.Policy{
+		FreeAttempts:    cfg.LoginFreeAttempts,
+		MaxFailures:     cfg.LoginMaxFailures,
+		BaseDelay:       time.Second * time.Duration(cfg.LoginBackoffBase),
+		LockoutDuration: time.Minute * time.Duration(cfg.LoginLockoutDuration),
+	}
+	loginIPPolicy := loginPolicy
+	loginIPPolicy.FreeAttempts = cfg.LoginIPFreeAttempts
+	loginIPPolicy.MaxFailures = cfg.LoginIPMaxFailures
+
+	loginService := services.NewLoginService(
+		userRepository,
+		refreshTokenRepository,
+		sessionRepository,
+		jwtAuth,
+		mfaService,
+		lockout.NewLimiter(loginAttemptStore, "email:", loginPolicy),
+		lockout.NewLimiter(loginAttemptStore, "ip:", loginIPPolicy),
+		passwordHasher,
+		cfg.RefreshTokenExpTime,
+		cfg.EmailVerificationRequired,
+	)
 	loginHandler := handlers.NewLoginHandler(loginService)
 
+	sessionService := services.NewSessionService(sessionRepository, refreshTokenRepository, jwtAuth)
+	sessionHandler := handlers.NewSessionHandler(sessionService)
+
+	personalAccessTokenRepository := repositories.NewPersonalAccessTokenRepository(dbClient, cfg.MongoDBDatabase, cfg.MongoDBPersonalAccessTokenCollection)
+	if err := personalAccessTokenRepository.EnsureIndexes(context.Background()); err != nil {
+		log.Fatal(err)
+	}
+
+	personalAccessTokenService := services.NewPersonalAccessTokenService(personalAccessTokenRepository, userRepository, cfg.PersonalAccessTokenMaxExpTime)
+	personalAccessTokenHandler := handlers.NewPersonalAccessTokenHandler(personalAccessTokenService)
+
+	// authenticate also accepts the personal access tokens, the routes managing the
+	// credentials of the user keep accepting only the jwts
+	authenticate := middlewares.Authenticate(jwtAuth.VerifyTokenMiddleware, personalAccessTokenService)
+
+	oauthClientRepository := repositories.NewOAuthClientRepository(dbClient, cfg.MongoDBDatabase, cfg.MongoDBOAuthClientCollection)
+	if err := oauthClientRepository.EnsureIndexes(context.Background()); err != nil {
+		log.Fatal(err)
+	}
+
+	oauthService := services.NewOAuthService(oauthClientRepository, jwtAuth, cfg.OAuthAccessTokenExpTime)
+	oauthHandler := handlers.NewOAuthHandler(oauthService)
+
+	impersonationService := services.NewImpersonationService(userRepository, jwtAuth, cfg.ImpersonationTokenExpTime)
+	impersonationHandler := handlers.NewImpersonationHandler(impersonationService)
+
+	if cfg.OIDCIssuerURL != "" {
+		oidcProvider, err := oidc.Discover(context.Background(), oidc.Config{
+			Issuer:       cfg.OIDCIssuerURL,
+			ClientID:     cfg.OIDCClientID,
+			ClientSecret: cfg.OIDCClientSecret,
+			RedirectURL:  cfg.OIDCRedirectURL,
+			Scopes:       cfg.OIDCScopes,
+		}, &http.Client{Timeout: 10 * time.Second})
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		oidcService := services.NewOIDCService(oidcProvider, userRepository, loginService, linkSigner, cfg.OIDCFlowExpTime)
+		oidcHandler := handlers.NewOIDCHandler(oidcService)
+
+		r.GET("/auth/oidc/login", oidcHandler.Login)
+		r.GET("/auth/oidc/callback", oidcHandler.Callback)
+	}
+
+	r.GET("/.well-known/jwks.json", jwtAuth.JWKSHandler)
+
 	r.POST("/login", loginHandler.Login)
+	r.POST("/login/mfa", loginHandler.LoginMFA)
+	r.POST("/token/refresh", loginHandler.RefreshToken)
+	r.POST("/logout", jwtAuth.VerifyTokenMiddleware, loginHandler.Logout)
+
+	r.POST("/oauth/token", oauthHandler.Token)
+
+	r.POST("/password/forgot", passwordHandler.ForgotPassword)
+	r.POST("/password/reset", passwordHandler.ResetPassword)
+
+	adminOnly := middlewares.RequireRole(domain.RoleAdmin)
+	ownerOrAdmin := middlewares.RequireOwnerOrRole("id", domain.RoleAdmin)
+
+	// the OAuth clients have no roles, the scopes of their tokens authorize them
+	adminOrClient := middlewares.AllowClients(adminOnly)
+	ownerAdminOrClient := middlewares.AllowClients(ownerOrAdmin)
+	readScope := middlewares.RequireScope(domain.ScopeUsersRead)
+	writeScope := middlewares.RequireScope(domain.ScopeUsersWrite)
+
+	// an admin impersonating an user can't take over or delete the account
+	notImpersonated := middlewares.RejectImpersonation
+
+	r.GET("/oauth/clients", jwtAuth.VerifyTokenMiddleware, adminOnly, oauthHandler.ListClients)
+	r.POST("/oauth/clients", jwtAuth.VerifyTokenMiddleware, adminOnly, oauthHandler.RegisterClient)
+	r.DELETE("/oauth/clients/:client_id", jwtAuth.VerifyTokenMiddleware, adminOnly, oauthHandler.DeleteClient)
+
+	r.GET("/users", authenticate, readScope, adminOrClient, userHandler.ListAll)
+	r.POST("/users", authenticate, writeScope, adminOrClient, userHandler.CreateUser)
+	r.POST("/users/verify-email", emailVerificationHandler.VerifyEmail)
+	r.GET("/users/me", authenticate, readScope, userHandler.GetMe)
+	r.PATCH("/users/me", authenticate, writeScope, userHandler.PatchMe)
+	r.DELETE("/users/me", authenticate, writeScope, notImpersonated, userHandler.DeleteMe)
+	r.POST("/users/me/password", jwtAuth.VerifyTokenMiddleware, notImpersonated, userHandler.ChangePassword)
+	r.POST("/users/me/mfa", jwtAuth.VerifyTokenMiddleware, notImpersonated, mfaHandler.Enroll)
+	r.POST("/users/me/mfa/confirm", jwtAuth.VerifyTokenMiddleware, notImpersonated, mfaHandler.Confirm)
+	r.GET("/users/me/tokens", jwtAuth.VerifyTokenMiddleware, personalAccessTokenHandler.ListTokens)
+	r.POST("/users/me/tokens", jwtAuth.VerifyTokenMiddleware, notImpersonated, personalAccessTokenHandler.CreateToken)
+	r.DELETE("/users/me/tokens/:token_id", jwtAuth.VerifyTokenMiddleware, notImpersonated, personalAccessTokenHandler.RevokeToken)
+	r.GET("/users/me/sessions", jwtAuth.VerifyTokenMiddleware, sessionHandler.ListSessions)
+	r.DELETE("/users/me/sessions/:session_id", jwtAuth.VerifyTokenMiddleware, notImpersonated, sessionHandler.RevokeSession)
+	r.GET("/users/:id", authenticate, readScope, ownerAdminOrClient, userHandler.GetUserById)
+	r.PUT("/users/:id", authenticate, writeScope, ownerAdminOrClient, userHandler.UpdateUser)
+	r.DELETE("/users/:id", authenticate, writeScope, ownerAdminOrClient, notImpersonated, userHandler.DeleteUser)
+	r.POST("/users/:id/unlock", authenticate, writeScope, adminOnly, loginHandler.UnlockUser)
+	r.POST("/users/:id/restore", authenticate, writeScope, adminOnly, userHandler.RestoreUser)
 
-	r.GET("/users", jwtAuth.VerifyTokenMiddleware, userHandler.ListAll)
-	r.POST("/users", jwtAuth.VerifyTokenMiddleware, userHandler.CreateUser)
-	r.GET("/users/:id", jwtAuth.VerifyTokenMiddleware, userHandler.GetUserById)
-	r.PUT("/users/:id", jwtAuth.VerifyTokenMiddleware, userHandler.UpdateUser)
-	r.DELETE("/users/:id", jwtAuth.VerifyTokenMiddleware, userHandler.DeleteUser)
+	r.POST("/admin/users/:id/suspend", authenticate, writeScope, adminOnly, userHandler.SuspendUser)
+	r.POST("/admin/users/:id/reactivate", authenticate, writeScope, adminOnly, userHandler.ReactivateUser)
+	r.POST("/admin/users/:id/impersonate", jwtAuth.VerifyTokenMiddleware, notImpersonated, adminOnly, impersonationHandler.Impersonate)
 
 	docs.SwaggerInfo.BasePath = "/"
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerfiles.Handler))
@@ -6,6 +6,7 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
@@ -27,6 +28,76 @@ type Configs struct {
 	MongoDBTimeout    int
 	JwtSecret         string
 	JwtExpTime        int
+
+	MongoDBRefreshTokenCollection string
+	MongoDBRevokedTokenCollection string
+	MongoDBSessionCollection      string
+	RefreshTokenExpTime           int
+
+	JwtSigningMethod  string
+	JwtKeyID          string
+	JwtPrivateKeyFile string
+	JwtPublicKeyFiles string
+
+	MongoDBPasswordResetTokenCollection string
+	PasswordResetTokenExpTime           int
+	PasswordResetURL                    string
+
+	NotifierType     string
+	NotifierFilePath string
+
+	SigningSecret                 string
+	EmailVerificationTokenExpTime int
+	EmailVerificationURL          string
+	EmailVerificationRequired     bool
+
+	MFAIssuer           string
+	MFAChallengeExpTime int
+
+	MongoDBLoginAttemptCollection string
+	LoginAttemptStore             string
+	LoginFreeAttempts             int
+	LoginMaxFailures              int
+	LoginIPFreeAttempts           int
+	LoginIPMaxFailures            int
+	LoginBackoffBase              int
+	LoginLockoutDuration          int
+	TrustedProxies                []string
+
+	PasswordHashAlgorithm     string
+	PasswordBcryptCost        int
+	PasswordArgon2Memory      int
+	PasswordArgon2Iterations  int
+	PasswordArgon2Parallelism int
+
+	PasswordMinLength     int
+	PasswordMaxLength     int
+	PasswordRequireUpper  bool
+	PasswordRequireLower  bool
+	PasswordRequireDigit  bool
+	PasswordRequireSymbol bool
+	PasswordDenyListFile  string
+	PasswordHistorySize   int
+
+	MongoDBPersonalAccessTokenCollection string
+	PersonalAccessTokenMaxExpTime        int
+
+	MongoDBOAuthClientCollection string
+	OAuthAccessTokenExpTime      int
+
+	OIDCIssuerURL    string
+	OIDCClientID     string
+	OIDCClientSecret string
+	OIDCRedirectURL  string
+	OIDCScopes       []string
+	OIDCFlowExpTime  int
+
+	ImpersonationTokenExpTime int
+
+	UserPurgeRetention int
+	UserPurgeInterval  int
+
+	UsersMaxPerPage int
 }
 
 func Load(filenames ...string) (*Configs, error) {
@@ -48,6 +119,151 @@ func Load(filenames ...string) (*Configs, error) {
 		return nil, err
 	}
 
+	refreshTokenExpTime, err := parseEnvToInt("REFRESH_TOKEN_EXP_TIME")
+	if err != nil {
+		return nil, err
+	}
+
+	passwordResetTokenExpTime, err := parseEnvToInt("PASSWORD_RESET_TOKEN_EXP_TIME_IN_MINUTES")
+	if err != nil {
+		return nil, err
+	}
+
+	emailVerificationTokenExpTime, err := parseEnvToInt("EMAIL_VERIFICATION_TOKEN_EXP_TIME_IN_HOURS")
+	if err != nil {
+		return nil, err
+	}
+
+	emailVerificationRequired, err := parseEnvToBool("EMAIL_VERIFICATION_REQUIRED")
+	if err != nil {
+		return nil, err
+	}
+
+	mfaChallengeExpTime, err := parseEnvToInt("MFA_CHALLENGE_EXP_TIME_IN_MINUTES")
+	if err != nil {
+		return nil, err
+	}
+
+	loginFreeAttempts, err := parseEnvToInt("LOGIN_FREE_ATTEMPTS")
+	if err != nil {
+		return nil, err
+	}
+
+	loginMaxFailures, err := parseEnvToInt("LOGIN_MAX_FAILURES")
+	if err != nil {
+		return nil, err
+	}
+
+	loginIPFreeAttempts, err := parseEnvToInt("LOGIN_IP_FREE_ATTEMPTS")
+	if err != nil {
+		return nil, err
+	}
+
+	loginIPMaxFailures, err := parseEnvToInt("LOGIN_IP_MAX_FAILURES")
+	if err != nil {
+		return nil, err
+	}
+
+	loginBackoffBase, err := parseEnvToInt("LOGIN_BACKOFF_BASE_IN_SECONDS")
+	if err != nil {
+		return nil, err
+	}
+
+	loginLockoutDuration, err := parseEnvToInt("LOGIN_LOCKOUT_IN_MINUTES")
+	if err != nil {
+		return nil, err
+	}
+
+	passwordBcryptCost, err := parseEnvToInt("PASSWORD_BCRYPT_COST")
+	if err != nil {
+		return nil, err
+	}
+
+	passwordArgon2Memory, err := parseEnvToInt("PASSWORD_ARGON2_MEMORY_IN_KIB")
+	if err != nil {
+		return nil, err
+	}
+
+	passwordArgon2Iterations, err := parseEnvToInt("PASSWORD_ARGON2_ITERATIONS")
+	if err != nil {
+		return nil, err
+	}
+
+	passwordArgon2Parallelism, err := parseEnvToInt("PASSWORD_ARGON2_PARALLELISM")
+	if err != nil {
+		return nil, err
+	}
+
+	passwordMinLength, err := parseEnvToInt("PASSWORD_MIN_LENGTH")
+	if err != nil {
+		return nil, err
+	}
+
+	passwordMaxLength, err := parseEnvToInt("PASSWORD_MAX_LENGTH")
+	if err != nil {
+		return nil, err
+	}
+
+	passwordRequireUpper, err := parseEnvToBool("PASSWORD_REQUIRE_UPPER")
+	if err != nil {
+		return nil, err
+	}
+
+	passwordRequireLower, err := parseEnvToBool("PASSWORD_REQUIRE_LOWER")
+	if err != nil {
+		return nil, err
+	}
+
+	passwordRequireDigit, err := parseEnvToBool("PASSWORD_REQUIRE_DIGIT")
+	if err != nil {
+		return nil, err
+	}
+
+	passwordRequireSymbol, err := parseEnvToBool("PASSWORD_REQUIRE_SYMBOL")
+	if err != nil {
+		return nil, err
+	}
+
+	passwordHistorySize, err := parseEnvToInt("PASSWORD_HISTORY_SIZE")
+	if err != nil {
+		return nil, err
+	}
+
+	personalAccessTokenMaxExpTime, err := parseEnvToInt("PERSONAL_ACCESS_TOKEN_MAX_EXP_TIME_IN_DAYS")
+	if err != nil {
+		return nil, err
+	}
+
+	oauthAccessTokenExpTime, err := parseEnvToInt("OAUTH_ACCESS_TOKEN_EXP_TIME_IN_MINUTES")
+	if err != nil {
+		return nil, err
+	}
+
+	oidcFlowExpTime, err := parseEnvToInt("OIDC_FLOW_EXP_TIME_IN_MINUTES")
+	if err != nil {
+		return nil, err
+	}
+
+	impersonationTokenExpTime, err := parseEnvToInt("IMPERSONATION_TOKEN_EXP_TIME_IN_MINUTES")
+	if err != nil {
+		return nil, err
+	}
+
+	userPurgeRetention, err := parseEnvToInt("USER_PURGE_RETENTION_IN_DAYS")
+	if err != nil {
+		return nil, err
+	}
+
+	userPurgeInterval, err := parseEnvToInt("USER_PURGE_INTERVAL_IN_MINUTES")
+	if err != nil {
+		return nil, err
+	}
+
+	usersMaxPerPage, err := parseEnvToInt("USERS_MAX_PER_PAGE")
+	if err != nil {
+		return nil, err
+	}
+
 	return &Configs{
 		ApiPort:           os.Getenv("API_PORT"),
 		LogOutput:         os.Getenv("LOG_OUTPUT"),
@@ -58,6 +274,76 @@ func Load(filenames ...string) (*Configs, error) {
 		MongoDBTimeout:    mongoDbTimeout,
 		JwtSecret:         os.Getenv("JWT_SECRET"),
 		JwtExpTime:        expTime,
+
+		MongoDBRefreshTokenCollection: os.Getenv("MONGODB_REFRESH_TOKEN_COLLECTION"),
+		MongoDBRevokedTokenCollection: os.Getenv("MONGODB_REVOKED_TOKEN_COLLECTION"),
+		MongoDBSessionCollection:      os.Getenv("MONGODB_SESSION_COLLECTION"),
+		RefreshTokenExpTime:           refreshTokenExpTime,
+
+		JwtSigningMethod:  os.Getenv("JWT_SIGNING_METHOD"),
+		JwtKeyID:          os.Getenv("JWT_KEY_ID"),
+		JwtPrivateKeyFile: os.Getenv("JWT_PRIVATE_KEY_FILE"),
+		JwtPublicKeyFiles: os.Getenv("JWT_PUBLIC_KEY_FILES"),
+
+		MongoDBPasswordResetTokenCollection: os.Getenv("MONGODB_PASSWORD_RESET_TOKEN_COLLECTION"),
+		PasswordResetTokenExpTime:           passwordResetTokenExpTime,
+		PasswordResetURL:                    os.Getenv("PASSWORD_RESET_URL"),
+
+		NotifierType:     os.Getenv("NOTIFIER_TYPE"),
+		NotifierFilePath: os.Getenv("NOTIFIER_FILE_PATH"),
+
+		SigningSecret:                 os.Getenv("SIGNING_SECRET"),
+		EmailVerificationTokenExpTime: emailVerificationTokenExpTime,
+		EmailVerificationURL:          os.Getenv("EMAIL_VERIFICATION_URL"),
+		EmailVerificationRequired:     emailVerificationRequired,
+
+		MFAIssuer:           os.Getenv("MFA_ISSUER"),
+		MFAChallengeExpTime: mfaChallengeExpTime,
+
+		MongoDBLoginAttemptCollection: os.Getenv("MONGODB_LOGIN_ATTEMPT_COLLECTION"),
+		LoginAttemptStore:             os.Getenv("LOGIN_ATTEMPT_STORE"),
+		LoginFreeAttempts:             loginFreeAttempts,
+		LoginMaxFailures:              loginMaxFailures,
+		LoginIPFreeAttempts:           loginIPFreeAttempts,
+		LoginIPMaxFailures:            loginIPMaxFailures,
+		LoginBackoffBase:              loginBackoffBase,
+		LoginLockoutDuration:          loginLockoutDuration,
+		TrustedProxies:                parseEnvToList("TRUSTED_PROXIES"),
+
+		PasswordHashAlgorithm:     os.Getenv("PASSWORD_HASH_ALGORITHM"),
+		PasswordBcryptCost:        passwordBcryptCost,
+		PasswordArgon2Memory:      passwordArgon2Memory,
+		PasswordArgon2Iterations:  passwordArgon2Iterations,
+		PasswordArgon2Parallelism: passwordArgon2Parallelism,
+
+		PasswordMinLength:     passwordMinLength,
+		PasswordMaxLength:     passwordMaxLength,
+		PasswordRequireUpper:  passwordRequireUpper,
+		PasswordRequireLower:  passwordRequireLower,
+		PasswordRequireDigit:  passwordRequireDigit,
+		PasswordRequireSymbol: passwordRequireSymbol,
+		PasswordDenyListFile:  os.Getenv("PASSWORD_DENY_LIST_FILE"),
+		PasswordHistorySize:   passwordHistorySize,
+
+		MongoDBPersonalAccessTokenCollection: os.Getenv("MONGODB_PERSONAL_ACCESS_TOKEN_COLLECTION"),
+		PersonalAccessTokenMaxExpTime:        personalAccessTokenMaxExpTime,
+
+		MongoDBOAuthClientCollection: os.Getenv("MONGODB_OAUTH_CLIENT_COLLECTION"),
+		OAuthAccessTokenExpTime:      oauthAccessTokenExpTime,
+
+		OIDCIssuerURL:    os.Getenv("OIDC_ISSUER_URL"),
+		OIDCClientID:     os.Getenv("OIDC_CLIENT_ID"),
+		OIDCClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+		OIDCRedirectURL:  os.Getenv("OIDC_REDIRECT_URL"),
+		OIDCScopes:       parseEnvToList("OIDC_SCOPES"),
+		OIDCFlowExpTime:  oidcFlowExpTime,
+
+		ImpersonationTokenExpTime: impersonationTokenExpTime,
+
+		UserPurgeRetention: userPurgeRetention,
+		UserPurgeInterval:  userPurgeInterval,
+
+		UsersMaxPerPage: usersMaxPerPage,
 	}, nil
 }
 
@@ -69,3 +355,22 @@ func parseEnvToInt(key string) (int, error) {
 	}
 	return value, nil
 }
+
+func parseEnvToBool(key string) (bool, error) {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return value, fmt.Errorf(errToParseEnv, key, err.Error())
+	}
+	return value, nil
+}
+
+// parseEnvToList splits a comma separated env, an empty env is a nil list.
+func parseEnvToList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
@@ -32,6 +32,72 @@ func TestLoad(t *testing.T) {
 				MongoDBTimeout:    10,
 				JwtSecret:         "secret",
 				JwtExpTime:        24,
+
+				MongoDBRefreshTokenCollection: "refresh_tokens",
+				MongoDBRevokedTokenCollection: "revoked_tokens",
+				MongoDBSessionCollection:      "sessions",
+				RefreshTokenExpTime:           720,
+
+				JwtSigningMethod: "HS256",
+
+				MongoDBPasswordResetTokenCollection: "password_reset_tokens",
+				PasswordResetTokenExpTime:           30,
+				PasswordResetURL:                    "http://localhost:3000/reset-password?token=",
+
+				NotifierType:     "log",
+				NotifierFilePath: "notifications.log",
+
+				SigningSecret:                 "secret",
+				EmailVerificationTokenExpTime: 48,
+				EmailVerificationURL:          "http://localhost:3000/verify-email?token=",
+				EmailVerificationRequired:     false,
+
+				MFAIssuer:           "go-rest-api-crud",
+				MFAChallengeExpTime: 5,
+
+				MongoDBLoginAttemptCollection: "login_attempts",
+				LoginAttemptStore:             "mongo",
+				LoginFreeAttempts:             3,
+				LoginMaxFailures:              10,
+				LoginIPFreeAttempts:           20,
+				LoginIPMaxFailures:            100,
+				LoginBackoffBase:              1,
+				LoginLockoutDuration:          15,
+
+				PasswordHashAlgorithm:     "argon2id",
+				PasswordBcryptCost:        10,
+				PasswordArgon2Memory:      65536,
+				PasswordArgon2Iterations:  3,
+				PasswordArgon2Parallelism: 4,
+
+				PasswordMinLength:     8,
+				PasswordMaxLength:     64,
+				PasswordRequireUpper:  false,
+				PasswordRequireLower:  false,
+				PasswordRequireDigit:  false,
+				PasswordRequireSymbol: true,
+				PasswordDenyListFile:  "configs/common_passwords.txt",
+				PasswordHistorySize:   5,
+
+				MongoDBPersonalAccessTokenCollection: "personal_access_tokens",
+				PersonalAccessTokenMaxExpTime:        365,
+
+				MongoDBOAuthClientCollection: "oauth_clients",
+				OAuthAccessTokenExpTime:      60,
+
+				OIDCIssuerURL:    "",
+				OIDCClientID:     "",
+				OIDCClientSecret: "",
+				OIDCRedirectURL:  "http://localhost:8080/auth/oidc/callback",
+				OIDCScopes:       []string{"openid", "email", "profile"},
+				OIDCFlowExpTime:  10,
+
+				ImpersonationTokenExpTime: 15,
+
+				UserPurgeRetention: 30,
+				UserPurgeInterval:  60,
+
+				UsersMaxPerPage: 100,
 			},
 			wantErr: false,
 		},
@@ -0,0 +1,27 @@
+package auth
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+// ActorField identifies in the logs who made the request: the authenticated user, or
+// the admin behind an impersonation, as actor_id, and an OAuth client as
+// actor_client_id. The user an impersonation acts as is the user_id the entry is
+// logged with, as the act claim of the token, since an impersonation only reaches
+// the resources of the impersonated user.
+func ActorField(ctx context.Context) zap.Field {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return zap.Skip()
+	}
+
+	if principal.IsClient() {
+		return zap.String("actor_client_id", principal.ClientID)
+	}
+	if principal.IsImpersonated() {
+		return zap.String("actor_id", principal.ActorID)
+	}
+	return zap.String("actor_id", principal.UserID)
+}
@@ -0,0 +1,43 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func Test_ActorField(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal *domain.Principal
+		want      zap.Field
+	}{
+		{
+			name:      "Should log the user who made the request as the actor",
+			principal: &domain.Principal{UserID: "user_id"},
+			want:      zap.String("actor_id", "user_id"),
+		},
+		{
+			name:      "Should log the admin behind an impersonation as the actor",
+			principal: &domain.Principal{UserID: "user_id", ActorID: "admin_id"},
+			want:      zap.String("actor_id", "admin_id"),
+		},
+		{
+			name:      "Should log the OAuth client who made the request",
+			principal: &domain.Principal{ClientID: "client_id"},
+			want:      zap.String("actor_client_id", "client_id"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ActorField(WithPrincipal(context.Background(), tt.principal)))
+		})
+	}
+
+	t.Run("Should log nothing without a principal", func(t *testing.T) {
+		assert.Equal(t, zap.Skip(), ActorField(context.Background()))
+	})
+}
@@ -0,0 +1,23 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
+)
+
+type clientInfoCtxKey struct{}
+
+func WithClientInfo(ctx context.Context, clientInfo *domain.ClientInfo) context.Context {
+	return context.WithValue(ctx, clientInfoCtxKey{}, clientInfo)
+}
+
+// ClientInfoFromContext returns the client of the request, an empty one when the
+// request didn't go through the middleware.
+func ClientInfoFromContext(ctx context.Context) *domain.ClientInfo {
+	clientInfo, ok := ctx.Value(clientInfoCtxKey{}).(*domain.ClientInfo)
+	if !ok || clientInfo == nil {
+		return &domain.ClientInfo{}
+	}
+	return clientInfo
+}
@@ -0,0 +1,38 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	"github.com/gin-gonic/gin"
+)
+
+type principalCtxKey struct{}
+
+// PrincipalKey is the gin.Context key holding the authenticated *domain.Principal.
+const PrincipalKey = "principal"
+
+func WithPrincipal(ctx context.Context, principal *domain.Principal) context.Context {
+	return context.WithValue(ctx, principalCtxKey{}, principal)
+}
+
+func PrincipalFromContext(ctx context.Context) (*domain.Principal, bool) {
+	principal, ok := ctx.Value(principalCtxKey{}).(*domain.Principal)
+	return principal, ok && principal != nil
+}
+
+// SetPrincipal stores the principal in the gin.Context, for handlers and middlewares,
+// and in the request context, for services and repositories.
+func SetPrincipal(c *gin.Context, principal *domain.Principal) {
+	c.Set(PrincipalKey, principal)
+	c.Request = c.Request.WithContext(WithPrincipal(c.Request.Context(), principal))
+}
+
+func PrincipalFromGin(c *gin.Context) (*domain.Principal, bool) {
+	value, exists := c.Get(PrincipalKey)
+	if !exists {
+		return nil, false
+	}
+	principal, ok := value.(*domain.Principal)
+	return principal, ok && principal != nil
+}
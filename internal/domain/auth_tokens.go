@@ -0,0 +1,9 @@
+package domain
+
+// AuthTokens are the tokens of a login. When the user has MFA enabled, the login
+// only gets the MFAToken, which is exchanged for the other tokens with a code.
+type AuthTokens struct {
+	AccessToken  string
+	RefreshToken string
+	MFAToken     string
+}
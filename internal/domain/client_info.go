@@ -0,0 +1,7 @@
+package domain
+
+// ClientInfo describes the client that sent a request.
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
@@ -0,0 +1,15 @@
+package domain
+
+import "time"
+
+// Impersonation is a short-lived access token of an user issued to an admin, the
+// ActorID. The token carries both identities so every action can be told apart
+// from the ones of the user itself.
+type Impersonation struct {
+	AccessToken string
+	TokenID     string
+	UserID      string
+	ActorID     string
+	Scopes      []string
+	ExpiresAt   time.Time
+}
@@ -0,0 +1,6 @@
+package domain
+
+type MFAEnrollment struct {
+	Secret string
+	URI    string
+}
@@ -0,0 +1,43 @@
+package domain
+
+import "time"
+
+// GrantTypeClientCredentials is the OAuth2 grant letting a registered client get
+// an access token for itself, without any user.
+const GrantTypeClientCredentials = "client_credentials"
+
+// Error codes of the OAuth2 token endpoint, sent as the code of the errors.
+const (
+	OAuthErrInvalidRequest       = "invalid_request"
+	OAuthErrInvalidClient        = "invalid_client"
+	OAuthErrInvalidScope         = "invalid_scope"
+	OAuthErrUnsupportedGrantType = "unsupported_grant_type"
+)
+
+// OAuthClient is a service allowed to call the API on its own behalf. Only the hash
+// of the secret is stored, the secret itself is shown once on registration.
+type OAuthClient struct {
+	ID         string
+	ClientID   string
+	Name       string
+	SecretHash string
+	Scopes     []string
+	CreatedAt  time.Time
+}
+
+// OAuthToken is the access token issued to a client and the scopes it was granted.
+type OAuthToken struct {
+	AccessToken string
+	ExpiresIn   int
+	Scopes      []string
+}
+
+// AllowsScope tells if the client was registered with the scope.
+func (c *OAuthClient) AllowsScope(scope string) bool {
+	for _, clientScope := range c.Scopes {
+		if clientScope == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,29 @@
+package domain
+
+import "time"
+
+// PersonalAccessTokenPrefix tells the personal access tokens apart from the jwts
+// sent in the Authorization header.
+const PersonalAccessTokenPrefix = "pat_"
+
+// PersonalAccessToken lets a script act as the user within its scopes. Only the
+// hash of the token is stored, the token itself is shown once on creation.
+type PersonalAccessToken struct {
+	ID         string
+	UserID     string
+	Name       string
+	Scopes     []string
+	TokenHash  string
+	ExpiresAt  time.Time
+	CreatedAt  time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+}
+
+func (t *PersonalAccessToken) IsExpired() bool {
+	return time.Now().After(t.ExpiresAt)
+}
+
+func (t *PersonalAccessToken) IsRevoked() bool {
+	return t.RevokedAt != nil
+}
@@ -0,0 +1,61 @@
+package domain
+
+import "time"
+
+// Principal is the authenticated caller of a request, built from the token claims.
+// The principal of an OAuth client has no user, only the ClientID and its scopes.
+// An admin impersonating an user is the ActorID of a principal of that user.
+type Principal struct {
+	UserID    string
+	Name      string
+	Email     string
+	Roles     []string
+	TokenID   string
+	SessionID string
+	ExpiresAt time.Time
+	ClientID  string
+	ActorID   string
+
+	// Scopes are only set for the personal access tokens, the OAuth clients and the
+	// impersonations, nil means every scope.
+	Scopes []string
+}
+
+func (p *Principal) HasRole(roles ...string) bool {
+	for _, userRole := range p.Roles {
+		for _, role := range roles {
+			if userRole == role {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IsClient tells if the caller is an OAuth client acting on its own behalf.
+func (p *Principal) IsClient() bool {
+	return p.ClientID != ""
+}
+
+// IsImpersonated tells if an admin is acting as the user.
+func (p *Principal) IsImpersonated() bool {
+	return p.ActorID != ""
+}
+
+func (p *Principal) IsOwner(userID string) bool {
+	return p.UserID != "" && p.UserID == userID
+}
+
+// HasScope tells if the credentials allow the scope, a login session allows all of them.
+func (p *Principal) HasScope(scope string) bool {
+	if p.Scopes == nil {
+		return true
+	}
+
+	for _, principalScope := range p.Scopes {
+		if principalScope == scope {
+			return true
+		}
+	}
+	return false
+}
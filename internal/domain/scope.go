@@ -0,0 +1,10 @@
+package domain
+
+const (
+	ScopeUsersRead  = "users:read"
+	ScopeUsersWrite = "users:write"
+)
+
+// Scopes limit what the credentials of a machine client can do. The sessions of a
+// login are not scoped, they can do whatever the roles of the user allow.
+var Scopes = []string{ScopeUsersRead, ScopeUsersWrite}
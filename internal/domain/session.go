@@ -0,0 +1,16 @@
+package domain
+
+import "time"
+
+// Session is a login of the user on a device, the family of refresh tokens it started
+// and the access tokens carrying its id as sid. TokenID is the jti of its last access token.
+type Session struct {
+	ID         string
+	UserID     string
+	UserAgent  string
+	IP         string
+	TokenID    string
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	ExpiresAt  time.Time
+}
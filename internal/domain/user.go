@@ -1,19 +1,100 @@
 package domain
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"time"
+
+	"github.com/WalterPaes/go-rest-api-crud/pkg/hasher"
+)
+
+const (
+	RoleAdmin = "admin"
+	RoleUser  = "user"
+)
+
+var Roles = []string{RoleAdmin, RoleUser}
 
 type User struct {
-	ID       string
-	Name     string
-	Email    string
-	Password string
+	ID            string
+	Name          string
+	Email         string
+	Password      string
+	Roles         []string
+	EmailVerified bool
+	VerifiedAt    *time.Time
+
+	// Status is the account lifecycle, StatusReason and StatusChangedAt tell why and
+	// when it last changed.
+	Status          string
+	StatusReason    string
+	StatusChangedAt *time.Time
+
+	// PasswordHistory has the hashes of the previous passwords, the newest first.
+	PasswordHistory []string
+
+	// MFASecret is set on enrollment and MFAEnabled once a first code confirms it.
+	// MFACounter is the step of the last TOTP code used, RecoveryCodes are hashes.
+	MFAEnabled    bool
+	MFASecret     string
+	MFACounter    int64
+	RecoveryCodes []string
+
+	// MFAChallenge is the hash of the challenge a login has to answer with a code and
+	// MFAChallengeFailures the wrong codes sent to it.
+	MFAChallenge         string
+	MFAChallengeFailures int
+
+	// DeletedAt is set while the user is soft deleted, until it is restored or purged.
+	DeletedAt *time.Time
 }
 
-func (u *User) EncryptPassword() error {
-	hash, err := bcrypt.GenerateFromPassword([]byte(u.Password), bcrypt.DefaultCost)
+func (u *User) EncryptPassword(passwordHasher hasher.PasswordHasher) error {
+	hash, err := passwordHasher.Hash(u.Password)
 	if err != nil {
 		return err
 	}
-	u.Password = string(hash)
+	u.Password = hash
 	return nil
 }
+
+// ComparePassword accepts hashes of any supported algorithm, not only the configured one.
+func (u *User) ComparePassword(password string) bool {
+	return hasher.Verify(u.Password, password)
+}
+
+// UsedPassword tells if the password is the current one or one of the last
+// historySize passwords of the user.
+func (u *User) UsedPassword(password string, historySize int) bool {
+	if u.ComparePassword(password) {
+		return true
+	}
+
+	for i, hash := range u.PasswordHistory {
+		if i >= historySize {
+			break
+		}
+		if hasher.Verify(hash, password) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetRoles returns the user roles, users stored before roles existed are regular users.
+func (u *User) GetRoles() []string {
+	if len(u.Roles) == 0 {
+		return []string{RoleUser}
+	}
+	return u.Roles
+}
+
+// HasRole tells if the user has one of the roles.
+func (u *User) HasRole(roles ...string) bool {
+	for _, userRole := range u.GetRoles() {
+		for _, role := range roles {
+			if userRole == role {
+				return true
+			}
+		}
+	}
+	return false
+}
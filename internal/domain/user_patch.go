@@ -0,0 +1,11 @@
+package domain
+
+// UserPatch holds the fields of a partial update, nil fields are kept unchanged.
+type UserPatch struct {
+	Name  *string
+	Email *string
+}
+
+func (p *UserPatch) IsEmpty() bool {
+	return p.Name == nil && p.Email == nil
+}
@@ -0,0 +1,114 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	UserSortName      = "name"
+	UserSortEmail     = "email"
+	UserSortStatus    = "status"
+	UserSortCreatedAt = "created_at"
+)
+
+// UserSortFields are the fields the users can be sorted by.
+var UserSortFields = []string{UserSortName, UserSortEmail, UserSortStatus, UserSortCreatedAt}
+
+// SortField is one of the fields a list is sorted by, ascending unless Descending.
+type SortField struct {
+	Field      string
+	Descending bool
+}
+
+// UserQuery holds the search, the filters, the sort and the page of a users listing.
+// The empty filters match every user.
+type UserQuery struct {
+	// Search matches the users whose name or email starts with it, ignoring the case.
+	Search string
+	Email  string
+	Status string
+	Role   string
+
+	// CreatedFrom is inclusive and CreatedTo exclusive.
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+
+	// Sort is applied in order, the users are listed as created when it is empty.
+	Sort []SortField
+
+	// Cursor is the signed token of a previous page, which pages by From instead of
+	// by Page. The service decodes it into From.
+	Cursor string
+	From   *UserCursor
+
+	Page    int
+	PerPage int
+
+	// Fields are the fields of the users to read, named as in the response, every field
+	// when empty.
+	Fields []string
+
+	// Count asks for the total of users matching the query, which costs counting them
+	// on every page.
+	Count bool
+}
+
+// UserCursor is the position of an user in a listing: the values of the sort fields
+// of the user, in the order of the sort, and its ID, which breaks the ties.
+type UserCursor struct {
+	ID         string
+	SortValues []string
+
+	// Backward pages the users before the position instead of the ones after it.
+	Backward bool
+}
+
+// UserPage is a page of a users listing. HasMore tells if there are more users past
+// the page, in the direction it was read.
+type UserPage struct {
+	Users      []*User
+	HasMore    bool
+	NextCursor string
+	PrevCursor string
+
+	// TotalItems is the total of users matching the query, nil when not counted.
+	TotalItems *int64
+}
+
+// SortKey writes the sort as the query param it came from, like name,-created_at.
+func (q *UserQuery) SortKey() string {
+	fields := make([]string, 0, len(q.Sort))
+	for _, sortField := range q.Sort {
+		if sortField.Descending {
+			fields = append(fields, "-"+sortField.Field)
+			continue
+		}
+		fields = append(fields, sortField.Field)
+	}
+	return strings.Join(fields, ",")
+}
+
+// SortValue returns the value of the user the field sorts by, the created at has none
+// since the ID of the user tells it.
+func (u *User) SortValue(field string) string {
+	switch field {
+	case UserSortName:
+		return u.Name
+	case UserSortEmail:
+		return u.Email
+	case UserSortStatus:
+		return u.Status
+	}
+	return ""
+}
+
+// IsUserSortField tells if the users can be sorted by the field.
+func IsUserSortField(field string) bool {
+	for _, sortField := range UserSortFields {
+		if sortField == field {
+			return true
+		}
+	}
+	return false
+}
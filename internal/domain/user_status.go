@@ -0,0 +1,47 @@
+package domain
+
+const (
+	UserStatusActive      = "active"
+	UserStatusSuspended   = "suspended"
+	UserStatusLocked      = "locked"
+	UserStatusDeactivated = "deactivated"
+)
+
+var UserStatuses = []string{UserStatusActive, UserStatusSuspended, UserStatusLocked, UserStatusDeactivated}
+
+// userStatusTransitions are the statuses each status can change to. Only an active
+// user can log in, the other statuses keep the data of the user.
+var userStatusTransitions = map[string][]string{
+	UserStatusActive:      {UserStatusSuspended, UserStatusLocked, UserStatusDeactivated},
+	UserStatusSuspended:   {UserStatusActive, UserStatusDeactivated},
+	UserStatusLocked:      {UserStatusActive, UserStatusSuspended, UserStatusDeactivated},
+	UserStatusDeactivated: {UserStatusActive},
+}
+
+// IsUserStatus tells if the status is one of the known statuses.
+func IsUserStatus(status string) bool {
+	_, ok := userStatusTransitions[status]
+	return ok
+}
+
+// GetStatus returns the user status, users stored before statuses existed are active.
+func (u *User) GetStatus() string {
+	if u.Status == "" {
+		return UserStatusActive
+	}
+	return u.Status
+}
+
+func (u *User) IsActive() bool {
+	return u.GetStatus() == UserStatusActive
+}
+
+// CanTransitionTo tells if the status of the user can change to the status.
+func (u *User) CanTransitionTo(status string) bool {
+	for _, next := range userStatusTransitions[u.GetStatus()] {
+		if next == status {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,15 @@
+package converter
+
+import (
+	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	"github.com/WalterPaes/go-rest-api-crud/internal/handlers/dtos"
+)
+
+func AuthTokensToLoginResponse(tokens *domain.AuthTokens) dtos.LoginResponse {
+	return dtos.LoginResponse{
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		MFARequired:  tokens.MFAToken != "",
+		MFAToken:     tokens.MFAToken,
+	}
+}
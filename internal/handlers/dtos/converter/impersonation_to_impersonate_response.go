@@ -0,0 +1,16 @@
+package converter
+
+import (
+	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	"github.com/WalterPaes/go-rest-api-crud/internal/handlers/dtos"
+)
+
+func ImpersonationToImpersonateResponse(impersonation *domain.Impersonation) dtos.ImpersonateResponse {
+	return dtos.ImpersonateResponse{
+		AccessToken: impersonation.AccessToken,
+		TokenType:   "Bearer",
+		UserID:      impersonation.UserID,
+		Scopes:      impersonation.Scopes,
+		ExpiresAt:   impersonation.ExpiresAt,
+	}
+}
@@ -0,0 +1,13 @@
+package converter
+
+import (
+	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	"github.com/WalterPaes/go-rest-api-crud/internal/handlers/dtos"
+)
+
+func MFAEnrollmentToMFAEnrollmentResponse(enrollment *domain.MFAEnrollment) dtos.MFAEnrollmentResponse {
+	return dtos.MFAEnrollmentResponse{
+		Secret:     enrollment.Secret,
+		OtpauthURI: enrollment.URI,
+	}
+}
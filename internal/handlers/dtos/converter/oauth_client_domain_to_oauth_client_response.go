@@ -0,0 +1,15 @@
+package converter
+
+import (
+	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	"github.com/WalterPaes/go-rest-api-crud/internal/handlers/dtos"
+)
+
+func OAuthClientDomainToOAuthClientResponse(client *domain.OAuthClient) dtos.OAuthClientResponse {
+	return dtos.OAuthClientResponse{
+		ClientID:  client.ClientID,
+		Name:      client.Name,
+		Scopes:    client.Scopes,
+		CreatedAt: client.CreatedAt,
+	}
+}
@@ -0,0 +1,17 @@
+package converter
+
+import (
+	"strings"
+
+	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	"github.com/WalterPaes/go-rest-api-crud/internal/handlers/dtos"
+)
+
+func OAuthTokenToOAuthTokenResponse(token *domain.OAuthToken) dtos.OAuthTokenResponse {
+	return dtos.OAuthTokenResponse{
+		AccessToken: token.AccessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   token.ExpiresIn,
+		Scope:       strings.Join(token.Scopes, " "),
+	}
+}
@@ -0,0 +1,17 @@
+package converter
+
+import (
+	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	"github.com/WalterPaes/go-rest-api-crud/internal/handlers/dtos"
+)
+
+func PersonalAccessTokenDomainToPersonalAccessTokenResponse(token *domain.PersonalAccessToken) dtos.PersonalAccessTokenResponse {
+	return dtos.PersonalAccessTokenResponse{
+		ID:         token.ID,
+		Name:       token.Name,
+		Scopes:     token.Scopes,
+		ExpiresAt:  token.ExpiresAt,
+		CreatedAt:  token.CreatedAt,
+		LastUsedAt: token.LastUsedAt,
+	}
+}
@@ -0,0 +1,17 @@
+package converter
+
+import (
+	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	"github.com/WalterPaes/go-rest-api-crud/internal/handlers/dtos"
+)
+
+func SessionDomainToSessionResponse(session *domain.Session, currentSessionID string) dtos.SessionResponse {
+	return dtos.SessionResponse{
+		ID:         session.ID,
+		UserAgent:  session.UserAgent,
+		IP:         session.IP,
+		CreatedAt:  session.CreatedAt,
+		LastSeenAt: session.LastSeenAt,
+		Current:    session.ID == currentSessionID,
+	}
+}
@@ -7,19 +7,34 @@ import (
 
 func UserDomainToUserResponse(userDomain *domain.User) dtos.UserResponse {
 	return dtos.UserResponse{
-		ID:    userDomain.ID,
-		Name:  userDomain.Name,
-		Email: userDomain.Email,
+		ID:            userDomain.ID,
+		Name:          userDomain.Name,
+		Email:         userDomain.Email,
+		Roles:         userDomain.GetRoles(),
+		EmailVerified: userDomain.EmailVerified,
+		VerifiedAt:    userDomain.VerifiedAt,
+		MFAEnabled:    userDomain.MFAEnabled,
+		Status:        userDomain.GetStatus(),
+		StatusReason:  userDomain.StatusReason,
 	}
 }
 
-func UsersDomainListToUserListResponse(usersList []*domain.User, currentPage, totalPerPage int) dtos.UsersListResponse {
+func UserPageToUserListResponse(page *domain.UserPage, currentPage, totalPerPage int) dtos.UsersListResponse {
 	list := dtos.UsersListResponse{
 		CurrentPage:  currentPage,
 		TotalPerPage: totalPerPage,
+		TotalItems:   page.TotalItems,
+		HasNext:      page.NextCursor != "",
+		NextCursor:   page.NextCursor,
+		PrevCursor:   page.PrevCursor,
 	}
 
-	for _, user := range usersList {
+	if page.TotalItems != nil && totalPerPage > 0 {
+		totalPages := (*page.TotalItems + int64(totalPerPage) - 1) / int64(totalPerPage)
+		list.TotalPages = &totalPages
+	}
+
+	for _, user := range page.Users {
 		list.Users = append(list.Users, UserDomainToUserResponse(user))
 	}
 
@@ -0,0 +1,13 @@
+package converter
+
+import (
+	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	"github.com/WalterPaes/go-rest-api-crud/internal/handlers/dtos"
+)
+
+func UserPatchRequestToUserPatchDomain(userPatchRequest dtos.UserPatchRequest) *domain.UserPatch {
+	return &domain.UserPatch{
+		Name:  userPatchRequest.Name,
+		Email: userPatchRequest.Email,
+	}
+}
@@ -0,0 +1,14 @@
+package converter
+
+import (
+	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	"github.com/WalterPaes/go-rest-api-crud/internal/handlers/dtos"
+)
+
+func UserUpdateRequestToUserDomain(userUpdateRequest dtos.UserUpdateRequest) *domain.User {
+	return &domain.User{
+		Name:  userUpdateRequest.Name,
+		Email: userUpdateRequest.Email,
+		Roles: userUpdateRequest.Roles,
+	}
+}
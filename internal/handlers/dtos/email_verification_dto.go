@@ -0,0 +1,5 @@
+package dtos
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
@@ -0,0 +1,18 @@
+package dtos
+
+import "time"
+
+// ImpersonateRequest limits the impersonation to the scopes, every scope when none is
+// given. The reason is kept in the audit trail.
+type ImpersonateRequest struct {
+	Scopes []string `json:"scopes" binding:"omitempty,dive,oneof=users:read users:write"`
+	Reason string   `json:"reason" binding:"required,max=500"`
+}
+
+type ImpersonateResponse struct {
+	AccessToken string    `json:"access_token"`
+	TokenType   string    `json:"token_type"`
+	UserID      string    `json:"user_id"`
+	Scopes      []string  `json:"scopes"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
@@ -2,9 +2,23 @@ package dtos
 
 type LoginRequest struct {
 	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=6,containsany=!@#$%*"`
+	Password string `json:"password" binding:"required"`
 }
 
+// LoginResponse only has the MFA fields when the user must send a code to
+// POST /login/mfa to get the tokens.
 type LoginResponse struct {
-	Token string `json:"token"`
+	Token        string `json:"token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	MFARequired  bool   `json:"mfa_required,omitempty"`
+	MFAToken     string `json:"mfa_token,omitempty"`
+}
+
+type LoginMFARequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
 }
@@ -0,0 +1,14 @@
+package dtos
+
+type MFAEnrollmentResponse struct {
+	Secret     string `json:"secret"`
+	OtpauthURI string `json:"otpauth_uri"`
+}
+
+type ConfirmMFARequest struct {
+	Code string `json:"code" binding:"required,len=6,numeric"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
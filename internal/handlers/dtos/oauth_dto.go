@@ -0,0 +1,36 @@
+package dtos
+
+import "time"
+
+// OAuthTokenRequest is the form of the token endpoint, the client credentials can be
+// sent in it or, preferably, with HTTP Basic authentication.
+type OAuthTokenRequest struct {
+	GrantType    string `form:"grant_type" binding:"required"`
+	Scope        string `form:"scope"`
+	ClientID     string `form:"client_id"`
+	ClientSecret string `form:"client_secret"`
+}
+
+type OAuthTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+	Scope       string `json:"scope"`
+}
+
+type RegisterOAuthClientRequest struct {
+	Name   string   `json:"name" binding:"required,max=100"`
+	Scopes []string `json:"scopes" binding:"required,min=1,dive,oneof=users:read users:write"`
+}
+
+type OAuthClientResponse struct {
+	ClientID  string    `json:"client_id"`
+	Name      string    `json:"name"`
+	Scopes    []string  `json:"scopes"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type RegisterOAuthClientResponse struct {
+	OAuthClientResponse
+	ClientSecret string `json:"client_secret"`
+}
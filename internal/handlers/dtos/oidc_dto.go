@@ -0,0 +1,10 @@
+package dtos
+
+// OIDCCallbackRequest is the query of the redirect back from the identity provider,
+// it has either the code and the state or the error of a refused login.
+type OIDCCallbackRequest struct {
+	Code             string `form:"code" binding:"required_without=Error"`
+	State            string `form:"state" binding:"required_without=Error"`
+	Error            string `form:"error"`
+	ErrorDescription string `form:"error_description"`
+}
@@ -0,0 +1,23 @@
+package dtos
+
+import "time"
+
+type CreatePersonalAccessTokenRequest struct {
+	Name          string   `json:"name" binding:"required,max=100"`
+	Scopes        []string `json:"scopes" binding:"required,min=1,dive,oneof=users:read users:write"`
+	ExpiresInDays int      `json:"expires_in_days" binding:"required,min=1"`
+}
+
+type PersonalAccessTokenResponse struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+type CreatePersonalAccessTokenResponse struct {
+	PersonalAccessTokenResponse
+	Token string `json:"token"`
+}
@@ -0,0 +1,14 @@
+package dtos
+
+import "time"
+
+// SessionResponse describes a device the user is logged in on, Current tells the
+// session of the token that made the request.
+type SessionResponse struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	Current    bool      `json:"current"`
+}
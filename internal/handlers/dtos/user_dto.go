@@ -1,19 +1,114 @@
 package dtos
 
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// UserRequest only requires the password, its strength is checked by the password
+// policy of the user service.
 type UserRequest struct {
-	Name     string `json:"name" binding:"required,min=4,max=100"`
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=6,containsany=!@#$%*"`
+	Name     string   `json:"name" binding:"required,min=4,max=100"`
+	Email    string   `json:"email" binding:"required,email"`
+	Password string   `json:"password" binding:"required"`
+	Roles    []string `json:"roles" binding:"omitempty,dive,oneof=admin user"`
+}
+
+// UserUpdateRequest replaces the profile of an user, the password is changed only
+// through the change password endpoint.
+type UserUpdateRequest struct {
+	Name  string   `json:"name" binding:"required,min=4,max=100"`
+	Email string   `json:"email" binding:"required,email"`
+	Roles []string `json:"roles" binding:"omitempty,dive,oneof=admin user"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
 }
 
+// UserResponse sends every field, unless Select picks some of them.
 type UserResponse struct {
-	ID    string `json:"id"`
-	Name  string `json:"name"`
-	Email string `json:"email"`
+	ID            string     `json:"id"`
+	Name          string     `json:"name"`
+	Email         string     `json:"email"`
+	Roles         []string   `json:"roles"`
+	EmailVerified bool       `json:"email_verified"`
+	VerifiedAt    *time.Time `json:"verified_at,omitempty"`
+	MFAEnabled    bool       `json:"mfa_enabled"`
+	Status        string     `json:"status"`
+	StatusReason  string     `json:"status_reason,omitempty"`
+
+	fields []string
+}
+
+// UserResponseFields are the names of the fields of an UserResponse, as sent.
+var UserResponseFields = jsonFieldNames(reflect.TypeOf(UserResponse{}))
+
+// Select makes the response send only the fields, by their names as sent. Without
+// fields it sends all of them.
+func (r UserResponse) Select(fields []string) UserResponse {
+	r.fields = fields
+	return r
+}
+
+func (r UserResponse) MarshalJSON() ([]byte, error) {
+	// userResponse has the fields without the methods, so it marshals as a plain struct
+	type userResponse UserResponse
+
+	all, err := json.Marshal(userResponse(r))
+	if err != nil || len(r.fields) == 0 {
+		return all, err
+	}
+
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(all, &values); err != nil {
+		return nil, err
+	}
+
+	selected := make(map[string]json.RawMessage, len(r.fields))
+	for _, field := range r.fields {
+		if value, ok := values[field]; ok {
+			selected[field] = value
+		}
+	}
+	return json.Marshal(selected)
+}
+
+func jsonFieldNames(t reflect.Type) []string {
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			names = append(names, name)
+		}
+	}
+	return names
 }
 
+// UsersListResponse has the cursors to send to read the pages after and before this
+// one, which are missing when there are none. The pages read by cursor have no number,
+// their current page is 0, and the totals are missing when the users were not counted.
 type UsersListResponse struct {
 	Users        []UserResponse `json:"users"`
 	CurrentPage  int            `json:"current_page"`
 	TotalPerPage int            `json:"total_per_page"`
+	TotalItems   *int64         `json:"total_items,omitempty"`
+	TotalPages   *int64         `json:"total_pages,omitempty"`
+	HasNext      bool           `json:"has_next"`
+	NextCursor   string         `json:"next_cursor,omitempty"`
+	PrevCursor   string         `json:"prev_cursor,omitempty"`
+}
+
+// UserPatchRequest only changes the fields sent, the omitted ones are kept.
+type UserPatchRequest struct {
+	Name  *string `json:"name" binding:"omitempty,min=4,max=100"`
+	Email *string `json:"email" binding:"omitempty,email"`
+}
+
+// UserStatusRequest tells why the status of the user is changed, it is kept with the user.
+type UserStatusRequest struct {
+	Reason string `json:"reason" binding:"required,max=500"`
 }
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/WalterPaes/go-rest-api-crud/internal/handlers/dtos"
+	"github.com/WalterPaes/go-rest-api-crud/internal/services"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/logger"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/validation"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+var (
+	stacktraceVerifyEmailHandler = zap.String("stacktrace", "verify-email-handler")
+)
+
+type emailVerificationHandler struct {
+	emailVerificationService services.EmailVerificationService
+}
+
+func NewEmailVerificationHandler(emailVerificationService services.EmailVerificationService) *emailVerificationHandler {
+	return &emailVerificationHandler{
+		emailVerificationService: emailVerificationService,
+	}
+}
+
+// Verify Email godoc
+// @Summary Verify the email of an user
+// @Description Mark the email as verified using the token of the link sent on registration or email change
+// @Tags users
+// @Accept json
+// @Param request body dtos.VerifyEmailRequest true "Verify Email Request"
+// @Success 204
+// @Failure 400 {object} resterrors.RestErr
+// @Failure 500 {object} resterrors.RestErr
+// @Router /users/verify-email [post]
+func (h *emailVerificationHandler) VerifyEmail(c *gin.Context) {
+	logger.Info("Starting Verify Email Handler", stacktraceVerifyEmailHandler)
+
+	var verifyEmailRequest dtos.VerifyEmailRequest
+
+	if err := c.ShouldBindJSON(&verifyEmailRequest); err != nil {
+		logger.Error("Verify Email Request Validation Error", err, stacktraceVerifyEmailHandler)
+
+		restErr := validation.ValidationUserError(err)
+		c.JSON(restErr.HttpStatusCode, restErr)
+		return
+	}
+
+	if err := h.emailVerificationService.VerifyEmail(c.Request.Context(), verifyEmailRequest.Token); err != nil {
+		logger.Error("Error when trying call service", err, stacktraceVerifyEmailHandler)
+
+		c.JSON(err.HttpStatusCode, err)
+		return
+	}
+
+	logger.Info("Email was verified Successfully", stacktraceVerifyEmailHandler)
+	c.Status(http.StatusNoContent)
+}
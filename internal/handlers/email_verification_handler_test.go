@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/WalterPaes/go-rest-api-crud/mocks"
+	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_emailVerificationHandler_VerifyEmail(t *testing.T) {
+	t.Run("Should verify the email", func(t *testing.T) {
+		emailVerificationService := mocks.NewEmailVerificationService(t)
+		emailVerificationService.On("VerifyEmail", mock.Anything, "token").
+			Return(nil)
+
+		recorder := httptest.NewRecorder()
+		ctx := getJSONContext(recorder, `{"token": "token"}`)
+
+		NewEmailVerificationHandler(emailVerificationService).VerifyEmail(ctx)
+		ctx.Writer.WriteHeaderNow()
+
+		assert.EqualValues(t, http.StatusNoContent, recorder.Code)
+	})
+
+	t.Run("Should return an error when the token is missing", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		ctx := getJSONContext(recorder, `{}`)
+
+		NewEmailVerificationHandler(mocks.NewEmailVerificationService(t)).VerifyEmail(ctx)
+
+		assert.EqualValues(t, http.StatusBadRequest, recorder.Code)
+	})
+
+	t.Run("Should return the error code when the token is invalid", func(t *testing.T) {
+		emailVerificationService := mocks.NewEmailVerificationService(t)
+		emailVerificationService.On("VerifyEmail", mock.Anything, "token").
+			Return(resterrors.NewBadRequestError("Email Verification Token is Invalid").WithCode("invalid_verification_token"))
+
+		recorder := httptest.NewRecorder()
+		ctx := getJSONContext(recorder, `{"token": "token"}`)
+
+		NewEmailVerificationHandler(emailVerificationService).VerifyEmail(ctx)
+
+		var restErr resterrors.RestErr
+		assert.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &restErr))
+		assert.EqualValues(t, http.StatusBadRequest, recorder.Code)
+		assert.Equal(t, "invalid_verification_token", restErr.Code)
+	})
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/WalterPaes/go-rest-api-crud/internal/handlers/dtos"
+	"github.com/WalterPaes/go-rest-api-crud/internal/handlers/dtos/converter"
+	"github.com/WalterPaes/go-rest-api-crud/internal/services"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/logger"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/validation"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+var (
+	stacktraceImpersonateHandler = zap.String("stacktrace", "impersonate-handler")
+)
+
+type impersonationHandler struct {
+	impersonationService services.ImpersonationService
+}
+
+func NewImpersonationHandler(impersonationService services.ImpersonationService) *impersonationHandler {
+	return &impersonationHandler{
+		impersonationService: impersonationService,
+	}
+}
+
+// Impersonate godoc
+// @Summary Impersonate an user
+// @Description Issue to the admin a short-lived access token of the user, which can't be refreshed. The token carries the admin in its act claim, and can't change the password, the MFA, the credentials or delete the account of the user
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param request body dtos.ImpersonateRequest true "Impersonate Request"
+// @Success 201 {object} dtos.ImpersonateResponse
+// @Failure 400 {object} resterrors.RestErr
+// @Failure 401 {object} resterrors.RestErr
+// @Failure 403 {object} resterrors.RestErr
+// @Failure 404 {object} resterrors.RestErr
+// @Failure 500 {object} resterrors.RestErr
+// @Router /admin/users/{id}/impersonate [post]
+// @Security ApiKeyAuth
+func (h *impersonationHandler) Impersonate(c *gin.Context) {
+	logger.Info("Starting Impersonate Handler", stacktraceImpersonateHandler)
+
+	actorID, err := getIdFromPrincipal(c)
+	if err != nil {
+		c.JSON(err.HttpStatusCode, err)
+		return
+	}
+
+	userID, err := getIdFromParam(c)
+	if err != nil {
+		c.JSON(err.HttpStatusCode, err)
+		return
+	}
+
+	var impersonateRequest dtos.ImpersonateRequest
+	if err := c.ShouldBindJSON(&impersonateRequest); err != nil {
+		logger.Error("Impersonate Request Validation Error", err, stacktraceImpersonateHandler)
+
+		restErr := validation.ValidationUserError(err)
+		c.JSON(restErr.HttpStatusCode, restErr)
+		return
+	}
+
+	impersonation, err := h.impersonationService.Impersonate(
+		c.Request.Context(),
+		actorID,
+		userID,
+		impersonateRequest.Scopes,
+		impersonateRequest.Reason,
+	)
+	if err != nil {
+		logger.Error(errTryCallService, err, zap.String("user_id", userID), zap.String("actor_id", actorID), stacktraceImpersonateHandler)
+
+		c.JSON(err.HttpStatusCode, err)
+		return
+	}
+
+	logger.Info("Impersonate Executed Successfully", zap.String("user_id", userID), zap.String("actor_id", actorID), stacktraceImpersonateHandler)
+	c.JSON(http.StatusCreated, converter.ImpersonationToImpersonateResponse(impersonation))
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	"github.com/WalterPaes/go-rest-api-crud/internal/handlers/dtos"
+	"github.com/WalterPaes/go-rest-api-crud/mocks"
+	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func Test_impersonationHandler_Impersonate(t *testing.T) {
+	adminID := primitive.NewObjectID().Hex()
+	userID := primitive.NewObjectID().Hex()
+
+	t.Run("Should return the impersonation token", func(t *testing.T) {
+		impersonationService := mocks.NewImpersonationService(t)
+		impersonationService.On("Impersonate", mock.Anything, adminID, userID, []string{domain.ScopeUsersRead}, "support ticket 42").
+			Return(&domain.Impersonation{
+				AccessToken: "access-token",
+				TokenID:     "token_id",
+				UserID:      userID,
+				ActorID:     adminID,
+				Scopes:      []string{domain.ScopeUsersRead},
+				ExpiresAt:   time.Now().Add(15 * time.Minute),
+			}, nil)
+
+		recorder := httptest.NewRecorder()
+		ctx := getAuthenticatedJSONContext(recorder, adminID, `{"scopes": ["users:read"], "reason": "support ticket 42"}`)
+		ctx.Params = gin.Params{{Key: "id", Value: userID}}
+
+		NewImpersonationHandler(impersonationService).Impersonate(ctx)
+
+		var response dtos.ImpersonateResponse
+		assert.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		assert.EqualValues(t, http.StatusCreated, recorder.Code)
+		assert.Equal(t, "access-token", response.AccessToken)
+		assert.Equal(t, "Bearer", response.TokenType)
+		assert.Equal(t, userID, response.UserID)
+		assert.Equal(t, []string{domain.ScopeUsersRead}, response.Scopes)
+	})
+
+	t.Run("Should require a reason", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		ctx := getAuthenticatedJSONContext(recorder, adminID, `{"scopes": ["users:read"]}`)
+		ctx.Params = gin.Params{{Key: "id", Value: userID}}
+
+		NewImpersonationHandler(mocks.NewImpersonationService(t)).Impersonate(ctx)
+
+		assert.EqualValues(t, http.StatusBadRequest, recorder.Code)
+	})
+
+	t.Run("Should refuse an unknown scope", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		ctx := getAuthenticatedJSONContext(recorder, adminID, `{"scopes": ["admin"], "reason": "support ticket 42"}`)
+		ctx.Params = gin.Params{{Key: "id", Value: userID}}
+
+		NewImpersonationHandler(mocks.NewImpersonationService(t)).Impersonate(ctx)
+
+		assert.EqualValues(t, http.StatusBadRequest, recorder.Code)
+	})
+
+	t.Run("Should refuse an invalid user id", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		ctx := getAuthenticatedJSONContext(recorder, adminID, `{"reason": "support ticket 42"}`)
+		ctx.Params = gin.Params{{Key: "id", Value: "invalid"}}
+
+		NewImpersonationHandler(mocks.NewImpersonationService(t)).Impersonate(ctx)
+
+		assert.EqualValues(t, http.StatusBadRequest, recorder.Code)
+	})
+
+	t.Run("Should return an error when try call impersonation service", func(t *testing.T) {
+		impersonationService := mocks.NewImpersonationService(t)
+		impersonationService.On("Impersonate", mock.Anything, adminID, userID, []string(nil), "support ticket 42").
+			Return(nil, resterrors.NewForbiddenError("An admin can't be impersonated"))
+
+		recorder := httptest.NewRecorder()
+		ctx := getAuthenticatedJSONContext(recorder, adminID, `{"reason": "support ticket 42"}`)
+		ctx.Params = gin.Params{{Key: "id", Value: userID}}
+
+		NewImpersonationHandler(impersonationService).Impersonate(ctx)
+
+		assert.EqualValues(t, http.StatusForbidden, recorder.Code)
+	})
+}
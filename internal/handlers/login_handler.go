@@ -2,18 +2,24 @@ package handlers
 
 import (
 	"net/http"
+	"strconv"
 
 	"github.com/WalterPaes/go-rest-api-crud/internal/handlers/dtos"
 	"github.com/WalterPaes/go-rest-api-crud/internal/handlers/dtos/converter"
 	"github.com/WalterPaes/go-rest-api-crud/internal/services"
 	"github.com/WalterPaes/go-rest-api-crud/pkg/logger"
+	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
 	"github.com/WalterPaes/go-rest-api-crud/pkg/validation"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
 var (
-	stacktraceLoginUserHandler = zap.String("stacktrace", "login-user-handler")
+	stacktraceLoginUserHandler    = zap.String("stacktrace", "login-user-handler")
+	stacktraceRefreshTokenHandler = zap.String("stacktrace", "refresh-token-handler")
+	stacktraceLogoutUserHandler   = zap.String("stacktrace", "logout-user-handler")
+	stacktraceLoginMFAHandler     = zap.String("stacktrace", "login-mfa-handler")
+	stacktraceUnlockUserHandler   = zap.String("stacktrace", "unlock-user-handler")
 )
 
 type loginHandler struct {
@@ -28,7 +34,7 @@ func NewLoginHandler(loginService services.LoginService) *loginHandler {
 
 // User Login godoc
 // @Summary Login an user
-// @Description Login an user
+// @Description Login an user, when the user has MFA enabled the response only has the mfa token to send to /login/mfa
 // @Tags login
 // @Accept json
 // @Produce json
@@ -37,6 +43,7 @@ func NewLoginHandler(loginService services.LoginService) *loginHandler {
 // @Failure 400 {object} resterrors.RestErr
 // @Failure 401
 // @Failure 404 {object} resterrors.RestErr
+// @Failure 429 {object} resterrors.RestErr
 // @Router /login [post]
 func (h *loginHandler) Login(c *gin.Context) {
 	logger.Info("Starting Login User Handler", stacktraceLoginUserHandler)
@@ -51,14 +58,152 @@ func (h *loginHandler) Login(c *gin.Context) {
 		return
 	}
 
-	jwtToken, err := h.loginService.LoginUser(c.Request.Context(), converter.LoginRequestToUserDomain(loginRequest))
+	tokens, err := h.loginService.LoginUser(c.Request.Context(), converter.LoginRequestToUserDomain(loginRequest))
 	if err != nil {
 		logger.Error("Error when trying call service", err, stacktraceLoginUserHandler)
 
-		c.JSON(err.HttpStatusCode, err)
+		respondLoginError(c, err)
 		return
 	}
 
 	logger.Info("User was logged Successfully", zap.String("user_email", loginRequest.Email), stacktraceLoginUserHandler)
-	c.JSON(http.StatusOK, dtos.LoginResponse{Token: jwtToken})
+	c.JSON(http.StatusOK, converter.AuthTokensToLoginResponse(tokens))
+}
+
+// Login MFA godoc
+// @Summary Finish the login of an user with MFA enabled
+// @Description Exchange the mfa token returned by /login and a TOTP or recovery code for the tokens
+// @Tags login
+// @Accept json
+// @Produce json
+// @Param request body dtos.LoginMFARequest true "Login MFA Request"
+// @Success 200 {object} dtos.LoginResponse
+// @Failure 400 {object} resterrors.RestErr
+// @Failure 401 {object} resterrors.RestErr
+// @Failure 429 {object} resterrors.RestErr
+// @Router /login/mfa [post]
+func (h *loginHandler) LoginMFA(c *gin.Context) {
+	logger.Info("Starting Login MFA Handler", stacktraceLoginMFAHandler)
+
+	var loginMFARequest dtos.LoginMFARequest
+
+	if err := c.ShouldBindJSON(&loginMFARequest); err != nil {
+		logger.Error("Login MFA Request Validation Error", err, stacktraceLoginMFAHandler)
+
+		restErr := validation.ValidationUserError(err)
+		c.JSON(restErr.HttpStatusCode, restErr)
+		return
+	}
+
+	tokens, err := h.loginService.LoginMFA(c.Request.Context(), loginMFARequest.MFAToken, loginMFARequest.Code)
+	if err != nil {
+		logger.Error("Error when trying call service", err, stacktraceLoginMFAHandler)
+
+		respondLoginError(c, err)
+		return
+	}
+
+	logger.Info("User was logged with MFA Successfully", stacktraceLoginMFAHandler)
+	c.JSON(http.StatusOK, converter.AuthTokensToLoginResponse(tokens))
+}
+
+// Refresh Token godoc
+// @Summary Refresh the access token
+// @Description Exchange a refresh token for a new access token and a new refresh token
+// @Tags login
+// @Accept json
+// @Produce json
+// @Param request body dtos.RefreshTokenRequest true "Refresh Token Request"
+// @Success 200 {object} dtos.LoginResponse
+// @Failure 400 {object} resterrors.RestErr
+// @Failure 401 {object} resterrors.RestErr
+// @Router /token/refresh [post]
+func (h *loginHandler) RefreshToken(c *gin.Context) {
+	logger.Info("Starting Refresh Token Handler", stacktraceRefreshTokenHandler)
+
+	var refreshTokenRequest dtos.RefreshTokenRequest
+
+	if err := c.ShouldBindJSON(&refreshTokenRequest); err != nil {
+		logger.Error("Refresh Token Request Validation Error", err, stacktraceRefreshTokenHandler)
+
+		restErr := validation.ValidationUserError(err)
+		c.JSON(restErr.HttpStatusCode, restErr)
+		return
+	}
+
+	tokens, err := h.loginService.RefreshToken(c.Request.Context(), refreshTokenRequest.RefreshToken)
+	if err != nil {
+		logger.Error("Error when trying call service", err, stacktraceRefreshTokenHandler)
+
+		c.JSON(err.HttpStatusCode, err)
+		return
+	}
+
+	logger.Info("Token was refreshed Successfully", stacktraceRefreshTokenHandler)
+	c.JSON(http.StatusOK, converter.AuthTokensToLoginResponse(tokens))
+}
+
+// User Logout godoc
+// @Summary Logout an user
+// @Description Revoke the access token of the request and the refresh tokens of its session
+// @Tags login
+// @Success 204
+// @Failure 401 {object} resterrors.RestErr
+// @Failure 500 {object} resterrors.RestErr
+// @Router /logout [post]
+// @Security ApiKeyAuth
+func (h *loginHandler) Logout(c *gin.Context) {
+	logger.Info("Starting Logout User Handler", stacktraceLogoutUserHandler)
+
+	err := h.loginService.LogoutUser(c.Request.Context())
+	if err != nil {
+		logger.Error("Error when trying call service", err, stacktraceLogoutUserHandler)
+
+		c.JSON(err.HttpStatusCode, err)
+		return
+	}
+
+	logger.Info("User was logged out Successfully", stacktraceLogoutUserHandler)
+	c.Status(http.StatusNoContent)
+}
+
+// Unlock User godoc
+// @Summary Unlock an user
+// @Description Forget the failed logins of the user, ending the lockout of its email
+// @Tags users
+// @Param id path string true "User ID"
+// @Success 204
+// @Failure 400 {object} resterrors.RestErr
+// @Failure 401 {object} resterrors.RestErr
+// @Failure 403 {object} resterrors.RestErr
+// @Failure 404 {object} resterrors.RestErr
+// @Failure 500 {object} resterrors.RestErr
+// @Router /users/{id}/unlock [post]
+// @Security ApiKeyAuth
+func (h *loginHandler) UnlockUser(c *gin.Context) {
+	logger.Info("Starting Unlock User Handler", stacktraceUnlockUserHandler)
+
+	userID, err := getIdFromParam(c)
+	if err != nil {
+		c.JSON(err.HttpStatusCode, err)
+		return
+	}
+
+	if err := h.loginService.UnlockUser(c.Request.Context(), userID); err != nil {
+		logger.Error("Error when trying call service", err, stacktraceUnlockUserHandler)
+
+		c.JSON(err.HttpStatusCode, err)
+		return
+	}
+
+	logger.Info("User was unlocked Successfully", zap.String("user_id", userID), stacktraceUnlockUserHandler)
+	c.Status(http.StatusNoContent)
+}
+
+// respondLoginError tells throttled clients when to try again.
+func respondLoginError(c *gin.Context, err *resterrors.RestErr) {
+	if err.RetryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(err.RetryAfter))
+	}
+	c.JSON(err.HttpStatusCode, err)
 }
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/WalterPaes/go-rest-api-crud/internal/handlers/dtos"
+	"github.com/WalterPaes/go-rest-api-crud/internal/handlers/dtos/converter"
+	"github.com/WalterPaes/go-rest-api-crud/internal/services"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/logger"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/validation"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+var (
+	stacktraceEnrollMFAHandler  = zap.String("stacktrace", "enroll-mfa-handler")
+	stacktraceConfirmMFAHandler = zap.String("stacktrace", "confirm-mfa-handler")
+)
+
+type mfaHandler struct {
+	mfaService services.MFAService
+}
+
+func NewMFAHandler(mfaService services.MFAService) *mfaHandler {
+	return &mfaHandler{
+		mfaService: mfaService,
+	}
+}
+
+// Enroll MFA godoc
+// @Summary Start the MFA enrollment of the authenticated user
+// @Description Generate a TOTP secret and its otpauth uri, MFA is enabled once a first code is confirmed
+// @Tags mfa
+// @Produce json
+// @Success 200 {object} dtos.MFAEnrollmentResponse
+// @Failure 400 {object} resterrors.RestErr
+// @Failure 401 {object} resterrors.RestErr
+// @Failure 500 {object} resterrors.RestErr
+// @Router /users/me/mfa [post]
+// @Security ApiKeyAuth
+func (h *mfaHandler) Enroll(c *gin.Context) {
+	logger.Info("Starting Enroll MFA Handler", stacktraceEnrollMFAHandler)
+
+	userID, err := getIdFromPrincipal(c)
+	if err != nil {
+		c.JSON(err.HttpStatusCode, err)
+		return
+	}
+
+	enrollment, err := h.mfaService.Enroll(c.Request.Context(), userID)
+	if err != nil {
+		logger.Error(errTryCallService, err, stacktraceEnrollMFAHandler)
+
+		c.JSON(err.HttpStatusCode, err)
+		return
+	}
+
+	logger.Info("MFA Enrollment Started Successfully", zap.String("user_id", userID), stacktraceEnrollMFAHandler)
+	c.JSON(http.StatusOK, converter.MFAEnrollmentToMFAEnrollmentResponse(enrollment))
+}
+
+// Confirm MFA godoc
+// @Summary Confirm the MFA enrollment of the authenticated user
+// @Description Enable MFA with a first code of the enrolled secret, the recovery codes are only shown in this response
+// @Tags mfa
+// @Accept json
+// @Produce json
+// @Param request body dtos.ConfirmMFARequest true "Confirm MFA Request"
+// @Success 200 {object} dtos.RecoveryCodesResponse
+// @Failure 400 {object} resterrors.RestErr
+// @Failure 401 {object} resterrors.RestErr
+// @Failure 500 {object} resterrors.RestErr
+// @Router /users/me/mfa/confirm [post]
+// @Security ApiKeyAuth
+func (h *mfaHandler) Confirm(c *gin.Context) {
+	logger.Info("Starting Confirm MFA Handler", stacktraceConfirmMFAHandler)
+
+	userID, err := getIdFromPrincipal(c)
+	if err != nil {
+		c.JSON(err.HttpStatusCode, err)
+		return
+	}
+
+	var confirmMFARequest dtos.ConfirmMFARequest
+	if err := c.ShouldBindJSON(&confirmMFARequest); err != nil {
+		logger.Error("Confirm MFA Request Validation Error", err, stacktraceConfirmMFAHandler)
+
+		restErr := validation.ValidationUserError(err)
+		c.JSON(restErr.HttpStatusCode, restErr)
+		return
+	}
+
+	recoveryCodes, err := h.mfaService.Confirm(c.Request.Context(), userID, confirmMFARequest.Code)
+	if err != nil {
+		logger.Error(errTryCallService, err, stacktraceConfirmMFAHandler)
+
+		c.JSON(err.HttpStatusCode, err)
+		return
+	}
+
+	logger.Info("MFA Enabled Successfully", zap.String("user_id", userID), stacktraceConfirmMFAHandler)
+	c.JSON(http.StatusOK, dtos.RecoveryCodesResponse{RecoveryCodes: recoveryCodes})
+}
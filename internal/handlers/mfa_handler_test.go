@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	"github.com/WalterPaes/go-rest-api-crud/internal/handlers/dtos"
+	"github.com/WalterPaes/go-rest-api-crud/mocks"
+	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func Test_mfaHandler_Enroll(t *testing.T) {
+	userID := primitive.NewObjectID().Hex()
+
+	t.Run("Should return the secret and its otpauth uri", func(t *testing.T) {
+		mfaService := mocks.NewMFAService(t)
+		mfaService.On("Enroll", mock.Anything, userID).
+			Return(&domain.MFAEnrollment{Secret: "SECRET", URI: "otpauth://totp/issuer:user?secret=SECRET"}, nil)
+
+		recorder := httptest.NewRecorder()
+		ctx := getAuthenticatedContext(recorder, userID)
+
+		NewMFAHandler(mfaService).Enroll(ctx)
+
+		var response dtos.MFAEnrollmentResponse
+		assert.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		assert.EqualValues(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, "SECRET", response.Secret)
+		assert.Equal(t, "otpauth://totp/issuer:user?secret=SECRET", response.OtpauthURI)
+	})
+
+	t.Run("Should return unauthorized when there is no authenticated user", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+
+		NewMFAHandler(mocks.NewMFAService(t)).Enroll(getContext(recorder))
+
+		assert.EqualValues(t, http.StatusUnauthorized, recorder.Code)
+	})
+}
+
+func Test_mfaHandler_Confirm(t *testing.T) {
+	userID := primitive.NewObjectID().Hex()
+
+	t.Run("Should return the recovery codes", func(t *testing.T) {
+		mfaService := mocks.NewMFAService(t)
+		mfaService.On("Confirm", mock.Anything, userID, "123456").
+			Return([]string{"code-1", "code-2"}, nil)
+
+		recorder := httptest.NewRecorder()
+		ctx := getAuthenticatedJSONContext(recorder, userID, `{"code": "123456"}`)
+
+		NewMFAHandler(mfaService).Confirm(ctx)
+
+		var response dtos.RecoveryCodesResponse
+		assert.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		assert.EqualValues(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, []string{"code-1", "code-2"}, response.RecoveryCodes)
+	})
+
+	t.Run("Should return an error when the code is not numeric", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		ctx := getAuthenticatedJSONContext(recorder, userID, `{"code": "abcdef"}`)
+
+		NewMFAHandler(mocks.NewMFAService(t)).Confirm(ctx)
+
+		assert.EqualValues(t, http.StatusBadRequest, recorder.Code)
+	})
+
+	t.Run("Should return an error when try call mfa service", func(t *testing.T) {
+		mfaService := mocks.NewMFAService(t)
+		mfaService.On("Confirm", mock.Anything, userID, "123456").
+			Return(nil, resterrors.NewBadRequestError("MFA Code is Invalid"))
+
+		recorder := httptest.NewRecorder()
+		ctx := getAuthenticatedJSONContext(recorder, userID, `{"code": "123456"}`)
+
+		NewMFAHandler(mfaService).Confirm(ctx)
+
+		assert.EqualValues(t, http.StatusBadRequest, recorder.Code)
+	})
+}
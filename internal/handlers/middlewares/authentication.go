@@ -0,0 +1,41 @@
+package middlewares
+
+import (
+	"strings"
+
+	"github.com/WalterPaes/go-rest-api-crud/internal/auth"
+	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	"github.com/WalterPaes/go-rest-api-crud/internal/services"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+var (
+	stacktraceAuthenticationMiddleware = zap.String("stacktrace", "authentication-middleware")
+)
+
+// Authenticate accepts the personal access tokens, sent as "Bearer pat_...", and
+// hands any other token to the jwt middleware.
+func Authenticate(jwtMiddleware gin.HandlerFunc, personalAccessTokenService services.PersonalAccessTokenService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := strings.TrimPrefix(c.Request.Header.Get("Authorization"), "Bearer ")
+		if !strings.HasPrefix(token, domain.PersonalAccessTokenPrefix) {
+			jwtMiddleware(c)
+			return
+		}
+
+		principal, err := personalAccessTokenService.Authenticate(c.Request.Context(), token)
+		if err != nil {
+			logger.Error(err.Message, err, stacktraceAuthenticationMiddleware)
+
+			c.JSON(err.HttpStatusCode, err)
+			c.Abort()
+			return
+		}
+
+		auth.SetPrincipal(c, principal)
+
+		logger.Info("User authenticated", zap.String("user_id", principal.UserID), zap.String("personal_access_token_id", principal.TokenID))
+	}
+}
@@ -0,0 +1,70 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/WalterPaes/go-rest-api-crud/internal/auth"
+	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	"github.com/WalterPaes/go-rest-api-crud/mocks"
+	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_Authenticate(t *testing.T) {
+	jwtMiddleware := func(c *gin.Context) {
+		auth.SetPrincipal(c, &domain.Principal{UserID: otherID})
+	}
+
+	doAuthenticatedRequest := func(middleware gin.HandlerFunc, token string) (*httptest.ResponseRecorder, *domain.Principal) {
+		gin.SetMode(gin.TestMode)
+
+		var principal *domain.Principal
+
+		r := gin.New()
+		r.GET("/users/me", middleware, func(c *gin.Context) {
+			principal, _ = auth.PrincipalFromGin(c)
+			c.Status(http.StatusOK)
+		})
+
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodGet, "/users/me", nil)
+		request.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(recorder, request)
+
+		return recorder, principal
+	}
+
+	t.Run("Should authenticate a personal access token", func(t *testing.T) {
+		tokenService := mocks.NewPersonalAccessTokenService(t)
+		tokenService.On("Authenticate", mock.Anything, "pat_token").
+			Return(&domain.Principal{UserID: ownerID, Scopes: []string{domain.ScopeUsersRead}}, nil)
+
+		recorder, principal := doAuthenticatedRequest(Authenticate(jwtMiddleware, tokenService), "pat_token")
+
+		assert.EqualValues(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, ownerID, principal.UserID)
+		assert.Equal(t, []string{domain.ScopeUsersRead}, principal.Scopes)
+	})
+
+	t.Run("Should refuse an invalid personal access token", func(t *testing.T) {
+		tokenService := mocks.NewPersonalAccessTokenService(t)
+		tokenService.On("Authenticate", mock.Anything, "pat_invalid").
+			Return(nil, resterrors.NewUnauthorizedError("Personal Access Token is Invalid"))
+
+		recorder, principal := doAuthenticatedRequest(Authenticate(jwtMiddleware, tokenService), "pat_invalid")
+
+		assert.EqualValues(t, http.StatusUnauthorized, recorder.Code)
+		assert.Nil(t, principal)
+	})
+
+	t.Run("Should hand the other tokens to the jwt middleware", func(t *testing.T) {
+		recorder, principal := doAuthenticatedRequest(Authenticate(jwtMiddleware, mocks.NewPersonalAccessTokenService(t)), "header.payload.signature")
+
+		assert.EqualValues(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, otherID, principal.UserID)
+	})
+}
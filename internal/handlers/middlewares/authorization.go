@@ -0,0 +1,130 @@
+package middlewares
+
+import (
+	"github.com/WalterPaes/go-rest-api-crud/internal/auth"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/logger"
+	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	errAccessDenied         = "You don't have permission to access this resource"
+	errImpersonationRefused = "This action can't be done while impersonating an user"
+
+	// ErrCodeImpersonationForbidden tells the client the action needs the user itself.
+	ErrCodeImpersonationForbidden = "impersonation_forbidden"
+)
+
+var (
+	stacktraceAuthorizationMiddleware = zap.String("stacktrace", "authorization-middleware")
+)
+
+// RequireRole only lets the request through when the authenticated user has one
+// of the roles. It must run after jwtAuth.VerifyTokenMiddleware.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if HasRole(c, roles...) {
+			c.Next()
+			return
+		}
+
+		abortForbidden(c)
+	}
+}
+
+// RequireOwnerOrRole lets the request through when the user id in the path param
+// is the authenticated user itself, or when the user has one of the roles.
+func RequireOwnerOrRole(param string, roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if IsOwner(c, c.Param(param)) || HasRole(c, roles...) {
+			c.Next()
+			return
+		}
+
+		abortForbidden(c)
+	}
+}
+
+// RequireScope only lets the request through when the credentials have the scope.
+// The sessions of a login have every scope, only the tokens of machine clients are limited.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if principal, ok := auth.PrincipalFromGin(c); ok && principal.HasScope(scope) {
+			c.Next()
+			return
+		}
+
+		abortForbidden(c)
+	}
+}
+
+// AllowClients lets the OAuth clients skip the check, usually a RequireRole, since
+// they have no roles and are only authorized by RequireScope.
+func AllowClients(check gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if IsClient(c) {
+			c.Next()
+			return
+		}
+
+		check(c)
+	}
+}
+
+// RejectImpersonation refuses the sensitive actions, like changing the password or
+// deleting the account, to an admin impersonating the user.
+func RejectImpersonation(c *gin.Context) {
+	principal, ok := auth.PrincipalFromGin(c)
+	if !ok || !principal.IsImpersonated() {
+		c.Next()
+		return
+	}
+
+	restErr := resterrors.NewForbiddenError(errImpersonationRefused).WithCode(ErrCodeImpersonationForbidden)
+	logger.Error(restErr.Message, restErr,
+		zap.String("user_id", principal.UserID),
+		zap.String("actor_id", principal.ActorID),
+		zap.String("path", c.FullPath()),
+		stacktraceAuthorizationMiddleware,
+	)
+
+	c.JSON(restErr.HttpStatusCode, restErr)
+	c.Abort()
+}
+
+func HasRole(c *gin.Context, roles ...string) bool {
+	principal, ok := auth.PrincipalFromGin(c)
+	return ok && principal.HasRole(roles...)
+}
+
+func IsOwner(c *gin.Context, userID string) bool {
+	principal, ok := auth.PrincipalFromGin(c)
+	return ok && principal.IsOwner(userID)
+}
+
+func IsClient(c *gin.Context) bool {
+	principal, ok := auth.PrincipalFromGin(c)
+	return ok && principal.IsClient()
+}
+
+func abortForbidden(c *gin.Context) {
+	var userID, clientID, actorID string
+	if principal, ok := auth.PrincipalFromGin(c); ok {
+		userID = principal.UserID
+		clientID = principal.ClientID
+		actorID = principal.ActorID
+	}
+
+	restErr := resterrors.NewForbiddenError(errAccessDenied)
+	logger.Error(restErr.Message, restErr,
+		zap.String("user_id", userID),
+		zap.String("client_id", clientID),
+		zap.String("actor_id", actorID),
+		zap.String("path", c.FullPath()),
+		stacktraceAuthorizationMiddleware,
+	)
+
+	c.JSON(restErr.HttpStatusCode, restErr)
+	c.Abort()
+}
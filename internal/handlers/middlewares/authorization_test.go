@@ -0,0 +1,212 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/WalterPaes/go-rest-api-crud/internal/auth"
+	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	logger.Init("debug", "stdout")
+}
+
+const (
+	ownerID = "65d3f1d1e4b0a1a2b3c4d5e6"
+	otherID = "65d3f1d1e4b0a1a2b3c4d5e7"
+)
+
+func getRouter(userID string, roles []string, handlers ...gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	authenticated := func(c *gin.Context) {
+		if userID != "" {
+			auth.SetPrincipal(c, &domain.Principal{UserID: userID, Roles: roles})
+		}
+	}
+	handlers = append([]gin.HandlerFunc{authenticated}, handlers...)
+	handlers = append(handlers, func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	r.GET("/users/:id", handlers...)
+	return r
+}
+
+func getClientRouter(scopes []string, handlers ...gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	authenticated := func(c *gin.Context) {
+		auth.SetPrincipal(c, &domain.Principal{ClientID: "client_id", Scopes: scopes})
+	}
+	handlers = append([]gin.HandlerFunc{authenticated}, handlers...)
+	handlers = append(handlers, func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	r.GET("/users/:id", handlers...)
+	return r
+}
+
+func getImpersonationRouter(handlers ...gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	authenticated := func(c *gin.Context) {
+		auth.SetPrincipal(c, &domain.Principal{UserID: ownerID, Roles: []string{domain.RoleUser}, ActorID: otherID})
+	}
+	handlers = append([]gin.HandlerFunc{authenticated}, handlers...)
+	handlers = append(handlers, func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	r.GET("/users/:id", handlers...)
+	return r
+}
+
+func doRequest(r *gin.Engine, path string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+	return recorder
+}
+
+func Test_RequireRole(t *testing.T) {
+	t.Run("Should let an admin through", func(t *testing.T) {
+		r := getRouter(ownerID, []string{domain.RoleAdmin}, RequireRole(domain.RoleAdmin))
+
+		assert.EqualValues(t, http.StatusOK, doRequest(r, "/users/"+otherID).Code)
+	})
+
+	t.Run("Should forbid an user without the role", func(t *testing.T) {
+		r := getRouter(ownerID, []string{domain.RoleUser}, RequireRole(domain.RoleAdmin))
+
+		assert.EqualValues(t, http.StatusForbidden, doRequest(r, "/users/"+ownerID).Code)
+	})
+
+	t.Run("Should forbid a client, which has no role", func(t *testing.T) {
+		r := getClientRouter([]string{domain.ScopeUsersRead}, RequireRole(domain.RoleAdmin))
+
+		assert.EqualValues(t, http.StatusForbidden, doRequest(r, "/users/"+ownerID).Code)
+	})
+}
+
+func Test_RequireOwnerOrRole(t *testing.T) {
+	t.Run("Should let the owner through", func(t *testing.T) {
+		r := getRouter(ownerID, []string{domain.RoleUser}, RequireOwnerOrRole("id", domain.RoleAdmin))
+
+		assert.EqualValues(t, http.StatusOK, doRequest(r, "/users/"+ownerID).Code)
+	})
+
+	t.Run("Should let an admin access another user", func(t *testing.T) {
+		r := getRouter(ownerID, []string{domain.RoleAdmin}, RequireOwnerOrRole("id", domain.RoleAdmin))
+
+		assert.EqualValues(t, http.StatusOK, doRequest(r, "/users/"+otherID).Code)
+	})
+
+	t.Run("Should forbid an user to access another user", func(t *testing.T) {
+		r := getRouter(ownerID, []string{domain.RoleUser}, RequireOwnerOrRole("id", domain.RoleAdmin))
+
+		assert.EqualValues(t, http.StatusForbidden, doRequest(r, "/users/"+otherID).Code)
+	})
+
+	t.Run("Should forbid a request without authenticated user", func(t *testing.T) {
+		r := getRouter("", nil, RequireOwnerOrRole("id", domain.RoleAdmin))
+
+		assert.EqualValues(t, http.StatusForbidden, doRequest(r, "/users/"+ownerID).Code)
+	})
+}
+
+func Test_RequireScope(t *testing.T) {
+	getScopedRouter := func(scopes []string) *gin.Engine {
+		gin.SetMode(gin.TestMode)
+
+		r := gin.New()
+		r.GET("/users/:id", func(c *gin.Context) {
+			auth.SetPrincipal(c, &domain.Principal{UserID: ownerID, Scopes: scopes})
+		}, RequireScope(domain.ScopeUsersWrite), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+		return r
+	}
+
+	t.Run("Should let a login session through", func(t *testing.T) {
+		assert.EqualValues(t, http.StatusOK, doRequest(getScopedRouter(nil), "/users/"+ownerID).Code)
+	})
+
+	t.Run("Should let a token with the scope through", func(t *testing.T) {
+		r := getScopedRouter([]string{domain.ScopeUsersRead, domain.ScopeUsersWrite})
+
+		assert.EqualValues(t, http.StatusOK, doRequest(r, "/users/"+ownerID).Code)
+	})
+
+	t.Run("Should forbid a token without the scope", func(t *testing.T) {
+		r := getScopedRouter([]string{domain.ScopeUsersRead})
+
+		assert.EqualValues(t, http.StatusForbidden, doRequest(r, "/users/"+ownerID).Code)
+	})
+
+	t.Run("Should forbid a request without principal", func(t *testing.T) {
+		r := getRouter("", nil, RequireScope(domain.ScopeUsersRead))
+
+		assert.EqualValues(t, http.StatusForbidden, doRequest(r, "/users/"+ownerID).Code)
+	})
+
+	t.Run("Should forbid a client without the scope", func(t *testing.T) {
+		r := getClientRouter([]string{domain.ScopeUsersRead}, RequireScope(domain.ScopeUsersWrite))
+
+		assert.EqualValues(t, http.StatusForbidden, doRequest(r, "/users/"+ownerID).Code)
+	})
+}
+
+func Test_AllowClients(t *testing.T) {
+	t.Run("Should leave a client to the scope check", func(t *testing.T) {
+		r := getClientRouter([]string{domain.ScopeUsersRead}, AllowClients(RequireRole(domain.RoleAdmin)), RequireScope(domain.ScopeUsersRead))
+
+		assert.EqualValues(t, http.StatusOK, doRequest(r, "/users/"+ownerID).Code)
+	})
+
+	t.Run("Should still check the users", func(t *testing.T) {
+		r := getRouter(ownerID, []string{domain.RoleUser}, AllowClients(RequireRole(domain.RoleAdmin)))
+
+		assert.EqualValues(t, http.StatusForbidden, doRequest(r, "/users/"+otherID).Code)
+	})
+
+	t.Run("Should let an user with the role through", func(t *testing.T) {
+		r := getRouter(ownerID, []string{domain.RoleAdmin}, AllowClients(RequireRole(domain.RoleAdmin)))
+
+		assert.EqualValues(t, http.StatusOK, doRequest(r, "/users/"+otherID).Code)
+	})
+}
+
+func Test_RejectImpersonation(t *testing.T) {
+	t.Run("Should let the user itself through", func(t *testing.T) {
+		r := getRouter(ownerID, []string{domain.RoleUser}, RejectImpersonation)
+
+		assert.EqualValues(t, http.StatusOK, doRequest(r, "/users/"+ownerID).Code)
+	})
+
+	t.Run("Should let a client through", func(t *testing.T) {
+		r := getClientRouter([]string{domain.ScopeUsersRead}, RejectImpersonation)
+
+		assert.EqualValues(t, http.StatusOK, doRequest(r, "/users/"+ownerID).Code)
+	})
+
+	t.Run("Should forbid an admin impersonating the user", func(t *testing.T) {
+		r := getImpersonationRouter(RejectImpersonation)
+
+		recorder := doRequest(r, "/users/"+ownerID)
+
+		assert.EqualValues(t, http.StatusForbidden, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), ErrCodeImpersonationForbidden)
+	})
+
+	t.Run("Should still let an impersonation through the owner check", func(t *testing.T) {
+		r := getImpersonationRouter(RequireOwnerOrRole("id", domain.RoleAdmin))
+
+		assert.EqualValues(t, http.StatusOK, doRequest(r, "/users/"+ownerID).Code)
+	})
+}
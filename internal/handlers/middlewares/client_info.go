@@ -0,0 +1,18 @@
+package middlewares
+
+import (
+	"github.com/WalterPaes/go-rest-api-crud/internal/auth"
+	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	"github.com/gin-gonic/gin"
+)
+
+// ClientInfo stores the ip and user agent of the request in its context, so the
+// services can use them. The ip only comes from forwarded headers sent by the
+// trusted proxies configured in gin.
+func ClientInfo(c *gin.Context) {
+	c.Request = c.Request.WithContext(auth.WithClientInfo(c.Request.Context(), &domain.ClientInfo{
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}))
+	c.Next()
+}
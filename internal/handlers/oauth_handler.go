@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	"github.com/WalterPaes/go-rest-api-crud/internal/handlers/dtos"
+	"github.com/WalterPaes/go-rest-api-crud/internal/handlers/dtos/converter"
+	"github.com/WalterPaes/go-rest-api-crud/internal/services"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/logger"
+	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/validation"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	errUnsupportedGrantType  = "Only the client_credentials grant is supported"
+	errMissingClientSecret   = "Client Credentials are missing"
+	errMalformedClientHeader = "Client Credentials in the Authorization header are malformed"
+)
+
+var (
+	stacktraceOAuthTokenHandler          = zap.String("stacktrace", "oauth-token-handler")
+	stacktraceRegisterOAuthClientHandler = zap.String("stacktrace", "register-oauth-client-handler")
+	stacktraceListOAuthClientsHandler    = zap.String("stacktrace", "list-oauth-clients-handler")
+	stacktraceDeleteOAuthClientHandler   = zap.String("stacktrace", "delete-oauth-client-handler")
+)
+
+type oauthHandler struct {
+	oauthService services.OAuthService
+}
+
+func NewOAuthHandler(oauthService services.OAuthService) *oauthHandler {
+	return &oauthHandler{
+		oauthService: oauthService,
+	}
+}
+
+// OAuth Token godoc
+// @Summary Issue an access token to an OAuth client
+// @Description Implement the client_credentials grant, the client authenticates with HTTP Basic or with client_id and client_secret in the form
+// @Tags oauth
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param grant_type formData string true "Grant Type, only client_credentials"
+// @Param scope formData string false "Space separated scopes, every scope of the client when empty"
+// @Param client_id formData string false "Client ID, when not sent with HTTP Basic"
+// @Param client_secret formData string false "Client Secret, when not sent with HTTP Basic"
+// @Success 200 {object} dtos.OAuthTokenResponse
+// @Failure 400 {object} resterrors.RestErr
+// @Failure 401 {object} resterrors.RestErr
+// @Failure 500 {object} resterrors.RestErr
+// @Router /oauth/token [post]
+func (h *oauthHandler) Token(c *gin.Context) {
+	logger.Info("Starting OAuth Token Handler", stacktraceOAuthTokenHandler)
+
+	c.Header("Cache-Control", "no-store")
+
+	var tokenRequest dtos.OAuthTokenRequest
+	if err := c.ShouldBind(&tokenRequest); err != nil {
+		logger.Error("OAuth Token Request Validation Error", err, stacktraceOAuthTokenHandler)
+
+		restErr := validation.ValidationUserError(err).WithCode(domain.OAuthErrInvalidRequest)
+		c.JSON(restErr.HttpStatusCode, restErr)
+		return
+	}
+
+	if tokenRequest.GrantType != domain.GrantTypeClientCredentials {
+		restErr := resterrors.NewBadRequestError(errUnsupportedGrantType).WithCode(domain.OAuthErrUnsupportedGrantType)
+		logger.Error(restErr.Message, restErr, zap.String("grant_type", tokenRequest.GrantType), stacktraceOAuthTokenHandler)
+
+		c.JSON(restErr.HttpStatusCode, restErr)
+		return
+	}
+
+	clientID, clientSecret, err := clientCredentials(c, tokenRequest)
+	if err != nil {
+		logger.Error(err.Message, err, stacktraceOAuthTokenHandler)
+
+		c.JSON(err.HttpStatusCode, err)
+		return
+	}
+
+	token, err := h.oauthService.ClientCredentialsToken(c.Request.Context(), clientID, clientSecret, tokenRequest.Scope)
+	if err != nil {
+		logger.Error(errTryCallService, err, stacktraceOAuthTokenHandler)
+
+		c.JSON(err.HttpStatusCode, err)
+		return
+	}
+
+	logger.Info("OAuth Token Issued Successfully", zap.String("client_id", clientID), stacktraceOAuthTokenHandler)
+	c.JSON(http.StatusOK, converter.OAuthTokenToOAuthTokenResponse(token))
+}
+
+// Register OAuth Client godoc
+// @Summary Register an OAuth client
+// @Description Register a service allowed to get access tokens with the given scopes, the client secret is only shown in this response
+// @Tags oauth
+// @Accept json
+// @Produce json
+// @Param request body dtos.RegisterOAuthClientRequest true "Register OAuth Client Request"
+// @Success 201 {object} dtos.RegisterOAuthClientResponse
+// @Failure 400 {object} resterrors.RestErr
+// @Failure 401 {object} resterrors.RestErr
+// @Failure 403 {object} resterrors.RestErr
+// @Failure 500 {object} resterrors.RestErr
+// @Router /oauth/clients [post]
+// @Security ApiKeyAuth
+func (h *oauthHandler) RegisterClient(c *gin.Context) {
+	logger.Info("Starting Register OAuth Client Handler", stacktraceRegisterOAuthClientHandler)
+
+	var registerClientRequest dtos.RegisterOAuthClientRequest
+	if err := c.ShouldBindJSON(&registerClientRequest); err != nil {
+		logger.Error("Register OAuth Client Request Validation Error", err, stacktraceRegisterOAuthClientHandler)
+
+		restErr := validation.ValidationUserError(err)
+		c.JSON(restErr.HttpStatusCode, restErr)
+		return
+	}
+
+	client, clientSecret, err := h.oauthService.RegisterClient(c.Request.Context(), registerClientRequest.Name, registerClientRequest.Scopes)
+	if err != nil {
+		logger.Error(errTryCallService, err, stacktraceRegisterOAuthClientHandler)
+
+		c.JSON(err.HttpStatusCode, err)
+		return
+	}
+
+	logger.Info("OAuth Client Registered Successfully", zap.String("client_id", client.ClientID), stacktraceRegisterOAuthClientHandler)
+	c.JSON(http.StatusCreated, dtos.RegisterOAuthClientResponse{
+		OAuthClientResponse: converter.OAuthClientDomainToOAuthClientResponse(client),
+		ClientSecret:        clientSecret,
+	})
+}
+
+// List OAuth Clients godoc
+// @Summary List the OAuth clients
+// @Description List the registered clients, their secrets are never returned
+// @Tags oauth
+// @Produce json
+// @Success 200 {array} dtos.OAuthClientResponse
+// @Failure 401 {object} resterrors.RestErr
+// @Failure 403 {object} resterrors.RestErr
+// @Failure 500 {object} resterrors.RestErr
+// @Router /oauth/clients [get]
+// @Security ApiKeyAuth
+func (h *oauthHandler) ListClients(c *gin.Context) {
+	logger.Info("Starting List OAuth Clients Handler", stacktraceListOAuthClientsHandler)
+
+	clients, err := h.oauthService.ListClients(c.Request.Context())
+	if err != nil {
+		logger.Error(errTryCallService, err, stacktraceListOAuthClientsHandler)
+
+		c.JSON(err.HttpStatusCode, err)
+		return
+	}
+
+	response := make([]dtos.OAuthClientResponse, len(clients))
+	for i, client := range clients {
+		response[i] = converter.OAuthClientDomainToOAuthClientResponse(client)
+	}
+
+	logger.Info("OAuth Clients Listed Successfully", stacktraceListOAuthClientsHandler)
+	c.JSON(http.StatusOK, response)
+}
+
+// Delete OAuth Client godoc
+// @Summary Delete an OAuth client
+// @Description Delete the client, no token is issued to it anymore and the issued ones are accepted until they expire
+// @Tags oauth
+// @Param client_id path string true "Client ID"
+// @Success 204
+// @Failure 401 {object} resterrors.RestErr
+// @Failure 403 {object} resterrors.RestErr
+// @Failure 404 {object} resterrors.RestErr
+// @Failure 500 {object} resterrors.RestErr
+// @Router /oauth/clients/{client_id} [delete]
+// @Security ApiKeyAuth
+func (h *oauthHandler) DeleteClient(c *gin.Context) {
+	logger.Info("Starting Delete OAuth Client Handler", stacktraceDeleteOAuthClientHandler)
+
+	clientID := c.Param("client_id")
+	if err := h.oauthService.DeleteClient(c.Request.Context(), clientID); err != nil {
+		logger.Error(errTryCallService, err, stacktraceDeleteOAuthClientHandler)
+
+		c.JSON(err.HttpStatusCode, err)
+		return
+	}
+
+	logger.Info("OAuth Client Deleted Successfully", zap.String("client_id", clientID), stacktraceDeleteOAuthClientHandler)
+	c.Status(http.StatusNoContent)
+}
+
+// clientCredentials reads the client credentials from the HTTP Basic authentication,
+// whose values are form encoded as the RFC 6749 requires, or else from the form.
+func clientCredentials(c *gin.Context, tokenRequest dtos.OAuthTokenRequest) (string, string, *resterrors.RestErr) {
+	if username, password, ok := c.Request.BasicAuth(); ok {
+		clientID, errID := url.QueryUnescape(username)
+		clientSecret, errSecret := url.QueryUnescape(password)
+		if errID != nil || errSecret != nil {
+			return "", "", resterrors.NewUnauthorizedError(errMalformedClientHeader).WithCode(domain.OAuthErrInvalidClient)
+		}
+		return clientID, clientSecret, nil
+	}
+
+	if tokenRequest.ClientID == "" || tokenRequest.ClientSecret == "" {
+		return "", "", resterrors.NewUnauthorizedError(errMissingClientSecret).WithCode(domain.OAuthErrInvalidClient)
+	}
+	return tokenRequest.ClientID, tokenRequest.ClientSecret, nil
+}
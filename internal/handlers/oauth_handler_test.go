@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	"github.com/WalterPaes/go-rest-api-crud/internal/handlers/dtos"
+	"github.com/WalterPaes/go-rest-api-crud/mocks"
+	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func getFormContext(recorder *httptest.ResponseRecorder, body string) *gin.Context {
+	ctx := getContext(recorder)
+	ctx.Request.Method = http.MethodPost
+	ctx.Request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	ctx.Request.Body = io.NopCloser(strings.NewReader(body))
+	return ctx
+}
+
+func Test_oauthHandler_Token(t *testing.T) {
+	issuedToken := &domain.OAuthToken{
+		AccessToken: "access-token",
+		ExpiresIn:   3600,
+		Scopes:      []string{domain.ScopeUsersRead},
+	}
+
+	t.Run("Should issue a token to a client authenticated with http basic", func(t *testing.T) {
+		oauthService := mocks.NewOAuthService(t)
+		oauthService.On("ClientCredentialsToken", mock.Anything, "client-id", "client-secret", "users:read").
+			Return(issuedToken, nil)
+
+		recorder := httptest.NewRecorder()
+		ctx := getFormContext(recorder, "grant_type=client_credentials&scope=users%3Aread")
+		ctx.Request.SetBasicAuth("client-id", "client-secret")
+
+		NewOAuthHandler(oauthService).Token(ctx)
+
+		var response dtos.OAuthTokenResponse
+		assert.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		assert.EqualValues(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, "no-store", recorder.Header().Get("Cache-Control"))
+		assert.Equal(t, dtos.OAuthTokenResponse{
+			AccessToken: "access-token",
+			TokenType:   "Bearer",
+			ExpiresIn:   3600,
+			Scope:       "users:read",
+		}, response)
+	})
+
+	t.Run("Should issue a token to a client sending its credentials in the form", func(t *testing.T) {
+		oauthService := mocks.NewOAuthService(t)
+		oauthService.On("ClientCredentialsToken", mock.Anything, "client-id", "client-secret", "").
+			Return(issuedToken, nil)
+
+		recorder := httptest.NewRecorder()
+		ctx := getFormContext(recorder, "grant_type=client_credentials&client_id=client-id&client_secret=client-secret")
+
+		NewOAuthHandler(oauthService).Token(ctx)
+
+		assert.EqualValues(t, http.StatusOK, recorder.Code)
+	})
+
+	t.Run("Should return unsupported grant type for the other grants", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		ctx := getFormContext(recorder, "grant_type=password&client_id=client-id&client_secret=client-secret")
+
+		NewOAuthHandler(mocks.NewOAuthService(t)).Token(ctx)
+
+		var response resterrors.RestErr
+		assert.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		assert.EqualValues(t, http.StatusBadRequest, recorder.Code)
+		assert.Equal(t, domain.OAuthErrUnsupportedGrantType, response.Code)
+	})
+
+	t.Run("Should return invalid request when the grant type is missing", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		ctx := getFormContext(recorder, "client_id=client-id&client_secret=client-secret")
+
+		NewOAuthHandler(mocks.NewOAuthService(t)).Token(ctx)
+
+		var response map[string]interface{}
+		assert.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		assert.EqualValues(t, http.StatusBadRequest, recorder.Code)
+		assert.Equal(t, domain.OAuthErrInvalidRequest, response["code"])
+	})
+
+	t.Run("Should return invalid client when the credentials are missing", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		ctx := getFormContext(recorder, "grant_type=client_credentials&client_id=client-id")
+
+		NewOAuthHandler(mocks.NewOAuthService(t)).Token(ctx)
+
+		var response resterrors.RestErr
+		assert.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		assert.EqualValues(t, http.StatusUnauthorized, recorder.Code)
+		assert.Equal(t, domain.OAuthErrInvalidClient, response.Code)
+	})
+
+	t.Run("Should return an error when try call oauth service", func(t *testing.T) {
+		oauthService := mocks.NewOAuthService(t)
+		oauthService.On("ClientCredentialsToken", mock.Anything, "client-id", "wrong-secret", "").
+			Return(nil, resterrors.NewUnauthorizedError("Client Credentials are Invalid").WithCode(domain.OAuthErrInvalidClient))
+
+		recorder := httptest.NewRecorder()
+		ctx := getFormContext(recorder, "grant_type=client_credentials")
+		ctx.Request.SetBasicAuth("client-id", "wrong-secret")
+
+		NewOAuthHandler(oauthService).Token(ctx)
+
+		assert.EqualValues(t, http.StatusUnauthorized, recorder.Code)
+	})
+}
+
+func Test_oauthHandler_RegisterClient(t *testing.T) {
+	t.Run("Should return the client and its secret", func(t *testing.T) {
+		oauthService := mocks.NewOAuthService(t)
+		oauthService.On("RegisterClient", mock.Anything, "billing", []string{domain.ScopeUsersRead}).
+			Return(&domain.OAuthClient{ClientID: "client-id", Name: "billing", SecretHash: "hash", Scopes: []string{domain.ScopeUsersRead}}, "client-secret", nil)
+
+		recorder := httptest.NewRecorder()
+		ctx := getFormContext(recorder, `{"name": "billing", "scopes": ["users:read"]}`)
+		ctx.Request.Header.Set("Content-Type", "application/json")
+
+		NewOAuthHandler(oauthService).RegisterClient(ctx)
+
+		var response map[string]interface{}
+		assert.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		assert.EqualValues(t, http.StatusCreated, recorder.Code)
+		assert.Equal(t, "client-id", response["client_id"])
+		assert.Equal(t, "client-secret", response["client_secret"])
+		assert.NotContains(t, response, "secret_hash")
+	})
+
+	t.Run("Should return an error when a scope is unknown", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		ctx := getFormContext(recorder, `{"name": "billing", "scopes": ["admin"]}`)
+		ctx.Request.Header.Set("Content-Type", "application/json")
+
+		NewOAuthHandler(mocks.NewOAuthService(t)).RegisterClient(ctx)
+
+		assert.EqualValues(t, http.StatusBadRequest, recorder.Code)
+	})
+}
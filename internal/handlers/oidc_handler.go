@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/WalterPaes/go-rest-api-crud/internal/handlers/dtos"
+	"github.com/WalterPaes/go-rest-api-crud/internal/handlers/dtos/converter"
+	"github.com/WalterPaes/go-rest-api-crud/internal/services"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/logger"
+	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/validation"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	errOIDCLoginRefused = "Identity provider refused the login"
+
+	ErrCodeOIDCLoginRefused = "oidc_login_refused"
+
+	// oidcFlowCookie keeps the flow token between the login and the callback, only
+	// sent to the OIDC routes.
+	oidcFlowCookie     = "oidc_flow"
+	oidcFlowCookiePath = "/auth/oidc"
+)
+
+var (
+	stacktraceOIDCLoginHandler    = zap.String("stacktrace", "oidc-login-handler")
+	stacktraceOIDCCallbackHandler = zap.String("stacktrace", "oidc-callback-handler")
+)
+
+type oidcHandler struct {
+	oidcService services.OIDCService
+}
+
+func NewOIDCHandler(oidcService services.OIDCService) *oidcHandler {
+	return &oidcHandler{
+		oidcService: oidcService,
+	}
+}
+
+// OIDC Login godoc
+// @Summary Start the login with the external identity provider
+// @Description Redirect to the identity provider, which redirects back to /auth/oidc/callback once the user logs in
+// @Tags login
+// @Success 302
+// @Failure 500 {object} resterrors.RestErr
+// @Router /auth/oidc/login [get]
+func (h *oidcHandler) Login(c *gin.Context) {
+	logger.Info("Starting OIDC Login Handler", stacktraceOIDCLoginHandler)
+
+	authURL, flowToken, err := h.oidcService.StartLogin(c.Request.Context())
+	if err != nil {
+		logger.Error(errTryCallService, err, stacktraceOIDCLoginHandler)
+
+		c.JSON(err.HttpStatusCode, err)
+		return
+	}
+
+	// Lax lets the cookie go along the top level redirect back from the provider
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcFlowCookie, flowToken, 0, oidcFlowCookiePath, "", true, true)
+
+	logger.Info("User was redirected to the identity provider", stacktraceOIDCLoginHandler)
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// OIDC Callback godoc
+// @Summary Finish the login with the external identity provider
+// @Description Log in the user of the identity provider, linked by email to an existing user or registered on its first login. When the user has MFA enabled the response only has the mfa token to send to /login/mfa
+// @Tags login
+// @Produce json
+// @Param code query string false "Authorization Code"
+// @Param state query string false "State of the login"
+// @Param error query string false "Error of a refused login"
+// @Success 200 {object} dtos.LoginResponse
+// @Failure 400 {object} resterrors.RestErr
+// @Failure 401 {object} resterrors.RestErr
+// @Failure 403 {object} resterrors.RestErr
+// @Failure 500 {object} resterrors.RestErr
+// @Router /auth/oidc/callback [get]
+func (h *oidcHandler) Callback(c *gin.Context) {
+	logger.Info("Starting OIDC Callback Handler", stacktraceOIDCCallbackHandler)
+
+	// the flow token is good for a single callback, whatever its result
+	flowToken, _ := c.Cookie(oidcFlowCookie)
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcFlowCookie, "", -1, oidcFlowCookiePath, "", true, true)
+	c.Header("Cache-Control", "no-store")
+
+	var callbackRequest dtos.OIDCCallbackRequest
+	if err := c.ShouldBindQuery(&callbackRequest); err != nil {
+		logger.Error("OIDC Callback Request Validation Error", err, stacktraceOIDCCallbackHandler)
+
+		restErr := validation.ValidationUserError(err)
+		c.JSON(restErr.HttpStatusCode, restErr)
+		return
+	}
+
+	if callbackRequest.Error != "" {
+		restErr := resterrors.NewUnauthorizedError(errOIDCLoginRefused).WithCode(ErrCodeOIDCLoginRefused)
+		logger.Error(restErr.Message, restErr, zap.String("error", callbackRequest.Error), zap.String("error_description", callbackRequest.ErrorDescription), stacktraceOIDCCallbackHandler)
+
+		c.JSON(restErr.HttpStatusCode, restErr)
+		return
+	}
+
+	tokens, err := h.oidcService.FinishLogin(c.Request.Context(), flowToken, callbackRequest.State, callbackRequest.Code)
+	if err != nil {
+		logger.Error(errTryCallService, err, stacktraceOIDCCallbackHandler)
+
+		c.JSON(err.HttpStatusCode, err)
+		return
+	}
+
+	logger.Info("User was logged with the identity provider Successfully", stacktraceOIDCCallbackHandler)
+	c.JSON(http.StatusOK, converter.AuthTokensToLoginResponse(tokens))
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	"github.com/WalterPaes/go-rest-api-crud/internal/handlers/dtos"
+	"github.com/WalterPaes/go-rest-api-crud/internal/services"
+	"github.com/WalterPaes/go-rest-api-crud/mocks"
+	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func getCallbackRequest(query, flowToken string) *http.Request {
+	request := &http.Request{
+		Method: http.MethodGet,
+		Header: make(http.Header),
+		URL:    &url.URL{Path: "/auth/oidc/callback", RawQuery: query},
+	}
+	if flowToken != "" {
+		request.AddCookie(&http.Cookie{Name: oidcFlowCookie, Value: flowToken})
+	}
+	return request
+}
+
+func Test_oidcHandler_Login(t *testing.T) {
+	t.Run("Should redirect to the identity provider keeping the flow token in a cookie", func(t *testing.T) {
+		oidcService := mocks.NewOIDCService(t)
+		oidcService.On("StartLogin", mock.Anything).
+			Return("https://issuer/authorize?state=state", "flow_token", nil)
+
+		recorder := httptest.NewRecorder()
+		ctx := getContext(recorder)
+
+		NewOIDCHandler(oidcService).Login(ctx)
+		ctx.Writer.WriteHeaderNow()
+
+		assert.EqualValues(t, http.StatusFound, recorder.Code)
+		assert.Equal(t, "https://issuer/authorize?state=state", recorder.Header().Get("Location"))
+
+		cookies := recorder.Result().Cookies()
+		assert.Len(t, cookies, 1)
+		assert.Equal(t, oidcFlowCookie, cookies[0].Name)
+		assert.Equal(t, "flow_token", cookies[0].Value)
+		assert.Equal(t, oidcFlowCookiePath, cookies[0].Path)
+		assert.True(t, cookies[0].HttpOnly)
+		assert.True(t, cookies[0].Secure)
+		assert.Equal(t, http.SameSiteLaxMode, cookies[0].SameSite)
+	})
+
+	t.Run("Should return an error when the login can't be started", func(t *testing.T) {
+		oidcService := mocks.NewOIDCService(t)
+		oidcService.On("StartLogin", mock.Anything).
+			Return("", "", resterrors.NewInternalServerError("error"))
+
+		recorder := httptest.NewRecorder()
+		ctx := getContext(recorder)
+
+		NewOIDCHandler(oidcService).Login(ctx)
+
+		assert.EqualValues(t, http.StatusInternalServerError, recorder.Code)
+		assert.Empty(t, recorder.Result().Cookies())
+	})
+}
+
+func Test_oidcHandler_Callback(t *testing.T) {
+	t.Run("Should return the tokens of the user and clear the flow cookie", func(t *testing.T) {
+		oidcService := mocks.NewOIDCService(t)
+		oidcService.On("FinishLogin", mock.Anything, "flow_token", "state", "code").
+			Return(&domain.AuthTokens{AccessToken: "token", RefreshToken: "refresh_token"}, nil)
+
+		recorder := httptest.NewRecorder()
+		ctx := getContext(recorder)
+		ctx.Request = getCallbackRequest("code=code&state=state", "flow_token")
+
+		NewOIDCHandler(oidcService).Callback(ctx)
+
+		var response dtos.LoginResponse
+		assert.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		assert.EqualValues(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, dtos.LoginResponse{Token: "token", RefreshToken: "refresh_token"}, response)
+
+		cookies := recorder.Result().Cookies()
+		assert.Len(t, cookies, 1)
+		assert.Equal(t, oidcFlowCookie, cookies[0].Name)
+		assert.True(t, cookies[0].MaxAge < 0)
+	})
+
+	t.Run("Should return the error of the service", func(t *testing.T) {
+		oidcService := mocks.NewOIDCService(t)
+		oidcService.On("FinishLogin", mock.Anything, "", "state", "code").
+			Return(nil, resterrors.NewBadRequestError("OIDC Login is Invalid or Expired").WithCode(services.ErrCodeInvalidOIDCLogin))
+
+		recorder := httptest.NewRecorder()
+		ctx := getContext(recorder)
+		ctx.Request = getCallbackRequest("code=code&state=state", "")
+
+		NewOIDCHandler(oidcService).Callback(ctx)
+
+		var response resterrors.RestErr
+		assert.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		assert.EqualValues(t, http.StatusBadRequest, recorder.Code)
+		assert.Equal(t, services.ErrCodeInvalidOIDCLogin, response.Code)
+	})
+
+	t.Run("Should return unauthorized when the identity provider refused the login", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		ctx := getContext(recorder)
+		ctx.Request = getCallbackRequest("error=access_denied&state=state", "flow_token")
+
+		NewOIDCHandler(mocks.NewOIDCService(t)).Callback(ctx)
+
+		var response resterrors.RestErr
+		assert.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		assert.EqualValues(t, http.StatusUnauthorized, recorder.Code)
+		assert.Equal(t, ErrCodeOIDCLoginRefused, response.Code)
+	})
+
+	t.Run("Should return bad request when the code is missing", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		ctx := getContext(recorder)
+		ctx.Request = getCallbackRequest("state=state", "flow_token")
+
+		NewOIDCHandler(mocks.NewOIDCService(t)).Callback(ctx)
+
+		assert.EqualValues(t, http.StatusBadRequest, recorder.Code)
+	})
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/WalterPaes/go-rest-api-crud/internal/handlers/dtos"
+	"github.com/WalterPaes/go-rest-api-crud/internal/services"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/logger"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/validation"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+var (
+	stacktraceForgotPasswordHandler = zap.String("stacktrace", "forgot-password-handler")
+	stacktraceResetPasswordHandler  = zap.String("stacktrace", "reset-password-handler")
+)
+
+type passwordHandler struct {
+	passwordService services.PasswordService
+}
+
+func NewPasswordHandler(passwordService services.PasswordService) *passwordHandler {
+	return &passwordHandler{
+		passwordService: passwordService,
+	}
+}
+
+// Forgot Password godoc
+// @Summary Request a password reset
+// @Description Send a reset link to the email, the answer is the same whether the email is registered or not
+// @Tags password
+// @Accept json
+// @Param request body dtos.ForgotPasswordRequest true "Forgot Password Request"
+// @Success 202
+// @Failure 400 {object} resterrors.RestErr
+// @Failure 500 {object} resterrors.RestErr
+// @Router /password/forgot [post]
+func (h *passwordHandler) ForgotPassword(c *gin.Context) {
+	logger.Info("Starting Forgot Password Handler", stacktraceForgotPasswordHandler)
+
+	var forgotPasswordRequest dtos.ForgotPasswordRequest
+
+	if err := c.ShouldBindJSON(&forgotPasswordRequest); err != nil {
+		logger.Error("Forgot Password Request Validation Error", err, stacktraceForgotPasswordHandler)
+
+		restErr := validation.ValidationUserError(err)
+		c.JSON(restErr.HttpStatusCode, restErr)
+		return
+	}
+
+	if err := h.passwordService.ForgotPassword(c.Request.Context(), forgotPasswordRequest.Email); err != nil {
+		logger.Error("Error when trying call service", err, stacktraceForgotPasswordHandler)
+
+		c.JSON(err.HttpStatusCode, err)
+		return
+	}
+
+	logger.Info("Forgot Password was handled Successfully", stacktraceForgotPasswordHandler)
+	c.Status(http.StatusAccepted)
+}
+
+// Reset Password godoc
+// @Summary Reset the password
+// @Description Set a new password using the token sent by the forgot password endpoint
+// @Tags password
+// @Accept json
+// @Param request body dtos.ResetPasswordRequest true "Reset Password Request"
+// @Success 204
+// @Failure 400 {object} resterrors.RestErr
+// @Failure 500 {object} resterrors.RestErr
+// @Router /password/reset [post]
+func (h *passwordHandler) ResetPassword(c *gin.Context) {
+	logger.Info("Starting Reset Password Handler", stacktraceResetPasswordHandler)
+
+	var resetPasswordRequest dtos.ResetPasswordRequest
+
+	if err := c.ShouldBindJSON(&resetPasswordRequest); err != nil {
+		logger.Error("Reset Password Request Validation Error", err, stacktraceResetPasswordHandler)
+
+		restErr := validation.ValidationUserError(err)
+		c.JSON(restErr.HttpStatusCode, restErr)
+		return
+	}
+
+	err := h.passwordService.ResetPassword(c.Request.Context(), resetPasswordRequest.Token, resetPasswordRequest.NewPassword)
+	if err != nil {
+		logger.Error("Error when trying call service", err, stacktraceResetPasswordHandler)
+
+		c.JSON(err.HttpStatusCode, err)
+		return
+	}
+
+	logger.Info("Password was reset Successfully", stacktraceResetPasswordHandler)
+	c.Status(http.StatusNoContent)
+}
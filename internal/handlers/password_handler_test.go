@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/WalterPaes/go-rest-api-crud/mocks"
+	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func getJSONContext(recorder *httptest.ResponseRecorder, body string) *gin.Context {
+	ctx := getContext(recorder)
+	ctx.Request.Method = http.MethodPost
+	ctx.Request.Header.Set("Content-Type", "application/json")
+	ctx.Request.Body = io.NopCloser(strings.NewReader(body))
+	return ctx
+}
+
+func Test_passwordHandler_ForgotPassword(t *testing.T) {
+	t.Run("Should accept the request of a reset link", func(t *testing.T) {
+		passwordService := mocks.NewPasswordService(t)
+		passwordService.On("ForgotPassword", mock.Anything, "user@email.com").
+			Return(nil)
+
+		recorder := httptest.NewRecorder()
+		ctx := getJSONContext(recorder, `{"email": "user@email.com"}`)
+
+		NewPasswordHandler(passwordService).ForgotPassword(ctx)
+		ctx.Writer.WriteHeaderNow()
+
+		assert.EqualValues(t, http.StatusAccepted, recorder.Code)
+	})
+
+	t.Run("Should return an error when the email is invalid", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		ctx := getJSONContext(recorder, `{"email": "invalid"}`)
+
+		NewPasswordHandler(mocks.NewPasswordService(t)).ForgotPassword(ctx)
+
+		assert.EqualValues(t, http.StatusBadRequest, recorder.Code)
+	})
+}
+
+func Test_passwordHandler_ResetPassword(t *testing.T) {
+	t.Run("Should reset the password", func(t *testing.T) {
+		passwordService := mocks.NewPasswordService(t)
+		passwordService.On("ResetPassword", mock.Anything, "token", "new@123").
+			Return(nil)
+
+		recorder := httptest.NewRecorder()
+		ctx := getJSONContext(recorder, `{"token": "token", "new_password": "new@123"}`)
+
+		NewPasswordHandler(passwordService).ResetPassword(ctx)
+		ctx.Writer.WriteHeaderNow()
+
+		assert.EqualValues(t, http.StatusNoContent, recorder.Code)
+	})
+
+	t.Run("Should return an error when try call password service", func(t *testing.T) {
+		passwordService := mocks.NewPasswordService(t)
+		passwordService.On("ResetPassword", mock.Anything, "token", "new@123").
+			Return(resterrors.NewBadRequestError("Password Reset Token is Invalid"))
+
+		recorder := httptest.NewRecorder()
+		ctx := getJSONContext(recorder, `{"token": "token", "new_password": "new@123"}`)
+
+		NewPasswordHandler(passwordService).ResetPassword(ctx)
+
+		assert.EqualValues(t, http.StatusBadRequest, recorder.Code)
+	})
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/WalterPaes/go-rest-api-crud/internal/handlers/dtos"
+	"github.com/WalterPaes/go-rest-api-crud/internal/handlers/dtos/converter"
+	"github.com/WalterPaes/go-rest-api-crud/internal/services"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/logger"
+	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/validation"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+var (
+	stacktraceCreatePersonalAccessTokenHandler = zap.String("stacktrace", "create-personal-access-token-handler")
+	stacktraceListPersonalAccessTokensHandler  = zap.String("stacktrace", "list-personal-access-tokens-handler")
+	stacktraceRevokePersonalAccessTokenHandler = zap.String("stacktrace", "revoke-personal-access-token-handler")
+)
+
+type personalAccessTokenHandler struct {
+	personalAccessTokenService services.PersonalAccessTokenService
+}
+
+func NewPersonalAccessTokenHandler(personalAccessTokenService services.PersonalAccessTokenService) *personalAccessTokenHandler {
+	return &personalAccessTokenHandler{
+		personalAccessTokenService: personalAccessTokenService,
+	}
+}
+
+// Create Personal Access Token godoc
+// @Summary Create a personal access token for the authenticated user
+// @Description Create a named, scoped and expiring token for scripts, the token is only shown in this response
+// @Tags tokens
+// @Accept json
+// @Produce json
+// @Param request body dtos.CreatePersonalAccessTokenRequest true "Create Personal Access Token Request"
+// @Success 201 {object} dtos.CreatePersonalAccessTokenResponse
+// @Failure 400 {object} resterrors.RestErr
+// @Failure 401 {object} resterrors.RestErr
+// @Failure 500 {object} resterrors.RestErr
+// @Router /users/me/tokens [post]
+// @Security ApiKeyAuth
+func (h *personalAccessTokenHandler) CreateToken(c *gin.Context) {
+	logger.Info("Starting Create Personal Access Token Handler", stacktraceCreatePersonalAccessTokenHandler)
+
+	userID, err := getIdFromPrincipal(c)
+	if err != nil {
+		c.JSON(err.HttpStatusCode, err)
+		return
+	}
+
+	var createTokenRequest dtos.CreatePersonalAccessTokenRequest
+	if err := c.ShouldBindJSON(&createTokenRequest); err != nil {
+		logger.Error("Create Personal Access Token Request Validation Error", err, stacktraceCreatePersonalAccessTokenHandler)
+
+		restErr := validation.ValidationUserError(err)
+		c.JSON(restErr.HttpStatusCode, restErr)
+		return
+	}
+
+	storedToken, token, err := h.personalAccessTokenService.CreateToken(
+		c.Request.Context(),
+		userID,
+		createTokenRequest.Name,
+		createTokenRequest.Scopes,
+		createTokenRequest.ExpiresInDays,
+	)
+	if err != nil {
+		logger.Error(errTryCallService, err, stacktraceCreatePersonalAccessTokenHandler)
+
+		c.JSON(err.HttpStatusCode, err)
+		return
+	}
+
+	logger.Info("Personal Access Token Created Successfully", zap.String("user_id", userID), stacktraceCreatePersonalAccessTokenHandler)
+	c.JSON(http.StatusCreated, dtos.CreatePersonalAccessTokenResponse{
+		PersonalAccessTokenResponse: converter.PersonalAccessTokenDomainToPersonalAccessTokenResponse(storedToken),
+		Token:                       token,
+	})
+}
+
+// List Personal Access Tokens godoc
+// @Summary List the personal access tokens of the authenticated user
+// @Description List the tokens that were not revoked, the tokens themselves are never returned
+// @Tags tokens
+// @Produce json
+// @Success 200 {array} dtos.PersonalAccessTokenResponse
+// @Failure 401 {object} resterrors.RestErr
+// @Failure 500 {object} resterrors.RestErr
+// @Router /users/me/tokens [get]
+// @Security ApiKeyAuth
+func (h *personalAccessTokenHandler) ListTokens(c *gin.Context) {
+	logger.Info("Starting List Personal Access Tokens Handler", stacktraceListPersonalAccessTokensHandler)
+
+	userID, err := getIdFromPrincipal(c)
+	if err != nil {
+		c.JSON(err.HttpStatusCode, err)
+		return
+	}
+
+	tokens, err := h.personalAccessTokenService.ListTokens(c.Request.Context(), userID)
+	if err != nil {
+		logger.Error(errTryCallService, err, stacktraceListPersonalAccessTokensHandler)
+
+		c.JSON(err.HttpStatusCode, err)
+		return
+	}
+
+	response := make([]dtos.PersonalAccessTokenResponse, len(tokens))
+	for i, token := range tokens {
+		response[i] = converter.PersonalAccessTokenDomainToPersonalAccessTokenResponse(token)
+	}
+
+	logger.Info("Personal Access Tokens Listed Successfully", zap.String("user_id", userID), stacktraceListPersonalAccessTokensHandler)
+	c.JSON(http.StatusOK, response)
+}
+
+// Revoke Personal Access Token godoc
+// @Summary Revoke a personal access token of the authenticated user
+// @Description Revoke the token, the requests sent with it are refused right away
+// @Tags tokens
+// @Param token_id path string true "Token ID"
+// @Success 204
+// @Failure 400 {object} resterrors.RestErr
+// @Failure 401 {object} resterrors.RestErr
+// @Failure 404 {object} resterrors.RestErr
+// @Failure 500 {object} resterrors.RestErr
+// @Router /users/me/tokens/{token_id} [delete]
+// @Security ApiKeyAuth
+func (h *personalAccessTokenHandler) RevokeToken(c *gin.Context) {
+	logger.Info("Starting Revoke Personal Access Token Handler", stacktraceRevokePersonalAccessTokenHandler)
+
+	userID, err := getIdFromPrincipal(c)
+	if err != nil {
+		c.JSON(err.HttpStatusCode, err)
+		return
+	}
+
+	tokenID := c.Param("token_id")
+	if _, err := primitive.ObjectIDFromHex(tokenID); err != nil {
+		restErr := resterrors.NewBadRequestError("Invalid tokenID, must be a hex value")
+		logger.Error(restErr.Message, restErr, stacktraceRevokePersonalAccessTokenHandler)
+
+		c.JSON(restErr.HttpStatusCode, restErr)
+		return
+	}
+
+	if err := h.personalAccessTokenService.RevokeToken(c.Request.Context(), userID, tokenID); err != nil {
+		logger.Error(errTryCallService, err, stacktraceRevokePersonalAccessTokenHandler)
+
+		c.JSON(err.HttpStatusCode, err)
+		return
+	}
+
+	logger.Info("Personal Access Token Revoked Successfully", zap.String("user_id", userID), zap.String("token_id", tokenID), stacktraceRevokePersonalAccessTokenHandler)
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	"github.com/WalterPaes/go-rest-api-crud/internal/handlers/dtos"
+	"github.com/WalterPaes/go-rest-api-crud/mocks"
+	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func Test_personalAccessTokenHandler_CreateToken(t *testing.T) {
+	userID := primitive.NewObjectID().Hex()
+	tokenID := primitive.NewObjectID().Hex()
+
+	t.Run("Should return the created token", func(t *testing.T) {
+		tokenService := mocks.NewPersonalAccessTokenService(t)
+		tokenService.On("CreateToken", mock.Anything, userID, "ci", []string{domain.ScopeUsersRead}, 30).
+			Return(&domain.PersonalAccessToken{ID: tokenID, Name: "ci", Scopes: []string{domain.ScopeUsersRead}}, "pat_token", nil)
+
+		recorder := httptest.NewRecorder()
+		ctx := getAuthenticatedJSONContext(recorder, userID, `{"name": "ci", "scopes": ["users:read"], "expires_in_days": 30}`)
+
+		NewPersonalAccessTokenHandler(tokenService).CreateToken(ctx)
+
+		var response dtos.CreatePersonalAccessTokenResponse
+		assert.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		assert.EqualValues(t, http.StatusCreated, recorder.Code)
+		assert.Equal(t, tokenID, response.ID)
+		assert.Equal(t, "pat_token", response.Token)
+		assert.Equal(t, []string{domain.ScopeUsersRead}, response.Scopes)
+	})
+
+	t.Run("Should return an error when a scope is unknown", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		ctx := getAuthenticatedJSONContext(recorder, userID, `{"name": "ci", "scopes": ["admin"], "expires_in_days": 30}`)
+
+		NewPersonalAccessTokenHandler(mocks.NewPersonalAccessTokenService(t)).CreateToken(ctx)
+
+		assert.EqualValues(t, http.StatusBadRequest, recorder.Code)
+	})
+
+	t.Run("Should return an error when try call personal access token service", func(t *testing.T) {
+		tokenService := mocks.NewPersonalAccessTokenService(t)
+		tokenService.On("CreateToken", mock.Anything, userID, "ci", []string{domain.ScopeUsersWrite}, 900).
+			Return(nil, "", resterrors.NewBadRequestError("Personal access tokens can't expire after 365 days"))
+
+		recorder := httptest.NewRecorder()
+		ctx := getAuthenticatedJSONContext(recorder, userID, `{"name": "ci", "scopes": ["users:write"], "expires_in_days": 900}`)
+
+		NewPersonalAccessTokenHandler(tokenService).CreateToken(ctx)
+
+		assert.EqualValues(t, http.StatusBadRequest, recorder.Code)
+	})
+}
+
+func Test_personalAccessTokenHandler_ListTokens(t *testing.T) {
+	userID := primitive.NewObjectID().Hex()
+
+	t.Run("Should return the tokens without the token itself", func(t *testing.T) {
+		lastUsedAt := time.Now()
+		tokenService := mocks.NewPersonalAccessTokenService(t)
+		tokenService.On("ListTokens", mock.Anything, userID).
+			Return([]*domain.PersonalAccessToken{
+				{ID: "first", Name: "ci", TokenHash: "hash", LastUsedAt: &lastUsedAt},
+				{ID: "second", Name: "backup", TokenHash: "hash"},
+			}, nil)
+
+		recorder := httptest.NewRecorder()
+
+		NewPersonalAccessTokenHandler(tokenService).ListTokens(getAuthenticatedContext(recorder, userID))
+
+		var response []map[string]interface{}
+		assert.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		assert.EqualValues(t, http.StatusOK, recorder.Code)
+		assert.Len(t, response, 2)
+		assert.Equal(t, "first", response[0]["id"])
+		assert.Contains(t, response[0], "last_used_at")
+		assert.NotContains(t, response[1], "last_used_at")
+		assert.NotContains(t, response[0], "token")
+	})
+
+	t.Run("Should return unauthorized when there is no authenticated user", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+
+		NewPersonalAccessTokenHandler(mocks.NewPersonalAccessTokenService(t)).ListTokens(getContext(recorder))
+
+		assert.EqualValues(t, http.StatusUnauthorized, recorder.Code)
+	})
+}
+
+func Test_personalAccessTokenHandler_RevokeToken(t *testing.T) {
+	userID := primitive.NewObjectID().Hex()
+	tokenID := primitive.NewObjectID().Hex()
+
+	getRevokeContext := func(recorder *httptest.ResponseRecorder, tokenID string) *gin.Context {
+		ctx := getAuthenticatedContext(recorder, userID)
+		ctx.Params = gin.Params{{Key: "token_id", Value: tokenID}}
+		return ctx
+	}
+
+	t.Run("Should revoke the token", func(t *testing.T) {
+		tokenService := mocks.NewPersonalAccessTokenService(t)
+		tokenService.On("RevokeToken", mock.Anything, userID, tokenID).
+			Return(nil)
+
+		recorder := httptest.NewRecorder()
+		ctx := getRevokeContext(recorder, tokenID)
+
+		NewPersonalAccessTokenHandler(tokenService).RevokeToken(ctx)
+		ctx.Writer.WriteHeaderNow()
+
+		assert.EqualValues(t, http.StatusNoContent, recorder.Code)
+	})
+
+	t.Run("Should return an error when the token id is not hex", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+
+		NewPersonalAccessTokenHandler(mocks.NewPersonalAccessTokenService(t)).RevokeToken(getRevokeContext(recorder, "invalid"))
+
+		assert.EqualValues(t, http.StatusBadRequest, recorder.Code)
+	})
+
+	t.Run("Should return not found when the token is not of the user", func(t *testing.T) {
+		tokenService := mocks.NewPersonalAccessTokenService(t)
+		tokenService.On("RevokeToken", mock.Anything, userID, tokenID).
+			Return(resterrors.NewNotFoundError("Personal Access Token Not Found"))
+
+		recorder := httptest.NewRecorder()
+
+		NewPersonalAccessTokenHandler(tokenService).RevokeToken(getRevokeContext(recorder, tokenID))
+
+		assert.EqualValues(t, http.StatusNotFound, recorder.Code)
+	})
+}
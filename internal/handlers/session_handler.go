@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/WalterPaes/go-rest-api-crud/internal/auth"
+	"github.com/WalterPaes/go-rest-api-crud/internal/handlers/dtos"
+	"github.com/WalterPaes/go-rest-api-crud/internal/handlers/dtos/converter"
+	"github.com/WalterPaes/go-rest-api-crud/internal/services"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/logger"
+	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+var (
+	stacktraceListSessionsHandler  = zap.String("stacktrace", "list-sessions-handler")
+	stacktraceRevokeSessionHandler = zap.String("stacktrace", "revoke-session-handler")
+)
+
+type sessionHandler struct {
+	sessionService services.SessionService
+}
+
+func NewSessionHandler(sessionService services.SessionService) *sessionHandler {
+	return &sessionHandler{
+		sessionService: sessionService,
+	}
+}
+
+// List Sessions godoc
+// @Summary List the sessions of the authenticated user
+// @Description List the devices the user is logged in on, the last seen time is updated when the session refreshes its token
+// @Tags sessions
+// @Produce json
+// @Success 200 {array} dtos.SessionResponse
+// @Failure 401 {object} resterrors.RestErr
+// @Failure 500 {object} resterrors.RestErr
+// @Router /users/me/sessions [get]
+// @Security ApiKeyAuth
+func (h *sessionHandler) ListSessions(c *gin.Context) {
+	logger.Info("Starting List Sessions Handler", stacktraceListSessionsHandler)
+
+	userID, err := getIdFromPrincipal(c)
+	if err != nil {
+		c.JSON(err.HttpStatusCode, err)
+		return
+	}
+
+	sessions, err := h.sessionService.ListSessions(c.Request.Context(), userID)
+	if err != nil {
+		logger.Error(errTryCallService, err, stacktraceListSessionsHandler)
+
+		c.JSON(err.HttpStatusCode, err)
+		return
+	}
+
+	var currentSessionID string
+	if principal, ok := auth.PrincipalFromGin(c); ok {
+		currentSessionID = principal.SessionID
+	}
+
+	response := make([]dtos.SessionResponse, len(sessions))
+	for i, session := range sessions {
+		response[i] = converter.SessionDomainToSessionResponse(session, currentSessionID)
+	}
+
+	logger.Info("Sessions Listed Successfully", zap.String("user_id", userID), stacktraceListSessionsHandler)
+	c.JSON(http.StatusOK, response)
+}
+
+// Revoke Session godoc
+// @Summary Revoke a session of the authenticated user
+// @Description Sign the user out of the session, its tokens are refused right away
+// @Tags sessions
+// @Param session_id path string true "Session ID"
+// @Success 204
+// @Failure 400 {object} resterrors.RestErr
+// @Failure 401 {object} resterrors.RestErr
+// @Failure 404 {object} resterrors.RestErr
+// @Failure 500 {object} resterrors.RestErr
+// @Router /users/me/sessions/{session_id} [delete]
+// @Security ApiKeyAuth
+func (h *sessionHandler) RevokeSession(c *gin.Context) {
+	logger.Info("Starting Revoke Session Handler", stacktraceRevokeSessionHandler)
+
+	userID, err := getIdFromPrincipal(c)
+	if err != nil {
+		c.JSON(err.HttpStatusCode, err)
+		return
+	}
+
+	sessionID := c.Param("session_id")
+	if _, err := primitive.ObjectIDFromHex(sessionID); err != nil {
+		restErr := resterrors.NewBadRequestError("Invalid sessionID, must be a hex value")
+		logger.Error(restErr.Message, restErr, stacktraceRevokeSessionHandler)
+
+		c.JSON(restErr.HttpStatusCode, restErr)
+		return
+	}
+
+	if err := h.sessionService.RevokeSession(c.Request.Context(), userID, sessionID); err != nil {
+		logger.Error(errTryCallService, err, stacktraceRevokeSessionHandler)
+
+		c.JSON(err.HttpStatusCode, err)
+		return
+	}
+
+	logger.Info("Session Revoked Successfully", zap.String("user_id", userID), zap.String("session_id", sessionID), stacktraceRevokeSessionHandler)
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/WalterPaes/go-rest-api-crud/internal/auth"
+	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	"github.com/WalterPaes/go-rest-api-crud/internal/handlers/dtos"
+	"github.com/WalterPaes/go-rest-api-crud/mocks"
+	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func Test_sessionHandler_ListSessions(t *testing.T) {
+	userID := primitive.NewObjectID().Hex()
+	currentSessionID := primitive.NewObjectID().Hex()
+	otherSessionID := primitive.NewObjectID().Hex()
+
+	t.Run("Should return the sessions telling the current one", func(t *testing.T) {
+		sessionService := mocks.NewSessionService(t)
+		sessionService.On("ListSessions", mock.Anything, userID).
+			Return([]*domain.Session{
+				{ID: currentSessionID, UserAgent: "Mozilla/5.0", IP: "10.0.0.1", TokenID: "token_id"},
+				{ID: otherSessionID, UserAgent: "curl/8.0", IP: "10.0.0.2", TokenID: "other_token_id"},
+			}, nil)
+
+		recorder := httptest.NewRecorder()
+		ctx := getContext(recorder)
+		auth.SetPrincipal(ctx, &domain.Principal{UserID: userID, SessionID: currentSessionID})
+
+		NewSessionHandler(sessionService).ListSessions(ctx)
+
+		var response []dtos.SessionResponse
+		assert.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		assert.EqualValues(t, http.StatusOK, recorder.Code)
+		assert.Len(t, response, 2)
+		assert.True(t, response[0].Current)
+		assert.Equal(t, "10.0.0.1", response[0].IP)
+		assert.False(t, response[1].Current)
+		assert.NotContains(t, recorder.Body.String(), "token_id")
+	})
+
+	t.Run("Should return an error when try call session service", func(t *testing.T) {
+		sessionService := mocks.NewSessionService(t)
+		sessionService.On("ListSessions", mock.Anything, userID).
+			Return(nil, resterrors.NewInternalServerError("error"))
+
+		recorder := httptest.NewRecorder()
+
+		NewSessionHandler(sessionService).ListSessions(getAuthenticatedContext(recorder, userID))
+
+		assert.EqualValues(t, http.StatusInternalServerError, recorder.Code)
+	})
+}
+
+func Test_sessionHandler_RevokeSession(t *testing.T) {
+	userID := primitive.NewObjectID().Hex()
+	sessionID := primitive.NewObjectID().Hex()
+
+	t.Run("Should revoke the session", func(t *testing.T) {
+		sessionService := mocks.NewSessionService(t)
+		sessionService.On("RevokeSession", mock.Anything, userID, sessionID).
+			Return(nil)
+
+		recorder := httptest.NewRecorder()
+		ctx := getAuthenticatedContext(recorder, userID)
+		ctx.Params = gin.Params{{Key: "session_id", Value: sessionID}}
+
+		NewSessionHandler(sessionService).RevokeSession(ctx)
+		ctx.Writer.WriteHeaderNow()
+
+		assert.EqualValues(t, http.StatusNoContent, recorder.Code)
+	})
+
+	t.Run("Should return bad request when the session id isn't hex", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		ctx := getAuthenticatedContext(recorder, userID)
+		ctx.Params = gin.Params{{Key: "session_id", Value: "legacy_family"}}
+
+		NewSessionHandler(mocks.NewSessionService(t)).RevokeSession(ctx)
+
+		assert.EqualValues(t, http.StatusBadRequest, recorder.Code)
+	})
+
+	t.Run("Should return not found when the session isn't one of the user", func(t *testing.T) {
+		sessionService := mocks.NewSessionService(t)
+		sessionService.On("RevokeSession", mock.Anything, userID, sessionID).
+			Return(resterrors.NewNotFoundError("Session Not Found"))
+
+		recorder := httptest.NewRecorder()
+		ctx := getAuthenticatedContext(recorder, userID)
+		ctx.Params = gin.Params{{Key: "session_id", Value: sessionID}}
+
+		NewSessionHandler(sessionService).RevokeSession(ctx)
+
+		assert.EqualValues(t, http.StatusNotFound, recorder.Code)
+	})
+}
@@ -1,9 +1,15 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/WalterPaes/go-rest-api-crud/internal/auth"
+	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
 	"github.com/WalterPaes/go-rest-api-crud/internal/handlers/dtos"
 	"github.com/WalterPaes/go-rest-api-crud/internal/handlers/dtos/converter"
 	"github.com/WalterPaes/go-rest-api-crud/internal/services"
@@ -18,14 +24,21 @@ import (
 const (
 	errUserRequestValidation = "User Request Validation Error"
 	errTryCallService        = "Error when try call service"
+	errUnauthenticated       = "User is not authenticated"
+	errClientWithoutUser     = "The OAuth clients are not an user"
 )
 
 var (
-	stacktraceFindAllUsersHandler = zap.String("stacktrace", "find-all-users-handler")
-	stacktraceCreateUserHandler   = zap.String("stacktrace", "create-user-handler")
-	stacktraceFindUserByIdHandler = zap.String("stacktrace", "find-user-by-id-handler")
-	stacktraceUpdateUserHandler   = zap.String("stacktrace", "update-user-handler")
-	stacktraceDeleteUserHandler   = zap.String("stacktrace", "delete-user-handler")
+	stacktraceFindAllUsersHandler   = zap.String("stacktrace", "find-all-users-handler")
+	stacktraceCreateUserHandler     = zap.String("stacktrace", "create-user-handler")
+	stacktraceFindUserByIdHandler   = zap.String("stacktrace", "find-user-by-id-handler")
+	stacktraceUpdateUserHandler     = zap.String("stacktrace", "update-user-handler")
+	stacktraceDeleteUserHandler     = zap.String("stacktrace", "delete-user-handler")
+	stacktracePatchUserHandler      = zap.String("stacktrace", "patch-user-handler")
+	stacktraceChangePasswordHandler = zap.String("stacktrace", "change-password-handler")
+	stacktraceSuspendUserHandler    = zap.String("stacktrace", "suspend-user-handler")
+	stacktraceReactivateUserHandler = zap.String("stacktrace", "reactivate-user-handler")
+	stacktraceRestoreUserHandler    = zap.String("stacktrace", "restore-user-handler")
 )
 
 var (
@@ -33,62 +46,59 @@ var (
 	itemsPerPage int = 10
 )
 
+// userQueryParams are the query params the users listing accepts.
+var userQueryParams = []string{"page", "per_page", "cursor", "count", "fields", "q", "email", "status", "role", "created_from", "created_to", "sort"}
+
 type userHandler struct {
 	userService services.UserService
+	maxPerPage  int
 }
 
-func NewUserHandler(userService services.UserService) *userHandler {
+// NewUserHandler builds the user handler. maxPerPage caps the per_page of the users
+// listing, 0 doesn't cap it.
+func NewUserHandler(userService services.UserService, maxPerPage int) *userHandler {
 	return &userHandler{
 		userService: userService,
+		maxPerPage:  maxPerPage,
 	}
 }
 
 // List Users godoc
 // @Summary list all users
-// @Description list all users
+// @Description list the users, searched, filtered and sorted by the query params. An unknown param is refused with the allowed ones. The Link header has the first, prev, next and last pages as in RFC 8288
 // @Tags users
 // @Produce json
 // @Param page query string false "page number"
-// @Param per_page query string false "items per page number"
+// @Param per_page query string false "items per page number, capped to the maximum configured"
+// @Param count query bool false "count the users for total_items and total_pages, true when not sent"
+// @Param cursor query string false "next_cursor or prev_cursor of a previous page, to read the page after or before it instead of a page number"
+// @Param q query string false "users whose name or email starts with it, ignoring the case"
+// @Param email query string false "only the user with the email"
+// @Param status query string false "only the users with the status" Enums(active, suspended, locked, deactivated)
+// @Param role query string false "only the users with the role" Enums(admin, user)
+// @Param created_from query string false "only the users created from the time on, RFC 3339 or a date"
+// @Param created_to query string false "only the users created before the time, RFC 3339 or a date"
+// @Param fields query string false "comma separated fields of the users to send, like id,name, every field when not sent"
+// @Param sort query string false "comma separated fields to sort by, descending when prefixed by -, of: name, email, status, created_at"
 // @Success 200 {object} dtos.UsersListResponse
 // @Failure 400 {object} resterrors.RestErr
+// @Failure 403 {object} resterrors.RestErr
 // @Failure 500 {object} resterrors.RestErr
 // @Router /users [get]
 // @Security ApiKeyAuth
 func (h *userHandler) ListAll(c *gin.Context) {
 	logger.Info("Starting List Users", stacktraceFindAllUsersHandler)
 
-	page, exists := c.GetQuery("page")
-	if exists {
-		value, err := strconv.Atoi(page)
-		if err != nil {
-			restErr := resterrors.NewBadRequestError(`Param "page" must be a int value`)
-			c.JSON(restErr.HttpStatusCode, restErr)
-			return
-		}
-
-		if value > 0 {
-			currentPage = value
-		}
-	}
-
-	perPage, exists := c.GetQuery("per_page")
-	if exists {
-		value, err := strconv.Atoi(perPage)
-		if err != nil {
-			restErr := resterrors.NewBadRequestError(`Param "per_page" must be a int value`)
-			c.JSON(restErr.HttpStatusCode, restErr)
-			return
-		}
-
-		if value > 0 {
-			itemsPerPage = value
-		}
+	query, err := getUserQuery(c, h.maxPerPage)
+	if err != nil {
+		logger.Error(err.Message, err, auth.ActorField(c.Request.Context()), stacktraceFindAllUsersHandler)
+		c.JSON(err.HttpStatusCode, err)
+		return
 	}
 
-	userResult, err := h.userService.FindAll(c.Request.Context(), itemsPerPage, currentPage)
+	userResult, err := h.userService.FindAll(c.Request.Context(), query)
 	if err != nil {
-		logger.Error(errTryCallService, err, stacktraceFindUserByIdHandler)
+		logger.Error(errTryCallService, err, auth.ActorField(c.Request.Context()), stacktraceFindAllUsersHandler)
 
 		c.JSON(err.HttpStatusCode, err)
 		return
@@ -96,11 +106,17 @@ func (h *userHandler) ListAll(c *gin.Context) {
 
 	logger.Info(
 		"User Found Successfully",
-		zap.Int("items_per_page", itemsPerPage),
-		zap.Int("current_page", currentPage),
+		zap.Int("items_per_page", query.PerPage),
+		zap.Int("current_page", query.Page),
+		auth.ActorField(c.Request.Context()),
 		stacktraceFindAllUsersHandler,
 	)
-	c.JSON(http.StatusOK, converter.UsersDomainListToUserListResponse(userResult, currentPage, itemsPerPage))
+	response := converter.UserPageToUserListResponse(userResult, query.Page, query.PerPage)
+	for i := range response.Users {
+		response.Users[i] = response.Users[i].Select(query.Fields)
+	}
+	c.Header("Link", usersListLinks(c.Request, requestScheme(c), response))
+	c.JSON(http.StatusOK, response)
 }
 
 // Create User godoc
@@ -112,6 +128,7 @@ func (h *userHandler) ListAll(c *gin.Context) {
 // @Param request body dtos.UserRequest true "user request"
 // @Success 201 {object} dtos.UsersListResponse
 // @Failure 400 {object} resterrors.RestErr
+// @Failure 403 {object} resterrors.RestErr
 // @Failure 500 {object} resterrors.RestErr
 // @Router /users [post]
 // @Security ApiKeyAuth
@@ -121,29 +138,22 @@ func (h *userHandler) CreateUser(c *gin.Context) {
 	var userRequest dtos.UserRequest
 
 	if err := c.ShouldBindJSON(&userRequest); err != nil {
-		logger.Error(errUserRequestValidation, err, stacktraceCreateUserHandler)
+		logger.Error(errUserRequestValidation, err, auth.ActorField(c.Request.Context()), stacktraceCreateUserHandler)
 
 		restErr := validation.ValidationUserError(err)
 		c.JSON(restErr.HttpStatusCode, restErr)
 		return
 	}
 
-	user, convertionErr := converter.UserRequestToUserDomain(userRequest)
-	if convertionErr != nil {
-		restErr := resterrors.NewInternalServerError("Error when try create user")
-		c.JSON(restErr.HttpStatusCode, restErr)
-		return
-	}
-
-	userResult, err := h.userService.CreateUser(c.Request.Context(), user)
+	userResult, err := h.userService.CreateUser(c.Request.Context(), converter.UserRequestToUserDomain(userRequest))
 	if err != nil {
-		logger.Error(errTryCallService, err, stacktraceCreateUserHandler)
+		logger.Error(errTryCallService, err, auth.ActorField(c.Request.Context()), stacktraceCreateUserHandler)
 
 		c.JSON(err.HttpStatusCode, err)
 		return
 	}
 
-	logger.Info("User Created Successfully", zap.String("user_id", userResult.ID), stacktraceCreateUserHandler)
+	logger.Info("User Created Successfully", zap.String("user_id", userResult.ID), auth.ActorField(c.Request.Context()), stacktraceCreateUserHandler)
 	c.JSON(http.StatusCreated, converter.UserDomainToUserResponse(userResult))
 }
 
@@ -153,8 +163,10 @@ func (h *userHandler) CreateUser(c *gin.Context) {
 // @Tags users
 // @Produce json
 // @Param id path string true "user id"
+// @Param fields query string false "comma separated fields of the user to send, like id,name, every field when not sent"
 // @Success 200 {object} dtos.UsersListResponse
 // @Failure 400 {object} resterrors.RestErr
+// @Failure 403 {object} resterrors.RestErr
 // @Failure 404 {object} resterrors.RestErr
 // @Failure 500 {object} resterrors.RestErr
 // @Router /users/{id} [get]
@@ -162,22 +174,38 @@ func (h *userHandler) CreateUser(c *gin.Context) {
 func (h *userHandler) GetUserById(c *gin.Context) {
 	logger.Info("Starting Find User By Id", stacktraceFindUserByIdHandler)
 
-	userID, err := h.getIdFromParam(c)
+	userID, err := getIdFromParam(c)
 	if err != nil {
 		c.JSON(err.HttpStatusCode, err)
 		return
 	}
 
-	userResult, err := h.userService.FindUserById(c.Request.Context(), userID)
-	if err != nil {
-		logger.Error(errTryCallService, err, stacktraceFindUserByIdHandler)
+	h.findUserById(c, userID)
+}
 
+// Get Me godoc
+// @Summary get the authenticated user
+// @Description get the user identified by the access token
+// @Tags users
+// @Produce json
+// @Param fields query string false "comma separated fields of the user to send, like id,name, every field when not sent"
+// @Success 200 {object} dtos.UserResponse
+// @Failure 400 {object} resterrors.RestErr
+// @Failure 401 {object} resterrors.RestErr
+// @Failure 404 {object} resterrors.RestErr
+// @Failure 500 {object} resterrors.RestErr
+// @Router /users/me [get]
+// @Security ApiKeyAuth
+func (h *userHandler) GetMe(c *gin.Context) {
+	logger.Info("Starting Find Me", stacktraceFindUserByIdHandler)
+
+	userID, err := getIdFromPrincipal(c)
+	if err != nil {
 		c.JSON(err.HttpStatusCode, err)
 		return
 	}
 
-	logger.Info("User Found Successfully", zap.String("user_id", userResult.ID), stacktraceFindUserByIdHandler)
-	c.JSON(http.StatusOK, converter.UserDomainToUserResponse(userResult))
+	h.findUserById(c, userID)
 }
 
 // Update User godoc
@@ -187,81 +215,506 @@ func (h *userHandler) GetUserById(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param id path string true "user id"
-// @Param request body dtos.UserRequest true "user request"
+// @Param request body dtos.UserUpdateRequest true "user update request"
 // @Success 200 {object} dtos.UsersListResponse
 // @Failure 400 {object} resterrors.RestErr
+// @Failure 403 {object} resterrors.RestErr
 // @Router /users/{id} [put]
 // @Security ApiKeyAuth
 func (h *userHandler) UpdateUser(c *gin.Context) {
 	logger.Info("Starting Update User", stacktraceUpdateUserHandler)
 
-	userID, err := h.getIdFromParam(c)
+	userID, err := getIdFromParam(c)
 	if err != nil {
 		c.JSON(err.HttpStatusCode, err)
 		return
 	}
 
-	var userRequest dtos.UserRequest
-	if err := c.ShouldBindJSON(&userRequest); err != nil {
-		logger.Error(errUserRequestValidation, err, stacktraceCreateUserHandler)
+	var userUpdateRequest dtos.UserUpdateRequest
+	if err := c.ShouldBindJSON(&userUpdateRequest); err != nil {
+		logger.Error(errUserRequestValidation, err, zap.String("user_id", userID), auth.ActorField(c.Request.Context()), stacktraceUpdateUserHandler)
 
 		restErr := validation.ValidationUserError(err)
 		c.JSON(restErr.HttpStatusCode, restErr)
 		return
 	}
 
-	user, convertionErr := converter.UserRequestToUserDomain(userRequest)
-	if convertionErr != nil {
-		restErr := resterrors.NewInternalServerError("Error when try update user")
-		c.JSON(restErr.HttpStatusCode, restErr)
-		return
-	}
+	user := converter.UserUpdateRequestToUserDomain(userUpdateRequest)
 
 	userResult, err := h.userService.UpdateUser(c.Request.Context(), userID, user)
 	if err != nil {
-		logger.Error(errTryCallService, err, stacktraceUpdateUserHandler)
+		logger.Error(errTryCallService, err, zap.String("user_id", userID), auth.ActorField(c.Request.Context()), stacktraceUpdateUserHandler)
 
 		c.JSON(err.HttpStatusCode, err)
 		return
 	}
 
-	logger.Info("User Updated Successfully", zap.String("user_id", userResult.ID), stacktraceUpdateUserHandler)
+	logger.Info("User Updated Successfully", zap.String("user_id", userResult.ID), auth.ActorField(c.Request.Context()), stacktraceUpdateUserHandler)
 	c.JSON(http.StatusCreated, converter.UserDomainToUserResponse(userResult))
 }
 
 // Delete User godoc
 // @Summary delete an user
-// @Description delete an user
+// @Description delete an user, an admin can restore it until it is purged after the retention period
 // @Tags users
 // @Produce json
 // @Param id path string true "user id"
 // @Success 204
 // @Failure 400 {object} resterrors.RestErr
+// @Failure 403 {object} resterrors.RestErr
+// @Failure 404 {object} resterrors.RestErr
 // @Failure 500 {object} resterrors.RestErr
 // @Router /users/{id} [delete]
 // @Security ApiKeyAuth
 func (h *userHandler) DeleteUser(c *gin.Context) {
 	logger.Info("Starting Delete User", stacktraceDeleteUserHandler)
 
-	userID, err := h.getIdFromParam(c)
+	userID, err := getIdFromParam(c)
+	if err != nil {
+		c.JSON(err.HttpStatusCode, err)
+		return
+	}
+
+	h.deleteUser(c, userID)
+}
+
+// Patch Me godoc
+// @Summary update the authenticated user
+// @Description update only the fields sent of the user identified by the access token
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body dtos.UserPatchRequest true "user patch request"
+// @Success 200 {object} dtos.UserResponse
+// @Failure 400 {object} resterrors.RestErr
+// @Failure 401 {object} resterrors.RestErr
+// @Failure 404 {object} resterrors.RestErr
+// @Failure 500 {object} resterrors.RestErr
+// @Router /users/me [patch]
+// @Security ApiKeyAuth
+func (h *userHandler) PatchMe(c *gin.Context) {
+	logger.Info("Starting Patch Me", stacktracePatchUserHandler)
+
+	userID, err := getIdFromPrincipal(c)
+	if err != nil {
+		c.JSON(err.HttpStatusCode, err)
+		return
+	}
+
+	var userPatchRequest dtos.UserPatchRequest
+	if err := c.ShouldBindJSON(&userPatchRequest); err != nil {
+		logger.Error(errUserRequestValidation, err, zap.String("user_id", userID), auth.ActorField(c.Request.Context()), stacktracePatchUserHandler)
+
+		restErr := validation.ValidationUserError(err)
+		c.JSON(restErr.HttpStatusCode, restErr)
+		return
+	}
+
+	userResult, err := h.userService.PatchUser(c.Request.Context(), userID, converter.UserPatchRequestToUserPatchDomain(userPatchRequest))
+	if err != nil {
+		logger.Error(errTryCallService, err, zap.String("user_id", userID), auth.ActorField(c.Request.Context()), stacktracePatchUserHandler)
+
+		c.JSON(err.HttpStatusCode, err)
+		return
+	}
+
+	logger.Info("User Patched Successfully", zap.String("user_id", userResult.ID), auth.ActorField(c.Request.Context()), stacktracePatchUserHandler)
+	c.JSON(http.StatusOK, converter.UserDomainToUserResponse(userResult))
+}
+
+// Delete Me godoc
+// @Summary delete the authenticated user
+// @Description delete the user identified by the access token
+// @Tags users
+// @Success 204
+// @Failure 401 {object} resterrors.RestErr
+// @Failure 500 {object} resterrors.RestErr
+// @Router /users/me [delete]
+// @Security ApiKeyAuth
+func (h *userHandler) DeleteMe(c *gin.Context) {
+	logger.Info("Starting Delete Me", stacktraceDeleteUserHandler)
+
+	userID, err := getIdFromPrincipal(c)
+	if err != nil {
+		c.JSON(err.HttpStatusCode, err)
+		return
+	}
+
+	h.deleteUser(c, userID)
+}
+
+// Change Password godoc
+// @Summary change the password of the authenticated user
+// @Description change the password after confirming the current one, the other sessions of the user are revoked
+// @Tags users
+// @Accept json
+// @Param request body dtos.ChangePasswordRequest true "change password request"
+// @Success 204
+// @Failure 400 {object} resterrors.RestErr
+// @Failure 401 {object} resterrors.RestErr
+// @Failure 500 {object} resterrors.RestErr
+// @Router /users/me/password [post]
+// @Security ApiKeyAuth
+func (h *userHandler) ChangePassword(c *gin.Context) {
+	logger.Info("Starting Change Password", stacktraceChangePasswordHandler)
+
+	userID, err := getIdFromPrincipal(c)
+	if err != nil {
+		c.JSON(err.HttpStatusCode, err)
+		return
+	}
+
+	var changePasswordRequest dtos.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&changePasswordRequest); err != nil {
+		logger.Error(errUserRequestValidation, err, zap.String("user_id", userID), auth.ActorField(c.Request.Context()), stacktraceChangePasswordHandler)
+
+		restErr := validation.ValidationUserError(err)
+		c.JSON(restErr.HttpStatusCode, restErr)
+		return
+	}
+
+	err = h.userService.ChangePassword(
+		c.Request.Context(),
+		userID,
+		changePasswordRequest.CurrentPassword,
+		changePasswordRequest.NewPassword,
+	)
+	if err != nil {
+		logger.Error(errTryCallService, err, zap.String("user_id", userID), auth.ActorField(c.Request.Context()), stacktraceChangePasswordHandler)
+
+		c.JSON(err.HttpStatusCode, err)
+		return
+	}
+
+	logger.Info("Password Changed Successfully", zap.String("user_id", userID), auth.ActorField(c.Request.Context()), stacktraceChangePasswordHandler)
+	c.Status(http.StatusNoContent)
+}
+
+// Restore User godoc
+// @Summary restore a deleted user
+// @Description bring back an user deleted less than the retention period ago, the deleted users are purged for good after it
+// @Tags users
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} dtos.UserResponse
+// @Failure 400 {object} resterrors.RestErr
+// @Failure 401 {object} resterrors.RestErr
+// @Failure 403 {object} resterrors.RestErr
+// @Failure 404 {object} resterrors.RestErr
+// @Failure 500 {object} resterrors.RestErr
+// @Router /users/{id}/restore [post]
+// @Security ApiKeyAuth
+func (h *userHandler) RestoreUser(c *gin.Context) {
+	logger.Info("Starting Restore User", stacktraceRestoreUserHandler)
+
+	userID, err := getIdFromParam(c)
+	if err != nil {
+		c.JSON(err.HttpStatusCode, err)
+		return
+	}
+
+	userResult, err := h.userService.RestoreUser(c.Request.Context(), userID)
 	if err != nil {
+		logger.Error(errTryCallService, err, zap.String("user_id", userID), auth.ActorField(c.Request.Context()), stacktraceRestoreUserHandler)
+
+		c.JSON(err.HttpStatusCode, err)
+		return
+	}
+
+	logger.Info("User Restored Successfully", zap.String("user_id", userID), auth.ActorField(c.Request.Context()), stacktraceRestoreUserHandler)
+	c.JSON(http.StatusOK, converter.UserDomainToUserResponse(userResult))
+}
+
+// Suspend User godoc
+// @Summary suspend an user
+// @Description suspend the account of an user, which is signed out and can't log in until reactivated. The data of the user is kept
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param request body dtos.UserStatusRequest true "user status request"
+// @Success 200 {object} dtos.UserResponse
+// @Failure 400 {object} resterrors.RestErr
+// @Failure 401 {object} resterrors.RestErr
+// @Failure 403 {object} resterrors.RestErr
+// @Failure 404 {object} resterrors.RestErr
+// @Failure 409 {object} resterrors.RestErr
+// @Failure 500 {object} resterrors.RestErr
+// @Router /admin/users/{id}/suspend [post]
+// @Security ApiKeyAuth
+func (h *userHandler) SuspendUser(c *gin.Context) {
+	logger.Info("Starting Suspend User", stacktraceSuspendUserHandler)
+	h.changeStatus(c, domain.UserStatusSuspended, stacktraceSuspendUserHandler)
+}
+
+// Reactivate User godoc
+// @Summary reactivate an user
+// @Description make active again an user that was suspended, locked or deactivated
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param request body dtos.UserStatusRequest true "user status request"
+// @Success 200 {object} dtos.UserResponse
+// @Failure 400 {object} resterrors.RestErr
+// @Failure 401 {object} resterrors.RestErr
+// @Failure 403 {object} resterrors.RestErr
+// @Failure 404 {object} resterrors.RestErr
+// @Failure 409 {object} resterrors.RestErr
+// @Failure 500 {object} resterrors.RestErr
+// @Router /admin/users/{id}/reactivate [post]
+// @Security ApiKeyAuth
+func (h *userHandler) ReactivateUser(c *gin.Context) {
+	logger.Info("Starting Reactivate User", stacktraceReactivateUserHandler)
+	h.changeStatus(c, domain.UserStatusActive, stacktraceReactivateUserHandler)
+}
+
+func (h *userHandler) changeStatus(c *gin.Context, status string, stacktrace zap.Field) {
+	userID, err := getIdFromParam(c)
+	if err != nil {
+		c.JSON(err.HttpStatusCode, err)
+		return
+	}
+
+	var userStatusRequest dtos.UserStatusRequest
+	if err := c.ShouldBindJSON(&userStatusRequest); err != nil {
+		logger.Error(errUserRequestValidation, err, zap.String("user_id", userID), auth.ActorField(c.Request.Context()), stacktrace)
+
+		restErr := validation.ValidationUserError(err)
+		c.JSON(restErr.HttpStatusCode, restErr)
+		return
+	}
+
+	userResult, err := h.userService.ChangeStatus(c.Request.Context(), userID, status, userStatusRequest.Reason)
+	if err != nil {
+		logger.Error(errTryCallService, err, zap.String("user_id", userID), auth.ActorField(c.Request.Context()), stacktrace)
+
+		c.JSON(err.HttpStatusCode, err)
+		return
+	}
+
+	logger.Info("User Status Changed Successfully", zap.String("user_id", userID), zap.String("status", status), auth.ActorField(c.Request.Context()), stacktrace)
+	c.JSON(http.StatusOK, converter.UserDomainToUserResponse(userResult))
+}
+
+func (h *userHandler) findUserById(c *gin.Context, userID string) {
+	fields, err := getFieldsFromQuery(c)
+	if err != nil {
+		logger.Error(err.Message, err, zap.String("user_id", userID), auth.ActorField(c.Request.Context()), stacktraceFindUserByIdHandler)
 		c.JSON(err.HttpStatusCode, err)
 		return
 	}
 
-	err = h.userService.DeleteUser(c.Request.Context(), userID)
+	userResult, err := h.userService.FindUserById(c.Request.Context(), userID, fields...)
 	if err != nil {
-		logger.Error(errTryCallService, err, stacktraceDeleteUserHandler)
+		logger.Error(errTryCallService, err, zap.String("user_id", userID), auth.ActorField(c.Request.Context()), stacktraceFindUserByIdHandler)
 
 		c.JSON(err.HttpStatusCode, err)
 		return
 	}
 
-	logger.Info("User Deleted Successfully", zap.String("user_id", userID), stacktraceDeleteUserHandler)
+	logger.Info("User Found Successfully", zap.String("user_id", userID), auth.ActorField(c.Request.Context()), stacktraceFindUserByIdHandler)
+	c.JSON(http.StatusOK, converter.UserDomainToUserResponse(userResult).Select(fields))
+}
+
+func (h *userHandler) deleteUser(c *gin.Context, userID string) {
+	err := h.userService.DeleteUser(c.Request.Context(), userID)
+	if err != nil {
+		logger.Error(errTryCallService, err, zap.String("user_id", userID), auth.ActorField(c.Request.Context()), stacktraceDeleteUserHandler)
+
+		c.JSON(err.HttpStatusCode, err)
+		return
+	}
+
+	logger.Info("User Deleted Successfully", zap.String("user_id", userID), auth.ActorField(c.Request.Context()), stacktraceDeleteUserHandler)
 	c.Status(http.StatusNoContent)
 }
 
-func (*userHandler) getIdFromParam(c *gin.Context) (string, *resterrors.RestErr) {
+// getUserQuery reads the query params of the users listing, refusing the unknown
+// params and values with the allowed ones.
+func getUserQuery(c *gin.Context, maxPerPage int) (*domain.UserQuery, *resterrors.RestErr) {
+	for param := range c.Request.URL.Query() {
+		if !contains(userQueryParams, param) {
+			return nil, resterrors.NewBadRequestError(fmt.Sprintf(`Unknown param "%s", must be one of: %s`, param, strings.Join(userQueryParams, ", ")))
+		}
+	}
+
+	query := &domain.UserQuery{
+		Search:  c.Query("q"),
+		Email:   c.Query("email"),
+		Status:  c.Query("status"),
+		Role:    c.Query("role"),
+		Cursor:  c.Query("cursor"),
+		Page:    currentPage,
+		PerPage: itemsPerPage,
+	}
+
+	// the cursor tells the page, it has no number
+	if query.Cursor != "" {
+		if _, exists := c.GetQuery("page"); exists {
+			return nil, resterrors.NewBadRequestError(`Params "page" and "cursor" can't be sent together`)
+		}
+		query.Page = 0
+	}
+
+	pagination := []struct {
+		param string
+		value *int
+	}{{"page", &query.Page}, {"per_page", &query.PerPage}}
+
+	for _, p := range pagination {
+		raw, exists := c.GetQuery(p.param)
+		if !exists {
+			continue
+		}
+
+		number, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, resterrors.NewBadRequestError(fmt.Sprintf(`Param "%s" must be a int value`, p.param))
+		}
+		if number > 0 {
+			*p.value = number
+		}
+	}
+	if maxPerPage > 0 && query.PerPage > maxPerPage {
+		query.PerPage = maxPerPage
+	}
+
+	query.Count = true
+	if count, exists := c.GetQuery("count"); exists {
+		var err error
+		if query.Count, err = strconv.ParseBool(count); err != nil {
+			return nil, resterrors.NewBadRequestError(`Param "count" must be a bool value`)
+		}
+	}
+
+	if query.Status != "" && !domain.IsUserStatus(query.Status) {
+		return nil, resterrors.NewBadRequestError(`Param "status" must be one of: ` + strings.Join(domain.UserStatuses, ", "))
+	}
+	if query.Role != "" && !contains(domain.Roles, query.Role) {
+		return nil, resterrors.NewBadRequestError(`Param "role" must be one of: ` + strings.Join(domain.Roles, ", "))
+	}
+
+	var err *resterrors.RestErr
+	if query.Fields, err = getFieldsFromQuery(c); err != nil {
+		return nil, err
+	}
+	if query.CreatedFrom, err = getTimeFromQuery(c, "created_from"); err != nil {
+		return nil, err
+	}
+	if query.CreatedTo, err = getTimeFromQuery(c, "created_to"); err != nil {
+		return nil, err
+	}
+
+	if sort := c.Query("sort"); sort != "" {
+		for _, field := range strings.Split(sort, ",") {
+			sortField := domain.SortField{Field: strings.TrimSpace(field)}
+			if strings.HasPrefix(sortField.Field, "-") {
+				sortField.Field, sortField.Descending = sortField.Field[1:], true
+			}
+
+			if !domain.IsUserSortField(sortField.Field) {
+				return nil, resterrors.NewBadRequestError(fmt.Sprintf(`Can't sort by "%s", must be one of: %s`, sortField.Field, strings.Join(domain.UserSortFields, ", ")))
+			}
+			query.Sort = append(query.Sort, sortField)
+		}
+	}
+
+	return query, nil
+}
+
+// requestScheme returns the scheme the client sent the request on. X-Forwarded-Proto
+// is only read when gin took the client IP from the forwarded headers, which it does
+// only for the requests coming through a trusted proxy.
+func requestScheme(c *gin.Context) string {
+	if c.Request.TLS != nil {
+		return "https"
+	}
+
+	if c.ClientIP() != c.RemoteIP() {
+		if proto := c.GetHeader("X-Forwarded-Proto"); proto == "http" || proto == "https" {
+			return proto
+		}
+	}
+	return "http"
+}
+
+// usersListLinks builds the RFC 8288 links to the pages around the page, keeping the
+// query params of the request. The prev and next pages are read by cursor, the first
+// and the last ones by number, the last only when the users were counted. The links
+// are absolute, on the scheme and host the request was sent to.
+func usersListLinks(request *http.Request, scheme string, list dtos.UsersListResponse) string {
+	var links []string
+	addLink := func(rel, param, value string) {
+		params := request.URL.Query()
+		params.Del("page")
+		params.Del("cursor")
+		params.Set(param, value)
+
+		pageURL := url.URL{Scheme: scheme, Host: request.Host, Path: request.URL.Path, RawQuery: params.Encode()}
+		links = append(links, fmt.Sprintf(`<%s>; rel="%s"`, pageURL.String(), rel))
+	}
+
+	addLink("first", "page", "1")
+	if list.PrevCursor != "" {
+		addLink("prev", "cursor", list.PrevCursor)
+	}
+	if list.NextCursor != "" {
+		addLink("next", "cursor", list.NextCursor)
+	}
+	if list.TotalPages != nil && *list.TotalPages > 0 {
+		addLink("last", "page", strconv.FormatInt(*list.TotalPages, 10))
+	}
+
+	return strings.Join(links, ", ")
+}
+
+// getFieldsFromQuery reads the fields of the users to send, refusing the ones the
+// response doesn't have. Every field is sent when none is selected.
+func getFieldsFromQuery(c *gin.Context) ([]string, *resterrors.RestErr) {
+	raw := c.Query("fields")
+	if raw == "" {
+		return nil, nil
+	}
+
+	var fields []string
+	for _, field := range strings.Split(raw, ",") {
+		field = strings.TrimSpace(field)
+		if !contains(dtos.UserResponseFields, field) {
+			return nil, resterrors.NewBadRequestError(fmt.Sprintf(`Unknown field "%s", must be one of: %s`, field, strings.Join(dtos.UserResponseFields, ", ")))
+		}
+		if !contains(fields, field) {
+			fields = append(fields, field)
+		}
+	}
+	return fields, nil
+}
+
+// getTimeFromQuery reads a time param sent as RFC 3339 or as a date, nil when not sent.
+func getTimeFromQuery(c *gin.Context, param string) (*time.Time, *resterrors.RestErr) {
+	raw := c.Query(param)
+	if raw == "" {
+		return nil, nil
+	}
+
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if value, err := time.Parse(layout, raw); err == nil {
+			return &value, nil
+		}
+	}
+	return nil, resterrors.NewBadRequestError(fmt.Sprintf(`Param "%s" must be a RFC 3339 time or a date`, param))
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func getIdFromParam(c *gin.Context) (string, *resterrors.RestErr) {
 	userID := c.Param("id")
 	if _, err := primitive.ObjectIDFromHex(userID); err != nil {
 		restErr := resterrors.NewBadRequestError("Invalid userID, must be a hex value")
@@ -270,3 +723,22 @@ func (*userHandler) getIdFromParam(c *gin.Context) (string, *resterrors.RestErr)
 	}
 	return userID, nil
 }
+
+// getIdFromPrincipal resolves the /users/me routes to the user of the access token,
+// the OAuth clients have no user to resolve to.
+func getIdFromPrincipal(c *gin.Context) (string, *resterrors.RestErr) {
+	principal, ok := auth.PrincipalFromGin(c)
+	if !ok {
+		restErr := resterrors.NewUnauthorizedError(errUnauthenticated)
+		logger.Error(restErr.Message, restErr)
+		return "", restErr
+	}
+
+	if principal.IsClient() {
+		restErr := resterrors.NewForbiddenError(errClientWithoutUser)
+		logger.Error(restErr.Message, restErr, zap.String("client_id", principal.ClientID))
+		return "", restErr
+	}
+
+	return principal.UserID, nil
+}
@@ -2,18 +2,26 @@ package handlers
 
 import (
 	"context"
+	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/WalterPaes/go-rest-api-crud/internal/auth"
 	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	"github.com/WalterPaes/go-rest-api-crud/internal/handlers/dtos"
 	"github.com/WalterPaes/go-rest-api-crud/internal/services"
 	"github.com/WalterPaes/go-rest-api-crud/mocks"
 	"github.com/WalterPaes/go-rest-api-crud/pkg/logger"
 	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 func init() {
@@ -22,6 +30,8 @@ func init() {
 
 var (
 	ctx = context.Background()
+
+	maxPerPage = 50
 )
 
 func getContext(recorder *httptest.ResponseRecorder) *gin.Context {
@@ -37,20 +47,20 @@ func getContext(recorder *httptest.ResponseRecorder) *gin.Context {
 }
 
 func getUserHandler(userService services.UserService) *userHandler {
-	return NewUserHandler(userService)
+	return NewUserHandler(userService, maxPerPage)
 }
 
-func getUserServiceFindAll(t *testing.T, usersList []*domain.User, err error) services.UserService {
+func getUserServiceFindAll(t *testing.T, page *domain.UserPage, err error) services.UserService {
 	t.Helper()
 	m := mocks.NewUserService(t)
-	m.On("FindAll", ctx, itemsPerPage, currentPage).
-		Return(usersList, err)
+	m.On("FindAll", ctx, &domain.UserQuery{Page: currentPage, PerPage: itemsPerPage, Count: true}).
+		Return(page, err)
 	return m
 }
 
 func Test_userHandler_ListAll(t *testing.T) {
 	t.Run("Should return a list of users when not send pagination", func(t *testing.T) {
-		userService := getUserServiceFindAll(t, []*domain.User{}, nil)
+		userService := getUserServiceFindAll(t, &domain.UserPage{}, nil)
 		userHandler := getUserHandler(userService)
 
 		recorder := httptest.NewRecorder()
@@ -88,7 +98,7 @@ func Test_userHandler_ListAll(t *testing.T) {
 	})
 
 	t.Run("Should return a list of users when not send pagination", func(t *testing.T) {
-		userService := getUserServiceFindAll(t, []*domain.User{}, nil)
+		userService := getUserServiceFindAll(t, &domain.UserPage{}, nil)
 		userHandler := getUserHandler(userService)
 
 		recorder := httptest.NewRecorder()
@@ -107,7 +117,7 @@ func Test_userHandler_ListAll(t *testing.T) {
 	})
 
 	t.Run("Should return a list of users when send pagination", func(t *testing.T) {
-		userService := getUserServiceFindAll(t, []*domain.User{}, nil)
+		userService := getUserServiceFindAll(t, &domain.UserPage{}, nil)
 		userHandler := getUserHandler(userService)
 
 		recorder := httptest.NewRecorder()
@@ -127,6 +137,211 @@ func Test_userHandler_ListAll(t *testing.T) {
 		assert.EqualValues(t, http.StatusOK, recorder.Code)
 	})
 
+	t.Run("Should return the users with the status", func(t *testing.T) {
+		userService := mocks.NewUserService(t)
+		userService.On("FindAll", mock.Anything, &domain.UserQuery{Status: domain.UserStatusSuspended, Page: currentPage, PerPage: itemsPerPage, Count: true}).
+			Return(&domain.UserPage{Users: []*domain.User{{ID: "user_id", Status: domain.UserStatusSuspended}}}, nil)
+
+		recorder := httptest.NewRecorder()
+		ctx := getContext(recorder)
+		ctx.Request.URL.RawQuery = url.Values{"status": {domain.UserStatusSuspended}}.Encode()
+
+		getUserHandler(userService).ListAll(ctx)
+
+		assert.EqualValues(t, http.StatusOK, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), `"status":"suspended"`)
+	})
+
+	t.Run("Should return an error when send an unknown status", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		ctx := getContext(recorder)
+		ctx.Request.URL.RawQuery = url.Values{"status": {"banned"}}.Encode()
+
+		getUserHandler(mocks.NewUserService(t)).ListAll(ctx)
+
+		assert.EqualValues(t, http.StatusBadRequest, recorder.Code)
+	})
+
+	t.Run("Should search, filter and sort the users", func(t *testing.T) {
+		createdFrom := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+		createdTo := time.Date(2023, 2, 1, 12, 0, 0, 0, time.UTC)
+
+		userService := mocks.NewUserService(t)
+		userService.On("FindAll", mock.Anything, &domain.UserQuery{
+			Search:      "john",
+			Email:       "john@email.com",
+			Role:        domain.RoleAdmin,
+			CreatedFrom: &createdFrom,
+			CreatedTo:   &createdTo,
+			Sort:        []domain.SortField{{Field: domain.UserSortName}, {Field: domain.UserSortCreatedAt, Descending: true}},
+			Page:        2,
+			PerPage:     5,
+			Count:       true,
+		}).
+			Return(&domain.UserPage{}, nil)
+
+		recorder := httptest.NewRecorder()
+		ctx := getContext(recorder)
+		ctx.Request.URL.RawQuery = url.Values{
+			"q":            {"john"},
+			"email":        {"john@email.com"},
+			"role":         {domain.RoleAdmin},
+			"created_from": {"2023-01-01"},
+			"created_to":   {"2023-02-01T12:00:00Z"},
+			"sort":         {"name,-created_at"},
+			"page":         {"2"},
+			"per_page":     {"5"},
+		}.Encode()
+
+		getUserHandler(userService).ListAll(ctx)
+
+		assert.EqualValues(t, http.StatusOK, recorder.Code)
+	})
+
+	t.Run("Should page by the cursor and return the cursors of the pages around", func(t *testing.T) {
+		userService := mocks.NewUserService(t)
+		userService.On("FindAll", mock.Anything, &domain.UserQuery{Cursor: "next_cursor", PerPage: itemsPerPage, Count: true}).
+			Return(&domain.UserPage{Users: []*domain.User{{ID: "user_id"}}, NextCursor: "other_next_cursor", PrevCursor: "prev_cursor"}, nil)
+
+		recorder := httptest.NewRecorder()
+		ctx := getContext(recorder)
+		ctx.Request.URL.RawQuery = url.Values{"cursor": {"next_cursor"}}.Encode()
+
+		getUserHandler(userService).ListAll(ctx)
+
+		var response dtos.UsersListResponse
+		assert.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		assert.EqualValues(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, "other_next_cursor", response.NextCursor)
+		assert.Equal(t, "prev_cursor", response.PrevCursor)
+		assert.Contains(t, recorder.Body.String(), `"current_page":0`)
+	})
+
+	t.Run("Should return the totals and the links to the pages around", func(t *testing.T) {
+		totalItems := int64(45)
+		userService := mocks.NewUserService(t)
+		userService.On("FindAll", mock.Anything, &domain.UserQuery{Status: domain.UserStatusActive, Page: 2, PerPage: itemsPerPage, Count: true}).
+			Return(&domain.UserPage{Users: []*domain.User{{ID: "user_id"}}, NextCursor: "next", PrevCursor: "prev", TotalItems: &totalItems}, nil)
+
+		recorder := httptest.NewRecorder()
+		ctx := getContext(recorder)
+		ctx.Request.Host = "api.example.com"
+		ctx.Request.URL.Path = "/users"
+		ctx.Request.URL.RawQuery = url.Values{"status": {domain.UserStatusActive}, "page": {"2"}}.Encode()
+
+		getUserHandler(userService).ListAll(ctx)
+
+		var response dtos.UsersListResponse
+		assert.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		assert.EqualValues(t, http.StatusOK, recorder.Code)
+		assert.EqualValues(t, 45, *response.TotalItems)
+		assert.EqualValues(t, 5, *response.TotalPages)
+		assert.True(t, response.HasNext)
+		assert.Equal(t,
+			`<http://api.example.com/users?page=1&status=active>; rel="first", `+
+				`<http://api.example.com/users?cursor=prev&status=active>; rel="prev", `+
+				`<http://api.example.com/users?cursor=next&status=active>; rel="next", `+
+				`<http://api.example.com/users?page=5&status=active>; rel="last"`,
+			recorder.Header().Get("Link"),
+		)
+	})
+
+	t.Run("Should skip the count and cap the items per page", func(t *testing.T) {
+		userService := mocks.NewUserService(t)
+		userService.On("FindAll", mock.Anything, &domain.UserQuery{Page: currentPage, PerPage: maxPerPage}).
+			Return(&domain.UserPage{Users: []*domain.User{{ID: "user_id"}}}, nil)
+
+		recorder := httptest.NewRecorder()
+		ctx := getContext(recorder)
+		ctx.Request.Host = "api.example.com"
+		ctx.Request.RemoteAddr = "10.0.0.1:4321"
+		ctx.Request.Header.Set("X-Forwarded-For", "203.0.113.7")
+		ctx.Request.Header.Set("X-Forwarded-Proto", "https")
+		ctx.Request.URL.Path = "/users"
+		ctx.Request.URL.RawQuery = url.Values{"count": {"false"}, "per_page": {"1000"}}.Encode()
+
+		getUserHandler(userService).ListAll(ctx)
+
+		assert.EqualValues(t, http.StatusOK, recorder.Code)
+		assert.NotContains(t, recorder.Body.String(), "total_items")
+		assert.Contains(t, recorder.Body.String(), `"has_next":false`)
+		assert.Equal(t, `<https://api.example.com/users?count=false&page=1&per_page=1000>; rel="first"`, recorder.Header().Get("Link"))
+	})
+
+	t.Run("Should ignore the forwarded scheme of a request that didn't come through a trusted proxy", func(t *testing.T) {
+		userService := mocks.NewUserService(t)
+		userService.On("FindAll", mock.Anything, &domain.UserQuery{Page: currentPage, PerPage: itemsPerPage, Count: true}).
+			Return(&domain.UserPage{Users: []*domain.User{{ID: "user_id"}}}, nil)
+
+		recorder := httptest.NewRecorder()
+		ctx, engine := gin.CreateTestContext(recorder)
+		assert.Nil(t, engine.SetTrustedProxies([]string{"10.0.0.1"}))
+		ctx.Request = &http.Request{Header: make(http.Header), URL: &url.URL{}}
+		ctx.Request.Host = "api.example.com"
+		ctx.Request.RemoteAddr = "203.0.113.7:4321"
+		ctx.Request.Header.Set("X-Forwarded-For", "198.51.100.1")
+		ctx.Request.Header.Set("X-Forwarded-Proto", "https")
+		ctx.Request.URL.Path = "/users"
+
+		getUserHandler(userService).ListAll(ctx)
+
+		assert.EqualValues(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, `<http://api.example.com/users?page=1>; rel="first"`, recorder.Header().Get("Link"))
+	})
+
+	t.Run("Should send only the fields selected of the users", func(t *testing.T) {
+		userService := mocks.NewUserService(t)
+		userService.On("FindAll", mock.Anything, &domain.UserQuery{Fields: []string{"id", "name"}, Page: currentPage, PerPage: itemsPerPage, Count: true}).
+			Return(&domain.UserPage{Users: []*domain.User{{ID: "user_id", Name: "First User"}}}, nil)
+
+		recorder := httptest.NewRecorder()
+		ctx := getContext(recorder)
+		ctx.Request.URL.RawQuery = url.Values{"fields": {"id,name,id"}}.Encode()
+
+		getUserHandler(userService).ListAll(ctx)
+
+		var response struct {
+			Users []map[string]any `json:"users"`
+		}
+		assert.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		assert.EqualValues(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, []map[string]any{{"id": "user_id", "name": "First User"}}, response.Users)
+	})
+
+	t.Run("Should return an error when send a page and a cursor", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		ctx := getContext(recorder)
+		ctx.Request.URL.RawQuery = url.Values{"cursor": {"next_cursor"}, "page": {"2"}}.Encode()
+
+		getUserHandler(mocks.NewUserService(t)).ListAll(ctx)
+
+		assert.EqualValues(t, http.StatusBadRequest, recorder.Code)
+	})
+
+	for _, tt := range []struct {
+		name    string
+		query   url.Values
+		allowed string
+	}{
+		{"an unknown param", url.Values{"name": {"john"}}, "page, per_page, cursor, count, fields, q, email, status, role, created_from, created_to, sort"},
+		{"an unknown sort field", url.Values{"sort": {"name,-password"}}, "name, email, status, created_at"},
+		{"an unknown role", url.Values{"role": {"root"}}, "admin, user"},
+		{"an invalid created_from", url.Values{"created_from": {"yesterday"}}, "RFC 3339"},
+		{"an invalid count", url.Values{"count": {"maybe"}}, "bool"},
+		{"an unknown field", url.Values{"fields": {"id,password"}}, "id, name, email, roles, email_verified, verified_at, mfa_enabled, status, status_reason"},
+	} {
+		t.Run("Should return an error when send "+tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			ctx := getContext(recorder)
+			ctx.Request.URL.RawQuery = tt.query.Encode()
+
+			getUserHandler(mocks.NewUserService(t)).ListAll(ctx)
+
+			assert.EqualValues(t, http.StatusBadRequest, recorder.Code)
+			assert.Contains(t, recorder.Body.String(), tt.allowed)
+		})
+	}
+
 	t.Run("Should return an error when send not int values to 'per_page' query param", func(t *testing.T) {
 		userService := mocks.NewUserService(t)
 		userHandler := getUserHandler(userService)
@@ -169,3 +384,332 @@ func Test_userHandler_ListAll(t *testing.T) {
 		assert.EqualValues(t, http.StatusBadRequest, recorder.Code)
 	})
 }
+
+func getAuthenticatedContext(recorder *httptest.ResponseRecorder, userID string) *gin.Context {
+	c := getContext(recorder)
+	auth.SetPrincipal(c, &domain.Principal{UserID: userID, Roles: []string{domain.RoleUser}})
+	return c
+}
+
+func getAuthenticatedJSONContext(recorder *httptest.ResponseRecorder, userID, body string) *gin.Context {
+	ctx := getAuthenticatedContext(recorder, userID)
+	ctx.Request.Method = http.MethodPost
+	ctx.Request.Header.Set("Content-Type", "application/json")
+	ctx.Request.Body = io.NopCloser(strings.NewReader(body))
+	return ctx
+}
+
+func Test_userHandler_GetUserById(t *testing.T) {
+	userID := primitive.NewObjectID().Hex()
+
+	t.Run("Should return every field of the user", func(t *testing.T) {
+		userService := mocks.NewUserService(t)
+		userService.On("FindUserById", mock.Anything, userID).
+			Return(&domain.User{ID: userID, Name: "First User", Email: "firstuser@email.com"}, nil)
+
+		recorder := httptest.NewRecorder()
+		ctx := getAuthenticatedContext(recorder, userID)
+		ctx.Params = gin.Params{{Key: "id", Value: userID}}
+
+		getUserHandler(userService).GetUserById(ctx)
+
+		assert.EqualValues(t, http.StatusOK, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), `"email":"firstuser@email.com"`)
+		assert.Contains(t, recorder.Body.String(), `"status":"active"`)
+	})
+
+	t.Run("Should read and send only the fields selected", func(t *testing.T) {
+		userService := mocks.NewUserService(t)
+		userService.On("FindUserById", mock.Anything, userID, "id", "name").
+			Return(&domain.User{ID: userID, Name: "First User"}, nil)
+
+		recorder := httptest.NewRecorder()
+		ctx := getAuthenticatedContext(recorder, userID)
+		ctx.Params = gin.Params{{Key: "id", Value: userID}}
+		ctx.Request.URL.RawQuery = url.Values{"fields": {"id, name"}}.Encode()
+
+		getUserHandler(userService).GetUserById(ctx)
+
+		assert.EqualValues(t, http.StatusOK, recorder.Code)
+		assert.JSONEq(t, `{"id":"`+userID+`","name":"First User"}`, recorder.Body.String())
+	})
+
+	t.Run("Should return an error when select an unknown field", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		ctx := getAuthenticatedContext(recorder, userID)
+		ctx.Params = gin.Params{{Key: "id", Value: userID}}
+		ctx.Request.URL.RawQuery = url.Values{"fields": {"id,password"}}.Encode()
+
+		getUserHandler(mocks.NewUserService(t)).GetUserById(ctx)
+
+		assert.EqualValues(t, http.StatusBadRequest, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), `Unknown field \"password\"`)
+	})
+}
+
+func Test_userHandler_GetMe(t *testing.T) {
+	userID := primitive.NewObjectID().Hex()
+
+	t.Run("Should return the authenticated user", func(t *testing.T) {
+		userService := mocks.NewUserService(t)
+		userService.On("FindUserById", mock.Anything, userID).
+			Return(&domain.User{ID: userID, Name: "First User"}, nil)
+
+		recorder := httptest.NewRecorder()
+		ctx := getAuthenticatedContext(recorder, userID)
+
+		getUserHandler(userService).GetMe(ctx)
+
+		assert.EqualValues(t, http.StatusOK, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), userID)
+	})
+
+	t.Run("Should return an error when there is no authenticated user", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		ctx := getContext(recorder)
+
+		getUserHandler(mocks.NewUserService(t)).GetMe(ctx)
+
+		assert.EqualValues(t, http.StatusUnauthorized, recorder.Code)
+	})
+
+	t.Run("Should forbid an oauth client, which has no user", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		ctx := getContext(recorder)
+		auth.SetPrincipal(ctx, &domain.Principal{ClientID: "client_id", Scopes: []string{domain.ScopeUsersRead}})
+
+		getUserHandler(mocks.NewUserService(t)).GetMe(ctx)
+
+		assert.EqualValues(t, http.StatusForbidden, recorder.Code)
+	})
+}
+
+func Test_userHandler_PatchMe(t *testing.T) {
+	userID := primitive.NewObjectID().Hex()
+
+	newPatchContext := func(recorder *httptest.ResponseRecorder, body string) *gin.Context {
+		ctx := getAuthenticatedContext(recorder, userID)
+		ctx.Request.Method = http.MethodPatch
+		ctx.Request.Header.Set("Content-Type", "application/json")
+		ctx.Request.Body = io.NopCloser(strings.NewReader(body))
+		return ctx
+	}
+
+	t.Run("Should patch only the fields sent", func(t *testing.T) {
+		name := "Patched User"
+
+		userService := mocks.NewUserService(t)
+		userService.On("PatchUser", mock.Anything, userID, &domain.UserPatch{Name: &name}).
+			Return(&domain.User{ID: userID, Name: name}, nil)
+
+		recorder := httptest.NewRecorder()
+		ctx := newPatchContext(recorder, `{"name": "Patched User"}`)
+
+		getUserHandler(userService).PatchMe(ctx)
+
+		assert.EqualValues(t, http.StatusOK, recorder.Code)
+	})
+
+	t.Run("Should return an error when a field sent is invalid", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		ctx := newPatchContext(recorder, `{"email": "invalid"}`)
+
+		getUserHandler(mocks.NewUserService(t)).PatchMe(ctx)
+
+		assert.EqualValues(t, http.StatusBadRequest, recorder.Code)
+	})
+
+	t.Run("Should return an error when try call user service", func(t *testing.T) {
+		userService := mocks.NewUserService(t)
+		userService.On("PatchUser", mock.Anything, userID, mock.Anything).
+			Return(nil, resterrors.NewInternalServerError("error"))
+
+		recorder := httptest.NewRecorder()
+		ctx := newPatchContext(recorder, `{"email": "patched@email.com"}`)
+
+		getUserHandler(userService).PatchMe(ctx)
+
+		assert.EqualValues(t, http.StatusInternalServerError, recorder.Code)
+	})
+}
+
+func Test_userHandler_DeleteMe(t *testing.T) {
+	userID := primitive.NewObjectID().Hex()
+
+	t.Run("Should delete the authenticated user", func(t *testing.T) {
+		userService := mocks.NewUserService(t)
+		userService.On("DeleteUser", mock.Anything, userID).
+			Return(nil)
+
+		recorder := httptest.NewRecorder()
+		ctx := getAuthenticatedContext(recorder, userID)
+
+		getUserHandler(userService).DeleteMe(ctx)
+		ctx.Writer.WriteHeaderNow()
+
+		assert.EqualValues(t, http.StatusNoContent, recorder.Code)
+	})
+}
+
+func Test_userHandler_ChangePassword(t *testing.T) {
+	userID := primitive.NewObjectID().Hex()
+
+	t.Run("Should change the password of the authenticated user", func(t *testing.T) {
+		userService := mocks.NewUserService(t)
+		userService.On("ChangePassword", mock.Anything, userID, "current@123", "new@123").
+			Return(nil)
+
+		recorder := httptest.NewRecorder()
+		ctx := getAuthenticatedJSONContext(recorder, userID, `{"current_password": "current@123", "new_password": "new@123"}`)
+
+		getUserHandler(userService).ChangePassword(ctx)
+		ctx.Writer.WriteHeaderNow()
+
+		assert.EqualValues(t, http.StatusNoContent, recorder.Code)
+	})
+
+	t.Run("Should return an error when the new password is missing", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		ctx := getAuthenticatedJSONContext(recorder, userID, `{"current_password": "current@123"}`)
+
+		getUserHandler(mocks.NewUserService(t)).ChangePassword(ctx)
+
+		assert.EqualValues(t, http.StatusBadRequest, recorder.Code)
+	})
+
+	t.Run("Should return an error when try call user service", func(t *testing.T) {
+		userService := mocks.NewUserService(t)
+		userService.On("ChangePassword", mock.Anything, userID, "wrong@123", "new@123").
+			Return(resterrors.NewBadRequestError("Current password is invalid"))
+
+		recorder := httptest.NewRecorder()
+		ctx := getAuthenticatedJSONContext(recorder, userID, `{"current_password": "wrong@123", "new_password": "new@123"}`)
+
+		getUserHandler(userService).ChangePassword(ctx)
+
+		assert.EqualValues(t, http.StatusBadRequest, recorder.Code)
+	})
+}
+
+func Test_userHandler_RestoreUser(t *testing.T) {
+	adminID := primitive.NewObjectID().Hex()
+	userID := primitive.NewObjectID().Hex()
+
+	t.Run("Should restore the deleted user", func(t *testing.T) {
+		userService := mocks.NewUserService(t)
+		userService.On("RestoreUser", mock.Anything, userID).
+			Return(&domain.User{ID: userID, Email: "test@email.com"}, nil)
+
+		recorder := httptest.NewRecorder()
+		ctx := getAuthenticatedContext(recorder, adminID)
+		ctx.Params = gin.Params{{Key: "id", Value: userID}}
+
+		getUserHandler(userService).RestoreUser(ctx)
+
+		var response dtos.UserResponse
+		assert.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		assert.EqualValues(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, userID, response.ID)
+	})
+
+	t.Run("Should refuse an invalid user id", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		ctx := getAuthenticatedContext(recorder, adminID)
+		ctx.Params = gin.Params{{Key: "id", Value: "invalid"}}
+
+		getUserHandler(mocks.NewUserService(t)).RestoreUser(ctx)
+
+		assert.EqualValues(t, http.StatusBadRequest, recorder.Code)
+	})
+
+	t.Run("Should return not found when the user is not deleted", func(t *testing.T) {
+		userService := mocks.NewUserService(t)
+		userService.On("RestoreUser", mock.Anything, userID).
+			Return(nil, resterrors.NewNotFoundError("user not found"))
+
+		recorder := httptest.NewRecorder()
+		ctx := getAuthenticatedContext(recorder, adminID)
+		ctx.Params = gin.Params{{Key: "id", Value: userID}}
+
+		getUserHandler(userService).RestoreUser(ctx)
+
+		assert.EqualValues(t, http.StatusNotFound, recorder.Code)
+	})
+}
+
+func Test_userHandler_SuspendUser(t *testing.T) {
+	adminID := primitive.NewObjectID().Hex()
+	userID := primitive.NewObjectID().Hex()
+
+	t.Run("Should suspend the user with the reason", func(t *testing.T) {
+		userService := mocks.NewUserService(t)
+		userService.On("ChangeStatus", mock.Anything, userID, domain.UserStatusSuspended, "abuse report").
+			Return(&domain.User{ID: userID, Status: domain.UserStatusSuspended, StatusReason: "abuse report"}, nil)
+
+		recorder := httptest.NewRecorder()
+		ctx := getAuthenticatedJSONContext(recorder, adminID, `{"reason": "abuse report"}`)
+		ctx.Params = gin.Params{{Key: "id", Value: userID}}
+
+		getUserHandler(userService).SuspendUser(ctx)
+
+		var response dtos.UserResponse
+		assert.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		assert.EqualValues(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, domain.UserStatusSuspended, response.Status)
+		assert.Equal(t, "abuse report", response.StatusReason)
+	})
+
+	t.Run("Should require a reason", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		ctx := getAuthenticatedJSONContext(recorder, adminID, `{}`)
+		ctx.Params = gin.Params{{Key: "id", Value: userID}}
+
+		getUserHandler(mocks.NewUserService(t)).SuspendUser(ctx)
+
+		assert.EqualValues(t, http.StatusBadRequest, recorder.Code)
+	})
+
+	t.Run("Should return the error of a transition that is not allowed", func(t *testing.T) {
+		userService := mocks.NewUserService(t)
+		userService.On("ChangeStatus", mock.Anything, userID, domain.UserStatusSuspended, "abuse report").
+			Return(nil, resterrors.NewConflictError("User status can't change from suspended to suspended"))
+
+		recorder := httptest.NewRecorder()
+		ctx := getAuthenticatedJSONContext(recorder, adminID, `{"reason": "abuse report"}`)
+		ctx.Params = gin.Params{{Key: "id", Value: userID}}
+
+		getUserHandler(userService).SuspendUser(ctx)
+
+		assert.EqualValues(t, http.StatusConflict, recorder.Code)
+	})
+}
+
+func Test_userHandler_ReactivateUser(t *testing.T) {
+	adminID := primitive.NewObjectID().Hex()
+	userID := primitive.NewObjectID().Hex()
+
+	t.Run("Should reactivate the user", func(t *testing.T) {
+		userService := mocks.NewUserService(t)
+		userService.On("ChangeStatus", mock.Anything, userID, domain.UserStatusActive, "appeal accepted").
+			Return(&domain.User{ID: userID, Status: domain.UserStatusActive, StatusReason: "appeal accepted"}, nil)
+
+		recorder := httptest.NewRecorder()
+		ctx := getAuthenticatedJSONContext(recorder, adminID, `{"reason": "appeal accepted"}`)
+		ctx.Params = gin.Params{{Key: "id", Value: userID}}
+
+		getUserHandler(userService).ReactivateUser(ctx)
+
+		assert.EqualValues(t, http.StatusOK, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), `"status":"active"`)
+	})
+
+	t.Run("Should refuse an invalid user id", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		ctx := getAuthenticatedJSONContext(recorder, adminID, `{"reason": "appeal accepted"}`)
+		ctx.Params = gin.Params{{Key: "id", Value: "invalid"}}
+
+		getUserHandler(mocks.NewUserService(t)).ReactivateUser(ctx)
+
+		assert.EqualValues(t, http.StatusBadRequest, recorder.Code)
+	})
+}
@@ -0,0 +1,16 @@
+package converter
+
+import (
+	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	"github.com/WalterPaes/go-rest-api-crud/internal/repositories/entities"
+)
+
+func OAuthClientDomainToOAuthClientEntity(clientDomain *domain.OAuthClient) *entities.OAuthClientEntity {
+	return &entities.OAuthClientEntity{
+		ClientID:   clientDomain.ClientID,
+		Name:       clientDomain.Name,
+		SecretHash: clientDomain.SecretHash,
+		Scopes:     clientDomain.Scopes,
+		CreatedAt:  clientDomain.CreatedAt,
+	}
+}
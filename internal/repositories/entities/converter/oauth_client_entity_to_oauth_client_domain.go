@@ -0,0 +1,17 @@
+package converter
+
+import (
+	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	"github.com/WalterPaes/go-rest-api-crud/internal/repositories/entities"
+)
+
+func OAuthClientEntityToOAuthClientDomain(clientEntity entities.OAuthClientEntity) *domain.OAuthClient {
+	return &domain.OAuthClient{
+		ID:         clientEntity.ID.Hex(),
+		ClientID:   clientEntity.ClientID,
+		Name:       clientEntity.Name,
+		SecretHash: clientEntity.SecretHash,
+		Scopes:     clientEntity.Scopes,
+		CreatedAt:  clientEntity.CreatedAt,
+	}
+}
@@ -0,0 +1,16 @@
+package converter
+
+import (
+	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	"github.com/WalterPaes/go-rest-api-crud/internal/repositories/entities"
+)
+
+func PasswordResetTokenDomainToPasswordResetTokenEntity(tokenDomain *domain.PasswordResetToken) *entities.PasswordResetTokenEntity {
+	return &entities.PasswordResetTokenEntity{
+		UserID:    tokenDomain.UserID,
+		TokenHash: tokenDomain.TokenHash,
+		ExpiresAt: tokenDomain.ExpiresAt,
+		CreatedAt: tokenDomain.CreatedAt,
+		UsedAt:    tokenDomain.UsedAt,
+	}
+}
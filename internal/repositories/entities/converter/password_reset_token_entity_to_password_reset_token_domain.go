@@ -0,0 +1,17 @@
+package converter
+
+import (
+	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	"github.com/WalterPaes/go-rest-api-crud/internal/repositories/entities"
+)
+
+func PasswordResetTokenEntityToPasswordResetTokenDomain(tokenEntity entities.PasswordResetTokenEntity) *domain.PasswordResetToken {
+	return &domain.PasswordResetToken{
+		ID:        tokenEntity.ID.Hex(),
+		UserID:    tokenEntity.UserID,
+		TokenHash: tokenEntity.TokenHash,
+		ExpiresAt: tokenEntity.ExpiresAt,
+		CreatedAt: tokenEntity.CreatedAt,
+		UsedAt:    tokenEntity.UsedAt,
+	}
+}
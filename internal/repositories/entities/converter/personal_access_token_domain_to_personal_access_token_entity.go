@@ -0,0 +1,19 @@
+package converter
+
+import (
+	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	"github.com/WalterPaes/go-rest-api-crud/internal/repositories/entities"
+)
+
+func PersonalAccessTokenDomainToPersonalAccessTokenEntity(tokenDomain *domain.PersonalAccessToken) *entities.PersonalAccessTokenEntity {
+	return &entities.PersonalAccessTokenEntity{
+		UserID:     tokenDomain.UserID,
+		Name:       tokenDomain.Name,
+		Scopes:     tokenDomain.Scopes,
+		TokenHash:  tokenDomain.TokenHash,
+		ExpiresAt:  tokenDomain.ExpiresAt,
+		CreatedAt:  tokenDomain.CreatedAt,
+		LastUsedAt: tokenDomain.LastUsedAt,
+		RevokedAt:  tokenDomain.RevokedAt,
+	}
+}
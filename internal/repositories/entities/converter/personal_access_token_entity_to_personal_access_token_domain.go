@@ -0,0 +1,20 @@
+package converter
+
+import (
+	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	"github.com/WalterPaes/go-rest-api-crud/internal/repositories/entities"
+)
+
+func PersonalAccessTokenEntityToPersonalAccessTokenDomain(tokenEntity entities.PersonalAccessTokenEntity) *domain.PersonalAccessToken {
+	return &domain.PersonalAccessToken{
+		ID:         tokenEntity.ID.Hex(),
+		UserID:     tokenEntity.UserID,
+		Name:       tokenEntity.Name,
+		Scopes:     tokenEntity.Scopes,
+		TokenHash:  tokenEntity.TokenHash,
+		ExpiresAt:  tokenEntity.ExpiresAt,
+		CreatedAt:  tokenEntity.CreatedAt,
+		LastUsedAt: tokenEntity.LastUsedAt,
+		RevokedAt:  tokenEntity.RevokedAt,
+	}
+}
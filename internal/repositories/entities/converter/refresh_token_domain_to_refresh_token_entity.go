@@ -0,0 +1,18 @@
+package converter
+
+import (
+	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	"github.com/WalterPaes/go-rest-api-crud/internal/repositories/entities"
+)
+
+func RefreshTokenDomainToRefreshTokenEntity(tokenDomain *domain.RefreshToken) *entities.RefreshTokenEntity {
+	return &entities.RefreshTokenEntity{
+		UserID:    tokenDomain.UserID,
+		FamilyID:  tokenDomain.FamilyID,
+		TokenHash: tokenDomain.TokenHash,
+		ExpiresAt: tokenDomain.ExpiresAt,
+		CreatedAt: tokenDomain.CreatedAt,
+		RotatedAt: tokenDomain.RotatedAt,
+		RevokedAt: tokenDomain.RevokedAt,
+	}
+}
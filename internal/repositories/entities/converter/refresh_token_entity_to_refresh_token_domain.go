@@ -0,0 +1,19 @@
+package converter
+
+import (
+	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	"github.com/WalterPaes/go-rest-api-crud/internal/repositories/entities"
+)
+
+func RefreshTokenEntityToRefreshTokenDomain(tokenEntity entities.RefreshTokenEntity) *domain.RefreshToken {
+	return &domain.RefreshToken{
+		ID:        tokenEntity.ID.Hex(),
+		UserID:    tokenEntity.UserID,
+		FamilyID:  tokenEntity.FamilyID,
+		TokenHash: tokenEntity.TokenHash,
+		ExpiresAt: tokenEntity.ExpiresAt,
+		CreatedAt: tokenEntity.CreatedAt,
+		RotatedAt: tokenEntity.RotatedAt,
+		RevokedAt: tokenEntity.RevokedAt,
+	}
+}
@@ -0,0 +1,18 @@
+package converter
+
+import (
+	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	"github.com/WalterPaes/go-rest-api-crud/internal/repositories/entities"
+)
+
+func SessionDomainToSessionEntity(sessionDomain *domain.Session) *entities.SessionEntity {
+	return &entities.SessionEntity{
+		UserID:     sessionDomain.UserID,
+		UserAgent:  sessionDomain.UserAgent,
+		IP:         sessionDomain.IP,
+		TokenID:    sessionDomain.TokenID,
+		CreatedAt:  sessionDomain.CreatedAt,
+		LastSeenAt: sessionDomain.LastSeenAt,
+		ExpiresAt:  sessionDomain.ExpiresAt,
+	}
+}
@@ -0,0 +1,19 @@
+package converter
+
+import (
+	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	"github.com/WalterPaes/go-rest-api-crud/internal/repositories/entities"
+)
+
+func SessionEntityToSessionDomain(sessionEntity entities.SessionEntity) *domain.Session {
+	return &domain.Session{
+		ID:         sessionEntity.ID.Hex(),
+		UserID:     sessionEntity.UserID,
+		UserAgent:  sessionEntity.UserAgent,
+		IP:         sessionEntity.IP,
+		TokenID:    sessionEntity.TokenID,
+		CreatedAt:  sessionEntity.CreatedAt,
+		LastSeenAt: sessionEntity.LastSeenAt,
+		ExpiresAt:  sessionEntity.ExpiresAt,
+	}
+}
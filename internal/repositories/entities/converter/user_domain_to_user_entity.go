@@ -10,5 +10,7 @@ func UserDomainToUserEntity(userDomain *domain.User) *entities.UserEntity {
 		Name:     userDomain.Name,
 		Email:    userDomain.Email,
 		Password: userDomain.Password,
+		Roles:    userDomain.Roles,
+		Status:   userDomain.Status,
 	}
 }
@@ -6,10 +6,34 @@ import (
 )
 
 func UserEntityToUserDomain(userEntity entities.UserEntity) *domain.User {
-	return &domain.User{
+	user := &domain.User{
 		ID:       userEntity.ID.Hex(),
 		Name:     userEntity.Name,
 		Email:    userEntity.Email,
 		Password: userEntity.Password,
+		Roles:    userEntity.Roles,
+
+		Status:          userEntity.Status,
+		StatusReason:    userEntity.StatusReason,
+		StatusChangedAt: userEntity.StatusChangedAt,
+
+		PasswordHistory: userEntity.PasswordHistory,
+
+		MFAEnabled:    userEntity.MFAEnabled,
+		MFASecret:     userEntity.MFASecret,
+		MFACounter:    userEntity.MFACounter,
+		RecoveryCodes: userEntity.RecoveryCodes,
+
+		MFAChallenge:         userEntity.MFAChallenge,
+		MFAChallengeFailures: userEntity.MFAChallengeFailures,
+
+		DeletedAt: userEntity.DeletedAt,
+	}
+
+	if userEntity.VerifiedEmail != "" && userEntity.VerifiedEmail == userEntity.Email {
+		user.EmailVerified = true
+		user.VerifiedAt = userEntity.VerifiedAt
 	}
+
+	return user
 }
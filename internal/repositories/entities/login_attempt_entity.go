@@ -0,0 +1,10 @@
+package entities
+
+import "time"
+
+type LoginAttemptEntity struct {
+	ID            string    `bson:"_id"`
+	Failures      int       `bson:"failures"`
+	LastFailureAt time.Time `bson:"last_failure_at"`
+	ExpiresAt     time.Time `bson:"expires_at"`
+}
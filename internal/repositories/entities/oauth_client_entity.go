@@ -0,0 +1,16 @@
+package entities
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type OAuthClientEntity struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty"`
+	ClientID   string             `bson:"client_id"`
+	Name       string             `bson:"name"`
+	SecretHash string             `bson:"secret_hash"`
+	Scopes     []string           `bson:"scopes"`
+	CreatedAt  time.Time          `bson:"created_at"`
+}
@@ -0,0 +1,16 @@
+package entities
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type PasswordResetTokenEntity struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	UserID    string             `bson:"user_id"`
+	TokenHash string             `bson:"token_hash"`
+	ExpiresAt time.Time          `bson:"expires_at"`
+	CreatedAt time.Time          `bson:"created_at"`
+	UsedAt    *time.Time         `bson:"used_at,omitempty"`
+}
@@ -0,0 +1,19 @@
+package entities
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type PersonalAccessTokenEntity struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty"`
+	UserID     string             `bson:"user_id"`
+	Name       string             `bson:"name"`
+	Scopes     []string           `bson:"scopes"`
+	TokenHash  string             `bson:"token_hash"`
+	ExpiresAt  time.Time          `bson:"expires_at"`
+	CreatedAt  time.Time          `bson:"created_at"`
+	LastUsedAt *time.Time         `bson:"last_used_at,omitempty"`
+	RevokedAt  *time.Time         `bson:"revoked_at,omitempty"`
+}
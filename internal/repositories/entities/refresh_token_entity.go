@@ -0,0 +1,18 @@
+package entities
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type RefreshTokenEntity struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	UserID    string             `bson:"user_id"`
+	FamilyID  string             `bson:"family_id"`
+	TokenHash string             `bson:"token_hash"`
+	ExpiresAt time.Time          `bson:"expires_at"`
+	CreatedAt time.Time          `bson:"created_at"`
+	RotatedAt *time.Time         `bson:"rotated_at,omitempty"`
+	RevokedAt *time.Time         `bson:"revoked_at,omitempty"`
+}
@@ -0,0 +1,13 @@
+package entities
+
+import "time"
+
+// RevokedTokenEntity revokes the tokens of an user issued before RevokedBeforeNano,
+// in nanoseconds since a date only keeps milliseconds. RevokedBefore is the date the
+// older entries were stored with.
+type RevokedTokenEntity struct {
+	ID                string     `bson:"_id"`
+	RevokedBefore     *time.Time `bson:"revoked_before,omitempty"`
+	RevokedBeforeNano int64      `bson:"revoked_before_ns,omitempty"`
+	ExpiresAt         time.Time  `bson:"expires_at"`
+}
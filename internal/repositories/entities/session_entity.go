@@ -0,0 +1,18 @@
+package entities
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type SessionEntity struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty"`
+	UserID     string             `bson:"user_id"`
+	UserAgent  string             `bson:"user_agent"`
+	IP         string             `bson:"ip"`
+	TokenID    string             `bson:"token_id"`
+	CreatedAt  time.Time          `bson:"created_at"`
+	LastSeenAt time.Time          `bson:"last_seen_at"`
+	ExpiresAt  time.Time          `bson:"expires_at"`
+}
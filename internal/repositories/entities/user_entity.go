@@ -1,10 +1,37 @@
 package entities
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"time"
 
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// UserEntity stores the address that was verified instead of a flag, so changing
+// the email makes the user unverified without resetting anything.
 type UserEntity struct {
 	ID       primitive.ObjectID `bson:"_id,omitempty"`
 	Name     string             `bson:"name"`
 	Email    string             `bson:"email"`
-	Password string             `bson:"password"`
+	Password string             `bson:"password,omitempty"`
+	Roles    []string           `bson:"roles,omitempty"`
+
+	// PasswordHistory has the hashes of the previous passwords, the newest first.
+	PasswordHistory []string `bson:"password_history,omitempty"`
+
+	Status          string     `bson:"status,omitempty"`
+	StatusReason    string     `bson:"status_reason,omitempty"`
+	StatusChangedAt *time.Time `bson:"status_changed_at,omitempty"`
+
+	VerifiedEmail string     `bson:"verified_email,omitempty"`
+	VerifiedAt    *time.Time `bson:"verified_at,omitempty"`
+
+	MFAEnabled    bool     `bson:"mfa_enabled,omitempty"`
+	MFASecret     string   `bson:"mfa_secret,omitempty"`
+	MFACounter    int64    `bson:"mfa_counter,omitempty"`
+	RecoveryCodes []string `bson:"recovery_codes,omitempty"`
+
+	MFAChallenge         string `bson:"mfa_challenge,omitempty"`
+	MFAChallengeFailures int    `bson:"mfa_challenge_failures,omitempty"`
+
+	DeletedAt *time.Time `bson:"deleted_at,omitempty"`
 }
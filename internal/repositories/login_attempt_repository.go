@@ -0,0 +1,128 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/WalterPaes/go-rest-api-crud/internal/repositories/entities"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/lockout"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/logger"
+	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+const (
+	errFindLoginAttempts       = "Error When Try Find Login Attempts"
+	errRegisterLoginFailure    = "Error When Try Register Login Failure"
+	errResetLoginAttempts      = "Error When Try Reset Login Attempts"
+	errCreateLoginAttemptIndex = "Error When Try Create Login Attempt Indexes"
+)
+
+var (
+	stacktraceRegisterLoginFailureRepository = zap.String("stacktrace", "register-login-failure-repository")
+	stacktraceResetLoginAttemptsRepository   = zap.String("stacktrace", "reset-login-attempts-repository")
+)
+
+type loginAttemptRepo struct {
+	collection *mongo.Collection
+}
+
+// NewLoginAttemptRepository returns the mongo implementation of lockout.Store.
+func NewLoginAttemptRepository(client *mongo.Client, databaseName, collectionName string) *loginAttemptRepo {
+	return &loginAttemptRepo{
+		collection: client.Database(databaseName).Collection(collectionName),
+	}
+}
+
+// EnsureIndexes lets mongo drop the attempts once they are forgotten.
+func (r *loginAttemptRepo) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		logger.Error(errCreateLoginAttemptIndex, err)
+		return err
+	}
+	return nil
+}
+
+// GetAttempts ignores expired attempts, the TTL monitor doesn't run continuously.
+func (r *loginAttemptRepo) GetAttempts(parentCtx context.Context, key string) (*lockout.Attempts, *resterrors.RestErr) {
+	ctx, cancel := context.WithCancel(parentCtx)
+	defer cancel()
+
+	filter := bson.D{
+		{Key: "_id", Value: key},
+		{Key: "expires_at", Value: bson.D{{Key: "$gt", Value: time.Now()}}},
+	}
+
+	var loginAttempt entities.LoginAttemptEntity
+	err := r.collection.FindOne(ctx, filter).Decode(&loginAttempt)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return &lockout.Attempts{}, nil
+		}
+
+		logger.Error(errFindLoginAttempts, err, zap.String("key", key))
+		return nil, resterrors.NewInternalServerError(errFindLoginAttempts)
+	}
+
+	return &lockout.Attempts{
+		Failures:      loginAttempt.Failures,
+		LastFailureAt: loginAttempt.LastFailureAt,
+	}, nil
+}
+
+// RegisterFailure counts the failure in a single update, restarting the count
+// when the stored attempts are already expired.
+func (r *loginAttemptRepo) RegisterFailure(parentCtx context.Context, key string, failedAt, expiresAt time.Time) (*lockout.Attempts, *resterrors.RestErr) {
+	logger.Info("Starting Register Login Failure", stacktraceRegisterLoginFailureRepository)
+
+	ctx, cancel := context.WithCancel(parentCtx)
+	defer cancel()
+
+	filter := bson.D{{Key: "_id", Value: key}}
+	updateData := bson.A{bson.D{{Key: "$set", Value: bson.D{
+		{Key: "failures", Value: bson.D{{Key: "$cond", Value: bson.A{
+			bson.D{{Key: "$gt", Value: bson.A{"$expires_at", failedAt}}},
+			bson.D{{Key: "$add", Value: bson.A{"$failures", 1}}},
+			1,
+		}}}},
+		{Key: "last_failure_at", Value: failedAt},
+		{Key: "expires_at", Value: expiresAt},
+	}}}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var loginAttempt entities.LoginAttemptEntity
+	err := r.collection.FindOneAndUpdate(ctx, filter, updateData, opts).Decode(&loginAttempt)
+	if err != nil {
+		logger.Error(errRegisterLoginFailure, err, stacktraceRegisterLoginFailureRepository)
+		return nil, resterrors.NewInternalServerError(errRegisterLoginFailure)
+	}
+
+	logger.Info("Login Failure Registered Successfully", zap.String("key", key), zap.Int("failures", loginAttempt.Failures), stacktraceRegisterLoginFailureRepository)
+	return &lockout.Attempts{
+		Failures:      loginAttempt.Failures,
+		LastFailureAt: loginAttempt.LastFailureAt,
+	}, nil
+}
+
+func (r *loginAttemptRepo) ResetAttempts(parentCtx context.Context, key string) *resterrors.RestErr {
+	logger.Info("Starting Reset Login Attempts", stacktraceResetLoginAttemptsRepository)
+
+	ctx, cancel := context.WithCancel(parentCtx)
+	defer cancel()
+
+	_, err := r.collection.DeleteOne(ctx, bson.D{{Key: "_id", Value: key}})
+	if err != nil {
+		logger.Error(errResetLoginAttempts, err, stacktraceResetLoginAttemptsRepository)
+		return resterrors.NewInternalServerError(errResetLoginAttempts)
+	}
+
+	logger.Info("Login Attempts Reset Successfully", zap.String("key", key), stacktraceResetLoginAttemptsRepository)
+	return nil
+}
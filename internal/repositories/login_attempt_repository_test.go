@@ -0,0 +1,137 @@
+package repositories
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/WalterPaes/go-rest-api-crud/internal/repositories/entities"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+const loginAttemptCollectionName = "login_attempts"
+
+func Test_loginAttemptRepo_GetAttempts(t *testing.T) {
+	mtestDB := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mtestDB.Run("Should Find the Attempts of a Key", func(mtestDB *mtest.T) {
+		lastFailureAt := time.Now().UTC().Truncate(time.Millisecond)
+		mtestDB.AddMockResponses(
+			mtest.CreateCursorResponse(
+				1,
+				fmt.Sprintf("%s.%s", dbName, loginAttemptCollectionName),
+				mtest.FirstBatch,
+				bson.D{
+					{Key: "_id", Value: "email:user@email.com"},
+					{Key: "failures", Value: 3},
+					{Key: "last_failure_at", Value: lastFailureAt},
+					{Key: "expires_at", Value: lastFailureAt.Add(time.Minute)},
+				},
+			),
+		)
+
+		loginAttemptRepository := NewLoginAttemptRepository(mtestDB.Client, dbName, loginAttemptCollectionName)
+
+		attempts, err := loginAttemptRepository.GetAttempts(ctx, "email:user@email.com")
+
+		assert.Nil(t, err)
+		assert.Equal(t, 3, attempts.Failures)
+		assert.True(t, lastFailureAt.Equal(attempts.LastFailureAt))
+	})
+
+	mtestDB.Run("Should return zero attempts when the key has none", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(mtest.CreateCursorResponse(0, fmt.Sprintf("%s.%s", dbName, loginAttemptCollectionName), mtest.FirstBatch))
+
+		loginAttemptRepository := NewLoginAttemptRepository(mtestDB.Client, dbName, loginAttemptCollectionName)
+
+		attempts, err := loginAttemptRepository.GetAttempts(ctx, "email:user@email.com")
+
+		assert.Nil(t, err)
+		assert.Zero(t, attempts.Failures)
+	})
+
+	mtestDB.Run("Should return an error when try find the attempts", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 0},
+		})
+
+		loginAttemptRepository := NewLoginAttemptRepository(mtestDB.Client, dbName, loginAttemptCollectionName)
+
+		_, err := loginAttemptRepository.GetAttempts(ctx, "email:user@email.com")
+
+		assert.NotNil(t, err)
+		assert.Equal(t, err.HttpStatusCode, http.StatusInternalServerError)
+	})
+}
+
+func Test_loginAttemptRepo_RegisterFailure(t *testing.T) {
+	mtestDB := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mtestDB.Run("Should Register a Failure and Return the Attempts", func(mtestDB *mtest.T) {
+		failedAt := time.Now().UTC().Truncate(time.Millisecond)
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 1},
+			{Key: "value", Value: entities.LoginAttemptEntity{
+				ID:            "ip:127.0.0.1",
+				Failures:      2,
+				LastFailureAt: failedAt,
+				ExpiresAt:     failedAt.Add(time.Minute),
+			}},
+		})
+
+		loginAttemptRepository := NewLoginAttemptRepository(mtestDB.Client, dbName, loginAttemptCollectionName)
+
+		attempts, err := loginAttemptRepository.RegisterFailure(ctx, "ip:127.0.0.1", failedAt, failedAt.Add(time.Minute))
+
+		assert.Nil(t, err)
+		assert.Equal(t, 2, attempts.Failures)
+	})
+
+	mtestDB.Run("Should return an error when try register a failure", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 0},
+		})
+
+		loginAttemptRepository := NewLoginAttemptRepository(mtestDB.Client, dbName, loginAttemptCollectionName)
+
+		_, err := loginAttemptRepository.RegisterFailure(ctx, "ip:127.0.0.1", time.Now(), time.Now().Add(time.Minute))
+
+		assert.NotNil(t, err)
+		assert.Equal(t, err.HttpStatusCode, http.StatusInternalServerError)
+		assert.Equal(t, err.Message, errRegisterLoginFailure)
+	})
+}
+
+func Test_loginAttemptRepo_ResetAttempts(t *testing.T) {
+	mtestDB := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mtestDB.Run("Should Reset the Attempts Successfully", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 1},
+			{Key: "acknowledged", Value: true},
+			{Key: "n", Value: 1},
+		})
+
+		loginAttemptRepository := NewLoginAttemptRepository(mtestDB.Client, dbName, loginAttemptCollectionName)
+
+		err := loginAttemptRepository.ResetAttempts(ctx, "email:user@email.com")
+
+		assert.Nil(t, err)
+	})
+
+	mtestDB.Run("Should return an error when try reset the attempts", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 0},
+		})
+
+		loginAttemptRepository := NewLoginAttemptRepository(mtestDB.Client, dbName, loginAttemptCollectionName)
+
+		err := loginAttemptRepository.ResetAttempts(ctx, "email:user@email.com")
+
+		assert.NotNil(t, err)
+		assert.Equal(t, err.Message, errResetLoginAttempts)
+	})
+}
@@ -0,0 +1,155 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	"github.com/WalterPaes/go-rest-api-crud/internal/repositories/entities"
+	"github.com/WalterPaes/go-rest-api-crud/internal/repositories/entities/converter"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/logger"
+	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+const (
+	errInsertOAuthClient       = "Error When Try Insert OAuth Client"
+	errFindOAuthClient         = "Error When Try Find OAuth Client"
+	errOAuthClientNotFound     = "OAuth Client Not Found"
+	errDeleteOAuthClient       = "Error When Try Delete OAuth Client"
+	errCreateOAuthClientIndex  = "Error When Try Create OAuth Client Indexes"
+	errDecodeOAuthClientEntity = "Error When Try Decode OAuth Client"
+)
+
+var (
+	stacktraceCreateOAuthClientRepository = zap.String("stacktrace", "create-oauth-client-repository")
+	stacktraceFindOAuthClientRepository   = zap.String("stacktrace", "find-oauth-client-repository")
+	stacktraceDeleteOAuthClientRepository = zap.String("stacktrace", "delete-oauth-client-repository")
+)
+
+type OAuthClientRepository interface {
+	EnsureIndexes(ctx context.Context) error
+	CreateOAuthClient(ctx context.Context, client *domain.OAuthClient) (*domain.OAuthClient, *resterrors.RestErr)
+	FindOAuthClientByClientID(ctx context.Context, clientID string) (*domain.OAuthClient, *resterrors.RestErr)
+	FindOAuthClients(ctx context.Context) ([]*domain.OAuthClient, *resterrors.RestErr)
+	DeleteOAuthClient(ctx context.Context, clientID string) *resterrors.RestErr
+}
+
+type oauthClientRepo struct {
+	collection *mongo.Collection
+}
+
+func NewOAuthClientRepository(client *mongo.Client, databaseName, collectionName string) *oauthClientRepo {
+	return &oauthClientRepo{
+		collection: client.Database(databaseName).Collection(collectionName),
+	}
+}
+
+func (r *oauthClientRepo) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "client_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		logger.Error(errCreateOAuthClientIndex, err)
+		return err
+	}
+	return nil
+}
+
+func (r *oauthClientRepo) CreateOAuthClient(parentCtx context.Context, clientDomain *domain.OAuthClient) (*domain.OAuthClient, *resterrors.RestErr) {
+	logger.Info("Starting Create OAuth Client", stacktraceCreateOAuthClientRepository)
+
+	ctx, cancel := context.WithCancel(parentCtx)
+	defer cancel()
+
+	clientEntity := converter.OAuthClientDomainToOAuthClientEntity(clientDomain)
+
+	res, err := r.collection.InsertOne(ctx, clientEntity)
+	if err != nil {
+		logger.Error(errInsertOAuthClient, err, stacktraceCreateOAuthClientRepository)
+		return nil, resterrors.NewInternalServerError(errInsertOAuthClient)
+	}
+	clientEntity.ID = res.InsertedID.(primitive.ObjectID)
+
+	logger.Info("OAuth Client Created Successfully", zap.String("client_id", clientEntity.ClientID), stacktraceCreateOAuthClientRepository)
+	return converter.OAuthClientEntityToOAuthClientDomain(*clientEntity), nil
+}
+
+func (r *oauthClientRepo) FindOAuthClientByClientID(parentCtx context.Context, clientID string) (*domain.OAuthClient, *resterrors.RestErr) {
+	logger.Info("Starting Find OAuth Client", stacktraceFindOAuthClientRepository)
+
+	ctx, cancel := context.WithCancel(parentCtx)
+	defer cancel()
+
+	clientEntity := &entities.OAuthClientEntity{}
+
+	err := r.collection.FindOne(ctx, bson.D{{Key: "client_id", Value: clientID}}).Decode(clientEntity)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			logger.Error(errOAuthClientNotFound, err, stacktraceFindOAuthClientRepository)
+			return nil, resterrors.NewNotFoundError(errOAuthClientNotFound)
+		}
+
+		logger.Error(errFindOAuthClient, err, stacktraceFindOAuthClientRepository)
+		return nil, resterrors.NewInternalServerError(errFindOAuthClient)
+	}
+
+	logger.Info("OAuth Client Found Successfully", zap.String("client_id", clientID), stacktraceFindOAuthClientRepository)
+	return converter.OAuthClientEntityToOAuthClientDomain(*clientEntity), nil
+}
+
+// FindOAuthClients returns every registered client, the newest first.
+func (r *oauthClientRepo) FindOAuthClients(parentCtx context.Context) ([]*domain.OAuthClient, *resterrors.RestErr) {
+	logger.Info("Starting Find OAuth Clients", stacktraceFindOAuthClientRepository)
+
+	ctx, cancel := context.WithCancel(parentCtx)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	curr, err := r.collection.Find(ctx, bson.D{}, opts)
+	if err != nil {
+		logger.Error(errFindOAuthClient, err, stacktraceFindOAuthClientRepository)
+		return nil, resterrors.NewInternalServerError(errFindOAuthClient)
+	}
+	defer curr.Close(ctx)
+
+	clients := []*domain.OAuthClient{}
+
+	for curr.Next(ctx) {
+		var clientEntity entities.OAuthClientEntity
+		if err := curr.Decode(&clientEntity); err != nil {
+			logger.Error(errDecodeOAuthClientEntity, err, stacktraceFindOAuthClientRepository)
+			return nil, resterrors.NewInternalServerError(errDecodeOAuthClientEntity)
+		}
+		clients = append(clients, converter.OAuthClientEntityToOAuthClientDomain(clientEntity))
+	}
+
+	logger.Info("OAuth Clients Found Successfully", stacktraceFindOAuthClientRepository)
+	return clients, nil
+}
+
+func (r *oauthClientRepo) DeleteOAuthClient(parentCtx context.Context, clientID string) *resterrors.RestErr {
+	logger.Info("Starting Delete OAuth Client", stacktraceDeleteOAuthClientRepository)
+
+	ctx, cancel := context.WithCancel(parentCtx)
+	defer cancel()
+
+	res, err := r.collection.DeleteOne(ctx, bson.D{{Key: "client_id", Value: clientID}})
+	if err != nil {
+		logger.Error(errDeleteOAuthClient, err, stacktraceDeleteOAuthClientRepository)
+		return resterrors.NewInternalServerError(errDeleteOAuthClient)
+	}
+
+	if res.DeletedCount == 0 {
+		logger.Error(errOAuthClientNotFound, nil, stacktraceDeleteOAuthClientRepository)
+		return resterrors.NewNotFoundError(errOAuthClientNotFound)
+	}
+
+	logger.Info("OAuth Client Deleted Successfully", zap.String("client_id", clientID), stacktraceDeleteOAuthClientRepository)
+	return nil
+}
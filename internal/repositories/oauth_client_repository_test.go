@@ -0,0 +1,169 @@
+package repositories
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+const oauthClientCollectionName = "oauth_clients"
+
+var (
+	oauthClient = &domain.OAuthClient{
+		ClientID:   "client-id",
+		Name:       "billing",
+		SecretHash: "hash",
+		Scopes:     []string{domain.ScopeUsersRead},
+		CreatedAt:  time.Now(),
+	}
+)
+
+func oauthClientDocument() bson.D {
+	return bson.D{
+		{Key: "_id", Value: primitive.NewObjectID()},
+		{Key: "client_id", Value: oauthClient.ClientID},
+		{Key: "name", Value: oauthClient.Name},
+		{Key: "secret_hash", Value: oauthClient.SecretHash},
+		{Key: "scopes", Value: bson.A{domain.ScopeUsersRead}},
+	}
+}
+
+func Test_oauthClientRepo_CreateOAuthClient(t *testing.T) {
+	mtestDB := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mtestDB.Run("Should Create an OAuth Client Successfully", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(mtest.CreateSuccessResponse())
+
+		clientRepository := NewOAuthClientRepository(mtestDB.Client, dbName, oauthClientCollectionName)
+
+		result, err := clientRepository.CreateOAuthClient(ctx, oauthClient)
+
+		assert.Nil(t, err)
+		assert.NotEmpty(t, result.ID)
+		assert.Equal(t, oauthClient.ClientID, result.ClientID)
+		assert.Equal(t, oauthClient.Scopes, result.Scopes)
+	})
+
+	mtestDB.Run("Should return an error when try create an oauth client", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 0},
+		})
+
+		clientRepository := NewOAuthClientRepository(mtestDB.Client, dbName, oauthClientCollectionName)
+
+		result, err := clientRepository.CreateOAuthClient(ctx, oauthClient)
+
+		assert.Nil(t, result)
+		assert.Equal(t, http.StatusInternalServerError, err.HttpStatusCode)
+		assert.Equal(t, errInsertOAuthClient, err.Message)
+	})
+}
+
+func Test_oauthClientRepo_FindOAuthClientByClientID(t *testing.T) {
+	mtestDB := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mtestDB.Run("Should Find an OAuth Client By Client ID Successfully", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(
+			mtest.CreateCursorResponse(
+				1,
+				fmt.Sprintf("%s.%s", dbName, oauthClientCollectionName),
+				mtest.FirstBatch,
+				oauthClientDocument(),
+			),
+		)
+
+		clientRepository := NewOAuthClientRepository(mtestDB.Client, dbName, oauthClientCollectionName)
+
+		result, err := clientRepository.FindOAuthClientByClientID(ctx, oauthClient.ClientID)
+
+		assert.Nil(t, err)
+		assert.Equal(t, oauthClient.SecretHash, result.SecretHash)
+		assert.Equal(t, oauthClient.Scopes, result.Scopes)
+	})
+
+	mtestDB.Run("Should return an error when oauth client not found", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(
+			mtest.CreateCursorResponse(
+				0,
+				fmt.Sprintf("%s.%s", dbName, oauthClientCollectionName),
+				mtest.FirstBatch,
+			),
+		)
+
+		clientRepository := NewOAuthClientRepository(mtestDB.Client, dbName, oauthClientCollectionName)
+
+		result, err := clientRepository.FindOAuthClientByClientID(ctx, oauthClient.ClientID)
+
+		assert.Nil(t, result)
+		assert.Equal(t, http.StatusNotFound, err.HttpStatusCode)
+	})
+}
+
+func Test_oauthClientRepo_FindOAuthClients(t *testing.T) {
+	mtestDB := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mtestDB.Run("Should Find the OAuth Clients Successfully", func(mtestDB *mtest.T) {
+		namespace := fmt.Sprintf("%s.%s", dbName, oauthClientCollectionName)
+		mtestDB.AddMockResponses(
+			mtest.CreateCursorResponse(1, namespace, mtest.FirstBatch, oauthClientDocument(), oauthClientDocument()),
+			mtest.CreateCursorResponse(0, namespace, mtest.NextBatch),
+		)
+
+		clientRepository := NewOAuthClientRepository(mtestDB.Client, dbName, oauthClientCollectionName)
+
+		result, err := clientRepository.FindOAuthClients(ctx)
+
+		assert.Nil(t, err)
+		assert.Len(t, result, 2)
+	})
+
+	mtestDB.Run("Should return an error when try find the oauth clients", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 0},
+		})
+
+		clientRepository := NewOAuthClientRepository(mtestDB.Client, dbName, oauthClientCollectionName)
+
+		result, err := clientRepository.FindOAuthClients(ctx)
+
+		assert.Nil(t, result)
+		assert.Equal(t, http.StatusInternalServerError, err.HttpStatusCode)
+	})
+}
+
+func Test_oauthClientRepo_DeleteOAuthClient(t *testing.T) {
+	mtestDB := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mtestDB.Run("Should Delete an OAuth Client Successfully", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 1},
+			{Key: "n", Value: 1},
+		})
+
+		clientRepository := NewOAuthClientRepository(mtestDB.Client, dbName, oauthClientCollectionName)
+
+		err := clientRepository.DeleteOAuthClient(ctx, oauthClient.ClientID)
+
+		assert.Nil(t, err)
+	})
+
+	mtestDB.Run("Should return not found when the oauth client doesn't exist", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 1},
+			{Key: "n", Value: 0},
+		})
+
+		clientRepository := NewOAuthClientRepository(mtestDB.Client, dbName, oauthClientCollectionName)
+
+		err := clientRepository.DeleteOAuthClient(ctx, oauthClient.ClientID)
+
+		assert.Equal(t, http.StatusNotFound, err.HttpStatusCode)
+	})
+}
@@ -0,0 +1,172 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	"github.com/WalterPaes/go-rest-api-crud/internal/repositories/entities"
+	"github.com/WalterPaes/go-rest-api-crud/internal/repositories/entities/converter"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/logger"
+	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+const (
+	errInsertPasswordResetToken      = "Error When Try Insert Password Reset Token"
+	errFindPasswordResetToken        = "Error When Try Find Password Reset Token"
+	errPasswordResetTokenNotFound    = "Password Reset Token Not Found"
+	errUsePasswordResetToken         = "Error When Try Use Password Reset Token"
+	errUseUserPasswordResetTokens    = "Error When Try Use User Password Reset Tokens"
+	errCreatePasswordResetTokenIndex = "Error When Try Create Password Reset Token Indexes"
+)
+
+var (
+	stacktraceCreatePasswordResetTokenRepository   = zap.String("stacktrace", "create-password-reset-token-repository")
+	stacktraceFindPasswordResetTokenRepository     = zap.String("stacktrace", "find-password-reset-token-repository")
+	stacktraceUsePasswordResetTokenRepository      = zap.String("stacktrace", "use-password-reset-token-repository")
+	stacktraceUseUserPasswordResetTokensRepository = zap.String("stacktrace", "use-user-password-reset-tokens-repository")
+)
+
+type PasswordResetTokenRepository interface {
+	EnsureIndexes(ctx context.Context) error
+	CreatePasswordResetToken(ctx context.Context, token *domain.PasswordResetToken) (*domain.PasswordResetToken, *resterrors.RestErr)
+	FindPasswordResetTokenByHash(ctx context.Context, tokenHash string) (*domain.PasswordResetToken, *resterrors.RestErr)
+	UsePasswordResetToken(ctx context.Context, tokenID string) *resterrors.RestErr
+	UseUserPasswordResetTokens(ctx context.Context, userID string) *resterrors.RestErr
+}
+
+type passwordResetTokenRepo struct {
+	collection *mongo.Collection
+}
+
+func NewPasswordResetTokenRepository(client *mongo.Client, databaseName, collectionName string) *passwordResetTokenRepo {
+	return &passwordResetTokenRepo{
+		collection: client.Database(databaseName).Collection(collectionName),
+	}
+}
+
+// EnsureIndexes creates the lookup indexes on the token hash and on the user, and
+// lets mongo drop reset tokens by itself once they are expired.
+func (r *passwordResetTokenRepo) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "token_hash", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "user_id", Value: 1}},
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+	if err != nil {
+		logger.Error(errCreatePasswordResetTokenIndex, err)
+		return err
+	}
+	return nil
+}
+
+func (r *passwordResetTokenRepo) CreatePasswordResetToken(parentCtx context.Context, tokenDomain *domain.PasswordResetToken) (*domain.PasswordResetToken, *resterrors.RestErr) {
+	logger.Info("Starting Create Password Reset Token", stacktraceCreatePasswordResetTokenRepository)
+
+	ctx, cancel := context.WithCancel(parentCtx)
+	defer cancel()
+
+	tokenEntity := converter.PasswordResetTokenDomainToPasswordResetTokenEntity(tokenDomain)
+
+	res, err := r.collection.InsertOne(ctx, tokenEntity)
+	if err != nil {
+		logger.Error(errInsertPasswordResetToken, err, stacktraceCreatePasswordResetTokenRepository)
+		return nil, resterrors.NewInternalServerError(errInsertPasswordResetToken)
+	}
+	tokenEntity.ID = res.InsertedID.(primitive.ObjectID)
+
+	logger.Info("Password Reset Token Created Successfully", zap.String("user_id", tokenEntity.UserID), stacktraceCreatePasswordResetTokenRepository)
+	return converter.PasswordResetTokenEntityToPasswordResetTokenDomain(*tokenEntity), nil
+}
+
+func (r *passwordResetTokenRepo) FindPasswordResetTokenByHash(parentCtx context.Context, tokenHash string) (*domain.PasswordResetToken, *resterrors.RestErr) {
+	logger.Info("Starting Find Password Reset Token", stacktraceFindPasswordResetTokenRepository)
+
+	ctx, cancel := context.WithCancel(parentCtx)
+	defer cancel()
+
+	tokenEntity := &entities.PasswordResetTokenEntity{}
+
+	err := r.collection.FindOne(ctx, bson.D{{Key: "token_hash", Value: tokenHash}}).Decode(tokenEntity)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			logger.Error(errPasswordResetTokenNotFound, err, stacktraceFindPasswordResetTokenRepository)
+			return nil, resterrors.NewNotFoundError(errPasswordResetTokenNotFound)
+		}
+
+		logger.Error(errFindPasswordResetToken, err, stacktraceFindPasswordResetTokenRepository)
+		return nil, resterrors.NewInternalServerError(errFindPasswordResetToken)
+	}
+
+	logger.Info("Password Reset Token Found Successfully", zap.String("user_id", tokenEntity.UserID), stacktraceFindPasswordResetTokenRepository)
+	return converter.PasswordResetTokenEntityToPasswordResetTokenDomain(*tokenEntity), nil
+}
+
+// UsePasswordResetToken marks the token as used. The filter only matches a token
+// that was never used, so two concurrent resets with the same token can't both
+// succeed: the loser gets a not found error.
+func (r *passwordResetTokenRepo) UsePasswordResetToken(parentCtx context.Context, tokenID string) *resterrors.RestErr {
+	logger.Info("Starting Use Password Reset Token", stacktraceUsePasswordResetTokenRepository)
+
+	ctx, cancel := context.WithCancel(parentCtx)
+	defer cancel()
+
+	tokenObjectId, _ := primitive.ObjectIDFromHex(tokenID)
+
+	filter := bson.D{
+		{Key: "_id", Value: tokenObjectId},
+		{Key: "used_at", Value: bson.D{{Key: "$exists", Value: false}}},
+	}
+	updateData := bson.D{{Key: "$set", Value: bson.D{{Key: "used_at", Value: time.Now()}}}}
+
+	result, err := r.collection.UpdateOne(ctx, filter, updateData)
+	if err != nil {
+		logger.Error(errUsePasswordResetToken, err, stacktraceUsePasswordResetTokenRepository)
+		return resterrors.NewInternalServerError(errUsePasswordResetToken)
+	}
+
+	if result.MatchedCount == 0 {
+		logger.Error(errPasswordResetTokenNotFound, nil, stacktraceUsePasswordResetTokenRepository)
+		return resterrors.NewNotFoundError(errPasswordResetTokenNotFound)
+	}
+
+	logger.Info("Password Reset Token Used Successfully", zap.String("token_id", tokenID), stacktraceUsePasswordResetTokenRepository)
+	return nil
+}
+
+// UseUserPasswordResetTokens marks every token of the user that was never used as
+// used, so the other links sent before a reset can't change the password again.
+func (r *passwordResetTokenRepo) UseUserPasswordResetTokens(parentCtx context.Context, userID string) *resterrors.RestErr {
+	logger.Info("Starting Use User Password Reset Tokens", stacktraceUseUserPasswordResetTokensRepository)
+
+	ctx, cancel := context.WithCancel(parentCtx)
+	defer cancel()
+
+	filter := bson.D{
+		{Key: "user_id", Value: userID},
+		{Key: "used_at", Value: bson.D{{Key: "$exists", Value: false}}},
+	}
+	updateData := bson.D{{Key: "$set", Value: bson.D{{Key: "used_at", Value: time.Now()}}}}
+
+	result, err := r.collection.UpdateMany(ctx, filter, updateData)
+	if err != nil {
+		logger.Error(errUseUserPasswordResetTokens, err, stacktraceUseUserPasswordResetTokensRepository)
+		return resterrors.NewInternalServerError(errUseUserPasswordResetTokens)
+	}
+
+	logger.Info("User Password Reset Tokens Used Successfully", zap.String("user_id", userID), zap.Int64("used_tokens", result.ModifiedCount), stacktraceUseUserPasswordResetTokensRepository)
+	return nil
+}
@@ -0,0 +1,179 @@
+package repositories
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+const passwordResetTokenCollectionName = "password_reset_tokens"
+
+var (
+	passwordResetToken = &domain.PasswordResetToken{
+		UserID:    userEntity.ID.Hex(),
+		TokenHash: "hash",
+		ExpiresAt: time.Now().Add(time.Hour),
+		CreatedAt: time.Now(),
+	}
+)
+
+func Test_passwordResetTokenRepo_CreatePasswordResetToken(t *testing.T) {
+	mtestDB := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mtestDB.Run("Should Create a Password Reset Token Successfully", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(mtest.CreateSuccessResponse())
+
+		passwordResetTokenRepository := NewPasswordResetTokenRepository(mtestDB.Client, dbName, passwordResetTokenCollectionName)
+
+		result, err := passwordResetTokenRepository.CreatePasswordResetToken(ctx, passwordResetToken)
+
+		assert.Nil(t, err)
+		assert.NotEmpty(t, result.ID)
+		assert.Equal(t, result.UserID, passwordResetToken.UserID)
+		assert.Equal(t, result.TokenHash, passwordResetToken.TokenHash)
+	})
+
+	mtestDB.Run("Should return an error when try create a password reset token", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 0},
+		})
+
+		passwordResetTokenRepository := NewPasswordResetTokenRepository(mtestDB.Client, dbName, passwordResetTokenCollectionName)
+
+		result, err := passwordResetTokenRepository.CreatePasswordResetToken(ctx, passwordResetToken)
+
+		assert.Nil(t, result)
+		assert.NotNil(t, err)
+		assert.Equal(t, err.HttpStatusCode, http.StatusInternalServerError)
+		assert.Equal(t, err.Message, errInsertPasswordResetToken)
+	})
+}
+
+func Test_passwordResetTokenRepo_FindPasswordResetTokenByHash(t *testing.T) {
+	mtestDB := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mtestDB.Run("Should Find a Password Reset Token By Hash Successfully", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(
+			mtest.CreateCursorResponse(
+				1,
+				fmt.Sprintf("%s.%s", dbName, passwordResetTokenCollectionName),
+				mtest.FirstBatch,
+				bson.D{
+					{Key: "_id", Value: primitive.NewObjectID()},
+					{Key: "user_id", Value: passwordResetToken.UserID},
+					{Key: "token_hash", Value: passwordResetToken.TokenHash},
+					{Key: "expires_at", Value: passwordResetToken.ExpiresAt},
+				},
+			),
+		)
+
+		passwordResetTokenRepository := NewPasswordResetTokenRepository(mtestDB.Client, dbName, passwordResetTokenCollectionName)
+
+		result, err := passwordResetTokenRepository.FindPasswordResetTokenByHash(ctx, passwordResetToken.TokenHash)
+
+		assert.Nil(t, err)
+		assert.Equal(t, result.UserID, passwordResetToken.UserID)
+		assert.False(t, result.IsUsed())
+	})
+
+	mtestDB.Run("Should return an error when password reset token not found", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(
+			mtest.CreateCursorResponse(
+				0,
+				fmt.Sprintf("%s.%s", dbName, passwordResetTokenCollectionName),
+				mtest.FirstBatch,
+			),
+		)
+
+		passwordResetTokenRepository := NewPasswordResetTokenRepository(mtestDB.Client, dbName, passwordResetTokenCollectionName)
+
+		result, err := passwordResetTokenRepository.FindPasswordResetTokenByHash(ctx, passwordResetToken.TokenHash)
+
+		assert.Nil(t, result)
+		assert.NotNil(t, err)
+		assert.Equal(t, err.HttpStatusCode, http.StatusNotFound)
+	})
+}
+
+func Test_passwordResetTokenRepo_UsePasswordResetToken(t *testing.T) {
+	mtestDB := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mtestDB.Run("Should Use a Password Reset Token Successfully", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 1},
+			{Key: "n", Value: 1},
+			{Key: "nModified", Value: 1},
+		})
+
+		passwordResetTokenRepository := NewPasswordResetTokenRepository(mtestDB.Client, dbName, passwordResetTokenCollectionName)
+
+		err := passwordResetTokenRepository.UsePasswordResetToken(ctx, primitive.NewObjectID().Hex())
+
+		assert.Nil(t, err)
+	})
+
+	mtestDB.Run("Should return an error when the password reset token was already used", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 1},
+			{Key: "n", Value: 0},
+			{Key: "nModified", Value: 0},
+		})
+
+		passwordResetTokenRepository := NewPasswordResetTokenRepository(mtestDB.Client, dbName, passwordResetTokenCollectionName)
+
+		err := passwordResetTokenRepository.UsePasswordResetToken(ctx, primitive.NewObjectID().Hex())
+
+		assert.NotNil(t, err)
+		assert.Equal(t, err.HttpStatusCode, http.StatusNotFound)
+	})
+}
+
+func Test_passwordResetTokenRepo_UseUserPasswordResetTokens(t *testing.T) {
+	mtestDB := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mtestDB.Run("Should Use every Password Reset Token of the user Successfully", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 1},
+			{Key: "n", Value: 2},
+			{Key: "nModified", Value: 2},
+		})
+
+		passwordResetTokenRepository := NewPasswordResetTokenRepository(mtestDB.Client, dbName, passwordResetTokenCollectionName)
+
+		err := passwordResetTokenRepository.UseUserPasswordResetTokens(ctx, primitive.NewObjectID().Hex())
+
+		assert.Nil(t, err)
+	})
+
+	mtestDB.Run("Should not return an error when the user has no password reset token left", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 1},
+			{Key: "n", Value: 0},
+			{Key: "nModified", Value: 0},
+		})
+
+		passwordResetTokenRepository := NewPasswordResetTokenRepository(mtestDB.Client, dbName, passwordResetTokenCollectionName)
+
+		err := passwordResetTokenRepository.UseUserPasswordResetTokens(ctx, primitive.NewObjectID().Hex())
+
+		assert.Nil(t, err)
+	})
+
+	mtestDB.Run("Should return an error when the update fails", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{{Key: "ok", Value: 0}})
+
+		passwordResetTokenRepository := NewPasswordResetTokenRepository(mtestDB.Client, dbName, passwordResetTokenCollectionName)
+
+		err := passwordResetTokenRepository.UseUserPasswordResetTokens(ctx, primitive.NewObjectID().Hex())
+
+		assert.NotNil(t, err)
+		assert.Equal(t, err.HttpStatusCode, http.StatusInternalServerError)
+	})
+}
@@ -0,0 +1,203 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	"github.com/WalterPaes/go-rest-api-crud/internal/repositories/entities"
+	"github.com/WalterPaes/go-rest-api-crud/internal/repositories/entities/converter"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/logger"
+	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+const (
+	errInsertPersonalAccessToken       = "Error When Try Insert Personal Access Token"
+	errFindPersonalAccessToken         = "Error When Try Find Personal Access Token"
+	errPersonalAccessTokenNotFound     = "Personal Access Token Not Found"
+	errRevokePersonalAccessToken       = "Error When Try Revoke Personal Access Token"
+	errTouchPersonalAccessToken        = "Error When Try Update Personal Access Token Last Use"
+	errCreatePersonalAccessTokenIndex  = "Error When Try Create Personal Access Token Indexes"
+	errDecodePersonalAccessTokenEntity = "Error When Try Decode Personal Access Token"
+)
+
+var (
+	stacktraceCreatePersonalAccessTokenRepository = zap.String("stacktrace", "create-personal-access-token-repository")
+	stacktraceFindPersonalAccessTokenRepository   = zap.String("stacktrace", "find-personal-access-token-repository")
+	stacktraceRevokePersonalAccessTokenRepository = zap.String("stacktrace", "revoke-personal-access-token-repository")
+	stacktraceTouchPersonalAccessTokenRepository  = zap.String("stacktrace", "touch-personal-access-token-repository")
+)
+
+type PersonalAccessTokenRepository interface {
+	EnsureIndexes(ctx context.Context) error
+	CreatePersonalAccessToken(ctx context.Context, token *domain.PersonalAccessToken) (*domain.PersonalAccessToken, *resterrors.RestErr)
+	FindPersonalAccessTokenByHash(ctx context.Context, tokenHash string) (*domain.PersonalAccessToken, *resterrors.RestErr)
+	FindUserPersonalAccessTokens(ctx context.Context, userID string) ([]*domain.PersonalAccessToken, *resterrors.RestErr)
+	RevokePersonalAccessToken(ctx context.Context, userID, tokenID string) *resterrors.RestErr
+	TouchPersonalAccessToken(ctx context.Context, tokenID string, usedAt time.Time) *resterrors.RestErr
+}
+
+type personalAccessTokenRepo struct {
+	collection *mongo.Collection
+}
+
+func NewPersonalAccessTokenRepository(client *mongo.Client, databaseName, collectionName string) *personalAccessTokenRepo {
+	return &personalAccessTokenRepo{
+		collection: client.Database(databaseName).Collection(collectionName),
+	}
+}
+
+// EnsureIndexes creates the lookup indexes and lets mongo drop the tokens by itself
+// once they are expired.
+func (r *personalAccessTokenRepo) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "token_hash", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}},
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+	if err != nil {
+		logger.Error(errCreatePersonalAccessTokenIndex, err)
+		return err
+	}
+	return nil
+}
+
+func (r *personalAccessTokenRepo) CreatePersonalAccessToken(parentCtx context.Context, tokenDomain *domain.PersonalAccessToken) (*domain.PersonalAccessToken, *resterrors.RestErr) {
+	logger.Info("Starting Create Personal Access Token", stacktraceCreatePersonalAccessTokenRepository)
+
+	ctx, cancel := context.WithCancel(parentCtx)
+	defer cancel()
+
+	tokenEntity := converter.PersonalAccessTokenDomainToPersonalAccessTokenEntity(tokenDomain)
+
+	res, err := r.collection.InsertOne(ctx, tokenEntity)
+	if err != nil {
+		logger.Error(errInsertPersonalAccessToken, err, stacktraceCreatePersonalAccessTokenRepository)
+		return nil, resterrors.NewInternalServerError(errInsertPersonalAccessToken)
+	}
+	tokenEntity.ID = res.InsertedID.(primitive.ObjectID)
+
+	logger.Info("Personal Access Token Created Successfully", zap.String("token_id", tokenEntity.ID.Hex()), stacktraceCreatePersonalAccessTokenRepository)
+	return converter.PersonalAccessTokenEntityToPersonalAccessTokenDomain(*tokenEntity), nil
+}
+
+func (r *personalAccessTokenRepo) FindPersonalAccessTokenByHash(parentCtx context.Context, tokenHash string) (*domain.PersonalAccessToken, *resterrors.RestErr) {
+	logger.Info("Starting Find Personal Access Token", stacktraceFindPersonalAccessTokenRepository)
+
+	ctx, cancel := context.WithCancel(parentCtx)
+	defer cancel()
+
+	tokenEntity := &entities.PersonalAccessTokenEntity{}
+
+	err := r.collection.FindOne(ctx, bson.D{{Key: "token_hash", Value: tokenHash}}).Decode(tokenEntity)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			logger.Error(errPersonalAccessTokenNotFound, err, stacktraceFindPersonalAccessTokenRepository)
+			return nil, resterrors.NewNotFoundError(errPersonalAccessTokenNotFound)
+		}
+
+		logger.Error(errFindPersonalAccessToken, err, stacktraceFindPersonalAccessTokenRepository)
+		return nil, resterrors.NewInternalServerError(errFindPersonalAccessToken)
+	}
+
+	logger.Info("Personal Access Token Found Successfully", zap.String("token_id", tokenEntity.ID.Hex()), stacktraceFindPersonalAccessTokenRepository)
+	return converter.PersonalAccessTokenEntityToPersonalAccessTokenDomain(*tokenEntity), nil
+}
+
+// FindUserPersonalAccessTokens returns the tokens of the user that were not revoked,
+// the newest first. The expired ones are listed until mongo drops them.
+func (r *personalAccessTokenRepo) FindUserPersonalAccessTokens(parentCtx context.Context, userID string) ([]*domain.PersonalAccessToken, *resterrors.RestErr) {
+	logger.Info("Starting Find User Personal Access Tokens", stacktraceFindPersonalAccessTokenRepository)
+
+	ctx, cancel := context.WithCancel(parentCtx)
+	defer cancel()
+
+	filter := bson.D{
+		{Key: "user_id", Value: userID},
+		{Key: "revoked_at", Value: bson.D{{Key: "$exists", Value: false}}},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	curr, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		logger.Error(errFindPersonalAccessToken, err, stacktraceFindPersonalAccessTokenRepository)
+		return nil, resterrors.NewInternalServerError(errFindPersonalAccessToken)
+	}
+	defer curr.Close(ctx)
+
+	tokens := []*domain.PersonalAccessToken{}
+
+	for curr.Next(ctx) {
+		var tokenEntity entities.PersonalAccessTokenEntity
+		if err := curr.Decode(&tokenEntity); err != nil {
+			logger.Error(errDecodePersonalAccessTokenEntity, err, stacktraceFindPersonalAccessTokenRepository)
+			return nil, resterrors.NewInternalServerError(errDecodePersonalAccessTokenEntity)
+		}
+		tokens = append(tokens, converter.PersonalAccessTokenEntityToPersonalAccessTokenDomain(tokenEntity))
+	}
+
+	logger.Info("User Personal Access Tokens Found Successfully", zap.String("user_id", userID), stacktraceFindPersonalAccessTokenRepository)
+	return tokens, nil
+}
+
+// RevokePersonalAccessToken only matches a token of the user, so an user can't
+// revoke the tokens of another one by guessing their ids.
+func (r *personalAccessTokenRepo) RevokePersonalAccessToken(parentCtx context.Context, userID, tokenID string) *resterrors.RestErr {
+	logger.Info("Starting Revoke Personal Access Token", stacktraceRevokePersonalAccessTokenRepository)
+
+	ctx, cancel := context.WithCancel(parentCtx)
+	defer cancel()
+
+	tokenObjectId, _ := primitive.ObjectIDFromHex(tokenID)
+
+	filter := bson.D{
+		{Key: "_id", Value: tokenObjectId},
+		{Key: "user_id", Value: userID},
+		{Key: "revoked_at", Value: bson.D{{Key: "$exists", Value: false}}},
+	}
+	updateData := bson.D{{Key: "$set", Value: bson.D{{Key: "revoked_at", Value: time.Now()}}}}
+
+	res, err := r.collection.UpdateOne(ctx, filter, updateData)
+	if err != nil {
+		logger.Error(errRevokePersonalAccessToken, err, stacktraceRevokePersonalAccessTokenRepository)
+		return resterrors.NewInternalServerError(errRevokePersonalAccessToken)
+	}
+
+	if res.MatchedCount == 0 {
+		logger.Error(errPersonalAccessTokenNotFound, nil, stacktraceRevokePersonalAccessTokenRepository)
+		return resterrors.NewNotFoundError(errPersonalAccessTokenNotFound)
+	}
+
+	logger.Info("Personal Access Token Revoked Successfully", zap.String("token_id", tokenID), stacktraceRevokePersonalAccessTokenRepository)
+	return nil
+}
+
+// TouchPersonalAccessToken records the last time the token authenticated a request.
+func (r *personalAccessTokenRepo) TouchPersonalAccessToken(parentCtx context.Context, tokenID string, usedAt time.Time) *resterrors.RestErr {
+	ctx, cancel := context.WithCancel(parentCtx)
+	defer cancel()
+
+	tokenObjectId, _ := primitive.ObjectIDFromHex(tokenID)
+
+	filter := bson.D{{Key: "_id", Value: tokenObjectId}}
+	updateData := bson.D{{Key: "$set", Value: bson.D{{Key: "last_used_at", Value: usedAt}}}}
+
+	if _, err := r.collection.UpdateOne(ctx, filter, updateData); err != nil {
+		logger.Error(errTouchPersonalAccessToken, err, stacktraceTouchPersonalAccessTokenRepository)
+		return resterrors.NewInternalServerError(errTouchPersonalAccessToken)
+	}
+	return nil
+}
@@ -0,0 +1,201 @@
+package repositories
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+const personalAccessTokenCollectionName = "personal_access_tokens"
+
+var (
+	personalAccessToken = &domain.PersonalAccessToken{
+		UserID:    userEntity.ID.Hex(),
+		Name:      "ci",
+		Scopes:    []string{domain.ScopeUsersRead},
+		TokenHash: "hash",
+		ExpiresAt: time.Now().Add(time.Hour),
+		CreatedAt: time.Now(),
+	}
+)
+
+func personalAccessTokenDocument() bson.D {
+	return bson.D{
+		{Key: "_id", Value: primitive.NewObjectID()},
+		{Key: "user_id", Value: personalAccessToken.UserID},
+		{Key: "name", Value: personalAccessToken.Name},
+		{Key: "scopes", Value: bson.A{domain.ScopeUsersRead}},
+		{Key: "token_hash", Value: personalAccessToken.TokenHash},
+		{Key: "expires_at", Value: personalAccessToken.ExpiresAt},
+	}
+}
+
+func Test_personalAccessTokenRepo_CreatePersonalAccessToken(t *testing.T) {
+	mtestDB := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mtestDB.Run("Should Create a Personal Access Token Successfully", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(mtest.CreateSuccessResponse())
+
+		tokenRepository := NewPersonalAccessTokenRepository(mtestDB.Client, dbName, personalAccessTokenCollectionName)
+
+		result, err := tokenRepository.CreatePersonalAccessToken(ctx, personalAccessToken)
+
+		assert.Nil(t, err)
+		assert.NotEmpty(t, result.ID)
+		assert.Equal(t, personalAccessToken.Name, result.Name)
+		assert.Equal(t, personalAccessToken.Scopes, result.Scopes)
+	})
+
+	mtestDB.Run("Should return an error when try create a personal access token", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 0},
+		})
+
+		tokenRepository := NewPersonalAccessTokenRepository(mtestDB.Client, dbName, personalAccessTokenCollectionName)
+
+		result, err := tokenRepository.CreatePersonalAccessToken(ctx, personalAccessToken)
+
+		assert.Nil(t, result)
+		assert.Equal(t, http.StatusInternalServerError, err.HttpStatusCode)
+		assert.Equal(t, errInsertPersonalAccessToken, err.Message)
+	})
+}
+
+func Test_personalAccessTokenRepo_FindPersonalAccessTokenByHash(t *testing.T) {
+	mtestDB := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mtestDB.Run("Should Find a Personal Access Token By Hash Successfully", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(
+			mtest.CreateCursorResponse(
+				1,
+				fmt.Sprintf("%s.%s", dbName, personalAccessTokenCollectionName),
+				mtest.FirstBatch,
+				personalAccessTokenDocument(),
+			),
+		)
+
+		tokenRepository := NewPersonalAccessTokenRepository(mtestDB.Client, dbName, personalAccessTokenCollectionName)
+
+		result, err := tokenRepository.FindPersonalAccessTokenByHash(ctx, personalAccessToken.TokenHash)
+
+		assert.Nil(t, err)
+		assert.Equal(t, personalAccessToken.UserID, result.UserID)
+		assert.False(t, result.IsRevoked())
+	})
+
+	mtestDB.Run("Should return an error when personal access token not found", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(
+			mtest.CreateCursorResponse(
+				0,
+				fmt.Sprintf("%s.%s", dbName, personalAccessTokenCollectionName),
+				mtest.FirstBatch,
+			),
+		)
+
+		tokenRepository := NewPersonalAccessTokenRepository(mtestDB.Client, dbName, personalAccessTokenCollectionName)
+
+		result, err := tokenRepository.FindPersonalAccessTokenByHash(ctx, personalAccessToken.TokenHash)
+
+		assert.Nil(t, result)
+		assert.Equal(t, http.StatusNotFound, err.HttpStatusCode)
+	})
+}
+
+func Test_personalAccessTokenRepo_FindUserPersonalAccessTokens(t *testing.T) {
+	mtestDB := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mtestDB.Run("Should Find the Personal Access Tokens of the User Successfully", func(mtestDB *mtest.T) {
+		namespace := fmt.Sprintf("%s.%s", dbName, personalAccessTokenCollectionName)
+		mtestDB.AddMockResponses(
+			mtest.CreateCursorResponse(1, namespace, mtest.FirstBatch, personalAccessTokenDocument(), personalAccessTokenDocument()),
+			mtest.CreateCursorResponse(0, namespace, mtest.NextBatch),
+		)
+
+		tokenRepository := NewPersonalAccessTokenRepository(mtestDB.Client, dbName, personalAccessTokenCollectionName)
+
+		result, err := tokenRepository.FindUserPersonalAccessTokens(ctx, personalAccessToken.UserID)
+
+		assert.Nil(t, err)
+		assert.Len(t, result, 2)
+	})
+
+	mtestDB.Run("Should return an error when try find the personal access tokens", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 0},
+		})
+
+		tokenRepository := NewPersonalAccessTokenRepository(mtestDB.Client, dbName, personalAccessTokenCollectionName)
+
+		result, err := tokenRepository.FindUserPersonalAccessTokens(ctx, personalAccessToken.UserID)
+
+		assert.Nil(t, result)
+		assert.Equal(t, http.StatusInternalServerError, err.HttpStatusCode)
+	})
+}
+
+func Test_personalAccessTokenRepo_RevokePersonalAccessToken(t *testing.T) {
+	mtestDB := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mtestDB.Run("Should Revoke a Personal Access Token Successfully", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 1},
+			{Key: "n", Value: 1},
+			{Key: "nModified", Value: 1},
+		})
+
+		tokenRepository := NewPersonalAccessTokenRepository(mtestDB.Client, dbName, personalAccessTokenCollectionName)
+
+		err := tokenRepository.RevokePersonalAccessToken(ctx, personalAccessToken.UserID, primitive.NewObjectID().Hex())
+
+		assert.Nil(t, err)
+	})
+
+	mtestDB.Run("Should return not found when the token isn't an active token of the user", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 1},
+			{Key: "n", Value: 0},
+			{Key: "nModified", Value: 0},
+		})
+
+		tokenRepository := NewPersonalAccessTokenRepository(mtestDB.Client, dbName, personalAccessTokenCollectionName)
+
+		err := tokenRepository.RevokePersonalAccessToken(ctx, personalAccessToken.UserID, primitive.NewObjectID().Hex())
+
+		assert.Equal(t, http.StatusNotFound, err.HttpStatusCode)
+	})
+}
+
+func Test_personalAccessTokenRepo_TouchPersonalAccessToken(t *testing.T) {
+	mtestDB := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mtestDB.Run("Should Update the Last Use Successfully", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 1},
+			{Key: "n", Value: 1},
+			{Key: "nModified", Value: 1},
+		})
+
+		tokenRepository := NewPersonalAccessTokenRepository(mtestDB.Client, dbName, personalAccessTokenCollectionName)
+
+		assert.Nil(t, tokenRepository.TouchPersonalAccessToken(ctx, primitive.NewObjectID().Hex(), time.Now()))
+	})
+
+	mtestDB.Run("Should return an error when try update the last use", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 0},
+		})
+
+		tokenRepository := NewPersonalAccessTokenRepository(mtestDB.Client, dbName, personalAccessTokenCollectionName)
+
+		err := tokenRepository.TouchPersonalAccessToken(ctx, primitive.NewObjectID().Hex(), time.Now())
+
+		assert.Equal(t, http.StatusInternalServerError, err.HttpStatusCode)
+	})
+}
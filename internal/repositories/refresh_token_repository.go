@@ -0,0 +1,228 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	"github.com/WalterPaes/go-rest-api-crud/internal/repositories/entities"
+	"github.com/WalterPaes/go-rest-api-crud/internal/repositories/entities/converter"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/logger"
+	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+const (
+	errInsertRefreshToken       = "Error When Try Insert Refresh Token"
+	errFindRefreshToken         = "Error When Try Find Refresh Token"
+	errRefreshTokenNotFound     = "Refresh Token Not Found"
+	errRotateRefreshToken       = "Error When Try Rotate Refresh Token"
+	errRevokeRefreshTokenFamily = "Error When Try Revoke Refresh Token Family"
+	errRevokeUserRefreshTokens  = "Error When Try Revoke User Refresh Tokens"
+	errFindUserSessions         = "Error When Try Find User Sessions"
+	errCreateRefreshTokenIndex  = "Error When Try Create Refresh Token Indexes"
+)
+
+var (
+	stacktraceCreateRefreshTokenRepository = zap.String("stacktrace", "create-refresh-token-repository")
+	stacktraceFindRefreshTokenRepository   = zap.String("stacktrace", "find-refresh-token-repository")
+	stacktraceRotateRefreshTokenRepository = zap.String("stacktrace", "rotate-refresh-token-repository")
+	stacktraceRevokeRefreshTokenRepository = zap.String("stacktrace", "revoke-refresh-token-repository")
+)
+
+type RefreshTokenRepository interface {
+	EnsureIndexes(ctx context.Context) error
+	CreateRefreshToken(ctx context.Context, token *domain.RefreshToken) (*domain.RefreshToken, *resterrors.RestErr)
+	FindRefreshTokenByHash(ctx context.Context, tokenHash string) (*domain.RefreshToken, *resterrors.RestErr)
+	RotateRefreshToken(ctx context.Context, tokenID string) *resterrors.RestErr
+	RevokeRefreshTokenFamily(ctx context.Context, familyID string) *resterrors.RestErr
+	RevokeUserRefreshTokens(ctx context.Context, userID string) *resterrors.RestErr
+	FindUserSessionIDs(ctx context.Context, userID string) ([]string, *resterrors.RestErr)
+}
+
+type refreshTokenRepo struct {
+	collection *mongo.Collection
+}
+
+func NewRefreshTokenRepository(client *mongo.Client, databaseName, collectionName string) *refreshTokenRepo {
+	return &refreshTokenRepo{
+		collection: client.Database(databaseName).Collection(collectionName),
+	}
+}
+
+// EnsureIndexes creates the lookup index on the token hash and lets mongo
+// drop refresh tokens by itself once they are expired.
+func (r *refreshTokenRepo) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "token_hash", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "family_id", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "user_id", Value: 1}},
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+	if err != nil {
+		logger.Error(errCreateRefreshTokenIndex, err)
+		return err
+	}
+	return nil
+}
+
+func (r *refreshTokenRepo) CreateRefreshToken(parentCtx context.Context, tokenDomain *domain.RefreshToken) (*domain.RefreshToken, *resterrors.RestErr) {
+	logger.Info("Starting Create Refresh Token", stacktraceCreateRefreshTokenRepository)
+
+	ctx, cancel := context.WithCancel(parentCtx)
+	defer cancel()
+
+	tokenEntity := converter.RefreshTokenDomainToRefreshTokenEntity(tokenDomain)
+
+	res, err := r.collection.InsertOne(ctx, tokenEntity)
+	if err != nil {
+		logger.Error(errInsertRefreshToken, err, stacktraceCreateRefreshTokenRepository)
+		return nil, resterrors.NewInternalServerError(errInsertRefreshToken)
+	}
+	tokenEntity.ID = res.InsertedID.(primitive.ObjectID)
+
+	logger.Info("Refresh Token Created Successfully", zap.String("family_id", tokenEntity.FamilyID), stacktraceCreateRefreshTokenRepository)
+	return converter.RefreshTokenEntityToRefreshTokenDomain(*tokenEntity), nil
+}
+
+func (r *refreshTokenRepo) FindRefreshTokenByHash(parentCtx context.Context, tokenHash string) (*domain.RefreshToken, *resterrors.RestErr) {
+	logger.Info("Starting Find Refresh Token", stacktraceFindRefreshTokenRepository)
+
+	ctx, cancel := context.WithCancel(parentCtx)
+	defer cancel()
+
+	tokenEntity := &entities.RefreshTokenEntity{}
+
+	err := r.collection.FindOne(ctx, bson.D{{Key: "token_hash", Value: tokenHash}}).Decode(tokenEntity)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			logger.Error(errRefreshTokenNotFound, err, stacktraceFindRefreshTokenRepository)
+			return nil, resterrors.NewNotFoundError(errRefreshTokenNotFound)
+		}
+
+		logger.Error(errFindRefreshToken, err, stacktraceFindRefreshTokenRepository)
+		return nil, resterrors.NewInternalServerError(errFindRefreshToken)
+	}
+
+	logger.Info("Refresh Token Found Successfully", zap.String("family_id", tokenEntity.FamilyID), stacktraceFindRefreshTokenRepository)
+	return converter.RefreshTokenEntityToRefreshTokenDomain(*tokenEntity), nil
+}
+
+// RotateRefreshToken marks the token as used. The filter only matches a token
+// that was never rotated nor revoked, so two concurrent refreshes with the same
+// token can't both succeed: the loser gets a not found error.
+func (r *refreshTokenRepo) RotateRefreshToken(parentCtx context.Context, tokenID string) *resterrors.RestErr {
+	logger.Info("Starting Rotate Refresh Token", stacktraceRotateRefreshTokenRepository)
+
+	ctx, cancel := context.WithCancel(parentCtx)
+	defer cancel()
+
+	tokenObjectId, _ := primitive.ObjectIDFromHex(tokenID)
+
+	filter := bson.D{
+		{Key: "_id", Value: tokenObjectId},
+		{Key: "rotated_at", Value: bson.D{{Key: "$exists", Value: false}}},
+		{Key: "revoked_at", Value: bson.D{{Key: "$exists", Value: false}}},
+	}
+	updateData := bson.D{{Key: "$set", Value: bson.D{{Key: "rotated_at", Value: time.Now()}}}}
+
+	res, err := r.collection.UpdateOne(ctx, filter, updateData)
+	if err != nil {
+		logger.Error(errRotateRefreshToken, err, stacktraceRotateRefreshTokenRepository)
+		return resterrors.NewInternalServerError(errRotateRefreshToken)
+	}
+
+	if res.MatchedCount == 0 {
+		logger.Error(errRefreshTokenNotFound, nil, stacktraceRotateRefreshTokenRepository)
+		return resterrors.NewNotFoundError(errRefreshTokenNotFound)
+	}
+
+	logger.Info("Refresh Token Rotated Successfully", zap.String("token_id", tokenID), stacktraceRotateRefreshTokenRepository)
+	return nil
+}
+
+func (r *refreshTokenRepo) RevokeRefreshTokenFamily(parentCtx context.Context, familyID string) *resterrors.RestErr {
+	logger.Info("Starting Revoke Refresh Token Family", stacktraceRevokeRefreshTokenRepository)
+
+	ctx, cancel := context.WithCancel(parentCtx)
+	defer cancel()
+
+	filter := bson.D{
+		{Key: "family_id", Value: familyID},
+		{Key: "revoked_at", Value: bson.D{{Key: "$exists", Value: false}}},
+	}
+	updateData := bson.D{{Key: "$set", Value: bson.D{{Key: "revoked_at", Value: time.Now()}}}}
+
+	_, err := r.collection.UpdateMany(ctx, filter, updateData)
+	if err != nil {
+		logger.Error(errRevokeRefreshTokenFamily, err, stacktraceRevokeRefreshTokenRepository)
+		return resterrors.NewInternalServerError(errRevokeRefreshTokenFamily)
+	}
+
+	logger.Info("Refresh Token Family Revoked Successfully", zap.String("family_id", familyID), stacktraceRevokeRefreshTokenRepository)
+	return nil
+}
+
+func (r *refreshTokenRepo) RevokeUserRefreshTokens(parentCtx context.Context, userID string) *resterrors.RestErr {
+	logger.Info("Starting Revoke User Refresh Tokens", stacktraceRevokeRefreshTokenRepository)
+
+	ctx, cancel := context.WithCancel(parentCtx)
+	defer cancel()
+
+	filter := bson.D{
+		{Key: "user_id", Value: userID},
+		{Key: "revoked_at", Value: bson.D{{Key: "$exists", Value: false}}},
+	}
+	updateData := bson.D{{Key: "$set", Value: bson.D{{Key: "revoked_at", Value: time.Now()}}}}
+
+	_, err := r.collection.UpdateMany(ctx, filter, updateData)
+	if err != nil {
+		logger.Error(errRevokeUserRefreshTokens, err, stacktraceRevokeRefreshTokenRepository)
+		return resterrors.NewInternalServerError(errRevokeUserRefreshTokens)
+	}
+
+	logger.Info("User Refresh Tokens Revoked Successfully", zap.String("user_id", userID), stacktraceRevokeRefreshTokenRepository)
+	return nil
+}
+
+// FindUserSessionIDs returns the families of the user that still have a refresh token
+// able to be used, each family being a session started by a login.
+func (r *refreshTokenRepo) FindUserSessionIDs(parentCtx context.Context, userID string) ([]string, *resterrors.RestErr) {
+	ctx, cancel := context.WithCancel(parentCtx)
+	defer cancel()
+
+	filter := bson.D{
+		{Key: "user_id", Value: userID},
+		{Key: "revoked_at", Value: bson.D{{Key: "$exists", Value: false}}},
+		{Key: "expires_at", Value: bson.D{{Key: "$gt", Value: time.Now()}}},
+	}
+
+	values, err := r.collection.Distinct(ctx, "family_id", filter)
+	if err != nil {
+		logger.Error(errFindUserSessions, err, stacktraceFindRefreshTokenRepository)
+		return nil, resterrors.NewInternalServerError(errFindUserSessions)
+	}
+
+	sessionIDs := make([]string, 0, len(values))
+	for _, value := range values {
+		if sessionID, ok := value.(string); ok {
+			sessionIDs = append(sessionIDs, sessionID)
+		}
+	}
+
+	return sessionIDs, nil
+}
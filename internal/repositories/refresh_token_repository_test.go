@@ -0,0 +1,202 @@
+package repositories
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+const refreshTokenCollectionName = "refresh_tokens"
+
+var (
+	refreshToken = &domain.RefreshToken{
+		UserID:    userEntity.ID.Hex(),
+		FamilyID:  "family",
+		TokenHash: "hash",
+		ExpiresAt: time.Now().Add(time.Hour),
+		CreatedAt: time.Now(),
+	}
+)
+
+func Test_refreshTokenRepo_CreateRefreshToken(t *testing.T) {
+	mtestDB := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mtestDB.Run("Should Create a Refresh Token Successfully", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(mtest.CreateSuccessResponse())
+
+		refreshTokenRepository := NewRefreshTokenRepository(mtestDB.Client, dbName, refreshTokenCollectionName)
+
+		result, err := refreshTokenRepository.CreateRefreshToken(ctx, refreshToken)
+
+		assert.Nil(t, err)
+		assert.Equal(t, result.FamilyID, refreshToken.FamilyID)
+		assert.Equal(t, result.TokenHash, refreshToken.TokenHash)
+	})
+
+	mtestDB.Run("Should return an error when try create a refresh token", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 0},
+		})
+
+		refreshTokenRepository := NewRefreshTokenRepository(mtestDB.Client, dbName, refreshTokenCollectionName)
+
+		result, err := refreshTokenRepository.CreateRefreshToken(ctx, refreshToken)
+
+		assert.Nil(t, result)
+		assert.NotNil(t, err)
+		assert.Equal(t, err.HttpStatusCode, http.StatusInternalServerError)
+		assert.Equal(t, err.Message, errInsertRefreshToken)
+	})
+}
+
+func Test_refreshTokenRepo_FindRefreshTokenByHash(t *testing.T) {
+	mtestDB := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mtestDB.Run("Should Find a Refresh Token By Hash Successfully", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(
+			mtest.CreateCursorResponse(
+				1,
+				fmt.Sprintf("%s.%s", dbName, refreshTokenCollectionName),
+				mtest.FirstBatch,
+				bson.D{
+					{Key: "_id", Value: primitive.NewObjectID()},
+					{Key: "user_id", Value: refreshToken.UserID},
+					{Key: "family_id", Value: refreshToken.FamilyID},
+					{Key: "token_hash", Value: refreshToken.TokenHash},
+					{Key: "expires_at", Value: refreshToken.ExpiresAt},
+				},
+			),
+		)
+
+		refreshTokenRepository := NewRefreshTokenRepository(mtestDB.Client, dbName, refreshTokenCollectionName)
+
+		result, err := refreshTokenRepository.FindRefreshTokenByHash(ctx, refreshToken.TokenHash)
+
+		assert.Nil(t, err)
+		assert.Equal(t, result.UserID, refreshToken.UserID)
+		assert.Equal(t, result.FamilyID, refreshToken.FamilyID)
+	})
+
+	mtestDB.Run("Should return an error when refresh token not found", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(
+			mtest.CreateCursorResponse(
+				0,
+				fmt.Sprintf("%s.%s", dbName, refreshTokenCollectionName),
+				mtest.FirstBatch,
+			),
+		)
+
+		refreshTokenRepository := NewRefreshTokenRepository(mtestDB.Client, dbName, refreshTokenCollectionName)
+
+		result, err := refreshTokenRepository.FindRefreshTokenByHash(ctx, refreshToken.TokenHash)
+
+		assert.Nil(t, result)
+		assert.NotNil(t, err)
+		assert.Equal(t, err.HttpStatusCode, http.StatusNotFound)
+	})
+}
+
+func Test_refreshTokenRepo_RotateRefreshToken(t *testing.T) {
+	mtestDB := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mtestDB.Run("Should Rotate a Refresh Token Successfully", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 1},
+			{Key: "n", Value: 1},
+			{Key: "nModified", Value: 1},
+		})
+
+		refreshTokenRepository := NewRefreshTokenRepository(mtestDB.Client, dbName, refreshTokenCollectionName)
+
+		err := refreshTokenRepository.RotateRefreshToken(ctx, primitive.NewObjectID().Hex())
+
+		assert.Nil(t, err)
+	})
+
+	mtestDB.Run("Should return an error when the refresh token was already rotated", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 1},
+			{Key: "n", Value: 0},
+			{Key: "nModified", Value: 0},
+		})
+
+		refreshTokenRepository := NewRefreshTokenRepository(mtestDB.Client, dbName, refreshTokenCollectionName)
+
+		err := refreshTokenRepository.RotateRefreshToken(ctx, primitive.NewObjectID().Hex())
+
+		assert.NotNil(t, err)
+		assert.Equal(t, err.HttpStatusCode, http.StatusNotFound)
+	})
+}
+
+func Test_refreshTokenRepo_RevokeRefreshTokenFamily(t *testing.T) {
+	mtestDB := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mtestDB.Run("Should Revoke a Refresh Token Family Successfully", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 1},
+			{Key: "n", Value: 2},
+			{Key: "nModified", Value: 2},
+		})
+
+		refreshTokenRepository := NewRefreshTokenRepository(mtestDB.Client, dbName, refreshTokenCollectionName)
+
+		err := refreshTokenRepository.RevokeRefreshTokenFamily(ctx, refreshToken.FamilyID)
+
+		assert.Nil(t, err)
+	})
+
+	mtestDB.Run("Should return an error when try revoke a refresh token family", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 0},
+		})
+
+		refreshTokenRepository := NewRefreshTokenRepository(mtestDB.Client, dbName, refreshTokenCollectionName)
+
+		err := refreshTokenRepository.RevokeRefreshTokenFamily(ctx, refreshToken.FamilyID)
+
+		assert.NotNil(t, err)
+		assert.Equal(t, err.HttpStatusCode, http.StatusInternalServerError)
+		assert.Equal(t, err.Message, errRevokeRefreshTokenFamily)
+	})
+}
+
+func Test_refreshTokenRepo_FindUserSessionIDs(t *testing.T) {
+	mtestDB := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mtestDB.Run("Should Find the Sessions of an User Successfully", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 1},
+			{Key: "values", Value: bson.A{"family_1", "family_2"}},
+		})
+
+		refreshTokenRepository := NewRefreshTokenRepository(mtestDB.Client, dbName, refreshTokenCollectionName)
+
+		sessionIDs, err := refreshTokenRepository.FindUserSessionIDs(ctx, refreshToken.UserID)
+
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"family_1", "family_2"}, sessionIDs)
+	})
+
+	mtestDB.Run("Should return an error when try find the sessions of an user", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 0},
+		})
+
+		refreshTokenRepository := NewRefreshTokenRepository(mtestDB.Client, dbName, refreshTokenCollectionName)
+
+		sessionIDs, err := refreshTokenRepository.FindUserSessionIDs(ctx, refreshToken.UserID)
+
+		assert.Nil(t, sessionIDs)
+		assert.NotNil(t, err)
+		assert.Equal(t, err.HttpStatusCode, http.StatusInternalServerError)
+		assert.Equal(t, err.Message, errFindUserSessions)
+	})
+}
@@ -0,0 +1,164 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/WalterPaes/go-rest-api-crud/internal/repositories/entities"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/logger"
+	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+const (
+	errRevokeToken             = "Error When Try Revoke Token"
+	errRevokeSession           = "Error When Try Revoke Session"
+	errRevokeUserTokens        = "Error When Try Revoke User Tokens"
+	errFindRevokedTokens       = "Error When Try Find Revoked Tokens"
+	errCreateRevokedTokenIndex = "Error When Try Create Revoked Token Indexes"
+
+	revokedTokenPrefix   = "token:"
+	revokedSessionPrefix = "session:"
+	revokedUserPrefix    = "user:"
+)
+
+var (
+	stacktraceRevokeTokenRepository      = zap.String("stacktrace", "revoke-token-repository")
+	stacktraceRevokeSessionRepository    = zap.String("stacktrace", "revoke-session-repository")
+	stacktraceRevokeUserTokensRepository = zap.String("stacktrace", "revoke-user-tokens-repository")
+	stacktraceIsRevokedRepository        = zap.String("stacktrace", "is-revoked-repository")
+)
+
+type revokedTokenRepo struct {
+	collection *mongo.Collection
+}
+
+// NewRevokedTokenRepository returns the mongo implementation of jwt.RevocationStore.
+func NewRevokedTokenRepository(client *mongo.Client, databaseName, collectionName string) *revokedTokenRepo {
+	return &revokedTokenRepo{
+		collection: client.Database(databaseName).Collection(collectionName),
+	}
+}
+
+// EnsureIndexes lets mongo drop the revocations once the tokens they refer to are expired.
+func (r *revokedTokenRepo) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		logger.Error(errCreateRevokedTokenIndex, err)
+		return err
+	}
+	return nil
+}
+
+func (r *revokedTokenRepo) RevokeToken(parentCtx context.Context, tokenID string, expiresAt time.Time) *resterrors.RestErr {
+	logger.Info("Starting Revoke Token", stacktraceRevokeTokenRepository)
+
+	ctx, cancel := context.WithCancel(parentCtx)
+	defer cancel()
+
+	filter := bson.D{{Key: "_id", Value: revokedTokenPrefix + tokenID}}
+	updateData := bson.D{{Key: "$max", Value: bson.D{{Key: "expires_at", Value: expiresAt}}}}
+
+	_, err := r.collection.UpdateOne(ctx, filter, updateData, options.Update().SetUpsert(true))
+	if err != nil {
+		logger.Error(errRevokeToken, err, stacktraceRevokeTokenRepository)
+		return resterrors.NewInternalServerError(errRevokeToken)
+	}
+
+	logger.Info("Token Revoked Successfully", zap.String("token_id", tokenID), stacktraceRevokeTokenRepository)
+	return nil
+}
+
+func (r *revokedTokenRepo) RevokeSession(parentCtx context.Context, sessionID string, expiresAt time.Time) *resterrors.RestErr {
+	logger.Info("Starting Revoke Session", stacktraceRevokeSessionRepository)
+
+	ctx, cancel := context.WithCancel(parentCtx)
+	defer cancel()
+
+	filter := bson.D{{Key: "_id", Value: revokedSessionPrefix + sessionID}}
+	updateData := bson.D{{Key: "$max", Value: bson.D{{Key: "expires_at", Value: expiresAt}}}}
+
+	_, err := r.collection.UpdateOne(ctx, filter, updateData, options.Update().SetUpsert(true))
+	if err != nil {
+		logger.Error(errRevokeSession, err, stacktraceRevokeSessionRepository)
+		return resterrors.NewInternalServerError(errRevokeSession)
+	}
+
+	logger.Info("Session Revoked Successfully", zap.String("session_id", sessionID), stacktraceRevokeSessionRepository)
+	return nil
+}
+
+func (r *revokedTokenRepo) RevokeUserTokens(parentCtx context.Context, userID string, issuedBefore, expiresAt time.Time) *resterrors.RestErr {
+	logger.Info("Starting Revoke User Tokens", stacktraceRevokeUserTokensRepository)
+
+	ctx, cancel := context.WithCancel(parentCtx)
+	defer cancel()
+
+	filter := bson.D{{Key: "_id", Value: revokedUserPrefix + userID}}
+	updateData := bson.D{{Key: "$max", Value: bson.D{
+		{Key: "revoked_before_ns", Value: issuedBefore.UnixNano()},
+		{Key: "expires_at", Value: expiresAt},
+	}}}
+
+	_, err := r.collection.UpdateOne(ctx, filter, updateData, options.Update().SetUpsert(true))
+	if err != nil {
+		logger.Error(errRevokeUserTokens, err, stacktraceRevokeUserTokensRepository)
+		return resterrors.NewInternalServerError(errRevokeUserTokens)
+	}
+
+	logger.Info("User Tokens Revoked Successfully", zap.String("user_id", userID), stacktraceRevokeUserTokensRepository)
+	return nil
+}
+
+// IsRevoked looks up the token, its session and its user revocations in a single query.
+// The TTL monitor doesn't run continuously, so expired entries are ignored here as well.
+func (r *revokedTokenRepo) IsRevoked(parentCtx context.Context, tokenID, userID, sessionID string, issuedAt time.Time) (bool, *resterrors.RestErr) {
+	ctx, cancel := context.WithCancel(parentCtx)
+	defer cancel()
+
+	ids := bson.A{revokedTokenPrefix + tokenID, revokedUserPrefix + userID}
+	if sessionID != "" {
+		ids = append(ids, revokedSessionPrefix+sessionID)
+	}
+
+	now := time.Now()
+	filter := bson.D{
+		{Key: "_id", Value: bson.D{{Key: "$in", Value: ids}}},
+		{Key: "expires_at", Value: bson.D{{Key: "$gt", Value: now}}},
+	}
+
+	curr, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		logger.Error(errFindRevokedTokens, err, stacktraceIsRevokedRepository)
+		return false, resterrors.NewInternalServerError(errFindRevokedTokens)
+	}
+	defer curr.Close(ctx)
+
+	for curr.Next(ctx) {
+		var revokedToken entities.RevokedTokenEntity
+		if err := curr.Decode(&revokedToken); err != nil {
+			logger.Error(errFindRevokedTokens, err, stacktraceIsRevokedRepository)
+			return false, resterrors.NewInternalServerError(errFindRevokedTokens)
+		}
+
+		if revokedToken.ID == revokedTokenPrefix+tokenID || revokedToken.ID == revokedSessionPrefix+sessionID {
+			return true, nil
+		}
+
+		if revokedToken.RevokedBeforeNano != 0 && issuedAt.UnixNano() < revokedToken.RevokedBeforeNano {
+			return true, nil
+		}
+
+		if revokedToken.RevokedBefore != nil && issuedAt.Before(*revokedToken.RevokedBefore) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
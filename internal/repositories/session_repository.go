@@ -0,0 +1,158 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	"github.com/WalterPaes/go-rest-api-crud/internal/repositories/entities"
+	"github.com/WalterPaes/go-rest-api-crud/internal/repositories/entities/converter"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/logger"
+	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+const (
+	errInsertSession       = "Error When Try Insert Session"
+	errFindSession         = "Error When Try Find Sessions"
+	errSessionNotFound     = "Session Not Found"
+	errTouchSession        = "Error When Try Update Session Last Seen"
+	errCreateSessionIndex  = "Error When Try Create Session Indexes"
+	errDecodeSessionEntity = "Error When Try Decode Session"
+)
+
+var (
+	stacktraceCreateSessionRepository = zap.String("stacktrace", "create-session-repository")
+	stacktraceFindSessionRepository   = zap.String("stacktrace", "find-session-repository")
+	stacktraceTouchSessionRepository  = zap.String("stacktrace", "touch-session-repository")
+)
+
+type SessionRepository interface {
+	EnsureIndexes(ctx context.Context) error
+	CreateSession(ctx context.Context, session *domain.Session) (*domain.Session, *resterrors.RestErr)
+	FindUserSessions(ctx context.Context, userID string, sessionIDs []string) ([]*domain.Session, *resterrors.RestErr)
+	TouchSession(ctx context.Context, sessionID, tokenID string, lastSeenAt, expiresAt time.Time) *resterrors.RestErr
+}
+
+type sessionRepo struct {
+	collection *mongo.Collection
+}
+
+func NewSessionRepository(client *mongo.Client, databaseName, collectionName string) *sessionRepo {
+	return &sessionRepo{
+		collection: client.Database(databaseName).Collection(collectionName),
+	}
+}
+
+// EnsureIndexes creates the lookup index of the sessions of an user and lets mongo
+// drop them by itself once their refresh tokens are expired.
+func (r *sessionRepo) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "last_seen_at", Value: -1}},
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+	if err != nil {
+		logger.Error(errCreateSessionIndex, err)
+		return err
+	}
+	return nil
+}
+
+func (r *sessionRepo) CreateSession(parentCtx context.Context, sessionDomain *domain.Session) (*domain.Session, *resterrors.RestErr) {
+	logger.Info("Starting Create Session", stacktraceCreateSessionRepository)
+
+	ctx, cancel := context.WithCancel(parentCtx)
+	defer cancel()
+
+	sessionEntity := converter.SessionDomainToSessionEntity(sessionDomain)
+
+	res, err := r.collection.InsertOne(ctx, sessionEntity)
+	if err != nil {
+		logger.Error(errInsertSession, err, stacktraceCreateSessionRepository)
+		return nil, resterrors.NewInternalServerError(errInsertSession)
+	}
+	sessionEntity.ID = res.InsertedID.(primitive.ObjectID)
+
+	logger.Info("Session Created Successfully", zap.String("session_id", sessionEntity.ID.Hex()), stacktraceCreateSessionRepository)
+	return converter.SessionEntityToSessionDomain(*sessionEntity), nil
+}
+
+// FindUserSessions returns the sessions of the user among the given ids, the most
+// recently seen first. The ids of another user are ignored.
+func (r *sessionRepo) FindUserSessions(parentCtx context.Context, userID string, sessionIDs []string) ([]*domain.Session, *resterrors.RestErr) {
+	logger.Info("Starting Find User Sessions", stacktraceFindSessionRepository)
+
+	ctx, cancel := context.WithCancel(parentCtx)
+	defer cancel()
+
+	sessionObjectIds := bson.A{}
+	for _, sessionID := range sessionIDs {
+		if sessionObjectId, err := primitive.ObjectIDFromHex(sessionID); err == nil {
+			sessionObjectIds = append(sessionObjectIds, sessionObjectId)
+		}
+	}
+
+	filter := bson.D{
+		{Key: "_id", Value: bson.D{{Key: "$in", Value: sessionObjectIds}}},
+		{Key: "user_id", Value: userID},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "last_seen_at", Value: -1}})
+
+	curr, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		logger.Error(errFindSession, err, stacktraceFindSessionRepository)
+		return nil, resterrors.NewInternalServerError(errFindSession)
+	}
+	defer curr.Close(ctx)
+
+	sessions := []*domain.Session{}
+
+	for curr.Next(ctx) {
+		var sessionEntity entities.SessionEntity
+		if err := curr.Decode(&sessionEntity); err != nil {
+			logger.Error(errDecodeSessionEntity, err, stacktraceFindSessionRepository)
+			return nil, resterrors.NewInternalServerError(errDecodeSessionEntity)
+		}
+		sessions = append(sessions, converter.SessionEntityToSessionDomain(sessionEntity))
+	}
+
+	logger.Info("User Sessions Found Successfully", zap.String("user_id", userID), stacktraceFindSessionRepository)
+	return sessions, nil
+}
+
+// TouchSession records the access token issued by a refresh of the session and
+// extends it along with its refresh tokens.
+func (r *sessionRepo) TouchSession(parentCtx context.Context, sessionID, tokenID string, lastSeenAt, expiresAt time.Time) *resterrors.RestErr {
+	ctx, cancel := context.WithCancel(parentCtx)
+	defer cancel()
+
+	sessionObjectId, _ := primitive.ObjectIDFromHex(sessionID)
+
+	filter := bson.D{{Key: "_id", Value: sessionObjectId}}
+	updateData := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "token_id", Value: tokenID},
+		{Key: "last_seen_at", Value: lastSeenAt},
+		{Key: "expires_at", Value: expiresAt},
+	}}}
+
+	res, err := r.collection.UpdateOne(ctx, filter, updateData)
+	if err != nil {
+		logger.Error(errTouchSession, err, stacktraceTouchSessionRepository)
+		return resterrors.NewInternalServerError(errTouchSession)
+	}
+
+	if res.MatchedCount == 0 {
+		logger.Error(errSessionNotFound, nil, stacktraceTouchSessionRepository)
+		return resterrors.NewNotFoundError(errSessionNotFound)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package repositories
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+const sessionCollectionName = "sessions"
+
+var (
+	session = &domain.Session{
+		UserID:     userEntity.ID.Hex(),
+		UserAgent:  "Mozilla/5.0",
+		IP:         "203.0.113.7",
+		TokenID:    "token_id",
+		CreatedAt:  time.Now(),
+		LastSeenAt: time.Now(),
+		ExpiresAt:  time.Now().Add(time.Hour),
+	}
+)
+
+func sessionDocument() bson.D {
+	return bson.D{
+		{Key: "_id", Value: primitive.NewObjectID()},
+		{Key: "user_id", Value: session.UserID},
+		{Key: "user_agent", Value: session.UserAgent},
+		{Key: "ip", Value: session.IP},
+		{Key: "token_id", Value: session.TokenID},
+		{Key: "expires_at", Value: session.ExpiresAt},
+	}
+}
+
+func Test_sessionRepo_CreateSession(t *testing.T) {
+	mtestDB := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mtestDB.Run("Should Create a Session Successfully", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(mtest.CreateSuccessResponse())
+
+		sessionRepository := NewSessionRepository(mtestDB.Client, dbName, sessionCollectionName)
+
+		result, err := sessionRepository.CreateSession(ctx, session)
+
+		assert.Nil(t, err)
+		assert.NotEmpty(t, result.ID)
+		assert.Equal(t, session.UserAgent, result.UserAgent)
+		assert.Equal(t, session.IP, result.IP)
+	})
+
+	mtestDB.Run("Should return an error when try create a session", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 0},
+		})
+
+		sessionRepository := NewSessionRepository(mtestDB.Client, dbName, sessionCollectionName)
+
+		result, err := sessionRepository.CreateSession(ctx, session)
+
+		assert.Nil(t, result)
+		assert.Equal(t, http.StatusInternalServerError, err.HttpStatusCode)
+		assert.Equal(t, errInsertSession, err.Message)
+	})
+}
+
+func Test_sessionRepo_FindUserSessions(t *testing.T) {
+	mtestDB := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mtestDB.Run("Should Find the Sessions of the User Successfully", func(mtestDB *mtest.T) {
+		namespace := fmt.Sprintf("%s.%s", dbName, sessionCollectionName)
+		mtestDB.AddMockResponses(
+			mtest.CreateCursorResponse(1, namespace, mtest.FirstBatch, sessionDocument(), sessionDocument()),
+			mtest.CreateCursorResponse(0, namespace, mtest.NextBatch),
+		)
+
+		sessionRepository := NewSessionRepository(mtestDB.Client, dbName, sessionCollectionName)
+
+		result, err := sessionRepository.FindUserSessions(ctx, session.UserID, []string{primitive.NewObjectID().Hex(), "legacy_family"})
+
+		assert.Nil(t, err)
+		assert.Len(t, result, 2)
+	})
+
+	mtestDB.Run("Should return an error when try find the sessions", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 0},
+		})
+
+		sessionRepository := NewSessionRepository(mtestDB.Client, dbName, sessionCollectionName)
+
+		result, err := sessionRepository.FindUserSessions(ctx, session.UserID, []string{primitive.NewObjectID().Hex()})
+
+		assert.Nil(t, result)
+		assert.Equal(t, http.StatusInternalServerError, err.HttpStatusCode)
+	})
+}
+
+func Test_sessionRepo_TouchSession(t *testing.T) {
+	mtestDB := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mtestDB.Run("Should Update the Last Seen Successfully", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 1},
+			{Key: "n", Value: 1},
+			{Key: "nModified", Value: 1},
+		})
+
+		sessionRepository := NewSessionRepository(mtestDB.Client, dbName, sessionCollectionName)
+
+		assert.Nil(t, sessionRepository.TouchSession(ctx, primitive.NewObjectID().Hex(), "token_id", time.Now(), time.Now().Add(time.Hour)))
+	})
+
+	mtestDB.Run("Should return not found when there is no session", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 1},
+			{Key: "n", Value: 0},
+			{Key: "nModified", Value: 0},
+		})
+
+		sessionRepository := NewSessionRepository(mtestDB.Client, dbName, sessionCollectionName)
+
+		err := sessionRepository.TouchSession(ctx, primitive.NewObjectID().Hex(), "token_id", time.Now(), time.Now().Add(time.Hour))
+
+		assert.Equal(t, http.StatusNotFound, err.HttpStatusCode)
+	})
+}
@@ -3,6 +3,9 @@ package repositories
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"regexp"
+	"time"
 
 	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
 	"github.com/WalterPaes/go-rest-api-crud/internal/repositories/entities"
@@ -18,29 +21,75 @@ import (
 
 const (
 	errFindAllUsers    = "Error When Try Find All Users"
+	errCountUsers      = "Error When Try Count Users"
 	errFindByIdUser    = "Error When Try Find User By ID"
 	errFindByEmailUser = "Error When Try Find User By Email"
 	errInsertUser      = "Error When Try Insert User"
 	errUpdateUser      = "Error When Try Update User"
+	errPatchUser       = "Error When Try Patch User"
+	errUpdatePassword  = "Error When Try Update Password"
+	errVerifyEmail     = "Error When Try Verify Email"
+	errSetMFASecret    = "Error When Try Set MFA Secret"
+	errEnableMFA       = "Error When Try Enable MFA"
+	errUseMFACounter   = "Error When Try Use MFA Code"
+	errUseRecoveryCode = "Error When Try Use Recovery Code"
+	errMFAChallenge    = "Error When Try Update MFA Challenge"
 	errDeleteUser      = "Error When Try Delete User"
+	errUpdateStatus    = "Error When Try Update User Status"
+	errRestoreUser     = "Error When Try Restore User"
+	errPurgeUsers      = "Error When Try Purge Deleted Users"
+	errCreateUserIndex = "Error When Try Create User Indexes"
 )
 
 var (
 	stacktraceFindAllUserRepository     = zap.String("stacktrace", "find-all-user-repository")
+	stacktraceCountUsersRepository      = zap.String("stacktrace", "count-users-repository")
 	stacktraceFindUserByIdRepository    = zap.String("stacktrace", "find-user-by-id-repository")
 	stacktraceFindUserByEmailRepository = zap.String("stacktrace", "find-user-by-email-repository")
 	stacktraceCreateUserRepository      = zap.String("stacktrace", "create-user-repository")
 	stacktraceUpdateUserRepository      = zap.String("stacktrace", "update-user-repository")
+	stacktracePatchUserRepository       = zap.String("stacktrace", "patch-user-repository")
+	stacktraceUpdatePasswordRepository  = zap.String("stacktrace", "update-password-repository")
+	stacktraceVerifyEmailRepository     = zap.String("stacktrace", "verify-email-repository")
+	stacktraceSetMFASecretRepository    = zap.String("stacktrace", "set-mfa-secret-repository")
+	stacktraceEnableMFARepository       = zap.String("stacktrace", "enable-mfa-repository")
+	stacktraceUseMFACounterRepository   = zap.String("stacktrace", "use-mfa-counter-repository")
+	stacktraceUseRecoveryCodeRepository = zap.String("stacktrace", "use-recovery-code-repository")
+	stacktraceStartMFAChallengeRepo     = zap.String("stacktrace", "start-mfa-challenge-repository")
+	stacktraceFailMFAChallengeRepo      = zap.String("stacktrace", "fail-mfa-challenge-repository")
+	stacktraceEndMFAChallengeRepo       = zap.String("stacktrace", "end-mfa-challenge-repository")
 	stacktraceDeleteUserRepository      = zap.String("stacktrace", "delete-user-repository")
+	stacktraceUpdateStatusRepository    = zap.String("stacktrace", "update-status-repository")
+	stacktraceFindDeletedUserRepository = zap.String("stacktrace", "find-deleted-user-repository")
+	stacktraceRestoreUserRepository     = zap.String("stacktrace", "restore-user-repository")
+	stacktracePurgeUsersRepository      = zap.String("stacktrace", "purge-users-repository")
 )
 
+// notDeleted hides the soft deleted users, which keep their data until purged.
+var notDeleted = bson.E{Key: "deleted_at", Value: nil}
+
 type UserRepository interface {
-	FindUserById(parentCtx context.Context, userID string) (*domain.User, *resterrors.RestErr)
+	FindUserById(parentCtx context.Context, userID string, fields ...string) (*domain.User, *resterrors.RestErr)
 	FindUserByEmail(parentCtx context.Context, email string) (*domain.User, *resterrors.RestErr)
 	CreateUser(context.Context, *domain.User) (*domain.User, *resterrors.RestErr)
 	UpdateUser(ctx context.Context, userID string, user *domain.User) (*domain.User, *resterrors.RestErr)
+	PatchUser(ctx context.Context, userID string, patch *domain.UserPatch) (*domain.User, *resterrors.RestErr)
+	UpdatePassword(ctx context.Context, userID, passwordHash string, historySize int) *resterrors.RestErr
+	VerifyEmail(ctx context.Context, userID, email string, verifiedAt time.Time) *resterrors.RestErr
+	SetMFASecret(ctx context.Context, userID, secret string) *resterrors.RestErr
+	EnableMFA(ctx context.Context, userID string, counter int64, recoveryCodeHashes []string) *resterrors.RestErr
+	UseMFACounter(ctx context.Context, userID string, counter int64) *resterrors.RestErr
+	UseRecoveryCode(ctx context.Context, userID, recoveryCodeHash string) *resterrors.RestErr
+	StartMFAChallenge(ctx context.Context, userID, challengeHash string) *resterrors.RestErr
+	FailMFAChallenge(ctx context.Context, userID, challengeHash string, maxFailures int) *resterrors.RestErr
+	EndMFAChallenge(ctx context.Context, userID, challengeHash string) *resterrors.RestErr
+	UpdateStatus(ctx context.Context, userID, fromStatus, toStatus, reason string, changedAt time.Time) *resterrors.RestErr
 	DeleteUser(ctx context.Context, userID string) *resterrors.RestErr
-	FindAll(parentCtx context.Context, itemsPerPage, currentPage int) ([]*domain.User, *resterrors.RestErr)
+	FindDeletedUserById(ctx context.Context, userID string) (*domain.User, *resterrors.RestErr)
+	RestoreUser(ctx context.Context, userID string) (*domain.User, *resterrors.RestErr)
+	PurgeDeletedUsers(ctx context.Context, deletedBefore time.Time) (int64, *resterrors.RestErr)
+	FindAll(parentCtx context.Context, query *domain.UserQuery) (*domain.UserPage, *resterrors.RestErr)
+	CountUsers(ctx context.Context, query *domain.UserQuery) (int64, *resterrors.RestErr)
 }
 
 type userRepo struct {
@@ -53,16 +102,48 @@ func NewUserRepository(client *mongo.Client, databaseName, collectionName string
 	}
 }
 
-func (us *userRepo) FindAll(parentCtx context.Context, itemsPerPage, currentPage int) ([]*domain.User, *resterrors.RestErr) {
+// EnsureIndexes creates the index the purge of the soft deleted users looks them up by,
+// it is sparse since most of the users are not deleted.
+func (us *userRepo) EnsureIndexes(ctx context.Context) error {
+	_, err := us.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "deleted_at", Value: 1}},
+		Options: options.Index().SetSparse(true),
+	})
+	if err != nil {
+		logger.Error(errCreateUserIndex, err)
+		return err
+	}
+	return nil
+}
+
+// FindAll lists the users matching the query, sorted as it asks. The page starts at
+// the cursor of the query when it has one, skipping the previous pages otherwise. One
+// more user than the page is read to tell if there are more.
+func (us *userRepo) FindAll(parentCtx context.Context, query *domain.UserQuery) (*domain.UserPage, *resterrors.RestErr) {
 	logger.Info("Starting Find All Users", stacktraceFindAllUserRepository)
 
 	ctx, cancel := context.WithCancel(parentCtx)
 	defer cancel()
 
-	limit := int64(itemsPerPage)
-	skip := int64(currentPage*itemsPerPage - itemsPerPage)
+	filter := userQueryFilter(query)
+	sort := userQuerySort(query.Sort)
+	findOptions := options.Find().SetLimit(int64(query.PerPage + 1))
+	if projection := userProjection(query.Fields, query.Sort); projection != nil {
+		findOptions.SetProjection(projection)
+	}
 
-	curr, err := us.collection.Find(ctx, bson.D{{}}, &options.FindOptions{Limit: &limit, Skip: &skip})
+	backward := query.From != nil && query.From.Backward
+	if query.From != nil {
+		filter = append(filter, userKeysetFilter(sort, query.Sort, query.From))
+	} else {
+		findOptions.SetSkip(int64(query.Page*query.PerPage - query.PerPage))
+	}
+	if backward {
+		sort = reverseSort(sort)
+	}
+	findOptions.SetSort(sort)
+
+	curr, err := us.collection.Find(ctx, filter, findOptions)
 	if err != nil {
 		logger.Error(errFindAllUsers, err, stacktraceFindAllUserRepository)
 		return nil, resterrors.NewInternalServerError(errFindAllUsers)
@@ -78,13 +159,41 @@ func (us *userRepo) FindAll(parentCtx context.Context, itemsPerPage, currentPage
 		usersList = append(usersList, converter.UserEntityToUserDomain(*user))
 	}
 
+	page := &domain.UserPage{Users: usersList}
+	if len(usersList) > query.PerPage {
+		page.Users, page.HasMore = usersList[:query.PerPage], true
+	}
+	if backward {
+		for i, j := 0, len(page.Users)-1; i < j; i, j = i+1, j-1 {
+			page.Users[i], page.Users[j] = page.Users[j], page.Users[i]
+		}
+	}
+
 	logger.Info(
 		"List Users Successfully",
-		zap.Int("items_per_page", itemsPerPage),
-		zap.Int("current_page", currentPage),
-		stacktraceFindUserByIdRepository,
+		zap.Int("items_per_page", query.PerPage),
+		zap.Int("current_page", query.Page),
+		zap.Bool("from_cursor", query.From != nil),
+		stacktraceFindAllUserRepository,
 	)
-	return usersList, nil
+	return page, nil
+}
+
+// CountUsers counts the users matching the filters of the query, in every page.
+func (us *userRepo) CountUsers(parentCtx context.Context, query *domain.UserQuery) (int64, *resterrors.RestErr) {
+	logger.Info("Starting Count Users", stacktraceCountUsersRepository)
+
+	ctx, cancel := context.WithCancel(parentCtx)
+	defer cancel()
+
+	total, err := us.collection.CountDocuments(ctx, userQueryFilter(query))
+	if err != nil {
+		logger.Error(errCountUsers, err, stacktraceCountUsersRepository)
+		return 0, resterrors.NewInternalServerError(errCountUsers)
+	}
+
+	logger.Info("Users Counted Successfully", zap.Int64("total", total), stacktraceCountUsersRepository)
+	return total, nil
 }
 
 func (us *userRepo) CreateUser(parentCtx context.Context, userDomain *domain.User) (*domain.User, *resterrors.RestErr) {
@@ -107,7 +216,8 @@ func (us *userRepo) CreateUser(parentCtx context.Context, userDomain *domain.Use
 	return converter.UserEntityToUserDomain(*userEntity), nil
 }
 
-func (us *userRepo) FindUserById(parentCtx context.Context, userID string) (*domain.User, *resterrors.RestErr) {
+// FindUserById reads the user, only the fields when sent, named as in the response.
+func (us *userRepo) FindUserById(parentCtx context.Context, userID string, fields ...string) (*domain.User, *resterrors.RestErr) {
 	logger.Info("Starting Find User by Id", stacktraceFindUserByIdRepository)
 
 	ctx, cancel := context.WithCancel(parentCtx)
@@ -117,7 +227,12 @@ func (us *userRepo) FindUserById(parentCtx context.Context, userID string) (*dom
 
 	userEntity := &entities.UserEntity{}
 
-	err := us.collection.FindOne(ctx, bson.D{{Key: "_id", Value: userObjectId}}).Decode(userEntity)
+	findOptions := options.FindOne()
+	if projection := userProjection(fields, nil); projection != nil {
+		findOptions.SetProjection(projection)
+	}
+
+	err := us.collection.FindOne(ctx, bson.D{{Key: "_id", Value: userObjectId}, notDeleted}, findOptions).Decode(userEntity)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			errorMsg := fmt.Sprintf("No users found with this id: %s", userID)
@@ -141,7 +256,7 @@ func (us *userRepo) FindUserByEmail(parentCtx context.Context, email string) (*d
 
 	userEntity := &entities.UserEntity{}
 
-	err := us.collection.FindOne(ctx, bson.D{{Key: "email", Value: email}}).Decode(userEntity)
+	err := us.collection.FindOne(ctx, bson.D{{Key: "email", Value: email}, notDeleted}).Decode(userEntity)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			errorMsg := fmt.Sprintf("No users found with this email: %s", email)
@@ -166,11 +281,18 @@ func (us *userRepo) UpdateUser(parentCtx context.Context, userID string, userDom
 	userObjectId, _ := primitive.ObjectIDFromHex(userID)
 	userEntity := &entities.UserEntity{}
 
-	filter := bson.D{{Key: "_id", Value: userObjectId}}
+	filter := bson.D{{Key: "_id", Value: userObjectId}, notDeleted}
 	updateData := bson.D{{Key: "$set", Value: converter.UserDomainToUserEntity(userDomain)}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
 
-	err := us.collection.FindOneAndUpdate(ctx, filter, updateData).Decode(userEntity)
+	err := us.collection.FindOneAndUpdate(ctx, filter, updateData, opts).Decode(userEntity)
 	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			errorMsg := fmt.Sprintf("No users found with this id: %s", userID)
+			logger.Error(errorMsg, err, stacktraceUpdateUserRepository)
+			return nil, resterrors.NewNotFoundError(errorMsg)
+		}
+
 		logger.Error(errUpdateUser, err, stacktraceUpdateUserRepository)
 		return nil, resterrors.NewInternalServerError(errUpdateUser)
 	}
@@ -180,6 +302,400 @@ func (us *userRepo) UpdateUser(parentCtx context.Context, userID string, userDom
 	return converter.UserEntityToUserDomain(*userEntity), nil
 }
 
+// PatchUser sets only the fields present in the patch and returns the updated user.
+func (us *userRepo) PatchUser(parentCtx context.Context, userID string, patch *domain.UserPatch) (*domain.User, *resterrors.RestErr) {
+	logger.Info("Starting Patch User", stacktracePatchUserRepository)
+
+	ctx, cancel := context.WithCancel(parentCtx)
+	defer cancel()
+
+	userObjectId, _ := primitive.ObjectIDFromHex(userID)
+	userEntity := &entities.UserEntity{}
+
+	fields := bson.D{}
+	if patch.Name != nil {
+		fields = append(fields, bson.E{Key: "name", Value: *patch.Name})
+	}
+	if patch.Email != nil {
+		fields = append(fields, bson.E{Key: "email", Value: *patch.Email})
+	}
+
+	filter := bson.D{{Key: "_id", Value: userObjectId}, notDeleted}
+	updateData := bson.D{{Key: "$set", Value: fields}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	err := us.collection.FindOneAndUpdate(ctx, filter, updateData, opts).Decode(userEntity)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			errorMsg := fmt.Sprintf("No users found with this id: %s", userID)
+			logger.Error(errorMsg, err, stacktracePatchUserRepository)
+			return nil, resterrors.NewNotFoundError(errorMsg)
+		}
+
+		logger.Error(errPatchUser, err, stacktracePatchUserRepository)
+		return nil, resterrors.NewInternalServerError(errPatchUser)
+	}
+
+	logger.Info("User Patched Successfully", zap.String("user_id", userEntity.ID.Hex()), stacktracePatchUserRepository)
+
+	return converter.UserEntityToUserDomain(*userEntity), nil
+}
+
+// UpdatePassword replaces the password hash, moving the current one to the front of
+// the password history, which keeps only the newest historySize hashes. A zero
+// historySize, like when rehashing the same password, leaves the history as it is.
+func (us *userRepo) UpdatePassword(parentCtx context.Context, userID, passwordHash string, historySize int) *resterrors.RestErr {
+	logger.Info("Starting Update Password", stacktraceUpdatePasswordRepository)
+
+	ctx, cancel := context.WithCancel(parentCtx)
+	defer cancel()
+
+	userObjectId, _ := primitive.ObjectIDFromHex(userID)
+
+	filter := bson.D{{Key: "_id", Value: userObjectId}}
+
+	var updateData any = bson.D{{Key: "$set", Value: bson.D{{Key: "password", Value: passwordHash}}}}
+	if historySize > 0 {
+		// Every field of a $set stage sees the document before the stage, so the
+		// history gets the password being replaced.
+		updateData = mongo.Pipeline{
+			{{Key: "$set", Value: bson.D{
+				{Key: "password_history", Value: bson.D{{Key: "$slice", Value: bson.A{
+					bson.D{{Key: "$concatArrays", Value: bson.A{
+						bson.A{"$password"},
+						bson.D{{Key: "$ifNull", Value: bson.A{"$password_history", bson.A{}}}},
+					}}},
+					historySize,
+				}}}},
+				{Key: "password", Value: passwordHash},
+			}}},
+		}
+	}
+
+	result, err := us.collection.UpdateOne(ctx, filter, updateData)
+	if err != nil {
+		logger.Error(errUpdatePassword, err, stacktraceUpdatePasswordRepository)
+		return resterrors.NewInternalServerError(errUpdatePassword)
+	}
+
+	if result.MatchedCount == 0 {
+		errorMsg := fmt.Sprintf("No users found with this id: %s", userID)
+		logger.Error(errorMsg, nil, stacktraceUpdatePasswordRepository)
+		return resterrors.NewNotFoundError(errorMsg)
+	}
+
+	logger.Info("Password Updated Successfully", zap.String("user_id", userID), stacktraceUpdatePasswordRepository)
+	return nil
+}
+
+// VerifyEmail marks the email as verified. The filter only matches while the user
+// still has that email, so a link sent before an email change can't verify the new one.
+func (us *userRepo) VerifyEmail(parentCtx context.Context, userID, email string, verifiedAt time.Time) *resterrors.RestErr {
+	logger.Info("Starting Verify Email", stacktraceVerifyEmailRepository)
+
+	ctx, cancel := context.WithCancel(parentCtx)
+	defer cancel()
+
+	userObjectId, _ := primitive.ObjectIDFromHex(userID)
+
+	filter := bson.D{
+		{Key: "_id", Value: userObjectId},
+		{Key: "email", Value: email},
+	}
+	updateData := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "verified_email", Value: email},
+		{Key: "verified_at", Value: verifiedAt},
+	}}}
+
+	result, err := us.collection.UpdateOne(ctx, filter, updateData)
+	if err != nil {
+		logger.Error(errVerifyEmail, err, stacktraceVerifyEmailRepository)
+		return resterrors.NewInternalServerError(errVerifyEmail)
+	}
+
+	if result.MatchedCount == 0 {
+		errorMsg := fmt.Sprintf("No users found with this id and email: %s", userID)
+		logger.Error(errorMsg, nil, stacktraceVerifyEmailRepository)
+		return resterrors.NewNotFoundError(errorMsg)
+	}
+
+	logger.Info("Email Verified Successfully", zap.String("user_id", userID), stacktraceVerifyEmailRepository)
+	return nil
+}
+
+// SetMFASecret stores the secret of an enrollment, it only matches while MFA is not
+// enabled so an enrollment can't replace the secret of a confirmed one.
+func (us *userRepo) SetMFASecret(parentCtx context.Context, userID, secret string) *resterrors.RestErr {
+	logger.Info("Starting Set MFA Secret", stacktraceSetMFASecretRepository)
+
+	ctx, cancel := context.WithCancel(parentCtx)
+	defer cancel()
+
+	userObjectId, _ := primitive.ObjectIDFromHex(userID)
+
+	filter := bson.D{
+		{Key: "_id", Value: userObjectId},
+		{Key: "mfa_enabled", Value: bson.D{{Key: "$ne", Value: true}}},
+	}
+	updateData := bson.D{{Key: "$set", Value: bson.D{{Key: "mfa_secret", Value: secret}}}}
+
+	result, err := us.collection.UpdateOne(ctx, filter, updateData)
+	if err != nil {
+		logger.Error(errSetMFASecret, err, stacktraceSetMFASecretRepository)
+		return resterrors.NewInternalServerError(errSetMFASecret)
+	}
+
+	if result.MatchedCount == 0 {
+		errorMsg := fmt.Sprintf("No users without MFA found with this id: %s", userID)
+		logger.Error(errorMsg, nil, stacktraceSetMFASecretRepository)
+		return resterrors.NewNotFoundError(errorMsg)
+	}
+
+	logger.Info("MFA Secret Set Successfully", zap.String("user_id", userID), stacktraceSetMFASecretRepository)
+	return nil
+}
+
+// EnableMFA confirms the enrollment, counter is the step of the code that confirmed it.
+func (us *userRepo) EnableMFA(parentCtx context.Context, userID string, counter int64, recoveryCodeHashes []string) *resterrors.RestErr {
+	logger.Info("Starting Enable MFA", stacktraceEnableMFARepository)
+
+	ctx, cancel := context.WithCancel(parentCtx)
+	defer cancel()
+
+	userObjectId, _ := primitive.ObjectIDFromHex(userID)
+
+	filter := bson.D{
+		{Key: "_id", Value: userObjectId},
+		{Key: "mfa_enabled", Value: bson.D{{Key: "$ne", Value: true}}},
+	}
+	updateData := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "mfa_enabled", Value: true},
+		{Key: "mfa_counter", Value: counter},
+		{Key: "recovery_codes", Value: recoveryCodeHashes},
+	}}}
+
+	result, err := us.collection.UpdateOne(ctx, filter, updateData)
+	if err != nil {
+		logger.Error(errEnableMFA, err, stacktraceEnableMFARepository)
+		return resterrors.NewInternalServerError(errEnableMFA)
+	}
+
+	if result.MatchedCount == 0 {
+		errorMsg := fmt.Sprintf("No users without MFA found with this id: %s", userID)
+		logger.Error(errorMsg, nil, stacktraceEnableMFARepository)
+		return resterrors.NewNotFoundError(errorMsg)
+	}
+
+	logger.Info("MFA Enabled Successfully", zap.String("user_id", userID), stacktraceEnableMFARepository)
+	return nil
+}
+
+// UseMFACounter records the step of a TOTP code. It only matches a step after the
+// last one used, so the same code can't be used twice.
+func (us *userRepo) UseMFACounter(parentCtx context.Context, userID string, counter int64) *resterrors.RestErr {
+	logger.Info("Starting Use MFA Counter", stacktraceUseMFACounterRepository)
+
+	ctx, cancel := context.WithCancel(parentCtx)
+	defer cancel()
+
+	userObjectId, _ := primitive.ObjectIDFromHex(userID)
+
+	filter := bson.D{
+		{Key: "_id", Value: userObjectId},
+		{Key: "mfa_counter", Value: bson.D{{Key: "$lt", Value: counter}}},
+	}
+	updateData := bson.D{{Key: "$set", Value: bson.D{{Key: "mfa_counter", Value: counter}}}}
+
+	result, err := us.collection.UpdateOne(ctx, filter, updateData)
+	if err != nil {
+		logger.Error(errUseMFACounter, err, stacktraceUseMFACounterRepository)
+		return resterrors.NewInternalServerError(errUseMFACounter)
+	}
+
+	if result.MatchedCount == 0 {
+		errorMsg := fmt.Sprintf("MFA code was already used by the user: %s", userID)
+		logger.Error(errorMsg, nil, stacktraceUseMFACounterRepository)
+		return resterrors.NewNotFoundError(errorMsg)
+	}
+
+	logger.Info("MFA Counter Used Successfully", zap.String("user_id", userID), stacktraceUseMFACounterRepository)
+	return nil
+}
+
+// UseRecoveryCode removes the recovery code, the filter only matches while the user
+// still has it so every code is used only once.
+func (us *userRepo) UseRecoveryCode(parentCtx context.Context, userID, recoveryCodeHash string) *resterrors.RestErr {
+	logger.Info("Starting Use Recovery Code", stacktraceUseRecoveryCodeRepository)
+
+	ctx, cancel := context.WithCancel(parentCtx)
+	defer cancel()
+
+	userObjectId, _ := primitive.ObjectIDFromHex(userID)
+
+	filter := bson.D{
+		{Key: "_id", Value: userObjectId},
+		{Key: "recovery_codes", Value: recoveryCodeHash},
+	}
+	updateData := bson.D{{Key: "$pull", Value: bson.D{{Key: "recovery_codes", Value: recoveryCodeHash}}}}
+
+	result, err := us.collection.UpdateOne(ctx, filter, updateData)
+	if err != nil {
+		logger.Error(errUseRecoveryCode, err, stacktraceUseRecoveryCodeRepository)
+		return resterrors.NewInternalServerError(errUseRecoveryCode)
+	}
+
+	if result.MatchedCount == 0 {
+		errorMsg := fmt.Sprintf("No recovery code found for the user: %s", userID)
+		logger.Error(errorMsg, nil, stacktraceUseRecoveryCodeRepository)
+		return resterrors.NewNotFoundError(errorMsg)
+	}
+
+	logger.Info("Recovery Code Used Successfully", zap.String("user_id", userID), stacktraceUseRecoveryCodeRepository)
+	return nil
+}
+
+// StartMFAChallenge stores the challenge a login has to answer with a code, replacing
+// the one of a previous login.
+func (us *userRepo) StartMFAChallenge(parentCtx context.Context, userID, challengeHash string) *resterrors.RestErr {
+	logger.Info("Starting Start MFA Challenge", stacktraceStartMFAChallengeRepo)
+
+	ctx, cancel := context.WithCancel(parentCtx)
+	defer cancel()
+
+	userObjectId, _ := primitive.ObjectIDFromHex(userID)
+
+	filter := bson.D{{Key: "_id", Value: userObjectId}}
+	updateData := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "mfa_challenge", Value: challengeHash},
+		{Key: "mfa_challenge_failures", Value: 0},
+	}}}
+
+	result, err := us.collection.UpdateOne(ctx, filter, updateData)
+	if err != nil {
+		logger.Error(errMFAChallenge, err, stacktraceStartMFAChallengeRepo)
+		return resterrors.NewInternalServerError(errMFAChallenge)
+	}
+
+	if result.MatchedCount == 0 {
+		errorMsg := fmt.Sprintf("No users found with this id: %s", userID)
+		logger.Error(errorMsg, nil, stacktraceStartMFAChallengeRepo)
+		return resterrors.NewNotFoundError(errorMsg)
+	}
+
+	logger.Info("MFA Challenge Started Successfully", zap.String("user_id", userID), stacktraceStartMFAChallengeRepo)
+	return nil
+}
+
+// FailMFAChallenge counts a wrong code sent to the challenge. The failure that reaches
+// maxFailures removes the challenge instead, so the login has to start again.
+func (us *userRepo) FailMFAChallenge(parentCtx context.Context, userID, challengeHash string, maxFailures int) *resterrors.RestErr {
+	logger.Info("Starting Fail MFA Challenge", stacktraceFailMFAChallengeRepo)
+
+	ctx, cancel := context.WithCancel(parentCtx)
+	defer cancel()
+
+	userObjectId, _ := primitive.ObjectIDFromHex(userID)
+
+	filter := bson.D{
+		{Key: "_id", Value: userObjectId},
+		{Key: "mfa_challenge", Value: challengeHash},
+		{Key: "mfa_challenge_failures", Value: bson.D{{Key: "$lt", Value: maxFailures - 1}}},
+	}
+	updateData := bson.D{{Key: "$inc", Value: bson.D{{Key: "mfa_challenge_failures", Value: 1}}}}
+
+	result, err := us.collection.UpdateOne(ctx, filter, updateData)
+	if err != nil {
+		logger.Error(errMFAChallenge, err, stacktraceFailMFAChallengeRepo)
+		return resterrors.NewInternalServerError(errMFAChallenge)
+	}
+
+	if result.MatchedCount > 0 {
+		logger.Info("MFA Challenge Failure Counted Successfully", zap.String("user_id", userID), stacktraceFailMFAChallengeRepo)
+		return nil
+	}
+
+	if err := us.EndMFAChallenge(ctx, userID, challengeHash); err != nil && err.HttpStatusCode != http.StatusNotFound {
+		return err
+	}
+
+	logger.Info("MFA Challenge Ended After Too Many Failures", zap.String("user_id", userID), stacktraceFailMFAChallengeRepo)
+	return nil
+}
+
+// EndMFAChallenge removes the challenge, it only matches while the user still has it
+// so a challenge is answered only once.
+func (us *userRepo) EndMFAChallenge(parentCtx context.Context, userID, challengeHash string) *resterrors.RestErr {
+	logger.Info("Starting End MFA Challenge", stacktraceEndMFAChallengeRepo)
+
+	ctx, cancel := context.WithCancel(parentCtx)
+	defer cancel()
+
+	userObjectId, _ := primitive.ObjectIDFromHex(userID)
+
+	filter := bson.D{
+		{Key: "_id", Value: userObjectId},
+		{Key: "mfa_challenge", Value: challengeHash},
+	}
+	updateData := bson.D{{Key: "$unset", Value: bson.D{
+		{Key: "mfa_challenge", Value: ""},
+		{Key: "mfa_challenge_failures", Value: ""},
+	}}}
+
+	result, err := us.collection.UpdateOne(ctx, filter, updateData)
+	if err != nil {
+		logger.Error(errMFAChallenge, err, stacktraceEndMFAChallengeRepo)
+		return resterrors.NewInternalServerError(errMFAChallenge)
+	}
+
+	if result.MatchedCount == 0 {
+		errorMsg := fmt.Sprintf("No MFA challenge found for the user: %s", userID)
+		logger.Error(errorMsg, nil, stacktraceEndMFAChallengeRepo)
+		return resterrors.NewNotFoundError(errorMsg)
+	}
+
+	logger.Info("MFA Challenge Ended Successfully", zap.String("user_id", userID), stacktraceEndMFAChallengeRepo)
+	return nil
+}
+
+// UpdateStatus changes the status of the user. The filter only matches while the user
+// still has fromStatus, so two concurrent changes can't both be applied.
+func (us *userRepo) UpdateStatus(parentCtx context.Context, userID, fromStatus, toStatus, reason string, changedAt time.Time) *resterrors.RestErr {
+	logger.Info("Starting Update Status", stacktraceUpdateStatusRepository)
+
+	ctx, cancel := context.WithCancel(parentCtx)
+	defer cancel()
+
+	userObjectId, _ := primitive.ObjectIDFromHex(userID)
+
+	filter := bson.D{
+		{Key: "_id", Value: userObjectId},
+		statusFilter(fromStatus),
+		notDeleted,
+	}
+	updateData := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "status", Value: toStatus},
+		{Key: "status_reason", Value: reason},
+		{Key: "status_changed_at", Value: changedAt},
+	}}}
+
+	result, err := us.collection.UpdateOne(ctx, filter, updateData)
+	if err != nil {
+		logger.Error(errUpdateStatus, err, stacktraceUpdateStatusRepository)
+		return resterrors.NewInternalServerError(errUpdateStatus)
+	}
+
+	if result.MatchedCount == 0 {
+		errorMsg := fmt.Sprintf("No users found with this id and status: %s", userID)
+		logger.Error(errorMsg, nil, stacktraceUpdateStatusRepository)
+		return resterrors.NewNotFoundError(errorMsg)
+	}
+
+	logger.Info("User Status Updated Successfully", zap.String("user_id", userID), zap.String("status", toStatus), stacktraceUpdateStatusRepository)
+	return nil
+}
+
+// DeleteUser soft deletes the user, which is hidden from the finds until restored or
+// purged for good by PurgeDeletedUsers.
 func (us *userRepo) DeleteUser(parentCtx context.Context, userID string) *resterrors.RestErr {
 	logger.Info("Starting Delete User", stacktraceDeleteUserRepository)
 
@@ -188,12 +704,303 @@ func (us *userRepo) DeleteUser(parentCtx context.Context, userID string) *rester
 
 	userObjectId, _ := primitive.ObjectIDFromHex(userID)
 
-	_, err := us.collection.DeleteOne(ctx, bson.D{{Key: "_id", Value: userObjectId}})
+	filter := bson.D{{Key: "_id", Value: userObjectId}, notDeleted}
+	updateData := bson.D{{Key: "$set", Value: bson.D{{Key: "deleted_at", Value: time.Now()}}}}
+
+	result, err := us.collection.UpdateOne(ctx, filter, updateData)
 	if err != nil {
 		logger.Error(errDeleteUser, err, stacktraceDeleteUserRepository)
 		return resterrors.NewInternalServerError(errDeleteUser)
 	}
 
+	if result.MatchedCount == 0 {
+		errorMsg := fmt.Sprintf("No users found with this id: %s", userID)
+		logger.Error(errorMsg, nil, stacktraceDeleteUserRepository)
+		return resterrors.NewNotFoundError(errorMsg)
+	}
+
 	logger.Info("User Delete Successfully", zap.String("user_id", userID), stacktraceDeleteUserRepository)
 	return nil
 }
+
+// FindDeletedUserById finds an user only while it is soft deleted.
+func (us *userRepo) FindDeletedUserById(parentCtx context.Context, userID string) (*domain.User, *resterrors.RestErr) {
+	logger.Info("Starting Find Deleted User by Id", stacktraceFindDeletedUserRepository)
+
+	ctx, cancel := context.WithCancel(parentCtx)
+	defer cancel()
+
+	userObjectId, _ := primitive.ObjectIDFromHex(userID)
+
+	userEntity := &entities.UserEntity{}
+
+	filter := bson.D{
+		{Key: "_id", Value: userObjectId},
+		{Key: "deleted_at", Value: bson.D{{Key: "$ne", Value: nil}}},
+	}
+
+	err := us.collection.FindOne(ctx, filter).Decode(userEntity)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			errorMsg := fmt.Sprintf("No deleted users found with this id: %s", userID)
+			logger.Error(errorMsg, err, stacktraceFindDeletedUserRepository)
+			return nil, resterrors.NewNotFoundError(errorMsg)
+		}
+
+		logger.Error(errFindByIdUser, err, stacktraceFindDeletedUserRepository)
+		return nil, resterrors.NewInternalServerError(errFindByIdUser)
+	}
+
+	logger.Info("Deleted User Found Successfully", zap.String("user_id", userID), stacktraceFindDeletedUserRepository)
+	return converter.UserEntityToUserDomain(*userEntity), nil
+}
+
+// RestoreUser brings back a soft deleted user and returns it.
+func (us *userRepo) RestoreUser(parentCtx context.Context, userID string) (*domain.User, *resterrors.RestErr) {
+	logger.Info("Starting Restore User", stacktraceRestoreUserRepository)
+
+	ctx, cancel := context.WithCancel(parentCtx)
+	defer cancel()
+
+	userObjectId, _ := primitive.ObjectIDFromHex(userID)
+	userEntity := &entities.UserEntity{}
+
+	filter := bson.D{
+		{Key: "_id", Value: userObjectId},
+		{Key: "deleted_at", Value: bson.D{{Key: "$ne", Value: nil}}},
+	}
+	updateData := bson.D{{Key: "$unset", Value: bson.D{{Key: "deleted_at", Value: ""}}}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	err := us.collection.FindOneAndUpdate(ctx, filter, updateData, opts).Decode(userEntity)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			errorMsg := fmt.Sprintf("No deleted users found with this id: %s", userID)
+			logger.Error(errorMsg, err, stacktraceRestoreUserRepository)
+			return nil, resterrors.NewNotFoundError(errorMsg)
+		}
+
+		logger.Error(errRestoreUser, err, stacktraceRestoreUserRepository)
+		return nil, resterrors.NewInternalServerError(errRestoreUser)
+	}
+
+	logger.Info("User Restored Successfully", zap.String("user_id", userID), stacktraceRestoreUserRepository)
+	return converter.UserEntityToUserDomain(*userEntity), nil
+}
+
+// PurgeDeletedUsers deletes for good the users soft deleted before deletedBefore and
+// returns how many were purged.
+func (us *userRepo) PurgeDeletedUsers(parentCtx context.Context, deletedBefore time.Time) (int64, *resterrors.RestErr) {
+	logger.Info("Starting Purge Deleted Users", stacktracePurgeUsersRepository)
+
+	ctx, cancel := context.WithCancel(parentCtx)
+	defer cancel()
+
+	filter := bson.D{{Key: "deleted_at", Value: bson.D{{Key: "$lt", Value: deletedBefore}}}}
+
+	result, err := us.collection.DeleteMany(ctx, filter)
+	if err != nil {
+		logger.Error(errPurgeUsers, err, stacktracePurgeUsersRepository)
+		return 0, resterrors.NewInternalServerError(errPurgeUsers)
+	}
+
+	logger.Info("Deleted Users Purged Successfully", zap.Int64("purged", result.DeletedCount), stacktracePurgeUsersRepository)
+	return result.DeletedCount, nil
+}
+
+// userSortKeys are the stored keys of the sort fields, the _id of an user tells when
+// it was created.
+var userSortKeys = map[string]string{
+	domain.UserSortName:      "name",
+	domain.UserSortEmail:     "email",
+	domain.UserSortStatus:    "status",
+	domain.UserSortCreatedAt: "_id",
+}
+
+// userFieldKeys are the stored keys each field of the response is made from, whether
+// the email is verified tells the verified email apart from the current one.
+var userFieldKeys = map[string][]string{
+	"id":             {"_id"},
+	"name":           {"name"},
+	"email":          {"email"},
+	"roles":          {"roles"},
+	"email_verified": {"email", "verified_email"},
+	"verified_at":    {"email", "verified_email", "verified_at"},
+	"mfa_enabled":    {"mfa_enabled"},
+	"status":         {"status"},
+	"status_reason":  {"status_reason"},
+}
+
+// userProjection reads only the keys of the fields, and the ones of the sort the
+// cursors are made from. Every key is read when there are no fields, or one of them
+// is unknown.
+func userProjection(fields []string, sortFields []domain.SortField) bson.D {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	var keys []string
+	for _, field := range fields {
+		fieldKeys, ok := userFieldKeys[field]
+		if !ok {
+			return nil
+		}
+		keys = append(keys, fieldKeys...)
+	}
+	for _, sortField := range sortFields {
+		if key, ok := userSortKeys[sortField.Field]; ok {
+			keys = append(keys, key)
+		}
+	}
+
+	projection := bson.D{}
+	projected := map[string]bool{}
+	for _, key := range keys {
+		if !projected[key] {
+			projection = append(projection, bson.E{Key: key, Value: 1})
+			projected[key] = true
+		}
+	}
+	return projection
+}
+
+// userQueryFilter builds the filter of the query, the created at range is matched on
+// the timestamp of the _id.
+func userQueryFilter(query *domain.UserQuery) bson.D {
+	filter := bson.D{notDeleted}
+
+	if query.Search != "" {
+		prefix := primitive.Regex{Pattern: "^" + regexp.QuoteMeta(query.Search), Options: "i"}
+		filter = append(filter, bson.E{Key: "$or", Value: bson.A{
+			bson.D{{Key: "name", Value: prefix}},
+			bson.D{{Key: "email", Value: prefix}},
+		}})
+	}
+	if query.Email != "" {
+		filter = append(filter, bson.E{Key: "email", Value: query.Email})
+	}
+	if query.Status != "" {
+		filter = append(filter, statusFilter(query.Status))
+	}
+	if query.Role != "" {
+		filter = append(filter, roleFilter(query.Role))
+	}
+
+	created := bson.D{}
+	if query.CreatedFrom != nil {
+		created = append(created, bson.E{Key: "$gte", Value: primitive.NewObjectIDFromTimestamp(*query.CreatedFrom)})
+	}
+	if query.CreatedTo != nil {
+		created = append(created, bson.E{Key: "$lt", Value: primitive.NewObjectIDFromTimestamp(*query.CreatedTo)})
+	}
+	if len(created) > 0 {
+		filter = append(filter, bson.E{Key: "_id", Value: created})
+	}
+
+	return filter
+}
+
+// userQuerySort builds the sort of the query. The _id breaks the ties, so the pages
+// don't repeat or skip the users with the same values.
+func userQuerySort(sortFields []domain.SortField) bson.D {
+	sort := bson.D{}
+	sortedById := false
+
+	for _, sortField := range sortFields {
+		key, ok := userSortKeys[sortField.Field]
+		if !ok {
+			continue
+		}
+
+		direction := 1
+		if sortField.Descending {
+			direction = -1
+		}
+		sort = append(sort, bson.E{Key: key, Value: direction})
+		sortedById = sortedById || key == "_id"
+	}
+
+	if !sortedById {
+		sort = append(sort, bson.E{Key: "_id", Value: 1})
+	}
+	return sort
+}
+
+// userKeysetFilter matches the users past the cursor in the sort, the ones before it
+// when it goes backward. It is the users with a sort key beyond the one of the cursor
+// and the previous keys equal to its ones.
+func userKeysetFilter(sort bson.D, sortFields []domain.SortField, cursor *domain.UserCursor) bson.E {
+	values := map[string]any{}
+	for i, sortField := range sortFields {
+		if key, ok := userSortKeys[sortField.Field]; ok && i < len(cursor.SortValues) {
+			values[key] = cursor.SortValues[i]
+		}
+	}
+	values["_id"], _ = primitive.ObjectIDFromHex(cursor.ID)
+
+	// the users without a status have none stored, which is sorted before any status
+	if values["status"] == "" {
+		values["status"] = nil
+	}
+
+	branches := bson.A{}
+	previousEqual := bson.D{}
+	for _, sortKey := range sort {
+		value := values[sortKey.Key]
+		ascending := sortKey.Value == 1
+
+		if beyond, ok := keyBeyond(sortKey.Key, value, ascending != cursor.Backward); ok {
+			branch := append(bson.D{}, previousEqual...)
+			branches = append(branches, append(branch, beyond))
+		}
+		previousEqual = append(previousEqual, bson.E{Key: sortKey.Key, Value: value})
+	}
+
+	// the search already takes the $or of the filter
+	return bson.E{Key: "$and", Value: bson.A{bson.D{{Key: "$or", Value: branches}}}}
+}
+
+// keyBeyond matches the values greater than the value, or lower than it. A missing
+// key is sorted before any value, so nothing is lower than it.
+func keyBeyond(key string, value any, greater bool) (bson.E, bool) {
+	switch {
+	case value == nil && greater:
+		return bson.E{Key: key, Value: bson.D{{Key: "$ne", Value: nil}}}, true
+	case value == nil:
+		return bson.E{}, false
+	case greater:
+		return bson.E{Key: key, Value: bson.D{{Key: "$gt", Value: value}}}, true
+	case key == "status":
+		return bson.E{Key: "$or", Value: bson.A{
+			bson.D{{Key: key, Value: bson.D{{Key: "$lt", Value: value}}}},
+			bson.D{{Key: key, Value: nil}},
+		}}, true
+	}
+	return bson.E{Key: key, Value: bson.D{{Key: "$lt", Value: value}}}, true
+}
+
+func reverseSort(sort bson.D) bson.D {
+	reversed := make(bson.D, 0, len(sort))
+	for _, sortKey := range sort {
+		reversed = append(reversed, bson.E{Key: sortKey.Key, Value: -sortKey.Value.(int)})
+	}
+	return reversed
+}
+
+// roleFilter matches the users with the role, the users stored before roles existed
+// have none and are regular users.
+func roleFilter(role string) bson.E {
+	if role == domain.RoleUser {
+		return bson.E{Key: "roles", Value: bson.D{{Key: "$in", Value: bson.A{domain.RoleUser, nil}}}}
+	}
+	return bson.E{Key: "roles", Value: role}
+}
+
+// statusFilter matches the users with the status, the users stored before statuses
+// existed have none and are active.
+func statusFilter(status string) bson.E {
+	if status == domain.UserStatusActive {
+		return bson.E{Key: "status", Value: bson.D{{Key: "$in", Value: bson.A{domain.UserStatusActive, nil}}}}
+	}
+	return bson.E{Key: "status", Value: status}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
 	"github.com/WalterPaes/go-rest-api-crud/internal/repositories/entities"
@@ -69,6 +70,39 @@ func Test_userRepo_FindUserById(t *testing.T) {
 		assert.Nil(t, err)
 		assert.Equal(t, result.Name, user.Name)
 		assert.Equal(t, result.Email, user.Email)
+
+		// the soft deleted users are hidden
+		filter := mtestDB.GetStartedEvent().Command.Lookup("filter").Document()
+		assert.Equal(t, bson.TypeNull, filter.Lookup("deleted_at").Type)
+	})
+
+	mtestDB.Run("Should Read Only the Keys of the Fields Selected", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(
+			mtest.CreateCursorResponse(
+				1,
+				fmt.Sprintf("%s.%s", dbName, collectionName),
+				mtest.FirstBatch,
+				bson.D{
+					{Key: "_id", Value: userEntity.ID},
+					{Key: "name", Value: userEntity.Name},
+				},
+			),
+		)
+
+		userRepository := NewUserRepository(mtestDB.Client, dbName, collectionName)
+
+		result, err := userRepository.FindUserById(ctx, userEntity.ID.Hex(), "id", "name", "email_verified")
+
+		assert.Nil(t, err)
+		assert.Equal(t, user.Name, result.Name)
+
+		projection := mtestDB.GetStartedEvent().Command.Lookup("projection").Document()
+		elements, _ := projection.Elements()
+		var keys []string
+		for _, element := range elements {
+			keys = append(keys, element.Key())
+		}
+		assert.Equal(t, []string{"_id", "name", "email", "verified_email"}, keys)
 	})
 
 	mtestDB.Run("Should return an error when try Find an user by id", func(mtestDB *mtest.T) {
@@ -234,80 +268,969 @@ func Test_userRepo_UpdateUser(t *testing.T) {
 	})
 }
 
-func Test_userRepo_DeleteUser(t *testing.T) {
+func Test_userRepo_PatchUser(t *testing.T) {
+	mtestDB := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	name := "Patched"
+	patch := &domain.UserPatch{Name: &name}
+
+	mtestDB.Run("Should Patch an User Successfully", func(mtestDB *mtest.T) {
+		patchedEntity := *userEntity
+		patchedEntity.Name = name
+
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 1},
+			{Key: "value", Value: patchedEntity},
+		})
+
+		userRepository := NewUserRepository(mtestDB.Client, dbName, collectionName)
+
+		result, err := userRepository.PatchUser(ctx, userEntity.ID.Hex(), patch)
+
+		assert.Nil(t, err)
+		assert.Equal(t, name, result.Name)
+		assert.Equal(t, user.Email, result.Email)
+	})
+
+	mtestDB.Run("Should return not found when the user doesn't exist", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 1},
+			{Key: "value", Value: nil},
+		})
+
+		userRepository := NewUserRepository(mtestDB.Client, dbName, collectionName)
+
+		result, err := userRepository.PatchUser(ctx, userEntity.ID.Hex(), patch)
+
+		assert.Nil(t, result)
+		assert.NotNil(t, err)
+		assert.Equal(t, err.HttpStatusCode, http.StatusNotFound)
+	})
+
+	mtestDB.Run("Should return an error when try patch an user", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 0},
+		})
+
+		userRepository := NewUserRepository(mtestDB.Client, dbName, collectionName)
+
+		result, err := userRepository.PatchUser(ctx, userEntity.ID.Hex(), patch)
+
+		assert.Nil(t, result)
+		assert.NotNil(t, err)
+		assert.Equal(t, err.HttpStatusCode, http.StatusInternalServerError)
+		assert.Equal(t, err.Message, errPatchUser)
+	})
+}
+
+func Test_userRepo_UpdatePassword(t *testing.T) {
 	mtestDB := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
 
-	mtestDB.Run("Should Delete an User Successfully", func(mtestDB *mtest.T) {
+	mtestDB.Run("Should Update the Password Successfully", func(mtestDB *mtest.T) {
 		mtestDB.AddMockResponses(bson.D{
 			{Key: "ok", Value: 1},
 			{Key: "n", Value: 1},
-			{Key: "acknowledged", Value: true},
+			{Key: "nModified", Value: 1},
 		})
 
 		userRepository := NewUserRepository(mtestDB.Client, dbName, collectionName)
 
-		err := userRepository.DeleteUser(ctx, userEntity.ID.Hex())
+		err := userRepository.UpdatePassword(ctx, userEntity.ID.Hex(), "hash", 5)
 
 		assert.Nil(t, err)
 	})
 
-	mtestDB.Run("Should return an error when try delete an user", func(mtestDB *mtest.T) {
+	mtestDB.Run("Should move the current password to the bounded history", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 1},
+			{Key: "n", Value: 1},
+			{Key: "nModified", Value: 1},
+		})
+
+		userRepository := NewUserRepository(mtestDB.Client, dbName, collectionName)
+
+		err := userRepository.UpdatePassword(ctx, userEntity.ID.Hex(), "hash", 3)
+		assert.Nil(t, err)
+
+		update := mtestDB.GetStartedEvent().Command.Lookup("updates").Array().Index(0).Value().Document().Lookup("u")
+		assert.Equal(t, bson.TypeArray, update.Type)
+
+		set := update.Array().Index(0).Value().Document().Lookup("$set").Document()
+		assert.Equal(t, "hash", set.Lookup("password").StringValue())
+
+		slice := set.Lookup("password_history", "$slice").Array()
+		assert.EqualValues(t, 3, slice.Index(1).Value().Int32())
+	})
+
+	mtestDB.Run("Should keep the history when the history size is zero", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 1},
+			{Key: "n", Value: 1},
+			{Key: "nModified", Value: 1},
+		})
+
+		userRepository := NewUserRepository(mtestDB.Client, dbName, collectionName)
+
+		err := userRepository.UpdatePassword(ctx, userEntity.ID.Hex(), "hash", 0)
+		assert.Nil(t, err)
+
+		update := mtestDB.GetStartedEvent().Command.Lookup("updates").Array().Index(0).Value().Document().Lookup("u").Document()
+		assert.Equal(t, "hash", update.Lookup("$set", "password").StringValue())
+		_, historyErr := update.LookupErr("$set", "password_history")
+		assert.NotNil(t, historyErr)
+	})
+
+	mtestDB.Run("Should return not found when the user doesn't exist", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 1},
+			{Key: "n", Value: 0},
+			{Key: "nModified", Value: 0},
+		})
+
+		userRepository := NewUserRepository(mtestDB.Client, dbName, collectionName)
+
+		err := userRepository.UpdatePassword(ctx, userEntity.ID.Hex(), "hash", 5)
+
+		assert.NotNil(t, err)
+		assert.Equal(t, err.HttpStatusCode, http.StatusNotFound)
+	})
+
+	mtestDB.Run("Should return an error when try update the password", func(mtestDB *mtest.T) {
 		mtestDB.AddMockResponses(bson.D{
 			{Key: "ok", Value: 0},
 		})
 
 		userRepository := NewUserRepository(mtestDB.Client, dbName, collectionName)
 
-		err := userRepository.DeleteUser(ctx, userEntity.ID.Hex())
+		err := userRepository.UpdatePassword(ctx, userEntity.ID.Hex(), "hash", 5)
 
 		assert.NotNil(t, err)
 		assert.Equal(t, err.HttpStatusCode, http.StatusInternalServerError)
-		assert.Equal(t, err.Message, errDeleteUser)
+		assert.Equal(t, err.Message, errUpdatePassword)
 	})
 }
 
-func Test_userRepo_FindAll(t *testing.T) {
+func Test_userRepo_UpdateStatus(t *testing.T) {
 	mtestDB := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
 
-	mtestDB.Run("Should Find all Users Successfully", func(mtestDB *mtest.T) {
-		first := mtest.CreateCursorResponse(
-			1,
-			fmt.Sprintf("%s.%s", dbName, collectionName),
-			mtest.FirstBatch,
-			bson.D{
-				{Key: "_id", Value: userEntity.ID},
-				{Key: "email", Value: userEntity.Email},
-				{Key: "password", Value: userEntity.Password},
-				{Key: "name", Value: userEntity.Name},
-			},
-		)
+	mtestDB.Run("Should Update the Status Successfully", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 1},
+			{Key: "n", Value: 1},
+			{Key: "nModified", Value: 1},
+		})
 
-		getMore := mtest.CreateCursorResponse(
-			1,
-			fmt.Sprintf("%s.%s", dbName, collectionName),
-			mtest.NextBatch,
-			bson.D{
-				{Key: "_id", Value: userEntity.ID},
-				{Key: "email", Value: userEntity.Email},
-				{Key: "password", Value: userEntity.Password},
-				{Key: "name", Value: userEntity.Name},
-			},
-		)
+		userRepository := NewUserRepository(mtestDB.Client, dbName, collectionName)
 
-		killCursors := mtest.CreateCursorResponse(
-			0,
-			fmt.Sprintf("%s.%s", dbName, collectionName),
-			mtest.NextBatch,
-		)
+		err := userRepository.UpdateStatus(ctx, userEntity.ID.Hex(), domain.UserStatusActive, domain.UserStatusSuspended, "abuse report", time.Now())
+		assert.Nil(t, err)
 
-		mtestDB.AddMockResponses(first, getMore, killCursors)
+		update := mtestDB.GetStartedEvent().Command.Lookup("updates").Array().Index(0).Value().Document()
+		assert.Equal(t, domain.UserStatusSuspended, update.Lookup("u", "$set", "status").StringValue())
+		assert.Equal(t, "abuse report", update.Lookup("u", "$set", "status_reason").StringValue())
+
+		// the users stored before the statuses existed have none and are active
+		statuses := update.Lookup("q", "status", "$in").Array()
+		assert.Equal(t, domain.UserStatusActive, statuses.Index(0).Value().StringValue())
+		assert.Equal(t, bson.TypeNull, statuses.Index(1).Value().Type)
+	})
+
+	mtestDB.Run("Should return not found when the user doesn't have the status anymore", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 1},
+			{Key: "n", Value: 0},
+			{Key: "nModified", Value: 0},
+		})
+
+		userRepository := NewUserRepository(mtestDB.Client, dbName, collectionName)
+
+		err := userRepository.UpdateStatus(ctx, userEntity.ID.Hex(), domain.UserStatusSuspended, domain.UserStatusActive, "appeal accepted", time.Now())
+
+		assert.NotNil(t, err)
+		assert.Equal(t, err.HttpStatusCode, http.StatusNotFound)
+	})
+
+	mtestDB.Run("Should return an error when try update the status", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 0},
+		})
+
+		userRepository := NewUserRepository(mtestDB.Client, dbName, collectionName)
+
+		err := userRepository.UpdateStatus(ctx, userEntity.ID.Hex(), domain.UserStatusActive, domain.UserStatusSuspended, "abuse report", time.Now())
+
+		assert.NotNil(t, err)
+		assert.Equal(t, err.HttpStatusCode, http.StatusInternalServerError)
+		assert.Equal(t, err.Message, errUpdateStatus)
+	})
+}
+
+func Test_userRepo_VerifyEmail(t *testing.T) {
+	mtestDB := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mtestDB.Run("Should Verify the Email Successfully", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 1},
+			{Key: "n", Value: 1},
+			{Key: "nModified", Value: 1},
+		})
+
+		userRepository := NewUserRepository(mtestDB.Client, dbName, collectionName)
+
+		err := userRepository.VerifyEmail(ctx, userEntity.ID.Hex(), userEntity.Email, time.Now())
+
+		assert.Nil(t, err)
+	})
+
+	mtestDB.Run("Should return not found when the user doesn't have the email anymore", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 1},
+			{Key: "n", Value: 0},
+			{Key: "nModified", Value: 0},
+		})
+
+		userRepository := NewUserRepository(mtestDB.Client, dbName, collectionName)
+
+		err := userRepository.VerifyEmail(ctx, userEntity.ID.Hex(), "changed@email.com", time.Now())
+
+		assert.NotNil(t, err)
+		assert.Equal(t, err.HttpStatusCode, http.StatusNotFound)
+	})
+
+	mtestDB.Run("Should return an error when try verify the email", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 0},
+		})
+
+		userRepository := NewUserRepository(mtestDB.Client, dbName, collectionName)
+
+		err := userRepository.VerifyEmail(ctx, userEntity.ID.Hex(), userEntity.Email, time.Now())
+
+		assert.NotNil(t, err)
+		assert.Equal(t, err.HttpStatusCode, http.StatusInternalServerError)
+		assert.Equal(t, err.Message, errVerifyEmail)
+	})
+}
+
+func Test_userRepo_SetMFASecret(t *testing.T) {
+	mtestDB := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mtestDB.Run("Should Set the MFA Secret Successfully", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 1},
+			{Key: "n", Value: 1},
+			{Key: "nModified", Value: 1},
+		})
+
+		userRepository := NewUserRepository(mtestDB.Client, dbName, collectionName)
+
+		err := userRepository.SetMFASecret(ctx, userEntity.ID.Hex(), "secret")
+
+		assert.Nil(t, err)
+	})
+
+	mtestDB.Run("Should return not found when MFA is already enabled", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 1},
+			{Key: "n", Value: 0},
+			{Key: "nModified", Value: 0},
+		})
+
+		userRepository := NewUserRepository(mtestDB.Client, dbName, collectionName)
+
+		err := userRepository.SetMFASecret(ctx, userEntity.ID.Hex(), "secret")
+
+		assert.NotNil(t, err)
+		assert.Equal(t, err.HttpStatusCode, http.StatusNotFound)
+	})
+
+	mtestDB.Run("Should return an error when try set the MFA secret", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 0},
+		})
+
+		userRepository := NewUserRepository(mtestDB.Client, dbName, collectionName)
+
+		err := userRepository.SetMFASecret(ctx, userEntity.ID.Hex(), "secret")
+
+		assert.NotNil(t, err)
+		assert.Equal(t, err.HttpStatusCode, http.StatusInternalServerError)
+		assert.Equal(t, err.Message, errSetMFASecret)
+	})
+}
+
+func Test_userRepo_EnableMFA(t *testing.T) {
+	mtestDB := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mtestDB.Run("Should Enable MFA Successfully", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 1},
+			{Key: "n", Value: 1},
+			{Key: "nModified", Value: 1},
+		})
 
 		userRepository := NewUserRepository(mtestDB.Client, dbName, collectionName)
 
-		result, err := userRepository.FindAll(ctx, itemsPerPage, currentPage)
+		err := userRepository.EnableMFA(ctx, userEntity.ID.Hex(), 1, []string{"hash"})
 
 		assert.Nil(t, err)
-		assert.LessOrEqual(t, len(result), itemsPerPage)
+	})
+
+	mtestDB.Run("Should return not found when MFA is already enabled", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 1},
+			{Key: "n", Value: 0},
+			{Key: "nModified", Value: 0},
+		})
+
+		userRepository := NewUserRepository(mtestDB.Client, dbName, collectionName)
+
+		err := userRepository.EnableMFA(ctx, userEntity.ID.Hex(), 1, []string{"hash"})
+
+		assert.NotNil(t, err)
+		assert.Equal(t, err.HttpStatusCode, http.StatusNotFound)
+	})
+
+	mtestDB.Run("Should return an error when try enable MFA", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 0},
+		})
+
+		userRepository := NewUserRepository(mtestDB.Client, dbName, collectionName)
+
+		err := userRepository.EnableMFA(ctx, userEntity.ID.Hex(), 1, []string{"hash"})
+
+		assert.NotNil(t, err)
+		assert.Equal(t, err.HttpStatusCode, http.StatusInternalServerError)
+		assert.Equal(t, err.Message, errEnableMFA)
+	})
+}
+
+func Test_userRepo_UseMFACounter(t *testing.T) {
+	mtestDB := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mtestDB.Run("Should Use the MFA Counter Successfully", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 1},
+			{Key: "n", Value: 1},
+			{Key: "nModified", Value: 1},
+		})
+
+		userRepository := NewUserRepository(mtestDB.Client, dbName, collectionName)
+
+		err := userRepository.UseMFACounter(ctx, userEntity.ID.Hex(), 2)
+
+		assert.Nil(t, err)
+	})
+
+	mtestDB.Run("Should return not found when the code was already used", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 1},
+			{Key: "n", Value: 0},
+			{Key: "nModified", Value: 0},
+		})
+
+		userRepository := NewUserRepository(mtestDB.Client, dbName, collectionName)
+
+		err := userRepository.UseMFACounter(ctx, userEntity.ID.Hex(), 2)
+
+		assert.NotNil(t, err)
+		assert.Equal(t, err.HttpStatusCode, http.StatusNotFound)
+	})
+
+	mtestDB.Run("Should return an error when try use the MFA counter", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 0},
+		})
+
+		userRepository := NewUserRepository(mtestDB.Client, dbName, collectionName)
+
+		err := userRepository.UseMFACounter(ctx, userEntity.ID.Hex(), 2)
+
+		assert.NotNil(t, err)
+		assert.Equal(t, err.HttpStatusCode, http.StatusInternalServerError)
+		assert.Equal(t, err.Message, errUseMFACounter)
+	})
+}
+
+func Test_userRepo_UseRecoveryCode(t *testing.T) {
+	mtestDB := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mtestDB.Run("Should Use the Recovery Code Successfully", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 1},
+			{Key: "n", Value: 1},
+			{Key: "nModified", Value: 1},
+		})
+
+		userRepository := NewUserRepository(mtestDB.Client, dbName, collectionName)
+
+		err := userRepository.UseRecoveryCode(ctx, userEntity.ID.Hex(), "hash")
+
+		assert.Nil(t, err)
+	})
+
+	mtestDB.Run("Should return not found when the recovery code was already used", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 1},
+			{Key: "n", Value: 0},
+			{Key: "nModified", Value: 0},
+		})
+
+		userRepository := NewUserRepository(mtestDB.Client, dbName, collectionName)
+
+		err := userRepository.UseRecoveryCode(ctx, userEntity.ID.Hex(), "hash")
+
+		assert.NotNil(t, err)
+		assert.Equal(t, err.HttpStatusCode, http.StatusNotFound)
+	})
+
+	mtestDB.Run("Should return an error when try use the recovery code", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 0},
+		})
+
+		userRepository := NewUserRepository(mtestDB.Client, dbName, collectionName)
+
+		err := userRepository.UseRecoveryCode(ctx, userEntity.ID.Hex(), "hash")
+
+		assert.NotNil(t, err)
+		assert.Equal(t, err.HttpStatusCode, http.StatusInternalServerError)
+		assert.Equal(t, err.Message, errUseRecoveryCode)
+	})
+}
+
+func Test_userRepo_FailMFAChallenge(t *testing.T) {
+	mtestDB := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mtestDB.Run("Should count the failure while the challenge has failures left", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 1},
+			{Key: "n", Value: 1},
+			{Key: "nModified", Value: 1},
+		})
+
+		userRepository := NewUserRepository(mtestDB.Client, dbName, collectionName)
+
+		err := userRepository.FailMFAChallenge(ctx, userEntity.ID.Hex(), "hash", 5)
+
+		assert.Nil(t, err)
+
+		started := mtestDB.GetStartedEvent()
+		assert.Equal(t, "update", started.CommandName)
+		assert.Nil(t, mtestDB.GetStartedEvent())
+	})
+
+	mtestDB.Run("Should end the challenge on the last failure", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(
+			bson.D{
+				{Key: "ok", Value: 1},
+				{Key: "n", Value: 0},
+				{Key: "nModified", Value: 0},
+			},
+			bson.D{
+				{Key: "ok", Value: 1},
+				{Key: "n", Value: 1},
+				{Key: "nModified", Value: 1},
+			},
+		)
+
+		userRepository := NewUserRepository(mtestDB.Client, dbName, collectionName)
+
+		err := userRepository.FailMFAChallenge(ctx, userEntity.ID.Hex(), "hash", 5)
+
+		assert.Nil(t, err)
+
+		mtestDB.GetStartedEvent()
+		ended := mtestDB.GetStartedEvent()
+		assert.NotNil(t, ended)
+		update := ended.Command.Lookup("updates").Array().Index(0).Value().Document().Lookup("u").Document()
+		assert.NotNil(t, update.Lookup("$unset").Document().Lookup("mfa_challenge"))
+	})
+
+	mtestDB.Run("Should return an error when try count the failure", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 0},
+		})
+
+		userRepository := NewUserRepository(mtestDB.Client, dbName, collectionName)
+
+		err := userRepository.FailMFAChallenge(ctx, userEntity.ID.Hex(), "hash", 5)
+
+		assert.NotNil(t, err)
+		assert.Equal(t, err.HttpStatusCode, http.StatusInternalServerError)
+		assert.Equal(t, err.Message, errMFAChallenge)
+	})
+}
+
+func Test_userRepo_EndMFAChallenge(t *testing.T) {
+	mtestDB := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mtestDB.Run("Should end the challenge successfully", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 1},
+			{Key: "n", Value: 1},
+			{Key: "nModified", Value: 1},
+		})
+
+		userRepository := NewUserRepository(mtestDB.Client, dbName, collectionName)
+
+		err := userRepository.EndMFAChallenge(ctx, userEntity.ID.Hex(), "hash")
+
+		assert.Nil(t, err)
+	})
+
+	mtestDB.Run("Should return not found when the challenge was already ended", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 1},
+			{Key: "n", Value: 0},
+			{Key: "nModified", Value: 0},
+		})
+
+		userRepository := NewUserRepository(mtestDB.Client, dbName, collectionName)
+
+		err := userRepository.EndMFAChallenge(ctx, userEntity.ID.Hex(), "hash")
+
+		assert.NotNil(t, err)
+		assert.Equal(t, err.HttpStatusCode, http.StatusNotFound)
+	})
+}
+
+func Test_userRepo_DeleteUser(t *testing.T) {
+	mtestDB := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mtestDB.Run("Should Soft Delete an User Successfully", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 1},
+			{Key: "n", Value: 1},
+			{Key: "nModified", Value: 1},
+		})
+
+		userRepository := NewUserRepository(mtestDB.Client, dbName, collectionName)
+
+		err := userRepository.DeleteUser(ctx, userEntity.ID.Hex())
+		assert.Nil(t, err)
+
+		event := mtestDB.GetStartedEvent()
+		assert.Equal(t, "update", event.CommandName)
+		update := event.Command.Lookup("updates").Array().Index(0).Value().Document()
+		assert.Equal(t, bson.TypeDateTime, update.Lookup("u", "$set", "deleted_at").Type)
+	})
+
+	mtestDB.Run("Should return not found when the user doesn't exist or is already deleted", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 1},
+			{Key: "n", Value: 0},
+			{Key: "nModified", Value: 0},
+		})
+
+		userRepository := NewUserRepository(mtestDB.Client, dbName, collectionName)
+
+		err := userRepository.DeleteUser(ctx, userEntity.ID.Hex())
+
+		assert.NotNil(t, err)
+		assert.Equal(t, err.HttpStatusCode, http.StatusNotFound)
+	})
+
+	mtestDB.Run("Should return an error when try delete an user", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 0},
+		})
+
+		userRepository := NewUserRepository(mtestDB.Client, dbName, collectionName)
+
+		err := userRepository.DeleteUser(ctx, userEntity.ID.Hex())
+
+		assert.NotNil(t, err)
+		assert.Equal(t, err.HttpStatusCode, http.StatusInternalServerError)
+		assert.Equal(t, err.Message, errDeleteUser)
+	})
+}
+
+func Test_userRepo_FindDeletedUserById(t *testing.T) {
+	mtestDB := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mtestDB.Run("Should Find a Deleted User Successfully", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(
+			mtest.CreateCursorResponse(
+				1,
+				fmt.Sprintf("%s.%s", dbName, collectionName),
+				mtest.FirstBatch,
+				bson.D{
+					{Key: "_id", Value: userEntity.ID},
+					{Key: "email", Value: userEntity.Email},
+					{Key: "name", Value: userEntity.Name},
+					{Key: "deleted_at", Value: time.Now()},
+				},
+			),
+		)
+
+		userRepository := NewUserRepository(mtestDB.Client, dbName, collectionName)
+
+		result, err := userRepository.FindDeletedUserById(ctx, userEntity.ID.Hex())
+
+		assert.Nil(t, err)
+		assert.Equal(t, user.Email, result.Email)
+		assert.NotNil(t, result.DeletedAt)
+	})
+
+	mtestDB.Run("Should return not found when the user is not deleted", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(mtest.CreateCursorResponse(
+			0,
+			fmt.Sprintf("%s.%s", dbName, collectionName),
+			mtest.FirstBatch,
+		))
+
+		userRepository := NewUserRepository(mtestDB.Client, dbName, collectionName)
+
+		result, err := userRepository.FindDeletedUserById(ctx, userEntity.ID.Hex())
+
+		assert.Nil(t, result)
+		assert.Equal(t, err.HttpStatusCode, http.StatusNotFound)
+	})
+}
+
+func Test_userRepo_RestoreUser(t *testing.T) {
+	mtestDB := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mtestDB.Run("Should Restore an User Successfully", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 1},
+			{Key: "value", Value: bson.D{
+				{Key: "_id", Value: userEntity.ID},
+				{Key: "email", Value: userEntity.Email},
+				{Key: "name", Value: userEntity.Name},
+			}},
+		})
+
+		userRepository := NewUserRepository(mtestDB.Client, dbName, collectionName)
+
+		result, err := userRepository.RestoreUser(ctx, userEntity.ID.Hex())
+
+		assert.Nil(t, err)
+		assert.Equal(t, user.Email, result.Email)
+		assert.Nil(t, result.DeletedAt)
+	})
+
+	mtestDB.Run("Should return not found when the user is not deleted", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 1},
+			{Key: "value", Value: nil},
+		})
+
+		userRepository := NewUserRepository(mtestDB.Client, dbName, collectionName)
+
+		result, err := userRepository.RestoreUser(ctx, userEntity.ID.Hex())
+
+		assert.Nil(t, result)
+		assert.Equal(t, err.HttpStatusCode, http.StatusNotFound)
+	})
+
+	mtestDB.Run("Should return an error when try restore an user", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 0},
+		})
+
+		userRepository := NewUserRepository(mtestDB.Client, dbName, collectionName)
+
+		result, err := userRepository.RestoreUser(ctx, userEntity.ID.Hex())
+
+		assert.Nil(t, result)
+		assert.Equal(t, err.Message, errRestoreUser)
+	})
+}
+
+func Test_userRepo_PurgeDeletedUsers(t *testing.T) {
+	mtestDB := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mtestDB.Run("Should Purge the Users Deleted Before the Time", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 1},
+			{Key: "n", Value: 2},
+		})
+
+		userRepository := NewUserRepository(mtestDB.Client, dbName, collectionName)
+
+		purged, err := userRepository.PurgeDeletedUsers(ctx, time.Now())
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, 2, purged)
+
+		event := mtestDB.GetStartedEvent()
+		assert.Equal(t, "delete", event.CommandName)
+		deletion := event.Command.Lookup("deletes").Array().Index(0).Value().Document()
+		assert.Equal(t, bson.TypeDateTime, deletion.Lookup("q", "deleted_at", "$lt").Type)
+	})
+
+	mtestDB.Run("Should return an error when try purge the users", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 0},
+		})
+
+		userRepository := NewUserRepository(mtestDB.Client, dbName, collectionName)
+
+		purged, err := userRepository.PurgeDeletedUsers(ctx, time.Now())
+
+		assert.Zero(t, purged)
+		assert.Equal(t, err.Message, errPurgeUsers)
+	})
+}
+
+func Test_userRepo_CountUsers(t *testing.T) {
+	mtestDB := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mtestDB.Run("Should Count the Users Matching the Query", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(mtest.CreateCursorResponse(
+			0,
+			fmt.Sprintf("%s.%s", dbName, collectionName),
+			mtest.FirstBatch,
+			bson.D{{Key: "n", Value: 45}},
+		))
+
+		userRepository := NewUserRepository(mtestDB.Client, dbName, collectionName)
+
+		total, err := userRepository.CountUsers(ctx, &domain.UserQuery{Status: domain.UserStatusSuspended})
+
+		assert.Nil(t, err)
+		assert.EqualValues(t, 45, total)
+
+		match := mtestDB.GetStartedEvent().Command.Lookup("pipeline").Array().Index(0).Value().Document()
+		assert.Equal(t, domain.UserStatusSuspended, match.Lookup("$match", "status").StringValue())
+	})
+
+	mtestDB.Run("Should return an error when try count the users", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(bson.D{
+			{Key: "ok", Value: 0},
+		})
+
+		userRepository := NewUserRepository(mtestDB.Client, dbName, collectionName)
+
+		total, err := userRepository.CountUsers(ctx, &domain.UserQuery{})
+
+		assert.Zero(t, total)
+		assert.Equal(t, err.Message, errCountUsers)
+	})
+}
+
+func Test_userProjection(t *testing.T) {
+	t.Run("Should read the keys of the fields and of the sort", func(t *testing.T) {
+		projection := userProjection([]string{"id", "email"}, []domain.SortField{{Field: domain.UserSortName}, {Field: domain.UserSortCreatedAt}})
+
+		assert.Equal(t, bson.D{{Key: "_id", Value: 1}, {Key: "email", Value: 1}, {Key: "name", Value: 1}}, projection)
+	})
+
+	t.Run("Should read every key without fields", func(t *testing.T) {
+		assert.Nil(t, userProjection(nil, []domain.SortField{{Field: domain.UserSortName}}))
+	})
+
+	t.Run("Should read every key when a field is unknown", func(t *testing.T) {
+		assert.Nil(t, userProjection([]string{"id", "unknown"}, nil))
+	})
+}
+
+func Test_userKeysetFilter(t *testing.T) {
+	sortByStatus := bson.D{{Key: "status", Value: 1}, {Key: "_id", Value: 1}}
+	byStatus := []domain.SortField{{Field: domain.UserSortStatus}}
+
+	t.Run("Should match the users with a status after the ones without it", func(t *testing.T) {
+		keyset := userKeysetFilter(sortByStatus, byStatus, &domain.UserCursor{ID: userEntity.ID.Hex(), SortValues: []string{""}})
+		branches := keyset.Value.(bson.A)[0].(bson.D)[0].Value.(bson.A)
+
+		assert.Equal(t, bson.D{{Key: "status", Value: bson.D{{Key: "$ne", Value: nil}}}}, branches[0])
+		assert.Equal(t, bson.D{{Key: "status", Value: nil}, {Key: "_id", Value: bson.D{{Key: "$gt", Value: userEntity.ID}}}}, branches[1])
+	})
+
+	t.Run("Should match the users without a status before the ones with it", func(t *testing.T) {
+		cursor := &domain.UserCursor{ID: userEntity.ID.Hex(), SortValues: []string{domain.UserStatusActive}, Backward: true}
+		keyset := userKeysetFilter(sortByStatus, byStatus, cursor)
+		branches := keyset.Value.(bson.A)[0].(bson.D)[0].Value.(bson.A)
+
+		assert.Equal(t, bson.D{{Key: "$or", Value: bson.A{
+			bson.D{{Key: "status", Value: bson.D{{Key: "$lt", Value: domain.UserStatusActive}}}},
+			bson.D{{Key: "status", Value: nil}},
+		}}}, branches[0])
+	})
+
+	t.Run("Should match nothing before the users without a status", func(t *testing.T) {
+		cursor := &domain.UserCursor{ID: userEntity.ID.Hex(), SortValues: []string{""}, Backward: true}
+		keyset := userKeysetFilter(sortByStatus, byStatus, cursor)
+		branches := keyset.Value.(bson.A)[0].(bson.D)[0].Value.(bson.A)
+
+		assert.Len(t, branches, 1)
+		assert.Equal(t, bson.D{{Key: "status", Value: nil}, {Key: "_id", Value: bson.D{{Key: "$lt", Value: userEntity.ID}}}}, branches[0])
+	})
+}
+
+func Test_userRepo_FindAll(t *testing.T) {
+	mtestDB := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mtestDB.Run("Should Find all Users Successfully", func(mtestDB *mtest.T) {
+		first := mtest.CreateCursorResponse(
+			1,
+			fmt.Sprintf("%s.%s", dbName, collectionName),
+			mtest.FirstBatch,
+			bson.D{
+				{Key: "_id", Value: userEntity.ID},
+				{Key: "email", Value: userEntity.Email},
+				{Key: "password", Value: userEntity.Password},
+				{Key: "name", Value: userEntity.Name},
+			},
+		)
+
+		getMore := mtest.CreateCursorResponse(
+			1,
+			fmt.Sprintf("%s.%s", dbName, collectionName),
+			mtest.NextBatch,
+			bson.D{
+				{Key: "_id", Value: userEntity.ID},
+				{Key: "email", Value: userEntity.Email},
+				{Key: "password", Value: userEntity.Password},
+				{Key: "name", Value: userEntity.Name},
+			},
+		)
+
+		killCursors := mtest.CreateCursorResponse(
+			0,
+			fmt.Sprintf("%s.%s", dbName, collectionName),
+			mtest.NextBatch,
+		)
+
+		mtestDB.AddMockResponses(first, getMore, killCursors)
+
+		userRepository := NewUserRepository(mtestDB.Client, dbName, collectionName)
+
+		result, err := userRepository.FindAll(ctx, &domain.UserQuery{Page: currentPage, PerPage: itemsPerPage})
+
+		assert.Nil(t, err)
+		assert.LessOrEqual(t, len(result.Users), itemsPerPage)
+		assert.False(t, result.HasMore)
+
+		// the users are listed as created, reading one more than the page
+		command := mtestDB.GetStartedEvent().Command
+		assert.Equal(t, int32(1), command.Lookup("sort").Document().Lookup("_id").Int32())
+		assert.EqualValues(t, itemsPerPage+1, command.Lookup("limit").AsInt64())
+	})
+
+	mtestDB.Run("Should tell there are more users than the page", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(mtest.CreateCursorResponse(
+			0,
+			fmt.Sprintf("%s.%s", dbName, collectionName),
+			mtest.FirstBatch,
+			bson.D{{Key: "_id", Value: primitive.NewObjectID()}, {Key: "name", Value: "Ana"}},
+			bson.D{{Key: "_id", Value: primitive.NewObjectID()}, {Key: "name", Value: "Bia"}},
+		))
+
+		userRepository := NewUserRepository(mtestDB.Client, dbName, collectionName)
+
+		result, err := userRepository.FindAll(ctx, &domain.UserQuery{Page: currentPage, PerPage: 1})
+
+		assert.Nil(t, err)
+		assert.True(t, result.HasMore)
+		assert.Len(t, result.Users, 1)
+		assert.Equal(t, "Ana", result.Users[0].Name)
+	})
+
+	mtestDB.Run("Should Find the Users After the Cursor", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(mtest.CreateCursorResponse(
+			0,
+			fmt.Sprintf("%s.%s", dbName, collectionName),
+			mtest.FirstBatch,
+		))
+
+		userRepository := NewUserRepository(mtestDB.Client, dbName, collectionName)
+
+		cursor := &domain.UserCursor{ID: userEntity.ID.Hex(), SortValues: []string{"Al"}}
+		query := &domain.UserQuery{Sort: []domain.SortField{{Field: domain.UserSortName}}, From: cursor, PerPage: itemsPerPage}
+
+		_, err := userRepository.FindAll(ctx, query)
+		assert.Nil(t, err)
+
+		command := mtestDB.GetStartedEvent().Command
+		_, skipped := command.Lookup("skip").AsInt64OK()
+		assert.False(t, skipped)
+
+		// the users named after the cursor, or named as it and created after it
+		branches := command.Lookup("filter").Document().Lookup("$and").Array().Index(0).Value().Document().Lookup("$or").Array()
+		assert.Equal(t, "Al", branches.Index(0).Value().Document().Lookup("name", "$gt").StringValue())
+		assert.Equal(t, "Al", branches.Index(1).Value().Document().Lookup("name").StringValue())
+		assert.Equal(t, userEntity.ID, branches.Index(1).Value().Document().Lookup("_id", "$gt").ObjectID())
+	})
+
+	mtestDB.Run("Should Find the Users Before the Cursor in the Sort Order", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(mtest.CreateCursorResponse(
+			0,
+			fmt.Sprintf("%s.%s", dbName, collectionName),
+			mtest.FirstBatch,
+			bson.D{{Key: "_id", Value: primitive.NewObjectID()}, {Key: "name", Value: "Bia"}},
+			bson.D{{Key: "_id", Value: primitive.NewObjectID()}, {Key: "name", Value: "Ana"}},
+		))
+
+		userRepository := NewUserRepository(mtestDB.Client, dbName, collectionName)
+
+		cursor := &domain.UserCursor{ID: userEntity.ID.Hex(), SortValues: []string{"Bob"}, Backward: true}
+		query := &domain.UserQuery{Sort: []domain.SortField{{Field: domain.UserSortName}}, From: cursor, PerPage: itemsPerPage}
+
+		result, err := userRepository.FindAll(ctx, query)
+		assert.Nil(t, err)
+		assert.Equal(t, "Ana", result.Users[0].Name)
+		assert.Equal(t, "Bia", result.Users[1].Name)
+
+		// read in the reversed sort from the cursor, the page is put back in the sort order
+		command := mtestDB.GetStartedEvent().Command
+		assert.Equal(t, int32(-1), command.Lookup("sort").Document().Lookup("name").Int32())
+		branches := command.Lookup("filter").Document().Lookup("$and").Array().Index(0).Value().Document().Lookup("$or").Array()
+		assert.Equal(t, "Bob", branches.Index(0).Value().Document().Lookup("name", "$lt").StringValue())
+	})
+
+	mtestDB.Run("Should Search, Filter and Sort the Users", func(mtestDB *mtest.T) {
+		mtestDB.AddMockResponses(mtest.CreateCursorResponse(
+			0,
+			fmt.Sprintf("%s.%s", dbName, collectionName),
+			mtest.FirstBatch,
+		))
+
+		userRepository := NewUserRepository(mtestDB.Client, dbName, collectionName)
+
+		createdFrom := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+		query := &domain.UserQuery{
+			Search:      "jo.n",
+			Status:      domain.UserStatusSuspended,
+			Role:        domain.RoleUser,
+			CreatedFrom: &createdFrom,
+			Sort:        []domain.SortField{{Field: domain.UserSortName}, {Field: domain.UserSortCreatedAt, Descending: true}},
+			Page:        2,
+			PerPage:     itemsPerPage,
+		}
+
+		_, err := userRepository.FindAll(ctx, query)
+		assert.Nil(t, err)
+
+		command := mtestDB.GetStartedEvent().Command
+		assert.EqualValues(t, itemsPerPage, command.Lookup("skip").AsInt64())
+
+		filter := command.Lookup("filter").Document()
+		pattern, options := filter.Lookup("$or").Array().Index(0).Value().Document().Lookup("name").Regex()
+		assert.Equal(t, `^jo\.n`, pattern)
+		assert.Equal(t, "i", options)
+		assert.Equal(t, domain.UserStatusSuspended, filter.Lookup("status").StringValue())
+
+		// the users stored before the roles existed have none and are regular users
+		roles := filter.Lookup("roles", "$in").Array()
+		assert.Equal(t, domain.RoleUser, roles.Index(0).Value().StringValue())
+		assert.Equal(t, bson.TypeNull, roles.Index(1).Value().Type)
+
+		createdFromID := filter.Lookup("_id", "$gte").ObjectID()
+		assert.Equal(t, createdFrom, createdFromID.Timestamp().UTC())
+
+		_, projected := command.Lookup("projection").DocumentOK()
+		assert.False(t, projected)
+
+		// created_at is sorted by the _id, which then isn't added again to break the ties
+		sort := command.Lookup("sort").Document()
+		elements, _ := sort.Elements()
+		assert.Len(t, elements, 2)
+		assert.Equal(t, "name", elements[0].Key())
+		assert.Equal(t, int32(-1), sort.Lookup("_id").Int32())
 	})
 
 	mtestDB.Run("Should return an error when try find users", func(mtestDB *mtest.T) {
@@ -317,7 +1240,7 @@ func Test_userRepo_FindAll(t *testing.T) {
 
 		userRepository := NewUserRepository(mtestDB.Client, dbName, collectionName)
 
-		result, err := userRepository.FindAll(ctx, itemsPerPage, currentPage)
+		result, err := userRepository.FindAll(ctx, &domain.UserQuery{Page: currentPage, PerPage: itemsPerPage})
 
 		assert.Nil(t, result)
 		assert.NotNil(t, err)
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	"github.com/WalterPaes/go-rest-api-crud/internal/repositories"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/logger"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/notifier"
+	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/signer"
+	"go.uber.org/zap"
+)
+
+const (
+	errInvalidVerificationToken = "Email Verification Token is Invalid"
+	errSignVerificationToken    = "Error when trying sign verification token"
+
+	ErrCodeInvalidVerificationToken = "invalid_verification_token"
+
+	emailVerificationPurpose = "email_verification"
+	emailVerificationSubject = "Verify your email"
+	emailVerificationBody    = "Use the link below to verify your email, it expires in %d hours:\n\n%s%s"
+)
+
+var (
+	stacktraceSendVerificationEmailService = zap.String("stacktrace", "send-verification-email-service")
+	stacktraceVerifyEmailService           = zap.String("stacktrace", "verify-email-service")
+)
+
+type EmailVerificationService interface {
+	SendVerificationEmail(ctx context.Context, user *domain.User) *resterrors.RestErr
+	VerifyEmail(ctx context.Context, verificationToken string) *resterrors.RestErr
+}
+
+type emailVerificationSvc struct {
+	userRepository           repositories.UserRepository
+	notifier                 notifier.Notifier
+	signer                   *signer.Signer
+	verificationTokenExpTime int
+	verificationURL          string
+}
+
+// verificationTokenData binds the token to the email it was sent to, so a link can't
+// verify an email set after it was sent. The signer is shared with other services, the
+// purpose keeps their tokens from being accepted as a verification.
+type verificationTokenData struct {
+	UserID  string `json:"uid"`
+	Email   string `json:"email"`
+	Purpose string `json:"purpose"`
+}
+
+// NewEmailVerificationService builds the email verification service. verificationTokenExpTime
+// is in hours and verificationURL is the front-end page receiving the token appended to it.
+func NewEmailVerificationService(
+	userRepository repositories.UserRepository,
+	notifier notifier.Notifier,
+	signer *signer.Signer,
+	verificationTokenExpTime int,
+	verificationURL string,
+) *emailVerificationSvc {
+	return &emailVerificationSvc{
+		userRepository:           userRepository,
+		notifier:                 notifier,
+		signer:                   signer,
+		verificationTokenExpTime: verificationTokenExpTime,
+		verificationURL:          verificationURL,
+	}
+}
+
+// SendVerificationEmail sends to the user a signed link verifying its current email.
+// Nothing is stored, the link carries everything needed to verify the email.
+func (s *emailVerificationSvc) SendVerificationEmail(ctx context.Context, user *domain.User) *resterrors.RestErr {
+	logger.Info("Starting Send Verification Email", stacktraceSendVerificationEmailService)
+
+	expiresAt := time.Now().Add(time.Hour * time.Duration(s.verificationTokenExpTime))
+
+	verificationToken, err := s.signer.Sign(verificationTokenData{UserID: user.ID, Email: user.Email, Purpose: emailVerificationPurpose}, expiresAt)
+	if err != nil {
+		logger.Error(errSignVerificationToken, err, stacktraceSendVerificationEmailService)
+		return resterrors.NewInternalServerError(errSignVerificationToken)
+	}
+
+	err = s.notifier.Notify(ctx, notifier.Message{
+		To:      user.Email,
+		Subject: emailVerificationSubject,
+		Body:    fmt.Sprintf(emailVerificationBody, s.verificationTokenExpTime, s.verificationURL, verificationToken),
+	})
+	if err != nil {
+		logger.Error(errSendNotification, err, stacktraceSendVerificationEmailService)
+		return resterrors.NewInternalServerError(errSendNotification)
+	}
+
+	logger.Info("Verification email was sent successfully", zap.String("user_id", user.ID), stacktraceSendVerificationEmailService)
+	return nil
+}
+
+func (s *emailVerificationSvc) VerifyEmail(ctx context.Context, verificationToken string) *resterrors.RestErr {
+	logger.Info("Starting Verify Email", stacktraceVerifyEmailService)
+
+	var data verificationTokenData
+	if err := s.signer.Verify(verificationToken, &data); err != nil || data.Purpose != emailVerificationPurpose {
+		logger.Error(errInvalidVerificationToken, err, stacktraceVerifyEmailService)
+		return resterrors.NewBadRequestError(errInvalidVerificationToken).WithCode(ErrCodeInvalidVerificationToken)
+	}
+
+	if err := s.userRepository.VerifyEmail(ctx, data.UserID, data.Email, time.Now()); err != nil {
+		if err.HttpStatusCode == http.StatusNotFound {
+			logger.Error(errInvalidVerificationToken, err, stacktraceVerifyEmailService)
+			return resterrors.NewBadRequestError(errInvalidVerificationToken).WithCode(ErrCodeInvalidVerificationToken)
+		}
+
+		logger.Error(errCallRepositoy, err, stacktraceVerifyEmailService)
+		return err
+	}
+
+	logger.Info("Email was verified successfully", zap.String("user_id", data.UserID), stacktraceVerifyEmailService)
+	return nil
+}
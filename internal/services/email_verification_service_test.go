@@ -0,0 +1,155 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/WalterPaes/go-rest-api-crud/internal/repositories"
+	"github.com/WalterPaes/go-rest-api-crud/mocks"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/notifier"
+	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/signer"
+	"github.com/stretchr/testify/mock"
+)
+
+var (
+	verificationSigner       = newSigner("secret")
+	verificationTokenExpTime = 48
+	verificationURL          = "http://localhost:3000/verify-email?token="
+)
+
+// newSigner builds the signers of the tests, whose secrets are never empty.
+func newSigner(secret string) *signer.Signer {
+	s, err := signer.New(secret)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func verificationToken(t *testing.T, s *signer.Signer, email string, expiresAt time.Time) string {
+	token, err := s.Sign(verificationTokenData{UserID: userID, Email: email, Purpose: emailVerificationPurpose}, expiresAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func Test_emailVerificationSvc_SendVerificationEmail(t *testing.T) {
+	tests := []struct {
+		name     string
+		notifier notifier.Notifier
+		wantErr  *resterrors.RestErr
+	}{
+		{
+			name: "Should send a verification link to the email of the user",
+			notifier: func() notifier.Notifier {
+				m := mocks.NewNotifier(t)
+				m.On("Notify", ctx, mock.MatchedBy(func(message notifier.Message) bool {
+					return message.To == responseUser.Email && strings.Contains(message.Body, verificationURL)
+				})).Return(nil)
+				return m
+			}(),
+			wantErr: nil,
+		},
+		{
+			name: "Should return an error when the notification fails",
+			notifier: func() notifier.Notifier {
+				m := mocks.NewNotifier(t)
+				m.On("Notify", ctx, mock.Anything).
+					Return(errors.New("error"))
+				return m
+			}(),
+			wantErr: resterrors.NewInternalServerError(errSendNotification),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewEmailVerificationService(mocks.NewUserRepository(t), tt.notifier, verificationSigner, verificationTokenExpTime, verificationURL)
+			if err := s.SendVerificationEmail(ctx, responseUser); !reflect.DeepEqual(err, tt.wantErr) {
+				t.Errorf("emailVerificationSvc.SendVerificationEmail() err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_emailVerificationSvc_VerifyEmail(t *testing.T) {
+	invalidTokenError := resterrors.NewBadRequestError(errInvalidVerificationToken).WithCode(ErrCodeInvalidVerificationToken)
+	validToken := verificationToken(t, verificationSigner, responseUser.Email, time.Now().Add(time.Hour))
+
+	tests := []struct {
+		name           string
+		userRepository repositories.UserRepository
+		token          string
+		wantErr        *resterrors.RestErr
+	}{
+		{
+			name: "Should verify the email the token was sent to",
+			userRepository: func() repositories.UserRepository {
+				m := mocks.NewUserRepository(t)
+				m.On("VerifyEmail", ctx, userID, responseUser.Email, mock.AnythingOfType("time.Time")).
+					Return(nil)
+				return m
+			}(),
+			token:   validToken,
+			wantErr: nil,
+		},
+		{
+			name:           "Should return an error when the token is expired",
+			userRepository: mocks.NewUserRepository(t),
+			token:          verificationToken(t, verificationSigner, responseUser.Email, time.Now().Add(-time.Hour)),
+			wantErr:        invalidTokenError,
+		},
+		{
+			name:           "Should return an error when the token was signed with another secret",
+			userRepository: mocks.NewUserRepository(t),
+			token:          verificationToken(t, newSigner("other"), responseUser.Email, time.Now().Add(time.Hour)),
+			wantErr:        invalidTokenError,
+		},
+		{
+			name:           "Should return an error when the token was signed for another purpose",
+			userRepository: mocks.NewUserRepository(t),
+			token: func() string {
+				token, err := verificationSigner.Sign(verificationTokenData{UserID: userID, Email: responseUser.Email, Purpose: mfaChallengePurpose}, time.Now().Add(time.Hour))
+				if err != nil {
+					t.Fatal(err)
+				}
+				return token
+			}(),
+			wantErr: invalidTokenError,
+		},
+		{
+			name: "Should return an error when the email of the user changed after the token was sent",
+			userRepository: func() repositories.UserRepository {
+				m := mocks.NewUserRepository(t)
+				m.On("VerifyEmail", ctx, userID, responseUser.Email, mock.AnythingOfType("time.Time")).
+					Return(resterrors.NewNotFoundError("not found"))
+				return m
+			}(),
+			token:   validToken,
+			wantErr: invalidTokenError,
+		},
+		{
+			name: "Should return an error when try call repository to verify the email",
+			userRepository: func() repositories.UserRepository {
+				m := mocks.NewUserRepository(t)
+				m.On("VerifyEmail", ctx, userID, responseUser.Email, mock.AnythingOfType("time.Time")).
+					Return(internalServerError)
+				return m
+			}(),
+			token:   validToken,
+			wantErr: internalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewEmailVerificationService(tt.userRepository, mocks.NewNotifier(t), verificationSigner, verificationTokenExpTime, verificationURL)
+			if err := s.VerifyEmail(ctx, tt.token); !reflect.DeepEqual(err, tt.wantErr) {
+				t.Errorf("emailVerificationSvc.VerifyEmail() err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	"github.com/WalterPaes/go-rest-api-crud/internal/repositories"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/jwt"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/logger"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/randtoken"
+	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
+	"go.uber.org/zap"
+)
+
+const (
+	errImpersonateItself   = "An user can't impersonate itself"
+	errImpersonateAdmin    = "An admin can't be impersonated"
+	errGenerateImpersonate = "Error when trying generate the impersonation token"
+)
+
+var (
+	stacktraceImpersonateService = zap.String("stacktrace", "impersonate-service")
+)
+
+type ImpersonationService interface {
+	Impersonate(ctx context.Context, actorID, userID string, scopes []string, reason string) (*domain.Impersonation, *resterrors.RestErr)
+}
+
+type impersonationSvc struct {
+	userRepository repositories.UserRepository
+	jwtAuth        jwt.JwtAuth
+	tokenExpTime   int
+}
+
+// NewImpersonationService builds the impersonation service. tokenExpTime, in minutes,
+// is the lifetime of the impersonation tokens, which can't be refreshed.
+func NewImpersonationService(
+	userRepository repositories.UserRepository,
+	jwtAuth jwt.JwtAuth,
+	tokenExpTime int,
+) *impersonationSvc {
+	return &impersonationSvc{
+		userRepository: userRepository,
+		jwtAuth:        jwtAuth,
+		tokenExpTime:   tokenExpTime,
+	}
+}
+
+// Impersonate issues to the admin an access token of the user, limited to the scopes,
+// every scope when none is given. The reason is only kept in the audit trail.
+func (s *impersonationSvc) Impersonate(ctx context.Context, actorID, userID string, scopes []string, reason string) (*domain.Impersonation, *resterrors.RestErr) {
+	logger.Info("Starting Impersonate", stacktraceImpersonateService)
+
+	if actorID == userID {
+		logger.Error(errImpersonateItself, nil, zap.String("actor_id", actorID), stacktraceImpersonateService)
+		return nil, resterrors.NewBadRequestError(errImpersonateItself)
+	}
+
+	user, err := s.userRepository.FindUserById(ctx, userID)
+	if err != nil {
+		logger.Error(errCallRepositoy, err, stacktraceImpersonateService)
+		return nil, err
+	}
+
+	if user.HasRole(domain.RoleAdmin) {
+		logger.Error(errImpersonateAdmin, nil, zap.String("user_id", userID), zap.String("actor_id", actorID), stacktraceImpersonateService)
+		return nil, resterrors.NewForbiddenError(errImpersonateAdmin)
+	}
+
+	if len(scopes) == 0 {
+		scopes = domain.Scopes
+	}
+
+	tokenID, genErr := randtoken.Generate(16)
+	if genErr != nil {
+		logger.Error(errGenerateImpersonate, genErr, stacktraceImpersonateService)
+		return nil, resterrors.NewInternalServerError(errGenerateImpersonate)
+	}
+
+	expiresAt := time.Now().Add(time.Minute * time.Duration(s.tokenExpTime))
+	accessToken, err := s.jwtAuth.GenerateToken(map[string]any{
+		"id":    user.ID,
+		"name":  user.Name,
+		"email": user.Email,
+		"roles": user.GetRoles(),
+		"act":   map[string]any{"sub": actorID},
+		"scope": strings.Join(scopes, " "),
+		"jti":   tokenID,
+		"exp":   expiresAt.Unix(),
+	})
+	if err != nil {
+		logger.Error(errGenerateImpersonate, err, stacktraceImpersonateService)
+		return nil, err
+	}
+
+	logger.Info("Impersonation Started Successfully",
+		zap.String("user_id", user.ID),
+		zap.String("actor_id", actorID),
+		zap.String("token_id", tokenID),
+		zap.Strings("scopes", scopes),
+		zap.String("reason", reason),
+		zap.Time("expires_at", expiresAt),
+		stacktraceImpersonateService,
+	)
+	return &domain.Impersonation{
+		AccessToken: accessToken,
+		TokenID:     tokenID,
+		UserID:      user.ID,
+		ActorID:     actorID,
+		Scopes:      scopes,
+		ExpiresAt:   expiresAt,
+	}, nil
+}
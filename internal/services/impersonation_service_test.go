@@ -0,0 +1,111 @@
+package services
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	"github.com/WalterPaes/go-rest-api-crud/internal/repositories"
+	"github.com/WalterPaes/go-rest-api-crud/mocks"
+	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	impersonationTokenExpTime = 15
+	adminID                   = primitive.NewObjectID().Hex()
+)
+
+func Test_impersonationSvc_Impersonate(t *testing.T) {
+	findUser := func(user *domain.User, err *resterrors.RestErr) repositories.UserRepository {
+		m := mocks.NewUserRepository(t)
+		m.On("FindUserById", ctx, userID).
+			Return(user, err)
+		return m
+	}
+
+	t.Run("Should issue a token of the user carrying the admin as actor", func(t *testing.T) {
+		jwtAuth := mocks.NewJwtAuth(t)
+		jwtAuth.On("GenerateToken", mock.MatchedBy(func(claims map[string]any) bool {
+			exp, _ := claims["exp"].(int64)
+			return claims["id"] == userID &&
+				assert.ObjectsAreEqual(map[string]any{"sub": adminID}, claims["act"]) &&
+				assert.ObjectsAreEqual([]string{domain.RoleUser}, claims["roles"]) &&
+				claims["scope"] == domain.ScopeUsersRead &&
+				claims["jti"] != "" &&
+				time.Until(time.Unix(exp, 0)) <= 15*time.Minute
+		})).
+			Return("access-token", nil)
+
+		s := NewImpersonationService(findUser(&domain.User{ID: userID, Name: "First User"}, nil), jwtAuth, impersonationTokenExpTime)
+
+		impersonation, err := s.Impersonate(ctx, adminID, userID, []string{domain.ScopeUsersRead}, "support ticket 42")
+
+		assert.Nil(t, err)
+		assert.Equal(t, "access-token", impersonation.AccessToken)
+		assert.NotEmpty(t, impersonation.TokenID)
+		assert.Equal(t, userID, impersonation.UserID)
+		assert.Equal(t, adminID, impersonation.ActorID)
+		assert.Equal(t, []string{domain.ScopeUsersRead}, impersonation.Scopes)
+		assert.True(t, impersonation.ExpiresAt.After(time.Now()))
+	})
+
+	t.Run("Should grant every scope when none is requested", func(t *testing.T) {
+		jwtAuth := mocks.NewJwtAuth(t)
+		jwtAuth.On("GenerateToken", mock.MatchedBy(func(claims map[string]any) bool {
+			return claims["scope"] == "users:read users:write"
+		})).
+			Return("access-token", nil)
+
+		s := NewImpersonationService(findUser(&domain.User{ID: userID}, nil), jwtAuth, impersonationTokenExpTime)
+
+		impersonation, err := s.Impersonate(ctx, adminID, userID, nil, "support ticket 42")
+
+		assert.Nil(t, err)
+		assert.Equal(t, domain.Scopes, impersonation.Scopes)
+	})
+
+	t.Run("Should refuse an admin impersonating itself", func(t *testing.T) {
+		s := NewImpersonationService(mocks.NewUserRepository(t), mocks.NewJwtAuth(t), impersonationTokenExpTime)
+
+		impersonation, err := s.Impersonate(ctx, userID, userID, nil, "support ticket 42")
+
+		assert.Nil(t, impersonation)
+		assert.EqualValues(t, http.StatusBadRequest, err.HttpStatusCode)
+	})
+
+	t.Run("Should refuse to impersonate another admin", func(t *testing.T) {
+		s := NewImpersonationService(findUser(&domain.User{ID: userID, Roles: []string{domain.RoleAdmin}}, nil), mocks.NewJwtAuth(t), impersonationTokenExpTime)
+
+		impersonation, err := s.Impersonate(ctx, adminID, userID, nil, "support ticket 42")
+
+		assert.Nil(t, impersonation)
+		assert.EqualValues(t, http.StatusForbidden, err.HttpStatusCode)
+	})
+
+	t.Run("Should return an error when the user is not found", func(t *testing.T) {
+		notFound := resterrors.NewNotFoundError("User Not Found")
+		s := NewImpersonationService(findUser(nil, notFound), mocks.NewJwtAuth(t), impersonationTokenExpTime)
+
+		impersonation, err := s.Impersonate(ctx, adminID, userID, nil, "support ticket 42")
+
+		assert.Nil(t, impersonation)
+		assert.Equal(t, notFound, err)
+	})
+
+	t.Run("Should return an error when try generate the token", func(t *testing.T) {
+		jwtAuth := mocks.NewJwtAuth(t)
+		jwtAuth.On("GenerateToken", mock.Anything).
+			Return("", internalServerError)
+
+		s := NewImpersonationService(findUser(&domain.User{ID: userID}, nil), jwtAuth, impersonationTokenExpTime)
+
+		impersonation, err := s.Impersonate(ctx, adminID, userID, nil, "support ticket 42")
+
+		assert.Nil(t, impersonation)
+		assert.Equal(t, internalServerError, err)
+	})
+}
@@ -2,73 +2,515 @@ package services
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
+	"github.com/WalterPaes/go-rest-api-crud/internal/auth"
 	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
 	"github.com/WalterPaes/go-rest-api-crud/internal/repositories"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/hasher"
 	"github.com/WalterPaes/go-rest-api-crud/pkg/jwt"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/lockout"
 	"github.com/WalterPaes/go-rest-api-crud/pkg/logger"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/randtoken"
 	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
 	"go.uber.org/zap"
-	"golang.org/x/crypto/bcrypt"
 )
 
-const errInvalidCredentials = "Credentials are Invalid"
+const (
+	errInvalidCredentials  = "Credentials are Invalid"
+	errInvalidRefreshToken = "Refresh Token is Invalid"
+	errGenerateToken       = "Error when trying generate token"
+	errUnauthenticated     = "User is not authenticated"
+	errEmailNotVerified    = "Email is not verified"
+	errTooManyAttempts     = "Too many failed login attempts, try again later"
+	errAccountInactive     = "Account is %s"
+
+	ErrCodeEmailNotVerified = "email_not_verified"
+	ErrCodeTooManyAttempts  = "too_many_login_attempts"
+	ErrCodeAccountInactive  = "account_inactive"
+)
 
 var (
-	stacktraceLoginService = zap.String("stacktrace", "login-service")
+	stacktraceLoginService        = zap.String("stacktrace", "login-service")
+	stacktraceRefreshTokenService = zap.String("stacktrace", "refresh-token-service")
+	stacktraceLogoutService       = zap.String("stacktrace", "logout-service")
+	stacktraceLoginMFAService     = zap.String("stacktrace", "login-mfa-service")
+	stacktraceUnlockUserService   = zap.String("stacktrace", "unlock-user-service")
+	stacktraceLoginExternalUser   = zap.String("stacktrace", "login-external-user-service")
 )
 
 type LoginService interface {
-	LoginUser(ctx context.Context, user *domain.User) (string, *resterrors.RestErr)
+	LoginUser(ctx context.Context, user *domain.User) (*domain.AuthTokens, *resterrors.RestErr)
+	LoginMFA(ctx context.Context, mfaToken, code string) (*domain.AuthTokens, *resterrors.RestErr)
+	LoginExternalUser(ctx context.Context, user *domain.User) (*domain.AuthTokens, *resterrors.RestErr)
+	RefreshToken(ctx context.Context, refreshToken string) (*domain.AuthTokens, *resterrors.RestErr)
+	LogoutUser(ctx context.Context) *resterrors.RestErr
+	UnlockUser(ctx context.Context, userID string) *resterrors.RestErr
 }
 
 type loginSvc struct {
-	userRepository repositories.UserRepository
-	jwtAuth        jwt.JwtAuth
+	userRepository         repositories.UserRepository
+	refreshTokenRepository repositories.RefreshTokenRepository
+	sessionRepository      repositories.SessionRepository
+	jwtAuth                jwt.JwtAuth
+	mfaService             MFAService
+	emailLimiter           *lockout.Limiter
+	ipLimiter              *lockout.Limiter
+	passwordHasher         hasher.PasswordHasher
+	refreshTokenExpTime    int
+	requireVerifiedEmail   bool
 }
 
-func NewLoginService(userRepository repositories.UserRepository, jwtAuth jwt.JwtAuth) *loginSvc {
+// NewLoginService builds the login service. Every login records a session of the
+// device it was made from. The limiters throttle the failed logins by email and by
+// client ip, and the hasher upgrades the outdated password hashes. When
+// requireVerifiedEmail is set, the users that didn't verify their email can't log in.
+func NewLoginService(
+	userRepository repositories.UserRepository,
+	refreshTokenRepository repositories.RefreshTokenRepository,
+	sessionRepository repositories.SessionRepository,
+	jwtAuth jwt.JwtAuth,
+	mfaService MFAService,
+	emailLimiter *lockout.Limiter,
+	ipLimiter *lockout.Limiter,
+	passwordHasher hasher.PasswordHasher,
+	refreshTokenExpTime int,
+	requireVerifiedEmail bool,
+) *loginSvc {
 	return &loginSvc{
-		userRepository: userRepository,
-		jwtAuth:        jwtAuth,
+		userRepository:         userRepository,
+		refreshTokenRepository: refreshTokenRepository,
+		sessionRepository:      sessionRepository,
+		jwtAuth:                jwtAuth,
+		mfaService:             mfaService,
+		emailLimiter:           emailLimiter,
+		ipLimiter:              ipLimiter,
+		passwordHasher:         passwordHasher,
+		refreshTokenExpTime:    refreshTokenExpTime,
+		requireVerifiedEmail:   requireVerifiedEmail,
 	}
 }
 
-func (s *loginSvc) LoginUser(ctx context.Context, user *domain.User) (string, *resterrors.RestErr) {
+func (s *loginSvc) LoginUser(ctx context.Context, user *domain.User) (*domain.AuthTokens, *resterrors.RestErr) {
 	logger.Info("Starting Login User", stacktraceLoginService)
 
+	emailKey := strings.ToLower(strings.TrimSpace(user.Email))
+	ip := auth.ClientInfoFromContext(ctx).IP
+
+	if err := s.checkLockout(ctx, emailKey, ip); err != nil {
+		logger.Error(err.Message, err, zap.String("ip", ip), stacktraceLoginService)
+		return nil, err
+	}
+
 	resultUser, err := s.userRepository.FindUserByEmail(ctx, user.Email)
 	if err != nil {
 		if err.HttpStatusCode == http.StatusNotFound {
 			logger.Error(errInvalidCredentials, err, stacktraceLoginService)
-			return "", resterrors.NewUnauthorizedError(errInvalidCredentials)
+			return nil, s.registerFailure(ctx, emailKey, ip)
 		}
 
 		logger.Error("Error when trying call repository", err, stacktraceLoginService)
-		return "", err
+		return nil, err
 	}
 
-	if !s.validatePassword(user.Password, resultUser.Password) {
-		logger.Error(errInvalidCredentials, err, stacktraceLoginService)
-		return "", resterrors.NewUnauthorizedError(errInvalidCredentials)
+	if !resultUser.ComparePassword(user.Password) {
+		logger.Error(errInvalidCredentials, nil, zap.String("user_id", resultUser.ID), stacktraceLoginService)
+		return nil, s.registerFailure(ctx, emailKey, ip)
 	}
 
-	token, err := s.jwtAuth.GenerateToken(map[string]any{
-		"id":    resultUser.ID,
-		"email": resultUser.Email,
-		"name":  resultUser.Name,
-	})
+	s.rehashPassword(ctx, resultUser, user.Password)
+
+	// The ip failures are kept, otherwise logging into an own account between
+	// guesses would reset them.
+	if err := s.emailLimiter.Reset(ctx, emailKey); err != nil {
+		logger.Error("Error when trying reset login attempts", err, zap.String("user_id", resultUser.ID), stacktraceLoginService)
+	}
+
+	if s.requireVerifiedEmail && !resultUser.EmailVerified {
+		logger.Error(errEmailNotVerified, nil, zap.String("user_id", resultUser.ID), stacktraceLoginService)
+		return nil, resterrors.NewForbiddenError(errEmailNotVerified).WithCode(ErrCodeEmailNotVerified)
+	}
+
+	if err := checkActive(resultUser); err != nil {
+		logger.Error(err.Message, err, zap.String("user_id", resultUser.ID), stacktraceLoginService)
+		return nil, err
+	}
+
+	if resultUser.MFAEnabled {
+		mfaToken, err := s.mfaService.CreateChallenge(ctx, resultUser)
+		if err != nil {
+			logger.Error(err.Error(), err, stacktraceLoginService)
+			return nil, err
+		}
+
+		logger.Info("User must send a MFA code to login", zap.String("user_id", resultUser.ID), stacktraceLoginService)
+		return &domain.AuthTokens{MFAToken: mfaToken}, nil
+	}
+
+	tokens, err := s.startSession(ctx, resultUser)
 	if err != nil {
 		logger.Error(err.Error(), err, stacktraceLoginService)
-		return "", err
+		return nil, err
 	}
 
 	logger.Info("User was logged successfully", zap.String("user_id", resultUser.ID), stacktraceLoginService)
-	return token, nil
+	return tokens, nil
+}
+
+// LoginMFA finishes the login of an user with MFA enabled, exchanging the token
+// returned by LoginUser and a code for the tokens of the user.
+func (s *loginSvc) LoginMFA(ctx context.Context, mfaToken, code string) (*domain.AuthTokens, *resterrors.RestErr) {
+	logger.Info("Starting Login MFA", stacktraceLoginMFAService)
+
+	ip := auth.ClientInfoFromContext(ctx).IP
+
+	if err := s.checkLockout(ctx, "", ip); err != nil {
+		logger.Error(err.Message, err, zap.String("ip", ip), stacktraceLoginMFAService)
+		return nil, err
+	}
+
+	resultUser, err := s.mfaService.VerifyChallenge(ctx, mfaToken, code)
+	if err != nil {
+		logger.Error("Error when trying verify mfa challenge", err, stacktraceLoginMFAService)
+
+		if err.HttpStatusCode == http.StatusUnauthorized {
+			s.registerFailure(ctx, "", ip)
+		}
+		return nil, err
+	}
+
+	if err := checkActive(resultUser); err != nil {
+		logger.Error(err.Message, err, zap.String("user_id", resultUser.ID), stacktraceLoginMFAService)
+		return nil, err
+	}
+
+	tokens, err := s.startSession(ctx, resultUser)
+	if err != nil {
+		logger.Error(err.Error(), err, stacktraceLoginMFAService)
+		return nil, err
+	}
+
+	logger.Info("User was logged successfully with MFA", zap.String("user_id", resultUser.ID), stacktraceLoginMFAService)
+	return tokens, nil
+}
+
+// LoginExternalUser logs in an user already authenticated by an external identity
+// provider. There is no password to check, but MFA is still asked when enabled.
+func (s *loginSvc) LoginExternalUser(ctx context.Context, user *domain.User) (*domain.AuthTokens, *resterrors.RestErr) {
+	logger.Info("Starting Login External User", stacktraceLoginExternalUser)
+
+	if err := checkActive(user); err != nil {
+		logger.Error(err.Message, err, zap.String("user_id", user.ID), stacktraceLoginExternalUser)
+		return nil, err
+	}
+
+	if user.MFAEnabled {
+		mfaToken, err := s.mfaService.CreateChallenge(ctx, user)
+		if err != nil {
+			logger.Error(err.Error(), err, stacktraceLoginExternalUser)
+			return nil, err
+		}
+
+		logger.Info("User must send a MFA code to login", zap.String("user_id", user.ID), stacktraceLoginExternalUser)
+		return &domain.AuthTokens{MFAToken: mfaToken}, nil
+	}
+
+	tokens, err := s.startSession(ctx, user)
+	if err != nil {
+		logger.Error(err.Error(), err, stacktraceLoginExternalUser)
+		return nil, err
+	}
+
+	logger.Info("External user was logged successfully", zap.String("user_id", user.ID), stacktraceLoginExternalUser)
+	return tokens, nil
 }
 
-func (s *loginSvc) validatePassword(password, userPassword string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(userPassword), []byte(password))
-	return err == nil
+// RefreshToken exchanges a refresh token for a new pair of tokens. Every refresh
+// token can be used only once; presenting one that was already rotated means it
+// leaked, so the whole family (every token descending from the same login) is revoked.
+func (s *loginSvc) RefreshToken(ctx context.Context, refreshToken string) (*domain.AuthTokens, *resterrors.RestErr) {
+	logger.Info("Starting Refresh Token", stacktraceRefreshTokenService)
+
+	storedToken, err := s.refreshTokenRepository.FindRefreshTokenByHash(ctx, randtoken.Hash(refreshToken))
+	if err != nil {
+		if err.HttpStatusCode == http.StatusNotFound {
+			logger.Error(errInvalidRefreshToken, err, stacktraceRefreshTokenService)
+			return nil, resterrors.NewUnauthorizedError(errInvalidRefreshToken)
+		}
+
+		logger.Error(errCallRepositoy, err, stacktraceRefreshTokenService)
+		return nil, err
+	}
+
+	if storedToken.IsUsed() {
+		return nil, s.revokeFamily(ctx, storedToken)
+	}
+
+	if storedToken.IsExpired() {
+		logger.Error(errInvalidRefreshToken, nil, stacktraceRefreshTokenService)
+		return nil, resterrors.NewUnauthorizedError(errInvalidRefreshToken)
+	}
+
+	if err := s.refreshTokenRepository.RotateRefreshToken(ctx, storedToken.ID); err != nil {
+		if err.HttpStatusCode == http.StatusNotFound {
+			return nil, s.revokeFamily(ctx, storedToken)
+		}
+
+		logger.Error(errCallRepositoy, err, stacktraceRefreshTokenService)
+		return nil, err
+	}
+
+	resultUser, err := s.userRepository.FindUserById(ctx, storedToken.UserID)
+	if err != nil {
+		if err.HttpStatusCode == http.StatusNotFound {
+			logger.Error(errInvalidRefreshToken, err, stacktraceRefreshTokenService)
+			return nil, resterrors.NewUnauthorizedError(errInvalidRefreshToken)
+		}
+
+		logger.Error(errCallRepositoy, err, stacktraceRefreshTokenService)
+		return nil, err
+	}
+
+	if err := checkActive(resultUser); err != nil {
+		logger.Error(err.Message, err, zap.String("user_id", resultUser.ID), stacktraceRefreshTokenService)
+		return nil, err
+	}
+
+	tokenID, genErr := randtoken.Generate(16)
+	if genErr != nil {
+		logger.Error(errGenerateToken, genErr, stacktraceRefreshTokenService)
+		return nil, resterrors.NewInternalServerError(errGenerateToken)
+	}
+
+	tokens, err := s.issueTokens(ctx, resultUser, storedToken.FamilyID, tokenID)
+	if err != nil {
+		logger.Error(err.Error(), err, stacktraceRefreshTokenService)
+		return nil, err
+	}
+
+	// the families started before the sessions were recorded have no session
+	now := time.Now()
+	expiresAt := now.Add(time.Hour * time.Duration(s.refreshTokenExpTime))
+	if err := s.sessionRepository.TouchSession(ctx, storedToken.FamilyID, tokenID, now, expiresAt); err != nil && err.HttpStatusCode != http.StatusNotFound {
+		logger.Error("Error when trying update session", err, zap.String("session_id", storedToken.FamilyID), stacktraceRefreshTokenService)
+	}
+
+	logger.Info("Token was refreshed successfully", zap.String("user_id", resultUser.ID), stacktraceRefreshTokenService)
+	return tokens, nil
+}
+
+// LogoutUser revokes the access token of the authenticated principal and the refresh
+// tokens of the same login, so the session can't be resumed with the refresh token.
+func (s *loginSvc) LogoutUser(ctx context.Context) *resterrors.RestErr {
+	logger.Info("Starting Logout User", stacktraceLogoutService)
+
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		logger.Error(errUnauthenticated, nil, stacktraceLogoutService)
+		return resterrors.NewUnauthorizedError(errUnauthenticated)
+	}
+
+	if err := s.jwtAuth.RevokeToken(ctx, principal.TokenID, principal.ExpiresAt); err != nil {
+		logger.Error("Error when trying revoke access token", err, stacktraceLogoutService)
+		return err
+	}
+
+	if principal.SessionID != "" {
+		if err := s.refreshTokenRepository.RevokeRefreshTokenFamily(ctx, principal.SessionID); err != nil {
+			logger.Error(errCallRepositoy, err, stacktraceLogoutService)
+			return err
+		}
+	}
+
+	logger.Info("User was logged out successfully", zap.String("user_id", principal.UserID), zap.String("token_id", principal.TokenID), stacktraceLogoutService)
+	return nil
+}
+
+// UnlockUser forgets the failed logins of the email of the user, ending its lockout.
+func (s *loginSvc) UnlockUser(ctx context.Context, userID string) *resterrors.RestErr {
+	logger.Info("Starting Unlock User", stacktraceUnlockUserService)
+
+	resultUser, err := s.userRepository.FindUserById(ctx, userID)
+	if err != nil {
+		logger.Error(errCallRepositoy, err, stacktraceUnlockUserService)
+		return err
+	}
+
+	if err := s.emailLimiter.Reset(ctx, strings.ToLower(strings.TrimSpace(resultUser.Email))); err != nil {
+		logger.Error("Error when trying reset login attempts", err, stacktraceUnlockUserService)
+		return err
+	}
+
+	logger.Info("User was unlocked successfully", zap.String("user_id", userID), auth.ActorField(ctx), stacktraceUnlockUserService)
+	return nil
+}
+
+// checkActive refuses the users that are not active, like the suspended ones, which
+// can't log in nor use their credentials until an admin reactivates them.
+func checkActive(user *domain.User) *resterrors.RestErr {
+	if user.IsActive() {
+		return nil
+	}
+	return resterrors.NewForbiddenError(fmt.Sprintf(errAccountInactive, user.GetStatus())).WithCode(ErrCodeAccountInactive)
+}
+
+// rehashPassword upgrades the hash of a password just verified when it was made with
+// an outdated algorithm or parameters. The login goes on when the upgrade fails, it
+// is tried again on the next one.
+func (s *loginSvc) rehashPassword(ctx context.Context, user *domain.User, password string) {
+	if !s.passwordHasher.NeedsRehash(user.Password) {
+		return
+	}
+
+	rehashedUser := &domain.User{Password: password}
+	if err := rehashedUser.EncryptPassword(s.passwordHasher); err != nil {
+		logger.Error(errEncryptPassword, err, zap.String("user_id", user.ID), stacktraceLoginService)
+		return
+	}
+
+	if err := s.userRepository.UpdatePassword(ctx, user.ID, rehashedUser.Password, 0); err != nil {
+		logger.Error("Error when trying rehash password", err, zap.String("user_id", user.ID), stacktraceLoginService)
+		return
+	}
+
+	user.Password = rehashedUser.Password
+	logger.Info("Password was rehashed", zap.String("user_id", user.ID), stacktraceLoginService)
+}
+
+// checkLockout refuses the login while the email or the ip are waiting after their
+// failures. Empty keys, like the ip of a request that didn't go through the
+// middleware, are not checked.
+func (s *loginSvc) checkLockout(ctx context.Context, emailKey, ip string) *resterrors.RestErr {
+	var retryAfter time.Duration
+
+	if emailKey != "" {
+		wait, err := s.emailLimiter.RetryAfter(ctx, emailKey)
+		if err != nil {
+			return err
+		}
+		retryAfter = wait
+	}
+
+	if ip != "" {
+		wait, err := s.ipLimiter.RetryAfter(ctx, ip)
+		if err != nil {
+			return err
+		}
+		if wait > retryAfter {
+			retryAfter = wait
+		}
+	}
+
+	if retryAfter > 0 {
+		return resterrors.NewTooManyRequestsError(errTooManyAttempts, retryAfter).WithCode(ErrCodeTooManyAttempts)
+	}
+
+	return nil
+}
+
+// registerFailure counts the failed login and returns the error answered to it. The
+// login that reaches the limit still gets invalid credentials, the next one is refused.
+func (s *loginSvc) registerFailure(ctx context.Context, emailKey, ip string) *resterrors.RestErr {
+	if emailKey != "" {
+		if retryAfter, err := s.emailLimiter.RegisterFailure(ctx, emailKey); err != nil {
+			logger.Error("Error when trying register login failure", err, stacktraceLoginService)
+		} else if retryAfter > 0 {
+			logger.Info("Email is locked after failed logins", zap.Duration("retry_after", retryAfter), stacktraceLoginService)
+		}
+	}
+
+	if ip != "" {
+		if retryAfter, err := s.ipLimiter.RegisterFailure(ctx, ip); err != nil {
+			logger.Error("Error when trying register login failure", err, stacktraceLoginService)
+		} else if retryAfter > 0 {
+			logger.Info("IP is locked after failed logins", zap.String("ip", ip), zap.Duration("retry_after", retryAfter), stacktraceLoginService)
+		}
+	}
+
+	return resterrors.NewUnauthorizedError(errInvalidCredentials)
+}
+
+func (s *loginSvc) revokeFamily(ctx context.Context, storedToken *domain.RefreshToken) *resterrors.RestErr {
+	logger.Error(
+		"Refresh token reuse detected, revoking token family",
+		nil,
+		zap.String("user_id", storedToken.UserID),
+		zap.String("family_id", storedToken.FamilyID),
+		stacktraceRefreshTokenService,
+	)
+
+	if err := s.refreshTokenRepository.RevokeRefreshTokenFamily(ctx, storedToken.FamilyID); err != nil {
+		logger.Error(errCallRepositoy, err, stacktraceRefreshTokenService)
+		return err
+	}
+
+	return resterrors.NewUnauthorizedError(errInvalidRefreshToken)
+}
+
+// startSession records the session of a new login and issues its tokens, the id of
+// the session being the refresh token family and the sid of the access tokens.
+func (s *loginSvc) startSession(ctx context.Context, user *domain.User) (*domain.AuthTokens, *resterrors.RestErr) {
+	tokenID, genErr := randtoken.Generate(16)
+	if genErr != nil {
+		return nil, resterrors.NewInternalServerError(errGenerateToken)
+	}
+
+	now := time.Now()
+	clientInfo := auth.ClientInfoFromContext(ctx)
+
+	session, err := s.sessionRepository.CreateSession(ctx, &domain.Session{
+		UserID:     user.ID,
+		UserAgent:  clientInfo.UserAgent,
+		IP:         clientInfo.IP,
+		TokenID:    tokenID,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(time.Hour * time.Duration(s.refreshTokenExpTime)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.issueTokens(ctx, user, session.ID, tokenID)
+}
+
+// issueTokens issues a refresh token of the family and an access token with the
+// given jti, chosen beforehand so the session can record it.
+func (s *loginSvc) issueTokens(ctx context.Context, user *domain.User, familyID, tokenID string) (*domain.AuthTokens, *resterrors.RestErr) {
+	refreshToken, genErr := randtoken.Generate(32)
+	if genErr != nil {
+		return nil, resterrors.NewInternalServerError(errGenerateToken)
+	}
+
+	now := time.Now()
+	_, err := s.refreshTokenRepository.CreateRefreshToken(ctx, &domain.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: randtoken.Hash(refreshToken),
+		ExpiresAt: now.Add(time.Hour * time.Duration(s.refreshTokenExpTime)),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, err := s.jwtAuth.GenerateToken(map[string]any{
+		"jti":   tokenID,
+		"id":    user.ID,
+		"email": user.Email,
+		"name":  user.Name,
+		"roles": user.GetRoles(),
+		"sid":   familyID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.AuthTokens{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+	}, nil
 }
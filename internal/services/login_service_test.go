@@ -2,55 +2,137 @@ package services
 
 import (
 	"context"
+	"fmt"
+	"net/http"
 	"reflect"
 	"testing"
+	"time"
 
+	"github.com/WalterPaes/go-rest-api-crud/internal/auth"
 	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
 	"github.com/WalterPaes/go-rest-api-crud/internal/repositories"
 	"github.com/WalterPaes/go-rest-api-crud/mocks"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/hasher"
 	"github.com/WalterPaes/go-rest-api-crud/pkg/jwt"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/lockout"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/randtoken"
 	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/crypto/bcrypt"
 )
 
 var (
-	claims = map[string]any{
-		"id":    responseUser.ID,
-		"email": responseUser.Email,
-		"name":  responseUser.Name,
-	}
+	claims = mock.MatchedBy(func(c map[string]any) bool {
+		return c["id"] == responseUser.ID &&
+			c["email"] == responseUser.Email &&
+			c["name"] == responseUser.Name &&
+			reflect.DeepEqual(c["roles"], responseUser.GetRoles()) &&
+			c["sid"] != ""
+	})
 
 	token = "token_test"
 
-	unauthorizedError = resterrors.NewUnauthorizedError(errInvalidCredentials)
+	refreshToken     = "refresh_token_test"
+	refreshTokenHash = randtoken.Hash(refreshToken)
+
+	storedRefreshToken = &domain.RefreshToken{
+		ID:        primitive.NewObjectID().Hex(),
+		UserID:    userID,
+		FamilyID:  "family_test",
+		TokenHash: refreshTokenHash,
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+
+	unauthorizedError        = resterrors.NewUnauthorizedError(errInvalidCredentials)
+	invalidRefreshTokenError = resterrors.NewUnauthorizedError(errInvalidRefreshToken)
+	refreshTokenExpTime      = 720
+	anyRefreshToken          = mock.AnythingOfType("*domain.RefreshToken")
+	anySession               = mock.AnythingOfType("*domain.Session")
+	sessionID                = primitive.NewObjectID().Hex()
+
+	loginPolicy = lockout.Policy{
+		FreeAttempts:    2,
+		MaxFailures:     4,
+		BaseDelay:       time.Minute,
+		LockoutDuration: time.Hour,
+	}
 )
 
+// newSessionRepository records the sessions of the logins and refreshes of a test
+// that doesn't check them.
+func newSessionRepository(t *testing.T) repositories.SessionRepository {
+	m := mocks.NewSessionRepository(t)
+	m.On("CreateSession", mock.Anything, anySession).
+		Return(func(_ context.Context, s *domain.Session) *domain.Session {
+			created := *s
+			created.ID = sessionID
+			return &created
+		}, nil).
+		Maybe()
+	m.On("TouchSession", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(nil).
+		Maybe()
+	return m
+}
+
+func newLimiter(prefix string) *lockout.Limiter {
+	return lockout.NewLimiter(lockout.NewMemoryStore(), prefix, loginPolicy)
+}
+
 func Test_loginSvc_LoginUser(t *testing.T) {
+	unverifiedUser := &domain.User{ID: userID, Email: inputUser.Email, Password: inputUser.Password}
+	if err := unverifiedUser.EncryptPassword(passwordHasher); err != nil {
+		t.Fatal(err)
+	}
+
+	verifiedUser := *unverifiedUser
+	verifiedUser.Name = responseUser.Name
+	verifiedUser.EmailVerified = true
+
+	mfaUser := verifiedUser
+	mfaUser.MFAEnabled = true
+
+	suspendedUser := verifiedUser
+	suspendedUser.Status = domain.UserStatusSuspended
+
 	type fields struct {
-		userRepository repositories.UserRepository
-		jwtAuth        jwt.JwtAuth
+		userRepository         repositories.UserRepository
+		refreshTokenRepository repositories.RefreshTokenRepository
+		jwtAuth                jwt.JwtAuth
+		mfaService             MFAService
+		requireVerifiedEmail   bool
 	}
 	type args struct {
 		ctx  context.Context
 		user *domain.User
 	}
 	tests := []struct {
-		name    string
-		fields  fields
-		args    args
-		want    string
-		wantErr *resterrors.RestErr
+		name         string
+		fields       fields
+		args         args
+		want         string
+		wantMFAToken string
+		wantErr      *resterrors.RestErr
 	}{
 		{
 			name: "Should generate a jwt token when user login is successful",
 			fields: fields{
 				userRepository: func() repositories.UserRepository {
-					responseUser.EncryptPassword()
+					responseUser.EncryptPassword(passwordHasher)
 
 					m := mocks.NewUserRepository(t)
 					m.On("FindUserByEmail", ctx, inputUser.Email).
 						Return(responseUser, nil)
 					return m
 				}(),
+				refreshTokenRepository: func() repositories.RefreshTokenRepository {
+					m := mocks.NewRefreshTokenRepository(t)
+					m.On("CreateRefreshToken", ctx, anyRefreshToken).
+						Return(storedRefreshToken, nil)
+					return m
+				}(),
 				jwtAuth: func() jwt.JwtAuth {
 					m := mocks.NewJwtAuth(t)
 					m.On("GenerateToken", claims).
@@ -74,7 +156,8 @@ func Test_loginSvc_LoginUser(t *testing.T) {
 						Return(nil, resterrors.NewNotFoundError("error"))
 					return m
 				}(),
-				jwtAuth: mocks.NewJwtAuth(t),
+				refreshTokenRepository: mocks.NewRefreshTokenRepository(t),
+				jwtAuth:                mocks.NewJwtAuth(t),
 			},
 			args: args{
 				ctx:  ctx,
@@ -92,7 +175,8 @@ func Test_loginSvc_LoginUser(t *testing.T) {
 						Return(nil, internalServerError)
 					return m
 				}(),
-				jwtAuth: mocks.NewJwtAuth(t),
+				refreshTokenRepository: mocks.NewRefreshTokenRepository(t),
+				jwtAuth:                mocks.NewJwtAuth(t),
 			},
 			args: args{
 				ctx:  ctx,
@@ -110,7 +194,8 @@ func Test_loginSvc_LoginUser(t *testing.T) {
 						Return(&domain.User{}, nil)
 					return m
 				}(),
-				jwtAuth: mocks.NewJwtAuth(t),
+				refreshTokenRepository: mocks.NewRefreshTokenRepository(t),
+				jwtAuth:                mocks.NewJwtAuth(t),
 			},
 			args: args{
 				ctx:  ctx,
@@ -128,6 +213,12 @@ func Test_loginSvc_LoginUser(t *testing.T) {
 						Return(responseUser, nil)
 					return m
 				}(),
+				refreshTokenRepository: func() repositories.RefreshTokenRepository {
+					m := mocks.NewRefreshTokenRepository(t)
+					m.On("CreateRefreshToken", ctx, anyRefreshToken).
+						Return(storedRefreshToken, nil)
+					return m
+				}(),
 				jwtAuth: func() jwt.JwtAuth {
 					m := mocks.NewJwtAuth(t)
 					m.On("GenerateToken", claims).
@@ -142,17 +233,780 @@ func Test_loginSvc_LoginUser(t *testing.T) {
 			want:    "",
 			wantErr: internalServerError,
 		},
+		{
+			name: "Should refuse an user with an unverified email when verification is required",
+			fields: fields{
+				userRepository: func() repositories.UserRepository {
+					m := mocks.NewUserRepository(t)
+					m.On("FindUserByEmail", ctx, inputUser.Email).
+						Return(unverifiedUser, nil)
+					return m
+				}(),
+				refreshTokenRepository: mocks.NewRefreshTokenRepository(t),
+				jwtAuth:                mocks.NewJwtAuth(t),
+				requireVerifiedEmail:   true,
+			},
+			args: args{
+				ctx:  ctx,
+				user: inputUser,
+			},
+			want:    "",
+			wantErr: resterrors.NewForbiddenError(errEmailNotVerified).WithCode(ErrCodeEmailNotVerified),
+		},
+		{
+			name: "Should login an user with a verified email when verification is required",
+			fields: fields{
+				userRepository: func() repositories.UserRepository {
+					m := mocks.NewUserRepository(t)
+					m.On("FindUserByEmail", ctx, inputUser.Email).
+						Return(&verifiedUser, nil)
+					return m
+				}(),
+				refreshTokenRepository: func() repositories.RefreshTokenRepository {
+					m := mocks.NewRefreshTokenRepository(t)
+					m.On("CreateRefreshToken", ctx, anyRefreshToken).
+						Return(storedRefreshToken, nil)
+					return m
+				}(),
+				jwtAuth: func() jwt.JwtAuth {
+					m := mocks.NewJwtAuth(t)
+					m.On("GenerateToken", claims).
+						Return(token, nil)
+					return m
+				}(),
+				requireVerifiedEmail: true,
+			},
+			args: args{
+				ctx:  ctx,
+				user: inputUser,
+			},
+			want:    token,
+			wantErr: nil,
+		},
+		{
+			name: "Should refuse a suspended user even with the right password",
+			fields: fields{
+				userRepository: func() repositories.UserRepository {
+					m := mocks.NewUserRepository(t)
+					m.On("FindUserByEmail", ctx, inputUser.Email).
+						Return(&suspendedUser, nil)
+					return m
+				}(),
+				refreshTokenRepository: mocks.NewRefreshTokenRepository(t),
+				jwtAuth:                mocks.NewJwtAuth(t),
+			},
+			args: args{
+				ctx:  ctx,
+				user: inputUser,
+			},
+			want:    "",
+			wantErr: resterrors.NewForbiddenError("Account is suspended").WithCode(ErrCodeAccountInactive),
+		},
+		{
+			name: "Should return a mfa token instead of the tokens when the user has MFA enabled",
+			fields: fields{
+				userRepository: func() repositories.UserRepository {
+					m := mocks.NewUserRepository(t)
+					m.On("FindUserByEmail", ctx, inputUser.Email).
+						Return(&mfaUser, nil)
+					return m
+				}(),
+				refreshTokenRepository: mocks.NewRefreshTokenRepository(t),
+				jwtAuth:                mocks.NewJwtAuth(t),
+				mfaService: func() MFAService {
+					m := mocks.NewMFAService(t)
+					m.On("CreateChallenge", ctx, &mfaUser).
+						Return("mfa_token", nil)
+					return m
+				}(),
+			},
+			args: args{
+				ctx:  ctx,
+				user: inputUser,
+			},
+			want:         "",
+			wantMFAToken: "mfa_token",
+			wantErr:      nil,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			s := NewLoginService(tt.fields.userRepository, tt.fields.jwtAuth)
+			if tt.fields.mfaService == nil {
+				tt.fields.mfaService = mocks.NewMFAService(t)
+			}
+			s := NewLoginService(tt.fields.userRepository, tt.fields.refreshTokenRepository, newSessionRepository(t), tt.fields.jwtAuth, tt.fields.mfaService, newLimiter("email:"), newLimiter("ip:"), passwordHasher, refreshTokenExpTime, tt.fields.requireVerifiedEmail)
 			got, err := s.LoginUser(tt.args.ctx, tt.args.user)
-			if got != tt.want {
+			if got := accessToken(got); got != tt.want {
 				t.Errorf("loginSvc.LoginUser() got = %v, want %v", got, tt.want)
 			}
+			if got := mfaToken(got); got != tt.wantMFAToken {
+				t.Errorf("loginSvc.LoginUser() mfa token = %v, want %v", got, tt.wantMFAToken)
+			}
 			if !reflect.DeepEqual(err, tt.wantErr) {
 				t.Errorf("loginSvc.LoginUser() err = %v, want %v", err, tt.wantErr)
 			}
 		})
 	}
 }
+
+func Test_loginSvc_RefreshToken(t *testing.T) {
+	rotatedAt := time.Now()
+	rotatedRefreshToken := *storedRefreshToken
+	rotatedRefreshToken.RotatedAt = &rotatedAt
+
+	expiredRefreshToken := *storedRefreshToken
+	expiredRefreshToken.ExpiresAt = time.Now().Add(-time.Hour)
+
+	type fields struct {
+		userRepository         repositories.UserRepository
+		refreshTokenRepository repositories.RefreshTokenRepository
+		jwtAuth                jwt.JwtAuth
+	}
+	type args struct {
+		ctx          context.Context
+		refreshToken string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    string
+		wantErr *resterrors.RestErr
+	}{
+		{
+			name: "Should rotate the refresh token and generate a new jwt token",
+			fields: fields{
+				userRepository: func() repositories.UserRepository {
+					m := mocks.NewUserRepository(t)
+					m.On("FindUserById", ctx, userID).
+						Return(responseUser, nil)
+					return m
+				}(),
+				refreshTokenRepository: func() repositories.RefreshTokenRepository {
+					m := mocks.NewRefreshTokenRepository(t)
+					m.On("FindRefreshTokenByHash", ctx, refreshTokenHash).
+						Return(storedRefreshToken, nil)
+					m.On("RotateRefreshToken", ctx, storedRefreshToken.ID).
+						Return(nil)
+					m.On("CreateRefreshToken", ctx, mock.MatchedBy(func(rt *domain.RefreshToken) bool {
+						return rt.FamilyID == storedRefreshToken.FamilyID && rt.TokenHash != refreshTokenHash
+					})).
+						Return(storedRefreshToken, nil)
+					return m
+				}(),
+				jwtAuth: func() jwt.JwtAuth {
+					m := mocks.NewJwtAuth(t)
+					m.On("GenerateToken", claims).
+						Return(token, nil)
+					return m
+				}(),
+			},
+			args: args{
+				ctx:          ctx,
+				refreshToken: refreshToken,
+			},
+			want:    token,
+			wantErr: nil,
+		},
+		{
+			name: "Should refuse to refresh the token of a suspended user",
+			fields: fields{
+				userRepository: func() repositories.UserRepository {
+					m := mocks.NewUserRepository(t)
+					m.On("FindUserById", ctx, userID).
+						Return(&domain.User{ID: userID, Status: domain.UserStatusSuspended}, nil)
+					return m
+				}(),
+				refreshTokenRepository: func() repositories.RefreshTokenRepository {
+					m := mocks.NewRefreshTokenRepository(t)
+					m.On("FindRefreshTokenByHash", ctx, refreshTokenHash).
+						Return(storedRefreshToken, nil)
+					m.On("RotateRefreshToken", ctx, storedRefreshToken.ID).
+						Return(nil)
+					return m
+				}(),
+				jwtAuth: mocks.NewJwtAuth(t),
+			},
+			args: args{
+				ctx:          ctx,
+				refreshToken: refreshToken,
+			},
+			want:    "",
+			wantErr: resterrors.NewForbiddenError("Account is suspended").WithCode(ErrCodeAccountInactive),
+		},
+		{
+			name: "Should return an unauthorized error when refresh token is unknown",
+			fields: fields{
+				userRepository: mocks.NewUserRepository(t),
+				refreshTokenRepository: func() repositories.RefreshTokenRepository {
+					m := mocks.NewRefreshTokenRepository(t)
+					m.On("FindRefreshTokenByHash", ctx, refreshTokenHash).
+						Return(nil, resterrors.NewNotFoundError("error"))
+					return m
+				}(),
+				jwtAuth: mocks.NewJwtAuth(t),
+			},
+			args: args{
+				ctx:          ctx,
+				refreshToken: refreshToken,
+			},
+			want:    "",
+			wantErr: invalidRefreshTokenError,
+		},
+		{
+			name: "Should revoke the token family when a rotated refresh token is reused",
+			fields: fields{
+				userRepository: mocks.NewUserRepository(t),
+				refreshTokenRepository: func() repositories.RefreshTokenRepository {
+					m := mocks.NewRefreshTokenRepository(t)
+					m.On("FindRefreshTokenByHash", ctx, refreshTokenHash).
+						Return(&rotatedRefreshToken, nil)
+					m.On("RevokeRefreshTokenFamily", ctx, storedRefreshToken.FamilyID).
+						Return(nil)
+					return m
+				}(),
+				jwtAuth: mocks.NewJwtAuth(t),
+			},
+			args: args{
+				ctx:          ctx,
+				refreshToken: refreshToken,
+			},
+			want:    "",
+			wantErr: invalidRefreshTokenError,
+		},
+		{
+			name: "Should revoke the token family when the refresh token was rotated concurrently",
+			fields: fields{
+				userRepository: mocks.NewUserRepository(t),
+				refreshTokenRepository: func() repositories.RefreshTokenRepository {
+					m := mocks.NewRefreshTokenRepository(t)
+					m.On("FindRefreshTokenByHash", ctx, refreshTokenHash).
+						Return(storedRefreshToken, nil)
+					m.On("RotateRefreshToken", ctx, storedRefreshToken.ID).
+						Return(resterrors.NewNotFoundError("error"))
+					m.On("RevokeRefreshTokenFamily", ctx, storedRefreshToken.FamilyID).
+						Return(nil)
+					return m
+				}(),
+				jwtAuth: mocks.NewJwtAuth(t),
+			},
+			args: args{
+				ctx:          ctx,
+				refreshToken: refreshToken,
+			},
+			want:    "",
+			wantErr: invalidRefreshTokenError,
+		},
+		{
+			name: "Should return an unauthorized error when refresh token is expired",
+			fields: fields{
+				userRepository: mocks.NewUserRepository(t),
+				refreshTokenRepository: func() repositories.RefreshTokenRepository {
+					m := mocks.NewRefreshTokenRepository(t)
+					m.On("FindRefreshTokenByHash", ctx, refreshTokenHash).
+						Return(&expiredRefreshToken, nil)
+					return m
+				}(),
+				jwtAuth: mocks.NewJwtAuth(t),
+			},
+			args: args{
+				ctx:          ctx,
+				refreshToken: refreshToken,
+			},
+			want:    "",
+			wantErr: invalidRefreshTokenError,
+		},
+		{
+			name: "Should return an error when try find the refresh token",
+			fields: fields{
+				userRepository: mocks.NewUserRepository(t),
+				refreshTokenRepository: func() repositories.RefreshTokenRepository {
+					m := mocks.NewRefreshTokenRepository(t)
+					m.On("FindRefreshTokenByHash", ctx, refreshTokenHash).
+						Return(nil, internalServerError)
+					return m
+				}(),
+				jwtAuth: mocks.NewJwtAuth(t),
+			},
+			args: args{
+				ctx:          ctx,
+				refreshToken: refreshToken,
+			},
+			want:    "",
+			wantErr: internalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewLoginService(tt.fields.userRepository, tt.fields.refreshTokenRepository, newSessionRepository(t), tt.fields.jwtAuth, mocks.NewMFAService(t), newLimiter("email:"), newLimiter("ip:"), passwordHasher, refreshTokenExpTime, false)
+			got, err := s.RefreshToken(tt.args.ctx, tt.args.refreshToken)
+			if got := accessToken(got); got != tt.want {
+				t.Errorf("loginSvc.RefreshToken() got = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(err, tt.wantErr) {
+				t.Errorf("loginSvc.RefreshToken() err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_loginSvc_Sessions(t *testing.T) {
+	storedUser := &domain.User{ID: userID, Name: responseUser.Name, Email: inputUser.Email, Password: inputUser.Password}
+	if err := storedUser.EncryptPassword(passwordHasher); err != nil {
+		t.Fatal(err)
+	}
+
+	clientCtx := auth.WithClientInfo(ctx, &domain.ClientInfo{IP: "10.0.0.1", UserAgent: "Mozilla/5.0"})
+
+	t.Run("Should record the session of a login with the device and the jti of its token", func(t *testing.T) {
+		userRepository := mocks.NewUserRepository(t)
+		userRepository.On("FindUserByEmail", clientCtx, inputUser.Email).
+			Return(storedUser, nil)
+
+		var recordedSession *domain.Session
+		sessionRepository := mocks.NewSessionRepository(t)
+		sessionRepository.On("CreateSession", clientCtx, mock.MatchedBy(func(s *domain.Session) bool {
+			return s.UserID == userID && s.IP == "10.0.0.1" && s.UserAgent == "Mozilla/5.0" && s.TokenID != ""
+		})).
+			Return(func(_ context.Context, s *domain.Session) *domain.Session {
+				recordedSession = s
+				created := *s
+				created.ID = sessionID
+				return &created
+			}, nil)
+
+		refreshTokenRepository := mocks.NewRefreshTokenRepository(t)
+		refreshTokenRepository.On("CreateRefreshToken", clientCtx, mock.MatchedBy(func(rt *domain.RefreshToken) bool {
+			return rt.FamilyID == sessionID
+		})).
+			Return(storedRefreshToken, nil)
+
+		jwtAuth := mocks.NewJwtAuth(t)
+		jwtAuth.On("GenerateToken", mock.MatchedBy(func(c map[string]any) bool {
+			return c["sid"] == sessionID && recordedSession != nil && c["jti"] == recordedSession.TokenID
+		})).
+			Return(token, nil)
+
+		s := NewLoginService(userRepository, refreshTokenRepository, sessionRepository, jwtAuth, mocks.NewMFAService(t), newLimiter("email:"), newLimiter("ip:"), passwordHasher, refreshTokenExpTime, false)
+
+		tokens, err := s.LoginUser(clientCtx, inputUser)
+
+		assert.Nil(t, err)
+		assert.Equal(t, token, accessToken(tokens))
+	})
+
+	t.Run("Should update the session with the token issued by a refresh", func(t *testing.T) {
+		userRepository := mocks.NewUserRepository(t)
+		userRepository.On("FindUserById", ctx, userID).
+			Return(responseUser, nil)
+
+		refreshTokenRepository := mocks.NewRefreshTokenRepository(t)
+		refreshTokenRepository.On("FindRefreshTokenByHash", ctx, refreshTokenHash).
+			Return(storedRefreshToken, nil)
+		refreshTokenRepository.On("RotateRefreshToken", ctx, storedRefreshToken.ID).
+			Return(nil)
+		refreshTokenRepository.On("CreateRefreshToken", ctx, anyRefreshToken).
+			Return(storedRefreshToken, nil)
+
+		var tokenID string
+		jwtAuth := mocks.NewJwtAuth(t)
+		jwtAuth.On("GenerateToken", claims).
+			Run(func(args mock.Arguments) {
+				tokenID, _ = args.Get(0).(map[string]any)["jti"].(string)
+			}).
+			Return(token, nil)
+
+		sessionRepository := mocks.NewSessionRepository(t)
+		sessionRepository.On("TouchSession", ctx, storedRefreshToken.FamilyID, mock.MatchedBy(func(id string) bool {
+			return id != "" && id == tokenID
+		}), mock.AnythingOfType("time.Time"), mock.AnythingOfType("time.Time")).
+			Return(resterrors.NewNotFoundError("Session Not Found"))
+
+		s := NewLoginService(userRepository, refreshTokenRepository, sessionRepository, jwtAuth, mocks.NewMFAService(t), newLimiter("email:"), newLimiter("ip:"), passwordHasher, refreshTokenExpTime, false)
+
+		tokens, err := s.RefreshToken(ctx, refreshToken)
+
+		assert.Nil(t, err, "a family without session is still refreshed")
+		assert.Equal(t, token, accessToken(tokens))
+	})
+}
+
+func Test_loginSvc_LoginUser_Lockout(t *testing.T) {
+	storedUser := &domain.User{ID: userID, Name: responseUser.Name, Email: inputUser.Email, Password: inputUser.Password}
+	if err := storedUser.EncryptPassword(passwordHasher); err != nil {
+		t.Fatal(err)
+	}
+
+	wrongPassword := &domain.User{Email: inputUser.Email, Password: "wrong@123"}
+	clientCtx := auth.WithClientInfo(ctx, &domain.ClientInfo{IP: "10.0.0.1"})
+
+	newLoginService := func(userRepository repositories.UserRepository, emailLimiter, ipLimiter *lockout.Limiter) LoginService {
+		refreshTokenRepository := mocks.NewRefreshTokenRepository(t)
+		refreshTokenRepository.On("CreateRefreshToken", mock.Anything, anyRefreshToken).
+			Return(storedRefreshToken, nil).Maybe()
+
+		jwtAuth := mocks.NewJwtAuth(t)
+		jwtAuth.On("GenerateToken", claims).
+			Return(token, nil).Maybe()
+
+		return NewLoginService(userRepository, refreshTokenRepository, newSessionRepository(t), jwtAuth, mocks.NewMFAService(t), emailLimiter, ipLimiter, passwordHasher, refreshTokenExpTime, false)
+	}
+
+	t.Run("Should refuse the email with too many failures without checking the password", func(t *testing.T) {
+		userRepository := mocks.NewUserRepository(t)
+		userRepository.On("FindUserByEmail", clientCtx, inputUser.Email).
+			Return(storedUser, nil).Times(loginPolicy.FreeAttempts + 1)
+
+		s := newLoginService(userRepository, newLimiter("email:"), newLimiter("ip:"))
+
+		for i := 0; i <= loginPolicy.FreeAttempts; i++ {
+			_, err := s.LoginUser(clientCtx, wrongPassword)
+			assert.Equal(t, unauthorizedError, err)
+		}
+
+		_, err := s.LoginUser(clientCtx, inputUser)
+		assert.Equal(t, http.StatusTooManyRequests, err.HttpStatusCode)
+		assert.Equal(t, ErrCodeTooManyAttempts, err.Code)
+		assert.InDelta(t, loginPolicy.BaseDelay.Seconds(), err.RetryAfter, 1)
+	})
+
+	t.Run("Should count the failures of unknown emails by client ip", func(t *testing.T) {
+		userRepository := mocks.NewUserRepository(t)
+		userRepository.On("FindUserByEmail", mock.Anything, mock.Anything).
+			Return(nil, resterrors.NewNotFoundError("not found")).Times(loginPolicy.FreeAttempts + 2)
+
+		s := newLoginService(userRepository, newLimiter("email:"), newLimiter("ip:"))
+
+		for i := 0; i <= loginPolicy.FreeAttempts; i++ {
+			_, err := s.LoginUser(clientCtx, &domain.User{Email: fmt.Sprintf("user%d@email.com", i), Password: "wrong@123"})
+			assert.Equal(t, unauthorizedError, err)
+		}
+
+		_, err := s.LoginUser(clientCtx, &domain.User{Email: "other@email.com", Password: "wrong@123"})
+		assert.Equal(t, http.StatusTooManyRequests, err.HttpStatusCode)
+
+		_, err = s.LoginUser(auth.WithClientInfo(ctx, &domain.ClientInfo{IP: "10.0.0.2"}), &domain.User{Email: "other@email.com", Password: "wrong@123"})
+		assert.NotEqual(t, http.StatusTooManyRequests, err.HttpStatusCode)
+	})
+
+	t.Run("Should forget the failures of the email after a login", func(t *testing.T) {
+		userRepository := mocks.NewUserRepository(t)
+		userRepository.On("FindUserByEmail", clientCtx, inputUser.Email).
+			Return(storedUser, nil)
+
+		emailLimiter := newLimiter("email:")
+		s := newLoginService(userRepository, emailLimiter, newLimiter("ip:"))
+
+		s.LoginUser(clientCtx, wrongPassword)
+
+		_, err := s.LoginUser(clientCtx, inputUser)
+		assert.Nil(t, err)
+
+		s.LoginUser(clientCtx, wrongPassword)
+
+		retryAfter, _ := emailLimiter.RetryAfter(ctx, inputUser.Email)
+		assert.Zero(t, retryAfter)
+	})
+
+	t.Run("Should unlock the email of an user", func(t *testing.T) {
+		userRepository := mocks.NewUserRepository(t)
+		userRepository.On("FindUserById", ctx, userID).
+			Return(storedUser, nil)
+
+		emailLimiter := newLimiter("email:")
+		for i := 0; i < loginPolicy.MaxFailures; i++ {
+			emailLimiter.RegisterFailure(ctx, inputUser.Email)
+		}
+
+		s := newLoginService(userRepository, emailLimiter, newLimiter("ip:"))
+
+		assert.Nil(t, s.UnlockUser(ctx, userID))
+
+		retryAfter, _ := emailLimiter.RetryAfter(ctx, inputUser.Email)
+		assert.Zero(t, retryAfter)
+	})
+}
+
+func Test_loginSvc_LoginUser_Rehash(t *testing.T) {
+	outdatedHasher, _ := hasher.NewBcrypt(bcrypt.MinCost + 1)
+
+	newStoredUser := func() *domain.User {
+		user := &domain.User{ID: userID, Name: responseUser.Name, Email: inputUser.Email, Password: inputUser.Password}
+		if err := user.EncryptPassword(outdatedHasher); err != nil {
+			t.Fatal(err)
+		}
+		return user
+	}
+
+	newLoginService := func(userRepository repositories.UserRepository) LoginService {
+		refreshTokenRepository := mocks.NewRefreshTokenRepository(t)
+		refreshTokenRepository.On("CreateRefreshToken", ctx, anyRefreshToken).
+			Return(storedRefreshToken, nil)
+
+		jwtAuth := mocks.NewJwtAuth(t)
+		jwtAuth.On("GenerateToken", claims).
+			Return(token, nil)
+
+		return NewLoginService(userRepository, refreshTokenRepository, newSessionRepository(t), jwtAuth, mocks.NewMFAService(t), newLimiter("email:"), newLimiter("ip:"), passwordHasher, refreshTokenExpTime, false)
+	}
+
+	t.Run("Should rehash a password hashed with outdated parameters", func(t *testing.T) {
+		storedUser := newStoredUser()
+
+		userRepository := mocks.NewUserRepository(t)
+		userRepository.On("FindUserByEmail", ctx, inputUser.Email).
+			Return(storedUser, nil)
+		userRepository.On("UpdatePassword", ctx, userID, mock.MatchedBy(func(hash string) bool {
+			return !passwordHasher.NeedsRehash(hash) && passwordHasher.Verify(hash, inputUser.Password)
+		}), 0).Return(nil)
+
+		_, err := newLoginService(userRepository).LoginUser(ctx, inputUser)
+		assert.Nil(t, err)
+	})
+
+	t.Run("Should login even when the rehashed password can't be saved", func(t *testing.T) {
+		userRepository := mocks.NewUserRepository(t)
+		userRepository.On("FindUserByEmail", ctx, inputUser.Email).
+			Return(newStoredUser(), nil)
+		userRepository.On("UpdatePassword", ctx, userID, mock.Anything, 0).
+			Return(internalServerError)
+
+		_, err := newLoginService(userRepository).LoginUser(ctx, inputUser)
+		assert.Nil(t, err)
+	})
+}
+
+func Test_loginSvc_LoginMFA(t *testing.T) {
+	type fields struct {
+		refreshTokenRepository repositories.RefreshTokenRepository
+		jwtAuth                jwt.JwtAuth
+		mfaService             MFAService
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		want    string
+		wantErr *resterrors.RestErr
+	}{
+		{
+			name: "Should generate a jwt token when the mfa code is valid",
+			fields: fields{
+				refreshTokenRepository: func() repositories.RefreshTokenRepository {
+					m := mocks.NewRefreshTokenRepository(t)
+					m.On("CreateRefreshToken", ctx, anyRefreshToken).
+						Return(storedRefreshToken, nil)
+					return m
+				}(),
+				jwtAuth: func() jwt.JwtAuth {
+					m := mocks.NewJwtAuth(t)
+					m.On("GenerateToken", claims).
+						Return(token, nil)
+					return m
+				}(),
+				mfaService: func() MFAService {
+					m := mocks.NewMFAService(t)
+					m.On("VerifyChallenge", ctx, "mfa_token", "123456").
+						Return(responseUser, nil)
+					return m
+				}(),
+			},
+			want:    token,
+			wantErr: nil,
+		},
+		{
+			name: "Should return an error when the mfa code is invalid",
+			fields: fields{
+				refreshTokenRepository: mocks.NewRefreshTokenRepository(t),
+				jwtAuth:                mocks.NewJwtAuth(t),
+				mfaService: func() MFAService {
+					m := mocks.NewMFAService(t)
+					m.On("VerifyChallenge", ctx, "mfa_token", "123456").
+						Return(nil, resterrors.NewUnauthorizedError(errInvalidMFACode))
+					return m
+				}(),
+			},
+			want:    "",
+			wantErr: resterrors.NewUnauthorizedError(errInvalidMFACode),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewLoginService(mocks.NewUserRepository(t), tt.fields.refreshTokenRepository, newSessionRepository(t), tt.fields.jwtAuth, tt.fields.mfaService, newLimiter("email:"), newLimiter("ip:"), passwordHasher, refreshTokenExpTime, false)
+			got, err := s.LoginMFA(ctx, "mfa_token", "123456")
+			if got := accessToken(got); got != tt.want {
+				t.Errorf("loginSvc.LoginMFA() got = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(err, tt.wantErr) {
+				t.Errorf("loginSvc.LoginMFA() err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_loginSvc_LoginExternalUser(t *testing.T) {
+	mfaUser := *responseUser
+	mfaUser.MFAEnabled = true
+
+	type fields struct {
+		refreshTokenRepository repositories.RefreshTokenRepository
+		jwtAuth                jwt.JwtAuth
+		mfaService             MFAService
+	}
+	tests := []struct {
+		name         string
+		fields       fields
+		user         *domain.User
+		want         string
+		wantMFAToken string
+		wantErr      *resterrors.RestErr
+	}{
+		{
+			name: "Should generate a jwt token for the user without checking a password",
+			fields: fields{
+				refreshTokenRepository: func() repositories.RefreshTokenRepository {
+					m := mocks.NewRefreshTokenRepository(t)
+					m.On("CreateRefreshToken", ctx, anyRefreshToken).
+						Return(storedRefreshToken, nil)
+					return m
+				}(),
+				jwtAuth: func() jwt.JwtAuth {
+					m := mocks.NewJwtAuth(t)
+					m.On("GenerateToken", claims).
+						Return(token, nil)
+					return m
+				}(),
+				mfaService: mocks.NewMFAService(t),
+			},
+			user:    responseUser,
+			want:    token,
+			wantErr: nil,
+		},
+		{
+			name: "Should return a mfa token instead of the tokens when the user has MFA enabled",
+			fields: fields{
+				refreshTokenRepository: mocks.NewRefreshTokenRepository(t),
+				jwtAuth:                mocks.NewJwtAuth(t),
+				mfaService: func() MFAService {
+					m := mocks.NewMFAService(t)
+					m.On("CreateChallenge", ctx, &mfaUser).
+						Return("mfa_token", nil)
+					return m
+				}(),
+			},
+			user:         &mfaUser,
+			want:         "",
+			wantMFAToken: "mfa_token",
+			wantErr:      nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewLoginService(mocks.NewUserRepository(t), tt.fields.refreshTokenRepository, newSessionRepository(t), tt.fields.jwtAuth, tt.fields.mfaService, newLimiter("email:"), newLimiter("ip:"), passwordHasher, refreshTokenExpTime, false)
+			got, err := s.LoginExternalUser(ctx, tt.user)
+			if got := accessToken(got); got != tt.want {
+				t.Errorf("loginSvc.LoginExternalUser() got = %v, want %v", got, tt.want)
+			}
+			if got := mfaToken(got); got != tt.wantMFAToken {
+				t.Errorf("loginSvc.LoginExternalUser() mfa token = %v, want %v", got, tt.wantMFAToken)
+			}
+			if !reflect.DeepEqual(err, tt.wantErr) {
+				t.Errorf("loginSvc.LoginExternalUser() err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_loginSvc_LogoutUser(t *testing.T) {
+	principal := &domain.Principal{
+		UserID:    userID,
+		TokenID:   "token_id",
+		SessionID: storedRefreshToken.FamilyID,
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	authCtx := auth.WithPrincipal(ctx, principal)
+
+	type fields struct {
+		refreshTokenRepository repositories.RefreshTokenRepository
+		jwtAuth                jwt.JwtAuth
+	}
+	type args struct {
+		ctx context.Context
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		wantErr *resterrors.RestErr
+	}{
+		{
+			name: "Should revoke the access token and the refresh tokens of its session",
+			fields: fields{
+				refreshTokenRepository: func() repositories.RefreshTokenRepository {
+					m := mocks.NewRefreshTokenRepository(t)
+					m.On("RevokeRefreshTokenFamily", authCtx, principal.SessionID).
+						Return(nil)
+					return m
+				}(),
+				jwtAuth: func() jwt.JwtAuth {
+					m := mocks.NewJwtAuth(t)
+					m.On("RevokeToken", authCtx, principal.TokenID, principal.ExpiresAt).
+						Return(nil)
+					return m
+				}(),
+			},
+			args: args{
+				ctx: authCtx,
+			},
+			wantErr: nil,
+		},
+		{
+			name: "Should return an error when try revoke the access token",
+			fields: fields{
+				refreshTokenRepository: mocks.NewRefreshTokenRepository(t),
+				jwtAuth: func() jwt.JwtAuth {
+					m := mocks.NewJwtAuth(t)
+					m.On("RevokeToken", authCtx, principal.TokenID, principal.ExpiresAt).
+						Return(internalServerError)
+					return m
+				}(),
+			},
+			args: args{
+				ctx: authCtx,
+			},
+			wantErr: internalServerError,
+		},
+		{
+			name: "Should return an error when there is no authenticated user",
+			fields: fields{
+				refreshTokenRepository: mocks.NewRefreshTokenRepository(t),
+				jwtAuth:                mocks.NewJwtAuth(t),
+			},
+			args: args{
+				ctx: ctx,
+			},
+			wantErr: resterrors.NewUnauthorizedError(errUnauthenticated),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewLoginService(mocks.NewUserRepository(t), tt.fields.refreshTokenRepository, newSessionRepository(t), tt.fields.jwtAuth, mocks.NewMFAService(t), newLimiter("email:"), newLimiter("ip:"), passwordHasher, refreshTokenExpTime, false)
+			if got := s.LogoutUser(tt.args.ctx); !reflect.DeepEqual(got, tt.wantErr) {
+				t.Errorf("loginSvc.LogoutUser() = %v, want %v", got, tt.wantErr)
+			}
+		})
+	}
+}
+
+func accessToken(tokens *domain.AuthTokens) string {
+	if tokens == nil {
+		return ""
+	}
+	return tokens.AccessToken
+}
+
+func mfaToken(tokens *domain.AuthTokens) string {
+	if tokens == nil {
+		return ""
+	}
+	return tokens.MFAToken
+}
@@ -0,0 +1,288 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	"github.com/WalterPaes/go-rest-api-crud/internal/repositories"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/logger"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/randtoken"
+	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/signer"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/totp"
+	"go.uber.org/zap"
+)
+
+const (
+	errMFAAlreadyEnabled  = "MFA is already enabled"
+	errMFANotEnrolled     = "MFA enrollment was not started"
+	errInvalidMFACode     = "MFA Code is Invalid"
+	errInvalidMFAToken    = "MFA Token is Invalid"
+	errGenerateMFASecret  = "Error when trying generate mfa secret"
+	errGenerateRecovery   = "Error when trying generate recovery codes"
+	errSignMFAToken       = "Error when trying sign mfa token"
+	errGenerateChallenge  = "Error when trying generate mfa challenge"
+	mfaChallengePurpose   = "mfa_challenge"
+	mfaChallengeIDSize    = 16
+	mfaChallengeMaxFails  = 5
+	recoveryCodesQuantity = 10
+	recoveryCodeSize      = 5
+)
+
+var (
+	stacktraceEnrollMFAService       = zap.String("stacktrace", "enroll-mfa-service")
+	stacktraceConfirmMFAService      = zap.String("stacktrace", "confirm-mfa-service")
+	stacktraceCreateChallengeService = zap.String("stacktrace", "create-mfa-challenge-service")
+	stacktraceVerifyChallengeService = zap.String("stacktrace", "verify-mfa-challenge-service")
+)
+
+type MFAService interface {
+	Enroll(ctx context.Context, userID string) (*domain.MFAEnrollment, *resterrors.RestErr)
+	Confirm(ctx context.Context, userID, code string) ([]string, *resterrors.RestErr)
+	CreateChallenge(ctx context.Context, user *domain.User) (string, *resterrors.RestErr)
+	VerifyChallenge(ctx context.Context, mfaToken, code string) (*domain.User, *resterrors.RestErr)
+}
+
+type mfaSvc struct {
+	userRepository   repositories.UserRepository
+	signer           *signer.Signer
+	issuer           string
+	challengeExpTime int
+}
+
+// mfaChallengeData is signed in the token a login gets when the user has MFA enabled.
+// The purpose keeps other signed tokens from being accepted as a challenge and the ID
+// is the challenge stored with the user, which ends on the first right code or after
+// mfaChallengeMaxFails wrong ones.
+type mfaChallengeData struct {
+	UserID      string `json:"uid"`
+	ChallengeID string `json:"cid"`
+	Purpose     string `json:"purpose"`
+}
+
+// NewMFAService builds the MFA service. issuer is the name authenticator apps show
+// and challengeExpTime, in minutes, is how long a login has to send the code.
+func NewMFAService(
+	userRepository repositories.UserRepository,
+	signer *signer.Signer,
+	issuer string,
+	challengeExpTime int,
+) *mfaSvc {
+	return &mfaSvc{
+		userRepository:   userRepository,
+		signer:           signer,
+		issuer:           issuer,
+		challengeExpTime: challengeExpTime,
+	}
+}
+
+// Enroll generates a new secret for the user. MFA is only enabled once Confirm gets
+// a code generated with it, enrolling again before that replaces the secret.
+func (s *mfaSvc) Enroll(ctx context.Context, userID string) (*domain.MFAEnrollment, *resterrors.RestErr) {
+	logger.Info("Starting Enroll MFA", stacktraceEnrollMFAService)
+
+	user, err := s.userRepository.FindUserById(ctx, userID)
+	if err != nil {
+		logger.Error(errCallRepositoy, err, stacktraceEnrollMFAService)
+		return nil, err
+	}
+
+	if user.MFAEnabled {
+		logger.Error(errMFAAlreadyEnabled, nil, zap.String("user_id", userID), stacktraceEnrollMFAService)
+		return nil, resterrors.NewBadRequestError(errMFAAlreadyEnabled)
+	}
+
+	secret, genErr := totp.GenerateSecret()
+	if genErr != nil {
+		logger.Error(errGenerateMFASecret, genErr, stacktraceEnrollMFAService)
+		return nil, resterrors.NewInternalServerError(errGenerateMFASecret)
+	}
+
+	if err := s.userRepository.SetMFASecret(ctx, userID, secret); err != nil {
+		if err.HttpStatusCode == http.StatusNotFound {
+			logger.Error(errMFAAlreadyEnabled, err, stacktraceEnrollMFAService)
+			return nil, resterrors.NewBadRequestError(errMFAAlreadyEnabled)
+		}
+
+		logger.Error(errCallRepositoy, err, stacktraceEnrollMFAService)
+		return nil, err
+	}
+
+	logger.Info("MFA enrollment was started successfully", zap.String("user_id", userID), stacktraceEnrollMFAService)
+	return &domain.MFAEnrollment{
+		Secret: secret,
+		URI:    totp.URI(s.issuer, user.Email, secret),
+	}, nil
+}
+
+// Confirm enables MFA when the code was generated with the enrolled secret. The
+// recovery codes are only returned here, they are stored hashed.
+func (s *mfaSvc) Confirm(ctx context.Context, userID, code string) ([]string, *resterrors.RestErr) {
+	logger.Info("Starting Confirm MFA", stacktraceConfirmMFAService)
+
+	user, err := s.userRepository.FindUserById(ctx, userID)
+	if err != nil {
+		logger.Error(errCallRepositoy, err, stacktraceConfirmMFAService)
+		return nil, err
+	}
+
+	if user.MFAEnabled {
+		logger.Error(errMFAAlreadyEnabled, nil, zap.String("user_id", userID), stacktraceConfirmMFAService)
+		return nil, resterrors.NewBadRequestError(errMFAAlreadyEnabled)
+	}
+
+	if user.MFASecret == "" {
+		logger.Error(errMFANotEnrolled, nil, zap.String("user_id", userID), stacktraceConfirmMFAService)
+		return nil, resterrors.NewBadRequestError(errMFANotEnrolled)
+	}
+
+	counter, ok := totp.Validate(user.MFASecret, code, time.Now())
+	if !ok {
+		logger.Error(errInvalidMFACode, nil, zap.String("user_id", userID), stacktraceConfirmMFAService)
+		return nil, resterrors.NewBadRequestError(errInvalidMFACode)
+	}
+
+	recoveryCodes, recoveryCodeHashes, genErr := generateRecoveryCodes()
+	if genErr != nil {
+		logger.Error(errGenerateRecovery, genErr, stacktraceConfirmMFAService)
+		return nil, resterrors.NewInternalServerError(errGenerateRecovery)
+	}
+
+	if err := s.userRepository.EnableMFA(ctx, userID, counter, recoveryCodeHashes); err != nil {
+		if err.HttpStatusCode == http.StatusNotFound {
+			logger.Error(errMFAAlreadyEnabled, err, stacktraceConfirmMFAService)
+			return nil, resterrors.NewBadRequestError(errMFAAlreadyEnabled)
+		}
+
+		logger.Error(errCallRepositoy, err, stacktraceConfirmMFAService)
+		return nil, err
+	}
+
+	logger.Info("MFA was enabled successfully", zap.String("user_id", userID), stacktraceConfirmMFAService)
+	return recoveryCodes, nil
+}
+
+// CreateChallenge returns the short-lived token a login exchanges for the tokens
+// of the user together with a code.
+func (s *mfaSvc) CreateChallenge(ctx context.Context, user *domain.User) (string, *resterrors.RestErr) {
+	logger.Info("Starting Create MFA Challenge", stacktraceCreateChallengeService)
+
+	challengeID, genErr := randtoken.Generate(mfaChallengeIDSize)
+	if genErr != nil {
+		logger.Error(errGenerateChallenge, genErr, stacktraceCreateChallengeService)
+		return "", resterrors.NewInternalServerError(errGenerateChallenge)
+	}
+
+	if err := s.userRepository.StartMFAChallenge(ctx, user.ID, randtoken.Hash(challengeID)); err != nil {
+		logger.Error(errCallRepositoy, err, stacktraceCreateChallengeService)
+		return "", err
+	}
+
+	expiresAt := time.Now().Add(time.Minute * time.Duration(s.challengeExpTime))
+
+	data := mfaChallengeData{UserID: user.ID, ChallengeID: challengeID, Purpose: mfaChallengePurpose}
+	mfaToken, err := s.signer.Sign(data, expiresAt)
+	if err != nil {
+		logger.Error(errSignMFAToken, err, stacktraceCreateChallengeService)
+		return "", resterrors.NewInternalServerError(errSignMFAToken)
+	}
+
+	logger.Info("MFA challenge was created successfully", zap.String("user_id", user.ID), stacktraceCreateChallengeService)
+	return mfaToken, nil
+}
+
+// VerifyChallenge returns the user of the challenge when the code is a TOTP code not
+// used yet or one of its recovery codes, which is then consumed. The challenge can
+// only be answered once and is dropped after mfaChallengeMaxFails wrong codes, so
+// the guesses are bounded per user and not only per client ip.
+func (s *mfaSvc) VerifyChallenge(ctx context.Context, mfaToken, code string) (*domain.User, *resterrors.RestErr) {
+	logger.Info("Starting Verify MFA Challenge", stacktraceVerifyChallengeService)
+
+	var data mfaChallengeData
+	if err := s.signer.Verify(mfaToken, &data); err != nil || data.Purpose != mfaChallengePurpose {
+		logger.Error(errInvalidMFAToken, err, stacktraceVerifyChallengeService)
+		return nil, resterrors.NewUnauthorizedError(errInvalidMFAToken)
+	}
+
+	user, err := s.userRepository.FindUserById(ctx, data.UserID)
+	if err != nil {
+		if err.HttpStatusCode == http.StatusNotFound {
+			logger.Error(errInvalidMFAToken, err, stacktraceVerifyChallengeService)
+			return nil, resterrors.NewUnauthorizedError(errInvalidMFAToken)
+		}
+
+		logger.Error(errCallRepositoy, err, stacktraceVerifyChallengeService)
+		return nil, err
+	}
+
+	challengeHash := randtoken.Hash(data.ChallengeID)
+	if !user.MFAEnabled || data.ChallengeID == "" || user.MFAChallenge != challengeHash {
+		logger.Error(errInvalidMFAToken, nil, zap.String("user_id", user.ID), stacktraceVerifyChallengeService)
+		return nil, resterrors.NewUnauthorizedError(errInvalidMFAToken)
+	}
+
+	if err := s.useCode(ctx, user, code); err != nil {
+		if err.HttpStatusCode == http.StatusNotFound {
+			logger.Error(errInvalidMFACode, err, zap.String("user_id", user.ID), stacktraceVerifyChallengeService)
+			if err := s.userRepository.FailMFAChallenge(ctx, user.ID, challengeHash, mfaChallengeMaxFails); err != nil {
+				logger.Error(errCallRepositoy, err, stacktraceVerifyChallengeService)
+			}
+			return nil, resterrors.NewUnauthorizedError(errInvalidMFACode)
+		}
+
+		logger.Error(errCallRepositoy, err, stacktraceVerifyChallengeService)
+		return nil, err
+	}
+
+	if err := s.userRepository.EndMFAChallenge(ctx, user.ID, challengeHash); err != nil {
+		if err.HttpStatusCode == http.StatusNotFound {
+			logger.Error(errInvalidMFAToken, err, zap.String("user_id", user.ID), stacktraceVerifyChallengeService)
+			return nil, resterrors.NewUnauthorizedError(errInvalidMFAToken)
+		}
+
+		logger.Error(errCallRepositoy, err, stacktraceVerifyChallengeService)
+		return nil, err
+	}
+
+	logger.Info("MFA challenge was verified successfully", zap.String("user_id", user.ID), stacktraceVerifyChallengeService)
+	return user, nil
+}
+
+// useCode consumes a TOTP code or a recovery code, a code that can't be used is
+// reported as not found.
+func (s *mfaSvc) useCode(ctx context.Context, user *domain.User, code string) *resterrors.RestErr {
+	if counter, ok := totp.Validate(user.MFASecret, code, time.Now()); ok {
+		return s.userRepository.UseMFACounter(ctx, user.ID, counter)
+	}
+
+	return s.userRepository.UseRecoveryCode(ctx, user.ID, randtoken.Hash(normalizeRecoveryCode(code)))
+}
+
+// generateRecoveryCodes returns the codes shown to the user and the hashes stored.
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodesQuantity)
+	hashes := make([]string, recoveryCodesQuantity)
+
+	for i := range codes {
+		b := make([]byte, recoveryCodeSize*2)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+
+		code := hex.EncodeToString(b)
+		codes[i] = code[:recoveryCodeSize*2] + "-" + code[recoveryCodeSize*2:]
+		hashes[i] = randtoken.Hash(normalizeRecoveryCode(codes[i]))
+	}
+
+	return codes, hashes, nil
+}
+
+// normalizeRecoveryCode accepts the codes typed without the dash or in upper case.
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
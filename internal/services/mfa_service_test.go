@@ -0,0 +1,410 @@
+package services
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	"github.com/WalterPaes/go-rest-api-crud/internal/repositories"
+	"github.com/WalterPaes/go-rest-api-crud/mocks"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/randtoken"
+	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var (
+	mfaIssuer           = "go-rest-api-crud"
+	mfaChallengeExpTime = 5
+)
+
+// mfaUser returns an user with a new secret, a valid code and the step of the code.
+func mfaUser(t *testing.T, enabled bool) (*domain.User, string, int64) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counter := totp.Counter(time.Now())
+	code, err := totp.Code(secret, counter)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &domain.User{ID: userID, Email: responseUser.Email, MFAEnabled: enabled, MFASecret: secret}, code, counter
+}
+
+func Test_mfaSvc_Enroll(t *testing.T) {
+	enabledUser, _, _ := mfaUser(t, true)
+
+	tests := []struct {
+		name           string
+		userRepository repositories.UserRepository
+		wantErr        *resterrors.RestErr
+	}{
+		{
+			name: "Should generate a secret and its otpauth uri",
+			userRepository: func() repositories.UserRepository {
+				m := mocks.NewUserRepository(t)
+				m.On("FindUserById", ctx, userID).
+					Return(responseUser, nil)
+
+				m.On("SetMFASecret", ctx, userID, mock.AnythingOfType("string")).
+					Return(nil)
+				return m
+			}(),
+			wantErr: nil,
+		},
+		{
+			name: "Should return an error when MFA is already enabled",
+			userRepository: func() repositories.UserRepository {
+				m := mocks.NewUserRepository(t)
+				m.On("FindUserById", ctx, userID).
+					Return(enabledUser, nil)
+				return m
+			}(),
+			wantErr: resterrors.NewBadRequestError(errMFAAlreadyEnabled),
+		},
+		{
+			name: "Should return an error when MFA was enabled concurrently",
+			userRepository: func() repositories.UserRepository {
+				m := mocks.NewUserRepository(t)
+				m.On("FindUserById", ctx, userID).
+					Return(responseUser, nil)
+
+				m.On("SetMFASecret", ctx, userID, mock.AnythingOfType("string")).
+					Return(resterrors.NewNotFoundError("not found"))
+				return m
+			}(),
+			wantErr: resterrors.NewBadRequestError(errMFAAlreadyEnabled),
+		},
+		{
+			name: "Should return an error when try call repository to find the user",
+			userRepository: func() repositories.UserRepository {
+				m := mocks.NewUserRepository(t)
+				m.On("FindUserById", ctx, userID).
+					Return(nil, internalServerError)
+				return m
+			}(),
+			wantErr: internalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewMFAService(tt.userRepository, verificationSigner, mfaIssuer, mfaChallengeExpTime)
+
+			got, err := s.Enroll(ctx, userID)
+			if !reflect.DeepEqual(err, tt.wantErr) {
+				t.Errorf("mfaSvc.Enroll() err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil {
+				assert.NotEmpty(t, got.Secret)
+				assert.True(t, strings.HasPrefix(got.URI, "otpauth://totp/"+mfaIssuer))
+				assert.Contains(t, got.URI, got.Secret)
+			}
+		})
+	}
+}
+
+func Test_mfaSvc_Confirm(t *testing.T) {
+	pendingUser, code, counter := mfaUser(t, false)
+	enabledUser, _, _ := mfaUser(t, true)
+
+	var storedHashes []string
+
+	tests := []struct {
+		name           string
+		userRepository repositories.UserRepository
+		code           string
+		wantErr        *resterrors.RestErr
+	}{
+		{
+			name: "Should enable MFA and return the recovery codes",
+			userRepository: func() repositories.UserRepository {
+				m := mocks.NewUserRepository(t)
+				m.On("FindUserById", ctx, userID).
+					Return(pendingUser, nil)
+
+				m.On("EnableMFA", ctx, userID, counter, mock.MatchedBy(func(hashes []string) bool {
+					storedHashes = hashes
+					return len(hashes) == recoveryCodesQuantity
+				})).Return(nil)
+				return m
+			}(),
+			code:    code,
+			wantErr: nil,
+		},
+		{
+			name: "Should return an error when the code is invalid",
+			userRepository: func() repositories.UserRepository {
+				m := mocks.NewUserRepository(t)
+				m.On("FindUserById", ctx, userID).
+					Return(pendingUser, nil)
+				return m
+			}(),
+			code:    "000000x",
+			wantErr: resterrors.NewBadRequestError(errInvalidMFACode),
+		},
+		{
+			name: "Should return an error when the enrollment was not started",
+			userRepository: func() repositories.UserRepository {
+				m := mocks.NewUserRepository(t)
+				m.On("FindUserById", ctx, userID).
+					Return(responseUser, nil)
+				return m
+			}(),
+			code:    code,
+			wantErr: resterrors.NewBadRequestError(errMFANotEnrolled),
+		},
+		{
+			name: "Should return an error when MFA is already enabled",
+			userRepository: func() repositories.UserRepository {
+				m := mocks.NewUserRepository(t)
+				m.On("FindUserById", ctx, userID).
+					Return(enabledUser, nil)
+				return m
+			}(),
+			code:    code,
+			wantErr: resterrors.NewBadRequestError(errMFAAlreadyEnabled),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewMFAService(tt.userRepository, verificationSigner, mfaIssuer, mfaChallengeExpTime)
+
+			got, err := s.Confirm(ctx, userID, tt.code)
+			if !reflect.DeepEqual(err, tt.wantErr) {
+				t.Errorf("mfaSvc.Confirm() err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil {
+				assert.Len(t, got, recoveryCodesQuantity)
+				for i, recoveryCode := range got {
+					assert.Equal(t, storedHashes[i], randtoken.Hash(normalizeRecoveryCode(recoveryCode)))
+				}
+			}
+		})
+	}
+}
+
+func Test_mfaSvc_CreateChallenge(t *testing.T) {
+	enabledUser, _, _ := mfaUser(t, true)
+
+	t.Run("Should store the hash of the challenge signed in the token", func(t *testing.T) {
+		var storedHash string
+		userRepository := mocks.NewUserRepository(t)
+		userRepository.On("StartMFAChallenge", ctx, userID, mock.AnythingOfType("string")).
+			Run(func(args mock.Arguments) { storedHash = args.String(2) }).
+			Return(nil)
+
+		s := NewMFAService(userRepository, verificationSigner, mfaIssuer, mfaChallengeExpTime)
+
+		mfaToken, err := s.CreateChallenge(ctx, enabledUser)
+		assert.Nil(t, err)
+
+		var data mfaChallengeData
+		assert.Nil(t, verificationSigner.Verify(mfaToken, &data))
+		assert.Equal(t, mfaChallengePurpose, data.Purpose)
+		assert.Equal(t, storedHash, randtoken.Hash(data.ChallengeID))
+	})
+
+	t.Run("Should return an error when try store the challenge", func(t *testing.T) {
+		userRepository := mocks.NewUserRepository(t)
+		userRepository.On("StartMFAChallenge", ctx, userID, mock.AnythingOfType("string")).
+			Return(internalServerError)
+
+		s := NewMFAService(userRepository, verificationSigner, mfaIssuer, mfaChallengeExpTime)
+
+		mfaToken, err := s.CreateChallenge(ctx, enabledUser)
+		assert.Equal(t, internalServerError, err)
+		assert.Empty(t, mfaToken)
+	})
+}
+
+func Test_mfaSvc_VerifyChallenge(t *testing.T) {
+	enabledUser, code, counter := mfaUser(t, true)
+	recoveryCode := "0123456789-ABCDEF0123"
+
+	challengeRepository := mocks.NewUserRepository(t)
+	challengeRepository.On("StartMFAChallenge", ctx, userID, mock.AnythingOfType("string")).
+		Run(func(args mock.Arguments) { enabledUser.MFAChallenge = args.String(2) }).
+		Return(nil)
+
+	s := NewMFAService(challengeRepository, verificationSigner, mfaIssuer, mfaChallengeExpTime)
+	mfaToken, err := s.CreateChallenge(ctx, enabledUser)
+	assert.Nil(t, err)
+	challengeHash := enabledUser.MFAChallenge
+
+	replacedUser := *enabledUser
+	replacedUser.MFAChallenge = randtoken.Hash("another challenge")
+
+	invalidTokenError := resterrors.NewUnauthorizedError(errInvalidMFAToken)
+	invalidCodeError := resterrors.NewUnauthorizedError(errInvalidMFACode)
+
+	tests := []struct {
+		name           string
+		userRepository repositories.UserRepository
+		mfaToken       string
+		code           string
+		wantErr        *resterrors.RestErr
+	}{
+		{
+			name: "Should accept a TOTP code not used yet",
+			userRepository: func() repositories.UserRepository {
+				m := mocks.NewUserRepository(t)
+				m.On("FindUserById", ctx, userID).
+					Return(enabledUser, nil)
+
+				m.On("UseMFACounter", ctx, userID, counter).
+					Return(nil)
+
+				m.On("EndMFAChallenge", ctx, userID, challengeHash).
+					Return(nil)
+				return m
+			}(),
+			mfaToken: mfaToken,
+			code:     code,
+			wantErr:  nil,
+		},
+		{
+			name: "Should accept a recovery code typed in another format",
+			userRepository: func() repositories.UserRepository {
+				m := mocks.NewUserRepository(t)
+				m.On("FindUserById", ctx, userID).
+					Return(enabledUser, nil)
+
+				m.On("UseRecoveryCode", ctx, userID, randtoken.Hash("0123456789abcdef0123")).
+					Return(nil)
+
+				m.On("EndMFAChallenge", ctx, userID, challengeHash).
+					Return(nil)
+				return m
+			}(),
+			mfaToken: mfaToken,
+			code:     recoveryCode,
+			wantErr:  nil,
+		},
+		{
+			name: "Should refuse a TOTP code already used",
+			userRepository: func() repositories.UserRepository {
+				m := mocks.NewUserRepository(t)
+				m.On("FindUserById", ctx, userID).
+					Return(enabledUser, nil)
+
+				m.On("UseMFACounter", ctx, userID, counter).
+					Return(resterrors.NewNotFoundError("not found"))
+
+				m.On("FailMFAChallenge", ctx, userID, challengeHash, mfaChallengeMaxFails).
+					Return(nil)
+				return m
+			}(),
+			mfaToken: mfaToken,
+			code:     code,
+			wantErr:  invalidCodeError,
+		},
+		{
+			name: "Should refuse the code even when the failure can't be counted",
+			userRepository: func() repositories.UserRepository {
+				m := mocks.NewUserRepository(t)
+				m.On("FindUserById", ctx, userID).
+					Return(enabledUser, nil)
+
+				m.On("UseRecoveryCode", ctx, userID, randtoken.Hash("wrong")).
+					Return(resterrors.NewNotFoundError("not found"))
+
+				m.On("FailMFAChallenge", ctx, userID, challengeHash, mfaChallengeMaxFails).
+					Return(internalServerError)
+				return m
+			}(),
+			mfaToken: mfaToken,
+			code:     "wrong",
+			wantErr:  invalidCodeError,
+		},
+		{
+			name: "Should refuse a challenge replaced by a new login or ended",
+			userRepository: func() repositories.UserRepository {
+				m := mocks.NewUserRepository(t)
+				m.On("FindUserById", ctx, userID).
+					Return(&replacedUser, nil)
+				return m
+			}(),
+			mfaToken: mfaToken,
+			code:     code,
+			wantErr:  invalidTokenError,
+		},
+		{
+			name: "Should refuse a challenge answered at the same time by another login",
+			userRepository: func() repositories.UserRepository {
+				m := mocks.NewUserRepository(t)
+				m.On("FindUserById", ctx, userID).
+					Return(enabledUser, nil)
+
+				m.On("UseMFACounter", ctx, userID, counter).
+					Return(nil)
+
+				m.On("EndMFAChallenge", ctx, userID, challengeHash).
+					Return(resterrors.NewNotFoundError("not found"))
+				return m
+			}(),
+			mfaToken: mfaToken,
+			code:     code,
+			wantErr:  invalidTokenError,
+		},
+		{
+			name:           "Should refuse a token signed for another purpose",
+			userRepository: mocks.NewUserRepository(t),
+			mfaToken:       verificationToken(t, verificationSigner, responseUser.Email, time.Now().Add(time.Hour)),
+			code:           code,
+			wantErr:        invalidTokenError,
+		},
+		{
+			name:           "Should refuse an invalid token",
+			userRepository: mocks.NewUserRepository(t),
+			mfaToken:       "invalid",
+			code:           code,
+			wantErr:        invalidTokenError,
+		},
+		{
+			name: "Should refuse the token when the user disabled MFA",
+			userRepository: func() repositories.UserRepository {
+				m := mocks.NewUserRepository(t)
+				m.On("FindUserById", ctx, userID).
+					Return(responseUser, nil)
+				return m
+			}(),
+			mfaToken: mfaToken,
+			code:     code,
+			wantErr:  invalidTokenError,
+		},
+		{
+			name: "Should return an error when try call repository to use the code",
+			userRepository: func() repositories.UserRepository {
+				m := mocks.NewUserRepository(t)
+				m.On("FindUserById", ctx, userID).
+					Return(enabledUser, nil)
+
+				m.On("UseMFACounter", ctx, userID, counter).
+					Return(internalServerError)
+				return m
+			}(),
+			mfaToken: mfaToken,
+			code:     code,
+			wantErr:  internalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewMFAService(tt.userRepository, verificationSigner, mfaIssuer, mfaChallengeExpTime)
+
+			got, err := s.VerifyChallenge(ctx, tt.mfaToken, tt.code)
+			if !reflect.DeepEqual(err, tt.wantErr) {
+				t.Errorf("mfaSvc.VerifyChallenge() err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil {
+				assert.Equal(t, enabledUser, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package services
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/WalterPaes/go-rest-api-crud/internal/auth"
+	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	"github.com/WalterPaes/go-rest-api-crud/internal/repositories"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/jwt"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/logger"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/randtoken"
+	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
+	"go.uber.org/zap"
+)
+
+const (
+	errInvalidClientCredentials = "Client Credentials are Invalid"
+	errInvalidClientScope       = "Requested scope is not allowed for the client"
+	errGenerateClientCredential = "Error when trying generate client credentials"
+	oauthClientIDSize           = 16
+	oauthClientSecretSize       = 32
+)
+
+var (
+	stacktraceRegisterOAuthClientService    = zap.String("stacktrace", "register-oauth-client-service")
+	stacktraceListOAuthClientsService       = zap.String("stacktrace", "list-oauth-clients-service")
+	stacktraceDeleteOAuthClientService      = zap.String("stacktrace", "delete-oauth-client-service")
+	stacktraceClientCredentialsTokenService = zap.String("stacktrace", "client-credentials-token-service")
+)
+
+type OAuthService interface {
+	RegisterClient(ctx context.Context, name string, scopes []string) (*domain.OAuthClient, string, *resterrors.RestErr)
+	ListClients(ctx context.Context) ([]*domain.OAuthClient, *resterrors.RestErr)
+	DeleteClient(ctx context.Context, clientID string) *resterrors.RestErr
+	ClientCredentialsToken(ctx context.Context, clientID, clientSecret, scope string) (*domain.OAuthToken, *resterrors.RestErr)
+}
+
+type oauthSvc struct {
+	oauthClientRepository repositories.OAuthClientRepository
+	jwtAuth               jwt.JwtAuth
+	accessTokenExpTime    int
+}
+
+// NewOAuthService builds the OAuth service. accessTokenExpTime, in minutes, is the
+// lifetime of the access tokens issued to the clients.
+func NewOAuthService(
+	oauthClientRepository repositories.OAuthClientRepository,
+	jwtAuth jwt.JwtAuth,
+	accessTokenExpTime int,
+) *oauthSvc {
+	return &oauthSvc{
+		oauthClientRepository: oauthClientRepository,
+		jwtAuth:               jwtAuth,
+		accessTokenExpTime:    accessTokenExpTime,
+	}
+}
+
+// RegisterClient returns the stored client and its secret, which can't be recovered
+// later since only its hash is stored.
+func (s *oauthSvc) RegisterClient(ctx context.Context, name string, scopes []string) (*domain.OAuthClient, string, *resterrors.RestErr) {
+	logger.Info("Starting Register OAuth Client", stacktraceRegisterOAuthClientService)
+
+	clientID, err := randtoken.Generate(oauthClientIDSize)
+	if err != nil {
+		logger.Error(errGenerateClientCredential, err, stacktraceRegisterOAuthClientService)
+		return nil, "", resterrors.NewInternalServerError(errGenerateClientCredential)
+	}
+
+	clientSecret, err := randtoken.Generate(oauthClientSecretSize)
+	if err != nil {
+		logger.Error(errGenerateClientCredential, err, stacktraceRegisterOAuthClientService)
+		return nil, "", resterrors.NewInternalServerError(errGenerateClientCredential)
+	}
+
+	client, restErr := s.oauthClientRepository.CreateOAuthClient(ctx, &domain.OAuthClient{
+		ClientID:   clientID,
+		Name:       name,
+		SecretHash: randtoken.Hash(clientSecret),
+		Scopes:     scopes,
+		CreatedAt:  time.Now(),
+	})
+	if restErr != nil {
+		logger.Error(errCallRepositoy, restErr, stacktraceRegisterOAuthClientService)
+		return nil, "", restErr
+	}
+
+	logger.Info("OAuth Client Registered Successfully", zap.String("client_id", client.ClientID), auth.ActorField(ctx), stacktraceRegisterOAuthClientService)
+	return client, clientSecret, nil
+}
+
+func (s *oauthSvc) ListClients(ctx context.Context) ([]*domain.OAuthClient, *resterrors.RestErr) {
+	logger.Info("Starting List OAuth Clients", stacktraceListOAuthClientsService)
+
+	clients, err := s.oauthClientRepository.FindOAuthClients(ctx)
+	if err != nil {
+		logger.Error(errCallRepositoy, err, stacktraceListOAuthClientsService)
+		return nil, err
+	}
+
+	logger.Info("OAuth Clients Listed Successfully", stacktraceListOAuthClientsService)
+	return clients, nil
+}
+
+// DeleteClient refuses new tokens to the client, the tokens already issued are
+// accepted until they expire.
+func (s *oauthSvc) DeleteClient(ctx context.Context, clientID string) *resterrors.RestErr {
+	logger.Info("Starting Delete OAuth Client", stacktraceDeleteOAuthClientService)
+
+	if err := s.oauthClientRepository.DeleteOAuthClient(ctx, clientID); err != nil {
+		logger.Error(errCallRepositoy, err, stacktraceDeleteOAuthClientService)
+		return err
+	}
+
+	logger.Info("OAuth Client Deleted Successfully", zap.String("client_id", clientID), auth.ActorField(ctx), stacktraceDeleteOAuthClientService)
+	return nil
+}
+
+// ClientCredentialsToken issues an access token to the client for the requested
+// scopes, space separated. When no scope is requested every scope of the client is granted.
+func (s *oauthSvc) ClientCredentialsToken(ctx context.Context, clientID, clientSecret, scope string) (*domain.OAuthToken, *resterrors.RestErr) {
+	logger.Info("Starting Client Credentials Token", stacktraceClientCredentialsTokenService)
+
+	client, err := s.oauthClientRepository.FindOAuthClientByClientID(ctx, clientID)
+	if err != nil {
+		if err.HttpStatusCode == http.StatusNotFound {
+			logger.Error(errInvalidClientCredentials, err, zap.String("client_id", clientID), stacktraceClientCredentialsTokenService)
+			return nil, resterrors.NewUnauthorizedError(errInvalidClientCredentials).WithCode(domain.OAuthErrInvalidClient)
+		}
+
+		logger.Error(errCallRepositoy, err, stacktraceClientCredentialsTokenService)
+		return nil, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(randtoken.Hash(clientSecret)), []byte(client.SecretHash)) != 1 {
+		logger.Error(errInvalidClientCredentials, nil, zap.String("client_id", clientID), stacktraceClientCredentialsTokenService)
+		return nil, resterrors.NewUnauthorizedError(errInvalidClientCredentials).WithCode(domain.OAuthErrInvalidClient)
+	}
+
+	scopes := strings.Fields(scope)
+	if len(scopes) == 0 {
+		scopes = client.Scopes
+	}
+
+	for _, requestedScope := range scopes {
+		if !client.AllowsScope(requestedScope) {
+			logger.Error(errInvalidClientScope, nil, zap.String("client_id", clientID), zap.String("scope", requestedScope), stacktraceClientCredentialsTokenService)
+			return nil, resterrors.NewBadRequestError(errInvalidClientScope).WithCode(domain.OAuthErrInvalidScope)
+		}
+	}
+
+	expiresIn := time.Minute * time.Duration(s.accessTokenExpTime)
+	accessToken, err := s.jwtAuth.GenerateToken(map[string]any{
+		"sub":       client.ClientID,
+		"client_id": client.ClientID,
+		"scope":     strings.Join(scopes, " "),
+		"exp":       time.Now().Add(expiresIn).Unix(),
+	})
+	if err != nil {
+		logger.Error("Error when trying generate client access token", err, stacktraceClientCredentialsTokenService)
+		return nil, err
+	}
+
+	logger.Info("Client Access Token Issued Successfully", zap.String("client_id", clientID), stacktraceClientCredentialsTokenService)
+	return &domain.OAuthToken{
+		AccessToken: accessToken,
+		ExpiresIn:   int(expiresIn.Seconds()),
+		Scopes:      scopes,
+	}, nil
+}
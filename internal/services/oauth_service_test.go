@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	"github.com/WalterPaes/go-rest-api-crud/internal/repositories"
+	"github.com/WalterPaes/go-rest-api-crud/mocks"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/jwt"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/randtoken"
+	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var (
+	oauthAccessTokenExpTime = 60
+	oauthClientSecret       = "client-secret"
+	registeredOAuthClient   = &domain.OAuthClient{
+		ClientID:   "client-id",
+		Name:       "billing",
+		SecretHash: randtoken.Hash(oauthClientSecret),
+		Scopes:     []string{domain.ScopeUsersRead, domain.ScopeUsersWrite},
+	}
+)
+
+func Test_oauthSvc_RegisterClient(t *testing.T) {
+	oauthClientRepository := mocks.NewOAuthClientRepository(t)
+	oauthClientRepository.On("CreateOAuthClient", ctx, mock.MatchedBy(func(client *domain.OAuthClient) bool {
+		return client.ClientID != "" && client.SecretHash != "" && client.Name == "billing"
+	})).
+		Return(func(_ context.Context, client *domain.OAuthClient) *domain.OAuthClient {
+			return client
+		}, nil)
+
+	s := NewOAuthService(oauthClientRepository, mocks.NewJwtAuth(t), oauthAccessTokenExpTime)
+
+	client, clientSecret, err := s.RegisterClient(ctx, "billing", []string{domain.ScopeUsersRead})
+
+	assert.Nil(t, err)
+	assert.NotEmpty(t, clientSecret)
+	assert.Equal(t, randtoken.Hash(clientSecret), client.SecretHash)
+	assert.Equal(t, []string{domain.ScopeUsersRead}, client.Scopes)
+}
+
+func Test_oauthSvc_DeleteClient(t *testing.T) {
+	oauthClientRepository := mocks.NewOAuthClientRepository(t)
+	oauthClientRepository.On("DeleteOAuthClient", ctx, "unknown").
+		Return(resterrors.NewNotFoundError("OAuth Client Not Found"))
+
+	s := NewOAuthService(oauthClientRepository, mocks.NewJwtAuth(t), oauthAccessTokenExpTime)
+
+	assert.Equal(t, resterrors.NewNotFoundError("OAuth Client Not Found"), s.DeleteClient(ctx, "unknown"))
+}
+
+func Test_oauthSvc_ClientCredentialsToken(t *testing.T) {
+	findClient := func(client *domain.OAuthClient, err *resterrors.RestErr) repositories.OAuthClientRepository {
+		m := mocks.NewOAuthClientRepository(t)
+		m.On("FindOAuthClientByClientID", ctx, "client-id").
+			Return(client, err)
+		return m
+	}
+
+	generateToken := func(scope string) jwt.JwtAuth {
+		m := mocks.NewJwtAuth(t)
+		m.On("GenerateToken", mock.MatchedBy(func(claims map[string]any) bool {
+			exp, _ := claims["exp"].(int64)
+			return claims["sub"] == "client-id" &&
+				claims["client_id"] == "client-id" &&
+				claims["scope"] == scope &&
+				time.Until(time.Unix(exp, 0)) <= time.Hour
+		})).
+			Return("access-token", nil)
+		return m
+	}
+
+	tests := []struct {
+		name                  string
+		clientSecret          string
+		scope                 string
+		oauthClientRepository repositories.OAuthClientRepository
+		jwtAuth               jwt.JwtAuth
+		want                  *domain.OAuthToken
+		wantErr               *resterrors.RestErr
+	}{
+		{
+			name:                  "Should grant every scope of the client when no scope is requested",
+			clientSecret:          oauthClientSecret,
+			oauthClientRepository: findClient(registeredOAuthClient, nil),
+			jwtAuth:               generateToken("users:read users:write"),
+			want: &domain.OAuthToken{
+				AccessToken: "access-token",
+				ExpiresIn:   3600,
+				Scopes:      []string{domain.ScopeUsersRead, domain.ScopeUsersWrite},
+			},
+			wantErr: nil,
+		},
+		{
+			name:                  "Should grant only the requested scopes",
+			clientSecret:          oauthClientSecret,
+			scope:                 "users:read",
+			oauthClientRepository: findClient(registeredOAuthClient, nil),
+			jwtAuth:               generateToken("users:read"),
+			want: &domain.OAuthToken{
+				AccessToken: "access-token",
+				ExpiresIn:   3600,
+				Scopes:      []string{domain.ScopeUsersRead},
+			},
+			wantErr: nil,
+		},
+		{
+			name:                  "Should return invalid scope when the client wasn't registered with the scope",
+			clientSecret:          oauthClientSecret,
+			scope:                 "users:read admin",
+			oauthClientRepository: findClient(registeredOAuthClient, nil),
+			jwtAuth:               mocks.NewJwtAuth(t),
+			wantErr:               resterrors.NewBadRequestError(errInvalidClientScope).WithCode(domain.OAuthErrInvalidScope),
+		},
+		{
+			name:                  "Should return invalid client when the secret is wrong",
+			clientSecret:          "wrong-secret",
+			oauthClientRepository: findClient(registeredOAuthClient, nil),
+			jwtAuth:               mocks.NewJwtAuth(t),
+			wantErr:               resterrors.NewUnauthorizedError(errInvalidClientCredentials).WithCode(domain.OAuthErrInvalidClient),
+		},
+		{
+			name:                  "Should return invalid client when the client is unknown",
+			clientSecret:          oauthClientSecret,
+			oauthClientRepository: findClient(nil, resterrors.NewNotFoundError("OAuth Client Not Found")),
+			jwtAuth:               mocks.NewJwtAuth(t),
+			wantErr:               resterrors.NewUnauthorizedError(errInvalidClientCredentials).WithCode(domain.OAuthErrInvalidClient),
+		},
+		{
+			name:                  "Should return an error when try find the client",
+			clientSecret:          oauthClientSecret,
+			oauthClientRepository: findClient(nil, resterrors.NewInternalServerError("Error When Try Find OAuth Client")),
+			jwtAuth:               mocks.NewJwtAuth(t),
+			wantErr:               resterrors.NewInternalServerError("Error When Try Find OAuth Client"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewOAuthService(tt.oauthClientRepository, tt.jwtAuth, oauthAccessTokenExpTime)
+
+			got, err := s.ClientCredentialsToken(ctx, "client-id", tt.clientSecret, tt.scope)
+
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
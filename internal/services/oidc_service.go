@@ -0,0 +1,195 @@
+package services
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	"github.com/WalterPaes/go-rest-api-crud/internal/repositories"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/logger"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/oidc"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/randtoken"
+	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/signer"
+	"go.uber.org/zap"
+)
+
+const (
+	errInvalidOIDCLogin     = "OIDC Login is Invalid or Expired"
+	errOIDCAuthentication   = "Identity provider didn't authenticate the user"
+	errOIDCEmailNotVerified = "Email of the identity provider is not verified"
+	errStartOIDCLogin       = "Error when trying start oidc login"
+	oidcLoginPurpose        = "oidc_login"
+
+	ErrCodeInvalidOIDCLogin = "invalid_oidc_login"
+)
+
+var (
+	stacktraceStartOIDCLoginService  = zap.String("stacktrace", "start-oidc-login-service")
+	stacktraceFinishOIDCLoginService = zap.String("stacktrace", "finish-oidc-login-service")
+)
+
+type OIDCService interface {
+	StartLogin(ctx context.Context) (authURL, flowToken string, err *resterrors.RestErr)
+	FinishLogin(ctx context.Context, flowToken, state, code string) (*domain.AuthTokens, *resterrors.RestErr)
+}
+
+type oidcSvc struct {
+	provider       oidc.Provider
+	userRepository repositories.UserRepository
+	loginService   LoginService
+	signer         *signer.Signer
+	flowExpTime    int
+}
+
+// oidcFlowData is signed in the flow token kept by the browser between the redirect
+// to the provider and its callback. The state ties the callback to the browser that
+// started the login and the nonce ties the ID token to it.
+type oidcFlowData struct {
+	State        string `json:"state"`
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"cv"`
+	Purpose      string `json:"purpose"`
+}
+
+// NewOIDCService builds the OIDC login service. flowExpTime, in minutes, is how long
+// the user has to log in on the provider.
+func NewOIDCService(
+	provider oidc.Provider,
+	userRepository repositories.UserRepository,
+	loginService LoginService,
+	signer *signer.Signer,
+	flowExpTime int,
+) *oidcSvc {
+	return &oidcSvc{
+		provider:       provider,
+		userRepository: userRepository,
+		loginService:   loginService,
+		signer:         signer,
+		flowExpTime:    flowExpTime,
+	}
+}
+
+// StartLogin returns the provider URL the user is redirected to and the flow token
+// the callback must present.
+func (s *oidcSvc) StartLogin(ctx context.Context) (string, string, *resterrors.RestErr) {
+	logger.Info("Starting Start OIDC Login", stacktraceStartOIDCLoginService)
+
+	data := oidcFlowData{Purpose: oidcLoginPurpose}
+	for _, value := range []*string{&data.State, &data.Nonce} {
+		generated, err := randtoken.Generate(16)
+		if err != nil {
+			logger.Error(errStartOIDCLogin, err, stacktraceStartOIDCLoginService)
+			return "", "", resterrors.NewInternalServerError(errStartOIDCLogin)
+		}
+		*value = generated
+	}
+
+	codeVerifier, err := oidc.NewCodeVerifier()
+	if err != nil {
+		logger.Error(errStartOIDCLogin, err, stacktraceStartOIDCLoginService)
+		return "", "", resterrors.NewInternalServerError(errStartOIDCLogin)
+	}
+	data.CodeVerifier = codeVerifier
+
+	flowToken, err := s.signer.Sign(data, time.Now().Add(time.Minute*time.Duration(s.flowExpTime)))
+	if err != nil {
+		logger.Error(errStartOIDCLogin, err, stacktraceStartOIDCLoginService)
+		return "", "", resterrors.NewInternalServerError(errStartOIDCLogin)
+	}
+
+	logger.Info("OIDC login was started successfully", stacktraceStartOIDCLoginService)
+	return s.provider.AuthCodeURL(data.State, data.Nonce, data.CodeVerifier), flowToken, nil
+}
+
+// FinishLogin redeems the code of the callback and logs in the user of the ID token.
+// Users are found by the verified email of the provider, an unknown email is
+// registered as a new user without password.
+func (s *oidcSvc) FinishLogin(ctx context.Context, flowToken, state, code string) (*domain.AuthTokens, *resterrors.RestErr) {
+	logger.Info("Starting Finish OIDC Login", stacktraceFinishOIDCLoginService)
+
+	var data oidcFlowData
+	if err := s.signer.Verify(flowToken, &data); err != nil || data.Purpose != oidcLoginPurpose {
+		logger.Error(errInvalidOIDCLogin, err, stacktraceFinishOIDCLoginService)
+		return nil, resterrors.NewBadRequestError(errInvalidOIDCLogin).WithCode(ErrCodeInvalidOIDCLogin)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(data.State), []byte(state)) != 1 {
+		logger.Error(errInvalidOIDCLogin, errors.New("state doesn't match the login"), stacktraceFinishOIDCLoginService)
+		return nil, resterrors.NewBadRequestError(errInvalidOIDCLogin).WithCode(ErrCodeInvalidOIDCLogin)
+	}
+
+	rawIDToken, err := s.provider.Exchange(ctx, code, data.CodeVerifier)
+	if err != nil {
+		logger.Error(errOIDCAuthentication, err, stacktraceFinishOIDCLoginService)
+		return nil, resterrors.NewUnauthorizedError(errOIDCAuthentication)
+	}
+
+	idToken, err := s.provider.VerifyIDToken(ctx, rawIDToken, data.Nonce)
+	if err != nil {
+		logger.Error(errOIDCAuthentication, err, stacktraceFinishOIDCLoginService)
+		return nil, resterrors.NewUnauthorizedError(errOIDCAuthentication)
+	}
+
+	if idToken.Email == "" || !idToken.EmailVerified {
+		logger.Error(errOIDCEmailNotVerified, nil, zap.String("subject", idToken.Subject), stacktraceFinishOIDCLoginService)
+		return nil, resterrors.NewForbiddenError(errOIDCEmailNotVerified).WithCode(ErrCodeEmailNotVerified)
+	}
+
+	user, restErr := s.findOrCreateUser(ctx, idToken)
+	if restErr != nil {
+		return nil, restErr
+	}
+
+	tokens, restErr := s.loginService.LoginExternalUser(ctx, user)
+	if restErr != nil {
+		logger.Error("Error when trying login external user", restErr, stacktraceFinishOIDCLoginService)
+		return nil, restErr
+	}
+
+	logger.Info("OIDC login was finished successfully", zap.String("user_id", user.ID), zap.String("subject", idToken.Subject), stacktraceFinishOIDCLoginService)
+	return tokens, nil
+}
+
+// findOrCreateUser links the ID token to the user of its email, provisioning one on
+// the first login. Either way the email is marked verified, the provider vouched for it.
+func (s *oidcSvc) findOrCreateUser(ctx context.Context, idToken *oidc.IDToken) (*domain.User, *resterrors.RestErr) {
+	user, err := s.userRepository.FindUserByEmail(ctx, idToken.Email)
+	if err != nil && err.HttpStatusCode != http.StatusNotFound {
+		logger.Error(errCallRepositoy, err, stacktraceFinishOIDCLoginService)
+		return nil, err
+	}
+
+	if user == nil {
+		name := idToken.Name
+		if name == "" {
+			name = idToken.Email
+		}
+
+		user, err = s.userRepository.CreateUser(ctx, &domain.User{
+			Name:   name,
+			Email:  idToken.Email,
+			Roles:  []string{domain.RoleUser},
+			Status: domain.UserStatusActive,
+		})
+		if err != nil {
+			logger.Error(errCallRepositoy, err, stacktraceFinishOIDCLoginService)
+			return nil, err
+		}
+
+		logger.Info("User was provisioned from the identity provider", zap.String("user_id", user.ID), stacktraceFinishOIDCLoginService)
+	}
+
+	if !user.EmailVerified {
+		if err := s.userRepository.VerifyEmail(ctx, user.ID, user.Email, time.Now()); err != nil {
+			logger.Error(errCallRepositoy, err, stacktraceFinishOIDCLoginService)
+			return nil, err
+		}
+		user.EmailVerified = true
+	}
+
+	return user, nil
+}
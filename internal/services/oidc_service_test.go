@@ -0,0 +1,291 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	"github.com/WalterPaes/go-rest-api-crud/internal/repositories"
+	"github.com/WalterPaes/go-rest-api-crud/mocks"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/oidc"
+	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/signer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var (
+	oidcFlowExpTime = 10
+	oidcFlow        = oidcFlowData{State: "state", Nonce: "nonce", CodeVerifier: "code_verifier", Purpose: oidcLoginPurpose}
+	oidcIDToken     = &oidc.IDToken{Subject: "subject", Email: responseUser.Email, EmailVerified: true, Name: responseUser.Name}
+	oidcTokens      = &domain.AuthTokens{AccessToken: token, RefreshToken: refreshToken}
+)
+
+func Test_oidcSvc_StartLogin(t *testing.T) {
+	provider := mocks.NewOIDCProvider(t)
+	provider.On("AuthCodeURL", mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("string")).
+		Return("https://issuer/authorize")
+
+	s := NewOIDCService(provider, mocks.NewUserRepository(t), mocks.NewLoginService(t), verificationSigner, oidcFlowExpTime)
+	authURL, flowToken, err := s.StartLogin(ctx)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "https://issuer/authorize", authURL)
+
+	var data oidcFlowData
+	assert.Nil(t, verificationSigner.Verify(flowToken, &data))
+	assert.Equal(t, oidcLoginPurpose, data.Purpose)
+	assert.Len(t, data.CodeVerifier, 43)
+	provider.AssertCalled(t, "AuthCodeURL", data.State, data.Nonce, data.CodeVerifier)
+	assert.NotEqual(t, data.State, data.Nonce)
+}
+
+func Test_oidcSvc_FinishLogin(t *testing.T) {
+	unverifiedUser := *responseUser
+	unverifiedUser.EmailVerified = false
+
+	verifiedUser := *responseUser
+	verifiedUser.EmailVerified = true
+
+	unverifiedIDToken := *oidcIDToken
+	unverifiedIDToken.EmailVerified = false
+
+	invalidOIDCLoginError := resterrors.NewBadRequestError(errInvalidOIDCLogin).WithCode(ErrCodeInvalidOIDCLogin)
+
+	type fields struct {
+		provider       oidc.Provider
+		userRepository repositories.UserRepository
+		loginService   LoginService
+	}
+	type args struct {
+		flowToken string
+		state     string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    *domain.AuthTokens
+		wantErr *resterrors.RestErr
+	}{
+		{
+			name: "Should login the user linked by the email of the ID token",
+			fields: fields{
+				provider: func() oidc.Provider {
+					m := mocks.NewOIDCProvider(t)
+					m.On("Exchange", ctx, "code", oidcFlow.CodeVerifier).
+						Return("id_token", nil)
+					m.On("VerifyIDToken", ctx, "id_token", oidcFlow.Nonce).
+						Return(oidcIDToken, nil)
+					return m
+				}(),
+				userRepository: func() repositories.UserRepository {
+					m := mocks.NewUserRepository(t)
+					m.On("FindUserByEmail", ctx, oidcIDToken.Email).
+						Return(&verifiedUser, nil)
+					return m
+				}(),
+				loginService: func() LoginService {
+					m := mocks.NewLoginService(t)
+					m.On("LoginExternalUser", ctx, &verifiedUser).
+						Return(oidcTokens, nil)
+					return m
+				}(),
+			},
+			args: args{
+				flowToken: oidcFlowToken(t, verificationSigner, oidcFlow),
+				state:     oidcFlow.State,
+			},
+			want:    oidcTokens,
+			wantErr: nil,
+		},
+		{
+			name: "Should verify the email of a linked user that didn't verify it",
+			fields: fields{
+				provider: func() oidc.Provider {
+					m := mocks.NewOIDCProvider(t)
+					m.On("Exchange", ctx, "code", oidcFlow.CodeVerifier).
+						Return("id_token", nil)
+					m.On("VerifyIDToken", ctx, "id_token", oidcFlow.Nonce).
+						Return(oidcIDToken, nil)
+					return m
+				}(),
+				userRepository: func() repositories.UserRepository {
+					m := mocks.NewUserRepository(t)
+					m.On("FindUserByEmail", ctx, oidcIDToken.Email).
+						Return(&unverifiedUser, nil)
+					m.On("VerifyEmail", ctx, userID, unverifiedUser.Email, mock.AnythingOfType("time.Time")).
+						Return(nil)
+					return m
+				}(),
+				loginService: func() LoginService {
+					m := mocks.NewLoginService(t)
+					m.On("LoginExternalUser", ctx, mock.MatchedBy(func(u *domain.User) bool {
+						return u.ID == userID && u.EmailVerified
+					})).
+						Return(oidcTokens, nil)
+					return m
+				}(),
+			},
+			args: args{
+				flowToken: oidcFlowToken(t, verificationSigner, oidcFlow),
+				state:     oidcFlow.State,
+			},
+			want:    oidcTokens,
+			wantErr: nil,
+		},
+		{
+			name: "Should provision an user on the first login of an unknown email",
+			fields: fields{
+				provider: func() oidc.Provider {
+					m := mocks.NewOIDCProvider(t)
+					m.On("Exchange", ctx, "code", oidcFlow.CodeVerifier).
+						Return("id_token", nil)
+					m.On("VerifyIDToken", ctx, "id_token", oidcFlow.Nonce).
+						Return(oidcIDToken, nil)
+					return m
+				}(),
+				userRepository: func() repositories.UserRepository {
+					m := mocks.NewUserRepository(t)
+					m.On("FindUserByEmail", ctx, oidcIDToken.Email).
+						Return(nil, resterrors.NewNotFoundError("not found"))
+					m.On("CreateUser", ctx, &domain.User{Name: oidcIDToken.Name, Email: oidcIDToken.Email, Roles: []string{domain.RoleUser}, Status: domain.UserStatusActive}).
+						Return(func(_ context.Context, u *domain.User) *domain.User {
+							created := *u
+							created.ID = userID
+							return &created
+						}, nil)
+					m.On("VerifyEmail", ctx, userID, oidcIDToken.Email, mock.AnythingOfType("time.Time")).
+						Return(nil)
+					return m
+				}(),
+				loginService: func() LoginService {
+					m := mocks.NewLoginService(t)
+					m.On("LoginExternalUser", ctx, mock.MatchedBy(func(u *domain.User) bool {
+						return u.ID == userID && u.Password == "" && u.EmailVerified
+					})).
+						Return(oidcTokens, nil)
+					return m
+				}(),
+			},
+			args: args{
+				flowToken: oidcFlowToken(t, verificationSigner, oidcFlow),
+				state:     oidcFlow.State,
+			},
+			want:    oidcTokens,
+			wantErr: nil,
+		},
+		{
+			name: "Should refuse a callback with another state",
+			fields: fields{
+				provider:       mocks.NewOIDCProvider(t),
+				userRepository: mocks.NewUserRepository(t),
+				loginService:   mocks.NewLoginService(t),
+			},
+			args: args{
+				flowToken: oidcFlowToken(t, verificationSigner, oidcFlow),
+				state:     "other_state",
+			},
+			want:    nil,
+			wantErr: invalidOIDCLoginError,
+		},
+		{
+			name: "Should refuse a flow token with an invalid signature",
+			fields: fields{
+				provider:       mocks.NewOIDCProvider(t),
+				userRepository: mocks.NewUserRepository(t),
+				loginService:   mocks.NewLoginService(t),
+			},
+			args: args{
+				flowToken: oidcFlowToken(t, newSigner("other"), oidcFlow),
+				state:     oidcFlow.State,
+			},
+			want:    nil,
+			wantErr: invalidOIDCLoginError,
+		},
+		{
+			name: "Should return an unauthorized error when the provider refuses the code",
+			fields: fields{
+				provider: func() oidc.Provider {
+					m := mocks.NewOIDCProvider(t)
+					m.On("Exchange", ctx, "code", oidcFlow.CodeVerifier).
+						Return("", errors.New("invalid_grant"))
+					return m
+				}(),
+				userRepository: mocks.NewUserRepository(t),
+				loginService:   mocks.NewLoginService(t),
+			},
+			args: args{
+				flowToken: oidcFlowToken(t, verificationSigner, oidcFlow),
+				state:     oidcFlow.State,
+			},
+			want:    nil,
+			wantErr: resterrors.NewUnauthorizedError(errOIDCAuthentication),
+		},
+		{
+			name: "Should return an unauthorized error when the ID token is invalid",
+			fields: fields{
+				provider: func() oidc.Provider {
+					m := mocks.NewOIDCProvider(t)
+					m.On("Exchange", ctx, "code", oidcFlow.CodeVerifier).
+						Return("id_token", nil)
+					m.On("VerifyIDToken", ctx, "id_token", oidcFlow.Nonce).
+						Return(nil, oidc.ErrNonceMismatch)
+					return m
+				}(),
+				userRepository: mocks.NewUserRepository(t),
+				loginService:   mocks.NewLoginService(t),
+			},
+			args: args{
+				flowToken: oidcFlowToken(t, verificationSigner, oidcFlow),
+				state:     oidcFlow.State,
+			},
+			want:    nil,
+			wantErr: resterrors.NewUnauthorizedError(errOIDCAuthentication),
+		},
+		{
+			name: "Should refuse an email the provider didn't verify",
+			fields: fields{
+				provider: func() oidc.Provider {
+					m := mocks.NewOIDCProvider(t)
+					m.On("Exchange", ctx, "code", oidcFlow.CodeVerifier).
+						Return("id_token", nil)
+					m.On("VerifyIDToken", ctx, "id_token", oidcFlow.Nonce).
+						Return(&unverifiedIDToken, nil)
+					return m
+				}(),
+				userRepository: mocks.NewUserRepository(t),
+				loginService:   mocks.NewLoginService(t),
+			},
+			args: args{
+				flowToken: oidcFlowToken(t, verificationSigner, oidcFlow),
+				state:     oidcFlow.State,
+			},
+			want:    nil,
+			wantErr: resterrors.NewForbiddenError(errOIDCEmailNotVerified).WithCode(ErrCodeEmailNotVerified),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewOIDCService(tt.fields.provider, tt.fields.userRepository, tt.fields.loginService, verificationSigner, oidcFlowExpTime)
+			got, err := s.FinishLogin(ctx, tt.args.flowToken, tt.args.state, "code")
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("oidcSvc.FinishLogin() got = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(err, tt.wantErr) {
+				t.Errorf("oidcSvc.FinishLogin() err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func oidcFlowToken(t *testing.T, s *signer.Signer, data oidcFlowData) string {
+	flowToken, err := s.Sign(data, time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return flowToken
+}
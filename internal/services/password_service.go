@@ -0,0 +1,246 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	"github.com/WalterPaes/go-rest-api-crud/internal/repositories"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/hasher"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/jwt"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/logger"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/notifier"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/randtoken"
+	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/validation"
+	"go.uber.org/zap"
+)
+
+const (
+	errInvalidResetToken = "Password Reset Token is Invalid"
+	errInvalidFields     = "Some fields are invalid"
+	errPasswordReused    = "%s must be different from the current password and the last %d passwords"
+	errSendNotification  = "Error when trying send notification"
+
+	passwordResetSubject = "Reset your password"
+	passwordResetBody    = "Use the link below to reset your password, it expires in %d minutes:\n\n%s%s"
+)
+
+var (
+	stacktraceForgotPasswordService = zap.String("stacktrace", "forgot-password-service")
+	stacktraceResetPasswordService  = zap.String("stacktrace", "reset-password-service")
+)
+
+type PasswordService interface {
+	ForgotPassword(ctx context.Context, email string) *resterrors.RestErr
+	ResetPassword(ctx context.Context, resetToken, newPassword string) *resterrors.RestErr
+}
+
+type passwordSvc struct {
+	userRepository               repositories.UserRepository
+	passwordResetTokenRepository repositories.PasswordResetTokenRepository
+	refreshTokenRepository       repositories.RefreshTokenRepository
+	jwtAuth                      jwt.JwtAuth
+	notifier                     notifier.Notifier
+	passwordHasher               hasher.PasswordHasher
+	passwordPolicy               *validation.PasswordPolicy
+	passwordHistorySize          int
+	resetTokenExpTime            int
+	resetURL                     string
+
+	// sending tracks the reset links still being sent after ForgotPassword returned.
+	sending sync.WaitGroup
+}
+
+// NewPasswordService builds the password recovery service. resetTokenExpTime is in
+// minutes and resetURL is the front-end page receiving the token appended to it.
+func NewPasswordService(
+	userRepository repositories.UserRepository,
+	passwordResetTokenRepository repositories.PasswordResetTokenRepository,
+	refreshTokenRepository repositories.RefreshTokenRepository,
+	jwtAuth jwt.JwtAuth,
+	notifier notifier.Notifier,
+	passwordHasher hasher.PasswordHasher,
+	passwordPolicy *validation.PasswordPolicy,
+	passwordHistorySize int,
+	resetTokenExpTime int,
+	resetURL string,
+) *passwordSvc {
+	return &passwordSvc{
+		userRepository:               userRepository,
+		passwordResetTokenRepository: passwordResetTokenRepository,
+		refreshTokenRepository:       refreshTokenRepository,
+		jwtAuth:                      jwtAuth,
+		notifier:                     notifier,
+		passwordHasher:               passwordHasher,
+		passwordPolicy:               passwordPolicy,
+		passwordHistorySize:          passwordHistorySize,
+		resetTokenExpTime:            resetTokenExpTime,
+		resetURL:                     resetURL,
+	}
+}
+
+// ForgotPassword sends a reset link to the email. An unknown email gets the same
+// answer as a registered one, so the endpoint can't be used to enumerate accounts.
+// The link is created and sent in the background, otherwise a registered email
+// would take longer to answer than an unknown one.
+func (s *passwordSvc) ForgotPassword(ctx context.Context, email string) *resterrors.RestErr {
+	logger.Info("Starting Forgot Password", stacktraceForgotPasswordService)
+
+	user, err := s.userRepository.FindUserByEmail(ctx, email)
+	if err != nil {
+		if err.HttpStatusCode == http.StatusNotFound {
+			logger.Info("Password reset requested for an unknown email", stacktraceForgotPasswordService)
+			return nil
+		}
+
+		logger.Error(errCallRepositoy, err, stacktraceForgotPasswordService)
+		return err
+	}
+
+	// The request context is canceled once the answer is sent.
+	s.sending.Add(1)
+	go func() {
+		defer s.sending.Done()
+		s.sendResetToken(context.Background(), user)
+	}()
+
+	return nil
+}
+
+// sendResetToken stores a new reset token of the user and mails the link with it.
+// The errors are only logged since ForgotPassword has already answered.
+func (s *passwordSvc) sendResetToken(ctx context.Context, user *domain.User) {
+	resetToken, genErr := randtoken.Generate(32)
+	if genErr != nil {
+		logger.Error(errGenerateToken, genErr, zap.String("user_id", user.ID), stacktraceForgotPasswordService)
+		return
+	}
+
+	now := time.Now()
+	_, err := s.passwordResetTokenRepository.CreatePasswordResetToken(ctx, &domain.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: randtoken.Hash(resetToken),
+		ExpiresAt: now.Add(time.Minute * time.Duration(s.resetTokenExpTime)),
+		CreatedAt: now,
+	})
+	if err != nil {
+		logger.Error(errCallRepositoy, err, zap.String("user_id", user.ID), stacktraceForgotPasswordService)
+		return
+	}
+
+	notifyErr := s.notifier.Notify(ctx, notifier.Message{
+		To:      user.Email,
+		Subject: passwordResetSubject,
+		Body:    fmt.Sprintf(passwordResetBody, s.resetTokenExpTime, s.resetURL, resetToken),
+	})
+	if notifyErr != nil {
+		logger.Error(errSendNotification, notifyErr, zap.String("user_id", user.ID), stacktraceForgotPasswordService)
+		return
+	}
+
+	logger.Info("Password reset token was sent successfully", zap.String("user_id", user.ID), stacktraceForgotPasswordService)
+}
+
+// ResetPassword sets the new password of the user owning the reset token. The token
+// can be used once, the other reset links of the user stop working, and every token
+// of the user is revoked since the account may have been compromised.
+func (s *passwordSvc) ResetPassword(ctx context.Context, resetToken, newPassword string) *resterrors.RestErr {
+	logger.Info("Starting Reset Password", stacktraceResetPasswordService)
+
+	storedToken, err := s.passwordResetTokenRepository.FindPasswordResetTokenByHash(ctx, randtoken.Hash(resetToken))
+	if err != nil {
+		if err.HttpStatusCode == http.StatusNotFound {
+			logger.Error(errInvalidResetToken, err, stacktraceResetPasswordService)
+			return resterrors.NewBadRequestError(errInvalidResetToken)
+		}
+
+		logger.Error(errCallRepositoy, err, stacktraceResetPasswordService)
+		return err
+	}
+
+	if storedToken.IsUsed() || storedToken.IsExpired() {
+		logger.Error(errInvalidResetToken, nil, zap.String("user_id", storedToken.UserID), stacktraceResetPasswordService)
+		return resterrors.NewBadRequestError(errInvalidResetToken)
+	}
+
+	// The policy is checked before using the token, so a refused password can be
+	// fixed with the same link.
+	storedUser, err := s.userRepository.FindUserById(ctx, storedToken.UserID)
+	if err != nil {
+		if err.HttpStatusCode == http.StatusNotFound {
+			logger.Error(errInvalidResetToken, err, zap.String("user_id", storedToken.UserID), stacktraceResetPasswordService)
+			return resterrors.NewBadRequestError(errInvalidResetToken)
+		}
+
+		logger.Error(errCallRepositoy, err, stacktraceResetPasswordService)
+		return err
+	}
+
+	if err := s.passwordPolicy.Check("NewPassword", newPassword, storedUser.Name, storedUser.Email); err != nil {
+		logger.Error(err.Message, err, zap.String("user_id", storedToken.UserID), stacktraceResetPasswordService)
+		return err
+	}
+
+	if err := checkPasswordReuse(storedUser, "NewPassword", newPassword, s.passwordHistorySize); err != nil {
+		logger.Error(err.Message, err, zap.String("user_id", storedToken.UserID), stacktraceResetPasswordService)
+		return err
+	}
+
+	if err := s.passwordResetTokenRepository.UsePasswordResetToken(ctx, storedToken.ID); err != nil {
+		if err.HttpStatusCode == http.StatusNotFound {
+			logger.Error(errInvalidResetToken, err, stacktraceResetPasswordService)
+			return resterrors.NewBadRequestError(errInvalidResetToken)
+		}
+
+		logger.Error(errCallRepositoy, err, stacktraceResetPasswordService)
+		return err
+	}
+
+	if err := s.passwordResetTokenRepository.UseUserPasswordResetTokens(ctx, storedToken.UserID); err != nil {
+		logger.Error(errCallRepositoy, err, zap.String("user_id", storedToken.UserID), stacktraceResetPasswordService)
+		return err
+	}
+
+	user := &domain.User{Password: newPassword}
+	if encryptErr := user.EncryptPassword(s.passwordHasher); encryptErr != nil {
+		logger.Error(errEncryptPassword, encryptErr, stacktraceResetPasswordService)
+		return resterrors.NewInternalServerError(errEncryptPassword)
+	}
+
+	if err := s.userRepository.UpdatePassword(ctx, storedToken.UserID, user.Password, s.passwordHistorySize); err != nil {
+		logger.Error(errCallRepositoy, err, stacktraceResetPasswordService)
+		return err
+	}
+
+	if err := s.jwtAuth.RevokeUserTokens(ctx, storedToken.UserID); err != nil {
+		logger.Error("Error when trying revoke user tokens", err, stacktraceResetPasswordService)
+		return err
+	}
+
+	if err := s.refreshTokenRepository.RevokeUserRefreshTokens(ctx, storedToken.UserID); err != nil {
+		logger.Error("Error when trying revoke user tokens", err, stacktraceResetPasswordService)
+		return err
+	}
+
+	logger.Info("Password was reset successfully", zap.String("user_id", storedToken.UserID), stacktraceResetPasswordService)
+	return nil
+}
+
+// checkPasswordReuse refuses a new password that is the current one or one of the
+// last historySize passwords of the user.
+func checkPasswordReuse(user *domain.User, field, password string, historySize int) *resterrors.RestErr {
+	if !user.UsedPassword(password, historySize) {
+		return nil
+	}
+
+	return resterrors.NewBadRequestValidationError(errInvalidFields, []error{
+		&validation.ValidationError{
+			Key:     field,
+			Message: fmt.Sprintf(errPasswordReused, field, historySize),
+		},
+	})
+}
@@ -0,0 +1,470 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	"github.com/WalterPaes/go-rest-api-crud/internal/repositories"
+	"github.com/WalterPaes/go-rest-api-crud/mocks"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/jwt"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/notifier"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/randtoken"
+	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/validation"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	resetToken     = "reset_token_test"
+	resetTokenHash = randtoken.Hash(resetToken)
+
+	resetTokenExpTime = 30
+	resetURL          = "http://localhost:3000/reset-password?token="
+)
+
+func storedResetToken(usedAt *time.Time, expiresAt time.Time) *domain.PasswordResetToken {
+	return &domain.PasswordResetToken{
+		ID:        primitive.NewObjectID().Hex(),
+		UserID:    userID,
+		TokenHash: resetTokenHash,
+		ExpiresAt: expiresAt,
+		UsedAt:    usedAt,
+	}
+}
+
+func Test_passwordSvc_ForgotPassword(t *testing.T) {
+	type fields struct {
+		userRepository               repositories.UserRepository
+		passwordResetTokenRepository repositories.PasswordResetTokenRepository
+		notifier                     notifier.Notifier
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		email   string
+		wantErr *resterrors.RestErr
+	}{
+		{
+			name: "Should send a reset link to a registered email",
+			fields: fields{
+				userRepository: func() repositories.UserRepository {
+					m := mocks.NewUserRepository(t)
+					m.On("FindUserByEmail", ctx, responseUser.Email).
+						Return(responseUser, nil)
+					return m
+				}(),
+				passwordResetTokenRepository: func() repositories.PasswordResetTokenRepository {
+					m := mocks.NewPasswordResetTokenRepository(t)
+					m.On("CreatePasswordResetToken", ctx, mock.MatchedBy(func(token *domain.PasswordResetToken) bool {
+						return token.UserID == responseUser.ID && token.TokenHash != "" && token.ExpiresAt.After(time.Now())
+					})).Return(&domain.PasswordResetToken{}, nil)
+					return m
+				}(),
+				notifier: func() notifier.Notifier {
+					m := mocks.NewNotifier(t)
+					m.On("Notify", ctx, mock.MatchedBy(func(message notifier.Message) bool {
+						return message.To == responseUser.Email && strings.Contains(message.Body, resetURL)
+					})).Return(nil)
+					return m
+				}(),
+			},
+			email:   responseUser.Email,
+			wantErr: nil,
+		},
+		{
+			name: "Should answer the same way when the email is not registered",
+			fields: fields{
+				userRepository: func() repositories.UserRepository {
+					m := mocks.NewUserRepository(t)
+					m.On("FindUserByEmail", ctx, "unknown@email.com").
+						Return(nil, resterrors.NewNotFoundError("not found"))
+					return m
+				}(),
+				passwordResetTokenRepository: mocks.NewPasswordResetTokenRepository(t),
+				notifier:                     mocks.NewNotifier(t),
+			},
+			email:   "unknown@email.com",
+			wantErr: nil,
+		},
+		{
+			name: "Should answer as for an unknown email when the notification fails",
+			fields: fields{
+				userRepository: func() repositories.UserRepository {
+					m := mocks.NewUserRepository(t)
+					m.On("FindUserByEmail", ctx, responseUser.Email).
+						Return(responseUser, nil)
+					return m
+				}(),
+				passwordResetTokenRepository: func() repositories.PasswordResetTokenRepository {
+					m := mocks.NewPasswordResetTokenRepository(t)
+					m.On("CreatePasswordResetToken", ctx, mock.Anything).
+						Return(&domain.PasswordResetToken{}, nil)
+					return m
+				}(),
+				notifier: func() notifier.Notifier {
+					m := mocks.NewNotifier(t)
+					m.On("Notify", ctx, mock.Anything).
+						Return(errors.New("error"))
+					return m
+				}(),
+			},
+			email:   responseUser.Email,
+			wantErr: nil,
+		},
+		{
+			name: "Should answer as for an unknown email when the reset token can't be stored",
+			fields: fields{
+				userRepository: func() repositories.UserRepository {
+					m := mocks.NewUserRepository(t)
+					m.On("FindUserByEmail", ctx, responseUser.Email).
+						Return(responseUser, nil)
+					return m
+				}(),
+				passwordResetTokenRepository: func() repositories.PasswordResetTokenRepository {
+					m := mocks.NewPasswordResetTokenRepository(t)
+					m.On("CreatePasswordResetToken", ctx, mock.Anything).
+						Return(nil, internalServerError)
+					return m
+				}(),
+				notifier: mocks.NewNotifier(t),
+			},
+			email:   responseUser.Email,
+			wantErr: nil,
+		},
+		{
+			name: "Should return an error when try call repository to find the user",
+			fields: fields{
+				userRepository: func() repositories.UserRepository {
+					m := mocks.NewUserRepository(t)
+					m.On("FindUserByEmail", ctx, responseUser.Email).
+						Return(nil, internalServerError)
+					return m
+				}(),
+				passwordResetTokenRepository: mocks.NewPasswordResetTokenRepository(t),
+				notifier:                     mocks.NewNotifier(t),
+			},
+			email:   responseUser.Email,
+			wantErr: internalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewPasswordService(
+				tt.fields.userRepository,
+				tt.fields.passwordResetTokenRepository,
+				mocks.NewRefreshTokenRepository(t),
+				mocks.NewJwtAuth(t),
+				tt.fields.notifier,
+				passwordHasher,
+				passwordPolicy,
+				passwordHistorySize,
+				resetTokenExpTime,
+				resetURL,
+			)
+			if err := s.ForgotPassword(ctx, tt.email); !reflect.DeepEqual(err, tt.wantErr) {
+				t.Errorf("passwordSvc.ForgotPassword() err = %v, want %v", err, tt.wantErr)
+			}
+			s.sending.Wait()
+		})
+	}
+}
+
+func Test_passwordSvc_ResetPassword(t *testing.T) {
+	newPassword := "new@123"
+	usedAt := time.Now()
+
+	type fields struct {
+		userRepository               repositories.UserRepository
+		passwordResetTokenRepository repositories.PasswordResetTokenRepository
+		refreshTokenRepository       repositories.RefreshTokenRepository
+		jwtAuth                      jwt.JwtAuth
+	}
+	type args struct {
+		ctx         context.Context
+		resetToken  string
+		newPassword string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		wantErr *resterrors.RestErr
+	}{
+		{
+			name: "Should reset the password and revoke the tokens of the user",
+			fields: fields{
+				userRepository: func() repositories.UserRepository {
+					m := mocks.NewUserRepository(t)
+					m.On("FindUserById", ctx, userID).
+						Return(responseUser, nil)
+
+					m.On("UpdatePassword", ctx, userID, mock.MatchedBy(func(hash string) bool {
+						return (&domain.User{Password: hash}).ComparePassword(newPassword)
+					}), passwordHistorySize).Return(nil)
+					return m
+				}(),
+				passwordResetTokenRepository: func() repositories.PasswordResetTokenRepository {
+					storedToken := storedResetToken(nil, time.Now().Add(time.Minute))
+
+					m := mocks.NewPasswordResetTokenRepository(t)
+					m.On("FindPasswordResetTokenByHash", ctx, resetTokenHash).
+						Return(storedToken, nil)
+
+					m.On("UsePasswordResetToken", ctx, storedToken.ID).
+						Return(nil)
+
+					m.On("UseUserPasswordResetTokens", ctx, userID).
+						Return(nil)
+					return m
+				}(),
+				refreshTokenRepository: func() repositories.RefreshTokenRepository {
+					m := mocks.NewRefreshTokenRepository(t)
+					m.On("RevokeUserRefreshTokens", ctx, userID).
+						Return(nil)
+					return m
+				}(),
+				jwtAuth: func() jwt.JwtAuth {
+					m := mocks.NewJwtAuth(t)
+					m.On("RevokeUserTokens", ctx, userID).
+						Return(nil)
+					return m
+				}(),
+			},
+			args: args{
+				ctx:        ctx,
+				resetToken: resetToken,
+			},
+			wantErr: nil,
+		},
+		{
+			name: "Should return an error when the reset token doesn't exist",
+			fields: fields{
+				userRepository: mocks.NewUserRepository(t),
+				passwordResetTokenRepository: func() repositories.PasswordResetTokenRepository {
+					m := mocks.NewPasswordResetTokenRepository(t)
+					m.On("FindPasswordResetTokenByHash", ctx, resetTokenHash).
+						Return(nil, resterrors.NewNotFoundError("not found"))
+					return m
+				}(),
+				refreshTokenRepository: mocks.NewRefreshTokenRepository(t),
+				jwtAuth:                mocks.NewJwtAuth(t),
+			},
+			args: args{
+				ctx:        ctx,
+				resetToken: resetToken,
+			},
+			wantErr: resterrors.NewBadRequestError(errInvalidResetToken),
+		},
+		{
+			name: "Should return an error when the reset token was already used",
+			fields: fields{
+				userRepository: mocks.NewUserRepository(t),
+				passwordResetTokenRepository: func() repositories.PasswordResetTokenRepository {
+					m := mocks.NewPasswordResetTokenRepository(t)
+					m.On("FindPasswordResetTokenByHash", ctx, resetTokenHash).
+						Return(storedResetToken(&usedAt, time.Now().Add(time.Minute)), nil)
+					return m
+				}(),
+				refreshTokenRepository: mocks.NewRefreshTokenRepository(t),
+				jwtAuth:                mocks.NewJwtAuth(t),
+			},
+			args: args{
+				ctx:        ctx,
+				resetToken: resetToken,
+			},
+			wantErr: resterrors.NewBadRequestError(errInvalidResetToken),
+		},
+		{
+			name: "Should return an error when the reset token is expired",
+			fields: fields{
+				userRepository: mocks.NewUserRepository(t),
+				passwordResetTokenRepository: func() repositories.PasswordResetTokenRepository {
+					m := mocks.NewPasswordResetTokenRepository(t)
+					m.On("FindPasswordResetTokenByHash", ctx, resetTokenHash).
+						Return(storedResetToken(nil, time.Now().Add(-time.Minute)), nil)
+					return m
+				}(),
+				refreshTokenRepository: mocks.NewRefreshTokenRepository(t),
+				jwtAuth:                mocks.NewJwtAuth(t),
+			},
+			args: args{
+				ctx:        ctx,
+				resetToken: resetToken,
+			},
+			wantErr: resterrors.NewBadRequestError(errInvalidResetToken),
+		},
+		{
+			name: "Should return an error when the new password breaks the password policy",
+			fields: fields{
+				userRepository: func() repositories.UserRepository {
+					m := mocks.NewUserRepository(t)
+					m.On("FindUserById", ctx, userID).
+						Return(responseUser, nil)
+					return m
+				}(),
+				passwordResetTokenRepository: func() repositories.PasswordResetTokenRepository {
+					m := mocks.NewPasswordResetTokenRepository(t)
+					m.On("FindPasswordResetTokenByHash", ctx, resetTokenHash).
+						Return(storedResetToken(nil, time.Now().Add(time.Minute)), nil)
+					return m
+				}(),
+				refreshTokenRepository: mocks.NewRefreshTokenRepository(t),
+				jwtAuth:                mocks.NewJwtAuth(t),
+			},
+			args: args{
+				ctx:         ctx,
+				resetToken:  resetToken,
+				newPassword: "first@123",
+			},
+			wantErr: passwordPolicy.Check("NewPassword", "first@123", responseUser.Name, responseUser.Email),
+		},
+		{
+			name: "Should return an error when the new password is one of the previous ones",
+			fields: fields{
+				userRepository: func() repositories.UserRepository {
+					previousUser := &domain.User{Password: newPassword}
+					if err := previousUser.EncryptPassword(passwordHasher); err != nil {
+						t.Fatal(err)
+					}
+
+					m := mocks.NewUserRepository(t)
+					m.On("FindUserById", ctx, userID).
+						Return(&domain.User{ID: userID, PasswordHistory: []string{"outdated_hash", previousUser.Password}}, nil)
+					return m
+				}(),
+				passwordResetTokenRepository: func() repositories.PasswordResetTokenRepository {
+					m := mocks.NewPasswordResetTokenRepository(t)
+					m.On("FindPasswordResetTokenByHash", ctx, resetTokenHash).
+						Return(storedResetToken(nil, time.Now().Add(time.Minute)), nil)
+					return m
+				}(),
+				refreshTokenRepository: mocks.NewRefreshTokenRepository(t),
+				jwtAuth:                mocks.NewJwtAuth(t),
+			},
+			args: args{
+				ctx:        ctx,
+				resetToken: resetToken,
+			},
+			wantErr: resterrors.NewBadRequestValidationError(errInvalidFields, []error{
+				&validation.ValidationError{
+					Key:     "NewPassword",
+					Message: fmt.Sprintf(errPasswordReused, "NewPassword", passwordHistorySize),
+				},
+			}),
+		},
+		{
+			name: "Should return an error when the user of the reset token doesn't exist",
+			fields: fields{
+				userRepository: func() repositories.UserRepository {
+					m := mocks.NewUserRepository(t)
+					m.On("FindUserById", ctx, userID).
+						Return(nil, resterrors.NewNotFoundError("not found"))
+					return m
+				}(),
+				passwordResetTokenRepository: func() repositories.PasswordResetTokenRepository {
+					m := mocks.NewPasswordResetTokenRepository(t)
+					m.On("FindPasswordResetTokenByHash", ctx, resetTokenHash).
+						Return(storedResetToken(nil, time.Now().Add(time.Minute)), nil)
+					return m
+				}(),
+				refreshTokenRepository: mocks.NewRefreshTokenRepository(t),
+				jwtAuth:                mocks.NewJwtAuth(t),
+			},
+			args: args{
+				ctx:        ctx,
+				resetToken: resetToken,
+			},
+			wantErr: resterrors.NewBadRequestError(errInvalidResetToken),
+		},
+		{
+			name: "Should return an error when the other reset tokens of the user can't be used",
+			fields: fields{
+				userRepository: func() repositories.UserRepository {
+					m := mocks.NewUserRepository(t)
+					m.On("FindUserById", ctx, userID).
+						Return(responseUser, nil)
+					return m
+				}(),
+				passwordResetTokenRepository: func() repositories.PasswordResetTokenRepository {
+					storedToken := storedResetToken(nil, time.Now().Add(time.Minute))
+
+					m := mocks.NewPasswordResetTokenRepository(t)
+					m.On("FindPasswordResetTokenByHash", ctx, resetTokenHash).
+						Return(storedToken, nil)
+
+					m.On("UsePasswordResetToken", ctx, storedToken.ID).
+						Return(nil)
+
+					m.On("UseUserPasswordResetTokens", ctx, userID).
+						Return(internalServerError)
+					return m
+				}(),
+				refreshTokenRepository: mocks.NewRefreshTokenRepository(t),
+				jwtAuth:                mocks.NewJwtAuth(t),
+			},
+			args: args{
+				ctx:        ctx,
+				resetToken: resetToken,
+			},
+			wantErr: internalServerError,
+		},
+		{
+			name: "Should return an error when the reset token is used concurrently",
+			fields: fields{
+				userRepository: func() repositories.UserRepository {
+					m := mocks.NewUserRepository(t)
+					m.On("FindUserById", ctx, userID).
+						Return(responseUser, nil)
+					return m
+				}(),
+				passwordResetTokenRepository: func() repositories.PasswordResetTokenRepository {
+					storedToken := storedResetToken(nil, time.Now().Add(time.Minute))
+
+					m := mocks.NewPasswordResetTokenRepository(t)
+					m.On("FindPasswordResetTokenByHash", ctx, resetTokenHash).
+						Return(storedToken, nil)
+
+					m.On("UsePasswordResetToken", ctx, storedToken.ID).
+						Return(resterrors.NewNotFoundError("not found"))
+					return m
+				}(),
+				refreshTokenRepository: mocks.NewRefreshTokenRepository(t),
+				jwtAuth:                mocks.NewJwtAuth(t),
+			},
+			args: args{
+				ctx:        ctx,
+				resetToken: resetToken,
+			},
+			wantErr: resterrors.NewBadRequestError(errInvalidResetToken),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewPasswordService(
+				tt.fields.userRepository,
+				tt.fields.passwordResetTokenRepository,
+				tt.fields.refreshTokenRepository,
+				tt.fields.jwtAuth,
+				mocks.NewNotifier(t),
+				passwordHasher,
+				passwordPolicy,
+				passwordHistorySize,
+				resetTokenExpTime,
+				resetURL,
+			)
+			if tt.args.newPassword == "" {
+				tt.args.newPassword = newPassword
+			}
+			if err := s.ResetPassword(tt.args.ctx, tt.args.resetToken, tt.args.newPassword); !reflect.DeepEqual(err, tt.wantErr) {
+				t.Errorf("passwordSvc.ResetPassword() err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	"github.com/WalterPaes/go-rest-api-crud/internal/repositories"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/logger"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/randtoken"
+	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
+	"go.uber.org/zap"
+)
+
+const (
+	errInvalidPersonalAccessToken  = "Personal Access Token is Invalid"
+	errGeneratePersonalAccessToken = "Error when trying generate personal access token"
+	errPersonalAccessTokenExpTime  = "Personal access tokens can't expire after %d days"
+	personalAccessTokenSize        = 32
+)
+
+var (
+	stacktraceCreatePersonalAccessTokenService       = zap.String("stacktrace", "create-personal-access-token-service")
+	stacktraceListPersonalAccessTokensService        = zap.String("stacktrace", "list-personal-access-tokens-service")
+	stacktraceRevokePersonalAccessTokenService       = zap.String("stacktrace", "revoke-personal-access-token-service")
+	stacktraceAuthenticatePersonalAccessTokenService = zap.String("stacktrace", "authenticate-personal-access-token-service")
+)
+
+type PersonalAccessTokenService interface {
+	CreateToken(ctx context.Context, userID, name string, scopes []string, expiresInDays int) (*domain.PersonalAccessToken, string, *resterrors.RestErr)
+	ListTokens(ctx context.Context, userID string) ([]*domain.PersonalAccessToken, *resterrors.RestErr)
+	RevokeToken(ctx context.Context, userID, tokenID string) *resterrors.RestErr
+	Authenticate(ctx context.Context, token string) (*domain.Principal, *resterrors.RestErr)
+}
+
+type personalAccessTokenSvc struct {
+	personalAccessTokenRepository repositories.PersonalAccessTokenRepository
+	userRepository                repositories.UserRepository
+	maxExpTime                    int
+}
+
+// NewPersonalAccessTokenService builds the personal access token service. maxExpTime,
+// in days, is the longest lifetime a token can be created with.
+func NewPersonalAccessTokenService(
+	personalAccessTokenRepository repositories.PersonalAccessTokenRepository,
+	userRepository repositories.UserRepository,
+	maxExpTime int,
+) *personalAccessTokenSvc {
+	return &personalAccessTokenSvc{
+		personalAccessTokenRepository: personalAccessTokenRepository,
+		userRepository:                userRepository,
+		maxExpTime:                    maxExpTime,
+	}
+}
+
+// CreateToken returns the stored token and the token itself, which can't be
+// recovered later since only its hash is stored.
+func (s *personalAccessTokenSvc) CreateToken(ctx context.Context, userID, name string, scopes []string, expiresInDays int) (*domain.PersonalAccessToken, string, *resterrors.RestErr) {
+	logger.Info("Starting Create Personal Access Token", stacktraceCreatePersonalAccessTokenService)
+
+	if expiresInDays > s.maxExpTime {
+		restErr := resterrors.NewBadRequestError(fmt.Sprintf(errPersonalAccessTokenExpTime, s.maxExpTime))
+		logger.Error(restErr.Message, restErr, zap.String("user_id", userID), stacktraceCreatePersonalAccessTokenService)
+		return nil, "", restErr
+	}
+
+	randomToken, err := randtoken.Generate(personalAccessTokenSize)
+	if err != nil {
+		logger.Error(errGeneratePersonalAccessToken, err, stacktraceCreatePersonalAccessTokenService)
+		return nil, "", resterrors.NewInternalServerError(errGeneratePersonalAccessToken)
+	}
+	token := domain.PersonalAccessTokenPrefix + randomToken
+
+	now := time.Now()
+	storedToken, restErr := s.personalAccessTokenRepository.CreatePersonalAccessToken(ctx, &domain.PersonalAccessToken{
+		UserID:    userID,
+		Name:      name,
+		Scopes:    scopes,
+		TokenHash: randtoken.Hash(token),
+		ExpiresAt: now.AddDate(0, 0, expiresInDays),
+		CreatedAt: now,
+	})
+	if restErr != nil {
+		logger.Error(errCallRepositoy, restErr, stacktraceCreatePersonalAccessTokenService)
+		return nil, "", restErr
+	}
+
+	logger.Info("Personal Access Token Created Successfully", zap.String("user_id", userID), zap.String("token_id", storedToken.ID), stacktraceCreatePersonalAccessTokenService)
+	return storedToken, token, nil
+}
+
+func (s *personalAccessTokenSvc) ListTokens(ctx context.Context, userID string) ([]*domain.PersonalAccessToken, *resterrors.RestErr) {
+	logger.Info("Starting List Personal Access Tokens", stacktraceListPersonalAccessTokensService)
+
+	tokens, err := s.personalAccessTokenRepository.FindUserPersonalAccessTokens(ctx, userID)
+	if err != nil {
+		logger.Error(errCallRepositoy, err, stacktraceListPersonalAccessTokensService)
+		return nil, err
+	}
+
+	logger.Info("Personal Access Tokens Listed Successfully", zap.String("user_id", userID), stacktraceListPersonalAccessTokensService)
+	return tokens, nil
+}
+
+func (s *personalAccessTokenSvc) RevokeToken(ctx context.Context, userID, tokenID string) *resterrors.RestErr {
+	logger.Info("Starting Revoke Personal Access Token", stacktraceRevokePersonalAccessTokenService)
+
+	if err := s.personalAccessTokenRepository.RevokePersonalAccessToken(ctx, userID, tokenID); err != nil {
+		logger.Error(errCallRepositoy, err, stacktraceRevokePersonalAccessTokenService)
+		return err
+	}
+
+	logger.Info("Personal Access Token Revoked Successfully", zap.String("user_id", userID), zap.String("token_id", tokenID), stacktraceRevokePersonalAccessTokenService)
+	return nil
+}
+
+// Authenticate returns the principal of a personal access token. The user is read
+// on every request, so a change of roles or a deleted user applies right away.
+func (s *personalAccessTokenSvc) Authenticate(ctx context.Context, token string) (*domain.Principal, *resterrors.RestErr) {
+	if !strings.HasPrefix(token, domain.PersonalAccessTokenPrefix) {
+		return nil, resterrors.NewUnauthorizedError(errInvalidPersonalAccessToken)
+	}
+
+	storedToken, err := s.personalAccessTokenRepository.FindPersonalAccessTokenByHash(ctx, randtoken.Hash(token))
+	if err != nil {
+		if err.HttpStatusCode == http.StatusNotFound {
+			logger.Error(errInvalidPersonalAccessToken, err, stacktraceAuthenticatePersonalAccessTokenService)
+			return nil, resterrors.NewUnauthorizedError(errInvalidPersonalAccessToken)
+		}
+
+		logger.Error(errCallRepositoy, err, stacktraceAuthenticatePersonalAccessTokenService)
+		return nil, err
+	}
+
+	if storedToken.IsRevoked() || storedToken.IsExpired() {
+		logger.Error(errInvalidPersonalAccessToken, nil, zap.String("token_id", storedToken.ID), stacktraceAuthenticatePersonalAccessTokenService)
+		return nil, resterrors.NewUnauthorizedError(errInvalidPersonalAccessToken)
+	}
+
+	user, err := s.userRepository.FindUserById(ctx, storedToken.UserID)
+	if err != nil {
+		if err.HttpStatusCode == http.StatusNotFound {
+			logger.Error(errInvalidPersonalAccessToken, err, zap.String("token_id", storedToken.ID), stacktraceAuthenticatePersonalAccessTokenService)
+			return nil, resterrors.NewUnauthorizedError(errInvalidPersonalAccessToken)
+		}
+
+		logger.Error(errCallRepositoy, err, stacktraceAuthenticatePersonalAccessTokenService)
+		return nil, err
+	}
+
+	if err := checkActive(user); err != nil {
+		logger.Error(err.Message, err, zap.String("token_id", storedToken.ID), stacktraceAuthenticatePersonalAccessTokenService)
+		return nil, err
+	}
+
+	if err := s.personalAccessTokenRepository.TouchPersonalAccessToken(ctx, storedToken.ID, time.Now()); err != nil {
+		logger.Error("Error when trying record the token use", err, zap.String("token_id", storedToken.ID), stacktraceAuthenticatePersonalAccessTokenService)
+	}
+
+	scopes := storedToken.Scopes
+	if scopes == nil {
+		scopes = []string{}
+	}
+
+	return &domain.Principal{
+		UserID:    user.ID,
+		Name:      user.Name,
+		Email:     user.Email,
+		Roles:     user.GetRoles(),
+		TokenID:   storedToken.ID,
+		ExpiresAt: storedToken.ExpiresAt,
+		Scopes:    scopes,
+	}, nil
+}
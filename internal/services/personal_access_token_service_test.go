@@ -0,0 +1,265 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	"github.com/WalterPaes/go-rest-api-crud/internal/repositories"
+	"github.com/WalterPaes/go-rest-api-crud/mocks"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/randtoken"
+	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	personalAccessTokenMaxExpTime = 365
+	personalAccessTokenID         = primitive.NewObjectID().Hex()
+)
+
+func Test_personalAccessTokenSvc_CreateToken(t *testing.T) {
+	tests := []struct {
+		name                          string
+		personalAccessTokenRepository repositories.PersonalAccessTokenRepository
+		expiresInDays                 int
+		wantErr                       *resterrors.RestErr
+	}{
+		{
+			name: "Should store the hash of a new token",
+			personalAccessTokenRepository: func() repositories.PersonalAccessTokenRepository {
+				m := mocks.NewPersonalAccessTokenRepository(t)
+				m.On("CreatePersonalAccessToken", ctx, mock.MatchedBy(func(token *domain.PersonalAccessToken) bool {
+					expiresIn := time.Until(token.ExpiresAt)
+					return token.UserID == userID &&
+						token.Name == "ci" &&
+						token.TokenHash != "" &&
+						expiresIn > 29*24*time.Hour && expiresIn <= 30*24*time.Hour
+				})).
+					Return(func(_ context.Context, token *domain.PersonalAccessToken) *domain.PersonalAccessToken {
+						token.ID = personalAccessTokenID
+						return token
+					}, nil)
+				return m
+			}(),
+			expiresInDays: 30,
+			wantErr:       nil,
+		},
+		{
+			name:                          "Should return an error when the token expires after the max exp time",
+			personalAccessTokenRepository: mocks.NewPersonalAccessTokenRepository(t),
+			expiresInDays:                 personalAccessTokenMaxExpTime + 1,
+			wantErr:                       resterrors.NewBadRequestError("Personal access tokens can't expire after 365 days"),
+		},
+		{
+			name: "Should return an error when try store the token",
+			personalAccessTokenRepository: func() repositories.PersonalAccessTokenRepository {
+				m := mocks.NewPersonalAccessTokenRepository(t)
+				m.On("CreatePersonalAccessToken", ctx, mock.AnythingOfType("*domain.PersonalAccessToken")).
+					Return(nil, resterrors.NewInternalServerError("Error when trying create personal access token"))
+				return m
+			}(),
+			expiresInDays: 30,
+			wantErr:       resterrors.NewInternalServerError("Error when trying create personal access token"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewPersonalAccessTokenService(tt.personalAccessTokenRepository, mocks.NewUserRepository(t), personalAccessTokenMaxExpTime)
+
+			storedToken, token, err := s.CreateToken(ctx, userID, "ci", []string{domain.ScopeUsersRead}, tt.expiresInDays)
+
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				assert.Nil(t, storedToken)
+				assert.Empty(t, token)
+				return
+			}
+
+			assert.True(t, strings.HasPrefix(token, domain.PersonalAccessTokenPrefix))
+			assert.Equal(t, randtoken.Hash(token), storedToken.TokenHash)
+			assert.Equal(t, personalAccessTokenID, storedToken.ID)
+		})
+	}
+}
+
+func Test_personalAccessTokenSvc_ListTokens(t *testing.T) {
+	tokens := []*domain.PersonalAccessToken{{ID: personalAccessTokenID, UserID: userID, Name: "ci"}}
+
+	personalAccessTokenRepository := mocks.NewPersonalAccessTokenRepository(t)
+	personalAccessTokenRepository.On("FindUserPersonalAccessTokens", ctx, userID).
+		Return(tokens, nil)
+
+	s := NewPersonalAccessTokenService(personalAccessTokenRepository, mocks.NewUserRepository(t), personalAccessTokenMaxExpTime)
+
+	got, err := s.ListTokens(ctx, userID)
+
+	assert.Nil(t, err)
+	assert.Equal(t, tokens, got)
+}
+
+func Test_personalAccessTokenSvc_RevokeToken(t *testing.T) {
+	tests := []struct {
+		name    string
+		repoErr *resterrors.RestErr
+		wantErr *resterrors.RestErr
+	}{
+		{
+			name:    "Should revoke the token",
+			repoErr: nil,
+			wantErr: nil,
+		},
+		{
+			name:    "Should return not found when the token is not of the user",
+			repoErr: resterrors.NewNotFoundError("Personal Access Token Not Found"),
+			wantErr: resterrors.NewNotFoundError("Personal Access Token Not Found"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			personalAccessTokenRepository := mocks.NewPersonalAccessTokenRepository(t)
+			personalAccessTokenRepository.On("RevokePersonalAccessToken", ctx, userID, personalAccessTokenID).
+				Return(tt.repoErr)
+
+			s := NewPersonalAccessTokenService(personalAccessTokenRepository, mocks.NewUserRepository(t), personalAccessTokenMaxExpTime)
+
+			assert.Equal(t, tt.wantErr, s.RevokeToken(ctx, userID, personalAccessTokenID))
+		})
+	}
+}
+
+func Test_personalAccessTokenSvc_Authenticate(t *testing.T) {
+	token := domain.PersonalAccessTokenPrefix + "token"
+	revokedAt := time.Now().Add(-time.Minute)
+
+	validToken := func() *domain.PersonalAccessToken {
+		return &domain.PersonalAccessToken{
+			ID:        personalAccessTokenID,
+			UserID:    userID,
+			Scopes:    []string{domain.ScopeUsersRead},
+			TokenHash: randtoken.Hash(token),
+			ExpiresAt: time.Now().Add(time.Hour),
+		}
+	}
+
+	findToken := func(storedToken *domain.PersonalAccessToken, err *resterrors.RestErr) *mocks.PersonalAccessTokenRepository {
+		m := mocks.NewPersonalAccessTokenRepository(t)
+		m.On("FindPersonalAccessTokenByHash", ctx, randtoken.Hash(token)).
+			Return(storedToken, err)
+		return m
+	}
+
+	tests := []struct {
+		name                          string
+		token                         string
+		personalAccessTokenRepository repositories.PersonalAccessTokenRepository
+		userRepository                repositories.UserRepository
+		wantPrincipal                 *domain.Principal
+		wantErr                       *resterrors.RestErr
+	}{
+		{
+			name:  "Should return the principal of the token owner with the token scopes",
+			token: token,
+			personalAccessTokenRepository: func() repositories.PersonalAccessTokenRepository {
+				m := findToken(validToken(), nil)
+				m.On("TouchPersonalAccessToken", ctx, personalAccessTokenID, mock.AnythingOfType("time.Time")).
+					Return(nil)
+				return m
+			}(),
+			userRepository: func() repositories.UserRepository {
+				m := mocks.NewUserRepository(t)
+				m.On("FindUserById", ctx, userID).
+					Return(&domain.User{ID: userID, Name: "First User", Email: "first@email.com"}, nil)
+				return m
+			}(),
+			wantPrincipal: &domain.Principal{
+				UserID:  userID,
+				Name:    "First User",
+				Email:   "first@email.com",
+				Roles:   []string{domain.RoleUser},
+				TokenID: personalAccessTokenID,
+				Scopes:  []string{domain.ScopeUsersRead},
+			},
+			wantErr: nil,
+		},
+		{
+			name:                          "Should refuse the token of a suspended user",
+			token:                         token,
+			personalAccessTokenRepository: findToken(validToken(), nil),
+			userRepository: func() repositories.UserRepository {
+				m := mocks.NewUserRepository(t)
+				m.On("FindUserById", ctx, userID).
+					Return(&domain.User{ID: userID, Status: domain.UserStatusSuspended}, nil)
+				return m
+			}(),
+			wantErr: resterrors.NewForbiddenError("Account is suspended").WithCode(ErrCodeAccountInactive),
+		},
+		{
+			name:                          "Should return unauthorized when the token has not the prefix",
+			token:                         "header.payload.signature",
+			personalAccessTokenRepository: mocks.NewPersonalAccessTokenRepository(t),
+			userRepository:                mocks.NewUserRepository(t),
+			wantErr:                       resterrors.NewUnauthorizedError(errInvalidPersonalAccessToken),
+		},
+		{
+			name:                          "Should return unauthorized when the token is unknown",
+			token:                         token,
+			personalAccessTokenRepository: findToken(nil, resterrors.NewNotFoundError("Personal Access Token Not Found")),
+			userRepository:                mocks.NewUserRepository(t),
+			wantErr:                       resterrors.NewUnauthorizedError(errInvalidPersonalAccessToken),
+		},
+		{
+			name:  "Should return unauthorized when the token was revoked",
+			token: token,
+			personalAccessTokenRepository: func() repositories.PersonalAccessTokenRepository {
+				revokedToken := validToken()
+				revokedToken.RevokedAt = &revokedAt
+				return findToken(revokedToken, nil)
+			}(),
+			userRepository: mocks.NewUserRepository(t),
+			wantErr:        resterrors.NewUnauthorizedError(errInvalidPersonalAccessToken),
+		},
+		{
+			name:  "Should return unauthorized when the token is expired",
+			token: token,
+			personalAccessTokenRepository: func() repositories.PersonalAccessTokenRepository {
+				expiredToken := validToken()
+				expiredToken.ExpiresAt = time.Now().Add(-time.Minute)
+				return findToken(expiredToken, nil)
+			}(),
+			userRepository: mocks.NewUserRepository(t),
+			wantErr:        resterrors.NewUnauthorizedError(errInvalidPersonalAccessToken),
+		},
+		{
+			name:                          "Should return unauthorized when the token owner was deleted",
+			token:                         token,
+			personalAccessTokenRepository: findToken(validToken(), nil),
+			userRepository: func() repositories.UserRepository {
+				m := mocks.NewUserRepository(t)
+				m.On("FindUserById", ctx, userID).
+					Return(nil, resterrors.NewNotFoundError("User Not Found"))
+				return m
+			}(),
+			wantErr: resterrors.NewUnauthorizedError(errInvalidPersonalAccessToken),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewPersonalAccessTokenService(tt.personalAccessTokenRepository, tt.userRepository, personalAccessTokenMaxExpTime)
+
+			principal, err := s.Authenticate(ctx, tt.token)
+
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantPrincipal == nil {
+				assert.Nil(t, principal)
+				return
+			}
+
+			principal.ExpiresAt = time.Time{}
+			assert.Equal(t, tt.wantPrincipal, principal)
+		})
+	}
+}
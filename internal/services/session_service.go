@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+
+	"github.com/WalterPaes/go-rest-api-crud/internal/auth"
+	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	"github.com/WalterPaes/go-rest-api-crud/internal/repositories"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/jwt"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/logger"
+	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
+	"go.uber.org/zap"
+)
+
+const (
+	errSessionNotFound = "Session Not Found"
+)
+
+var (
+	stacktraceListSessionsService  = zap.String("stacktrace", "list-sessions-service")
+	stacktraceRevokeSessionService = zap.String("stacktrace", "revoke-session-service")
+)
+
+type SessionService interface {
+	ListSessions(ctx context.Context, userID string) ([]*domain.Session, *resterrors.RestErr)
+	RevokeSession(ctx context.Context, userID, sessionID string) *resterrors.RestErr
+}
+
+type sessionSvc struct {
+	sessionRepository      repositories.SessionRepository
+	refreshTokenRepository repositories.RefreshTokenRepository
+	jwtAuth                jwt.JwtAuth
+}
+
+// NewSessionService builds the session service. A session is alive while its
+// refresh token family is, so every way of revoking the family ends the session.
+func NewSessionService(
+	sessionRepository repositories.SessionRepository,
+	refreshTokenRepository repositories.RefreshTokenRepository,
+	jwtAuth jwt.JwtAuth,
+) *sessionSvc {
+	return &sessionSvc{
+		sessionRepository:      sessionRepository,
+		refreshTokenRepository: refreshTokenRepository,
+		jwtAuth:                jwtAuth,
+	}
+}
+
+// ListSessions returns the sessions of the user that can still be refreshed, the most
+// recently seen first.
+func (s *sessionSvc) ListSessions(ctx context.Context, userID string) ([]*domain.Session, *resterrors.RestErr) {
+	logger.Info("Starting List Sessions", stacktraceListSessionsService)
+
+	sessionIDs, err := s.refreshTokenRepository.FindUserSessionIDs(ctx, userID)
+	if err != nil {
+		logger.Error(errCallRepositoy, err, stacktraceListSessionsService)
+		return nil, err
+	}
+
+	sessions, err := s.sessionRepository.FindUserSessions(ctx, userID, sessionIDs)
+	if err != nil {
+		logger.Error(errCallRepositoy, err, stacktraceListSessionsService)
+		return nil, err
+	}
+
+	logger.Info("Sessions Listed Successfully", zap.String("user_id", userID), stacktraceListSessionsService)
+	return sessions, nil
+}
+
+// RevokeSession signs the user out of the session: its refresh tokens can't be used
+// anymore and its access tokens are refused right away.
+func (s *sessionSvc) RevokeSession(ctx context.Context, userID, sessionID string) *resterrors.RestErr {
+	logger.Info("Starting Revoke Session", stacktraceRevokeSessionService)
+
+	sessionIDs, err := s.refreshTokenRepository.FindUserSessionIDs(ctx, userID)
+	if err != nil {
+		logger.Error(errCallRepositoy, err, stacktraceRevokeSessionService)
+		return err
+	}
+
+	found := false
+	for _, id := range sessionIDs {
+		if id == sessionID {
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		logger.Error(errSessionNotFound, nil, zap.String("user_id", userID), zap.String("session_id", sessionID), stacktraceRevokeSessionService)
+		return resterrors.NewNotFoundError(errSessionNotFound)
+	}
+
+	if err := s.refreshTokenRepository.RevokeRefreshTokenFamily(ctx, sessionID); err != nil {
+		logger.Error(errCallRepositoy, err, stacktraceRevokeSessionService)
+		return err
+	}
+
+	if err := s.jwtAuth.RevokeSessions(ctx, sessionID); err != nil {
+		logger.Error("Error when trying revoke session tokens", err, stacktraceRevokeSessionService)
+		return err
+	}
+
+	logger.Info("Session Revoked Successfully", zap.String("user_id", userID), zap.String("session_id", sessionID), auth.ActorField(ctx), stacktraceRevokeSessionService)
+	return nil
+}
@@ -0,0 +1,164 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	"github.com/WalterPaes/go-rest-api-crud/internal/repositories"
+	"github.com/WalterPaes/go-rest-api-crud/mocks"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/jwt"
+	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	storedSession = &domain.Session{
+		ID:         sessionID,
+		UserID:     userID,
+		UserAgent:  "Mozilla/5.0",
+		IP:         "10.0.0.1",
+		TokenID:    "token_id",
+		CreatedAt:  time.Now(),
+		LastSeenAt: time.Now(),
+		ExpiresAt:  time.Now().Add(time.Hour),
+	}
+)
+
+func Test_sessionSvc_ListSessions(t *testing.T) {
+	type fields struct {
+		sessionRepository      repositories.SessionRepository
+		refreshTokenRepository repositories.RefreshTokenRepository
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		want    []*domain.Session
+		wantErr *resterrors.RestErr
+	}{
+		{
+			name: "Should list the sessions of the refresh token families still alive",
+			fields: fields{
+				sessionRepository: func() repositories.SessionRepository {
+					m := mocks.NewSessionRepository(t)
+					m.On("FindUserSessions", ctx, userID, []string{sessionID, "family_test"}).
+						Return([]*domain.Session{storedSession}, nil)
+					return m
+				}(),
+				refreshTokenRepository: func() repositories.RefreshTokenRepository {
+					m := mocks.NewRefreshTokenRepository(t)
+					m.On("FindUserSessionIDs", ctx, userID).
+						Return([]string{sessionID, "family_test"}, nil)
+					return m
+				}(),
+			},
+			want:    []*domain.Session{storedSession},
+			wantErr: nil,
+		},
+		{
+			name: "Should return an error when try find the session ids",
+			fields: fields{
+				sessionRepository: mocks.NewSessionRepository(t),
+				refreshTokenRepository: func() repositories.RefreshTokenRepository {
+					m := mocks.NewRefreshTokenRepository(t)
+					m.On("FindUserSessionIDs", ctx, userID).
+						Return(nil, internalServerError)
+					return m
+				}(),
+			},
+			want:    nil,
+			wantErr: internalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewSessionService(tt.fields.sessionRepository, tt.fields.refreshTokenRepository, mocks.NewJwtAuth(t))
+			got, err := s.ListSessions(ctx, userID)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("sessionSvc.ListSessions() got = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(err, tt.wantErr) {
+				t.Errorf("sessionSvc.ListSessions() err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_sessionSvc_RevokeSession(t *testing.T) {
+	type fields struct {
+		refreshTokenRepository repositories.RefreshTokenRepository
+		jwtAuth                jwt.JwtAuth
+	}
+	tests := []struct {
+		name      string
+		fields    fields
+		sessionID string
+		wantErr   *resterrors.RestErr
+	}{
+		{
+			name: "Should revoke the refresh tokens and the access tokens of the session",
+			fields: fields{
+				refreshTokenRepository: func() repositories.RefreshTokenRepository {
+					m := mocks.NewRefreshTokenRepository(t)
+					m.On("FindUserSessionIDs", ctx, userID).
+						Return([]string{sessionID}, nil)
+					m.On("RevokeRefreshTokenFamily", ctx, sessionID).
+						Return(nil)
+					return m
+				}(),
+				jwtAuth: func() jwt.JwtAuth {
+					m := mocks.NewJwtAuth(t)
+					m.On("RevokeSessions", ctx, sessionID).
+						Return(nil)
+					return m
+				}(),
+			},
+			sessionID: sessionID,
+			wantErr:   nil,
+		},
+		{
+			name: "Should return not found when the session isn't an active session of the user",
+			fields: fields{
+				refreshTokenRepository: func() repositories.RefreshTokenRepository {
+					m := mocks.NewRefreshTokenRepository(t)
+					m.On("FindUserSessionIDs", ctx, userID).
+						Return([]string{sessionID}, nil)
+					return m
+				}(),
+				jwtAuth: mocks.NewJwtAuth(t),
+			},
+			sessionID: primitive.NewObjectID().Hex(),
+			wantErr:   resterrors.NewNotFoundError(errSessionNotFound),
+		},
+		{
+			name: "Should return an error when try revoke the access tokens",
+			fields: fields{
+				refreshTokenRepository: func() repositories.RefreshTokenRepository {
+					m := mocks.NewRefreshTokenRepository(t)
+					m.On("FindUserSessionIDs", ctx, userID).
+						Return([]string{sessionID}, nil)
+					m.On("RevokeRefreshTokenFamily", ctx, sessionID).
+						Return(nil)
+					return m
+				}(),
+				jwtAuth: func() jwt.JwtAuth {
+					m := mocks.NewJwtAuth(t)
+					m.On("RevokeSessions", ctx, sessionID).
+						Return(internalServerError)
+					return m
+				}(),
+			},
+			sessionID: sessionID,
+			wantErr:   internalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewSessionService(mocks.NewSessionRepository(t), tt.fields.refreshTokenRepository, tt.fields.jwtAuth)
+			if err := s.RevokeSession(ctx, userID, tt.sessionID); !reflect.DeepEqual(err, tt.wantErr) {
+				t.Errorf("sessionSvc.RevokeSession() err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/WalterPaes/go-rest-api-crud/internal/repositories"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/logger"
+	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
+	"go.uber.org/zap"
+)
+
+var (
+	stacktracePurgeDeletedUsersService = zap.String("stacktrace", "purge-deleted-users-service")
+)
+
+type userPurger struct {
+	userRepository repositories.UserRepository
+	retention      time.Duration
+}
+
+// NewUserPurger builds the purger of the soft deleted users. retention, in days, is
+// how long a deleted user can still be restored before it is deleted for good.
+func NewUserPurger(userRepository repositories.UserRepository, retention int) *userPurger {
+	return &userPurger{
+		userRepository: userRepository,
+		retention:      time.Hour * 24 * time.Duration(retention),
+	}
+}
+
+// Purge deletes for good the users deleted longer than the retention ago.
+func (p *userPurger) Purge(ctx context.Context) (int64, *resterrors.RestErr) {
+	logger.Info("Starting Purge Deleted Users", stacktracePurgeDeletedUsersService)
+
+	purged, err := p.userRepository.PurgeDeletedUsers(ctx, time.Now().Add(-p.retention))
+	if err != nil {
+		logger.Error(errCallRepositoy, err, stacktracePurgeDeletedUsersService)
+		return 0, err
+	}
+
+	logger.Info("Deleted Users Purged Successfully", zap.Int64("purged", purged), stacktracePurgeDeletedUsersService)
+	return purged, nil
+}
+
+// Run purges the deleted users right away and then every interval, until the context
+// is done. A failed purge is only logged, the next one tries again.
+func (p *userPurger) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		p.Purge(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/WalterPaes/go-rest-api-crud/internal/repositories"
+	"github.com/WalterPaes/go-rest-api-crud/mocks"
+	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_userPurger_Purge(t *testing.T) {
+	retention := 30
+
+	tests := []struct {
+		name           string
+		userRepository repositories.UserRepository
+		want           int64
+		wantErr        *resterrors.RestErr
+	}{
+		{
+			name: "Should purge the users deleted before the retention",
+			userRepository: func() repositories.UserRepository {
+				m := mocks.NewUserRepository(t)
+				m.On("PurgeDeletedUsers", ctx, mock.MatchedBy(func(deletedBefore time.Time) bool {
+					limit := time.Now().AddDate(0, 0, -retention)
+					return deletedBefore.Sub(limit).Abs() < time.Minute
+				})).
+					Return(int64(2), nil)
+				return m
+			}(),
+			want:    2,
+			wantErr: nil,
+		},
+		{
+			name: "Should return an error when try purge the users",
+			userRepository: func() repositories.UserRepository {
+				m := mocks.NewUserRepository(t)
+				m.On("PurgeDeletedUsers", ctx, mock.AnythingOfType("time.Time")).
+					Return(int64(0), internalServerError)
+				return m
+			}(),
+			want:    0,
+			wantErr: internalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewUserPurger(tt.userRepository, retention)
+			got, err := p.Purge(ctx)
+			if !reflect.DeepEqual(err, tt.wantErr) {
+				t.Errorf("userPurger.Purge() err = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("userPurger.Purge() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,18 +3,42 @@ package services
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
+	"time"
 
+	"github.com/WalterPaes/go-rest-api-crud/internal/auth"
 	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
 	"github.com/WalterPaes/go-rest-api-crud/internal/repositories"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/hasher"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/jwt"
 	"github.com/WalterPaes/go-rest-api-crud/pkg/logger"
 	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/signer"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/validation"
 	"go.uber.org/zap"
 )
 
 const (
 	errCallRepositoy          = "Error when try call repository"
 	errEmailAlreadyRegistered = "Email is already registered"
+	errChangeRoles            = "Only admins can change user roles"
+	errChangeAdminEmail       = "OAuth clients can't change the email of an admin"
+	errImpersonatedEmail      = "The email can't be changed while impersonating the user"
+	errEmptyPatch             = "At least one field must be sent to update the user"
+	errInvalidCurrentPassword = "Current password is invalid"
+	errSamePassword           = "New password must be different from the current one"
+	errEncryptPassword        = "Error when trying encrypt password"
+	errInvalidStatusChange    = "User status can't change from %s to %s"
+	errChangeOwnStatus        = "An user can't change its own status"
+	errStatusChangedMeanwhile = "User status was changed by another request"
+	errInvalidUsersCursor     = "Cursor is invalid, it must come from a page with the same sort"
+	errExpiredUsersCursor     = "Cursor is expired, the listing must start again from the first page"
+	errSignUsersCursor        = "Error when trying sign the users cursor"
+	usersCursorPurpose        = "users_cursor"
+	usersCursorExpTime        = 24
+
+	ErrCodeInvalidStatusTransition = "invalid_status_transition"
 )
 
 var (
@@ -22,66 +46,163 @@ var (
 	stacktraceCreateUserService   = zap.String("stacktrace", "create-user-service")
 	stacktraceFindUserByIdService = zap.String("stacktrace", "find-user-by-id-service")
 	stacktraceUpdateUserService   = zap.String("stacktrace", "update-user-service")
+	stacktracePatchUserService    = zap.String("stacktrace", "patch-user-service")
 	stacktraceDeleteUserService   = zap.String("stacktrace", "delete-user-service")
+	stacktraceChangePasswordSvc   = zap.String("stacktrace", "change-password-service")
+	stacktraceChangeStatusService = zap.String("stacktrace", "change-status-service")
+	stacktraceRestoreUserService  = zap.String("stacktrace", "restore-user-service")
 )
 
 type UserService interface {
-	FindAll(ctx context.Context, itemsPerPage, currentPage int) ([]*domain.User, *resterrors.RestErr)
+	FindAll(ctx context.Context, query *domain.UserQuery) (*domain.UserPage, *resterrors.RestErr)
 	CreateUser(context.Context, *domain.User) (*domain.User, *resterrors.RestErr)
-	FindUserById(ctx context.Context, userID string) (*domain.User, *resterrors.RestErr)
+	FindUserById(ctx context.Context, userID string, fields ...string) (*domain.User, *resterrors.RestErr)
 	UpdateUser(ctx context.Context, userID string, user *domain.User) (*domain.User, *resterrors.RestErr)
+	PatchUser(ctx context.Context, userID string, patch *domain.UserPatch) (*domain.User, *resterrors.RestErr)
 	DeleteUser(ctx context.Context, userID string) *resterrors.RestErr
+	RestoreUser(ctx context.Context, userID string) (*domain.User, *resterrors.RestErr)
+	ChangePassword(ctx context.Context, userID, currentPassword, newPassword string) *resterrors.RestErr
+	ChangeStatus(ctx context.Context, userID, status, reason string) (*domain.User, *resterrors.RestErr)
 }
 
 type userSvc struct {
-	userRepository repositories.UserRepository
+	userRepository           repositories.UserRepository
+	refreshTokenRepository   repositories.RefreshTokenRepository
+	jwtAuth                  jwt.JwtAuth
+	emailVerificationService EmailVerificationService
+	passwordHasher           hasher.PasswordHasher
+	passwordPolicy           *validation.PasswordPolicy
+	passwordHistorySize      int
+	cursorSigner             *signer.Signer
+}
+
+// usersCursorData is signed in the cursors of the users listing. The sort is kept since
+// the values of another sort would place the cursor wrong, the purpose keeps other
+// signed tokens from being accepted as a cursor.
+type usersCursorData struct {
+	ID       string   `json:"id"`
+	Values   []string `json:"values,omitempty"`
+	Sort     string   `json:"sort,omitempty"`
+	Backward bool     `json:"backward,omitempty"`
+	Purpose  string   `json:"purpose"`
 }
 
-func NewUserService(userRepository repositories.UserRepository) *userSvc {
+func NewUserService(
+	userRepository repositories.UserRepository,
+	refreshTokenRepository repositories.RefreshTokenRepository,
+	jwtAuth jwt.JwtAuth,
+	emailVerificationService EmailVerificationService,
+	passwordHasher hasher.PasswordHasher,
+	passwordPolicy *validation.PasswordPolicy,
+	passwordHistorySize int,
+	cursorSigner *signer.Signer,
+) *userSvc {
 	return &userSvc{
-		userRepository: userRepository,
+		userRepository:           userRepository,
+		refreshTokenRepository:   refreshTokenRepository,
+		jwtAuth:                  jwtAuth,
+		emailVerificationService: emailVerificationService,
+		passwordHasher:           passwordHasher,
+		passwordPolicy:           passwordPolicy,
+		passwordHistorySize:      passwordHistorySize,
+		cursorSigner:             cursorSigner,
 	}
 }
 
-func (s *userSvc) FindAll(ctx context.Context, itemsPerPage, currentPage int) ([]*domain.User, *resterrors.RestErr) {
+// FindAll lists the users matching the query, sorted and paged as it asks. The page
+// has the cursors of the pages around it, which keep their place while users are
+// created or deleted, and the total of users when the query asks to count them.
+func (s *userSvc) FindAll(ctx context.Context, query *domain.UserQuery) (*domain.UserPage, *resterrors.RestErr) {
 	logger.Info("Starting FindAll", stacktraceFindAllUsersService)
 
-	users, err := s.userRepository.FindAll(ctx, itemsPerPage, currentPage)
+	if query.Cursor != "" {
+		from, err := s.decodeUsersCursor(query)
+		if errors.Is(err, signer.ErrExpired) {
+			logger.Error(errExpiredUsersCursor, err, stacktraceFindAllUsersService)
+			return nil, resterrors.NewBadRequestError(errExpiredUsersCursor)
+		}
+		if err != nil {
+			logger.Error(errInvalidUsersCursor, err, stacktraceFindAllUsersService)
+			return nil, resterrors.NewBadRequestError(errInvalidUsersCursor)
+		}
+		query.From = from
+	}
+
+	page, err := s.userRepository.FindAll(ctx, query)
 	if err != nil {
 		logger.Error(errCallRepositoy, err, stacktraceFindAllUsersService)
 		return nil, err
 	}
 
+	if query.Count {
+		total, err := s.userRepository.CountUsers(ctx, query)
+		if err != nil {
+			logger.Error(errCallRepositoy, err, stacktraceFindAllUsersService)
+			return nil, err
+		}
+		page.TotalItems = &total
+	}
+
+	if err := s.setUsersCursors(query, page); err != nil {
+		logger.Error(errSignUsersCursor, err, stacktraceFindAllUsersService)
+		return nil, resterrors.NewInternalServerError(errSignUsersCursor)
+	}
+
 	logger.Info(
 		"FindAll executed successfully",
-		zap.Int("items_per_page", itemsPerPage),
-		zap.Int("current_page", currentPage),
+		zap.Int("items_per_page", query.PerPage),
+		zap.Int("current_page", query.Page),
+		zap.Bool("from_cursor", query.From != nil),
 		stacktraceFindAllUsersService,
 	)
-	return users, nil
+	return page, nil
 }
 
 func (s *userSvc) CreateUser(ctx context.Context, user *domain.User) (*domain.User, *resterrors.RestErr) {
 	logger.Info("Starting CreateUser", stacktraceCreateUserService)
 
-	if err := s.checkIfEmailIsAlreadyRegistered(ctx, user.Email, user.ID); err != nil {
+	if err := s.passwordPolicy.Check("Password", user.Password, user.Name, user.Email); err != nil {
+		logger.Error(err.Message, err, stacktraceCreateUserService)
 		return nil, err
 	}
 
+	principal, _ := auth.PrincipalFromContext(ctx)
+	if len(user.Roles) > 0 && (principal == nil || !principal.HasRole(domain.RoleAdmin)) {
+		logger.Error(errChangeRoles, nil, auth.ActorField(ctx), stacktraceCreateUserService)
+		return nil, resterrors.NewForbiddenError(errChangeRoles)
+	}
+
+	if _, err := s.checkIfEmailIsAlreadyRegistered(ctx, user.Email, user.ID); err != nil {
+		return nil, err
+	}
+
+	if len(user.Roles) == 0 {
+		user.Roles = []string{domain.RoleUser}
+	}
+	user.Status = domain.UserStatusActive
+
+	if encryptErr := user.EncryptPassword(s.passwordHasher); encryptErr != nil {
+		logger.Error(errEncryptPassword, encryptErr, stacktraceCreateUserService)
+		return nil, resterrors.NewInternalServerError(errEncryptPassword)
+	}
+
 	createdUser, err := s.userRepository.CreateUser(ctx, user)
 	if err != nil {
 		logger.Error(errCallRepositoy, err, stacktraceCreateUserService)
 		return nil, err
 	}
 
-	logger.Info("CreateUser executed successfully", zap.String("user_id", createdUser.ID), stacktraceCreateUserService)
+	s.sendVerificationEmail(ctx, createdUser)
+
+	logger.Info("CreateUser executed successfully", zap.String("user_id", createdUser.ID), auth.ActorField(ctx), stacktraceCreateUserService)
 	return createdUser, nil
 }
 
-func (s *userSvc) FindUserById(ctx context.Context, userID string) (*domain.User, *resterrors.RestErr) {
+// FindUserById finds the user, reading only the fields when sent.
+func (s *userSvc) FindUserById(ctx context.Context, userID string, fields ...string) (*domain.User, *resterrors.RestErr) {
 	logger.Info("Starting FindUserById", stacktraceFindUserByIdService)
 
-	user, err := s.userRepository.FindUserById(ctx, userID)
+	user, err := s.userRepository.FindUserById(ctx, userID, fields...)
 	if err != nil {
 		logger.Error(errCallRepositoy, err, stacktraceFindUserByIdService)
 		return nil, err
@@ -94,20 +215,78 @@ func (s *userSvc) FindUserById(ctx context.Context, userID string) (*domain.User
 func (s *userSvc) UpdateUser(ctx context.Context, userID string, user *domain.User) (*domain.User, *resterrors.RestErr) {
 	logger.Info("Starting UpdateUser", stacktraceUpdateUserService)
 
-	if err := s.checkIfEmailIsAlreadyRegistered(ctx, user.Email, userID); err != nil {
+	principal, _ := auth.PrincipalFromContext(ctx)
+	if len(user.Roles) > 0 && (principal == nil || !principal.HasRole(domain.RoleAdmin)) {
+		logger.Error(errChangeRoles, nil, auth.ActorField(ctx), zap.String("user_id", userID), stacktraceUpdateUserService)
+		return nil, resterrors.NewForbiddenError(errChangeRoles)
+	}
+
+	isCurrentEmail, err := s.checkIfEmailIsAlreadyRegistered(ctx, user.Email, userID)
+	if err != nil {
 		return nil, err
 	}
 
+	if !isCurrentEmail {
+		if err := s.checkEmailChange(ctx, userID); err != nil {
+			logger.Error(err.Message, err, auth.ActorField(ctx), zap.String("user_id", userID), stacktraceUpdateUserService)
+			return nil, err
+		}
+	}
+
 	updatedUser, err := s.userRepository.UpdateUser(ctx, userID, user)
 	if err != nil {
 		logger.Error(errCallRepositoy, err, stacktraceUpdateUserService)
 		return nil, err
 	}
 
-	logger.Info("UpdateUser executed successfully", zap.String("user_id", updatedUser.ID), stacktraceUpdateUserService)
+	if !isCurrentEmail {
+		s.sendVerificationEmail(ctx, updatedUser)
+	}
+
+	logger.Info("UpdateUser executed successfully", zap.String("user_id", updatedUser.ID), auth.ActorField(ctx), stacktraceUpdateUserService)
 	return updatedUser, nil
 }
 
+// PatchUser changes only the fields sent in the patch, keeping the others as stored.
+func (s *userSvc) PatchUser(ctx context.Context, userID string, patch *domain.UserPatch) (*domain.User, *resterrors.RestErr) {
+	logger.Info("Starting PatchUser", stacktracePatchUserService)
+
+	if patch.IsEmpty() {
+		logger.Error(errEmptyPatch, nil, stacktracePatchUserService)
+		return nil, resterrors.NewBadRequestError(errEmptyPatch)
+	}
+
+	isCurrentEmail := true
+	if patch.Email != nil {
+		var err *resterrors.RestErr
+		if isCurrentEmail, err = s.checkIfEmailIsAlreadyRegistered(ctx, *patch.Email, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	if !isCurrentEmail {
+		if err := s.checkEmailChange(ctx, userID); err != nil {
+			logger.Error(err.Message, err, auth.ActorField(ctx), zap.String("user_id", userID), stacktracePatchUserService)
+			return nil, err
+		}
+	}
+
+	patchedUser, err := s.userRepository.PatchUser(ctx, userID, patch)
+	if err != nil {
+		logger.Error(errCallRepositoy, err, stacktracePatchUserService)
+		return nil, err
+	}
+
+	if !isCurrentEmail {
+		s.sendVerificationEmail(ctx, patchedUser)
+	}
+
+	logger.Info("PatchUser executed successfully", zap.String("user_id", patchedUser.ID), auth.ActorField(ctx), stacktracePatchUserService)
+	return patchedUser, nil
+}
+
+// DeleteUser soft deletes the user and revokes its tokens, the data is kept until the
+// purge, so an admin can still restore the user.
 func (s *userSvc) DeleteUser(ctx context.Context, userID string) *resterrors.RestErr {
 	logger.Info("Starting DeleteUser", stacktraceDeleteUserService)
 
@@ -117,21 +296,304 @@ func (s *userSvc) DeleteUser(ctx context.Context, userID string) *resterrors.Res
 		return err
 	}
 
-	logger.Info("DeleteUser executed successfully", zap.String("user_id", userID), stacktraceDeleteUserService)
+	if err := s.revokeUserTokens(ctx, userID); err != nil {
+		logger.Error("Error when trying revoke user tokens", err, stacktraceDeleteUserService)
+		return err
+	}
+
+	logger.Info("DeleteUser executed successfully", zap.String("user_id", userID), auth.ActorField(ctx), stacktraceDeleteUserService)
+	return nil
+}
+
+// RestoreUser brings back a soft deleted user. It is refused when another user registered
+// the email meanwhile, since two users can't share an email.
+func (s *userSvc) RestoreUser(ctx context.Context, userID string) (*domain.User, *resterrors.RestErr) {
+	logger.Info("Starting RestoreUser", stacktraceRestoreUserService)
+
+	deletedUser, err := s.userRepository.FindDeletedUserById(ctx, userID)
+	if err != nil {
+		logger.Error(errCallRepositoy, err, stacktraceRestoreUserService)
+		return nil, err
+	}
+
+	if _, err := s.checkIfEmailIsAlreadyRegistered(ctx, deletedUser.Email, userID); err != nil {
+		return nil, err
+	}
+
+	restoredUser, err := s.userRepository.RestoreUser(ctx, userID)
+	if err != nil {
+		logger.Error(errCallRepositoy, err, stacktraceRestoreUserService)
+		return nil, err
+	}
+
+	logger.Info("RestoreUser executed successfully", zap.String("user_id", userID), auth.ActorField(ctx), stacktraceRestoreUserService)
+	return restoredUser, nil
+}
+
+// ChangePassword replaces the password once the current one is confirmed. Every other
+// session of the user is revoked, the session making the request is kept.
+func (s *userSvc) ChangePassword(ctx context.Context, userID, currentPassword, newPassword string) *resterrors.RestErr {
+	logger.Info("Starting ChangePassword", stacktraceChangePasswordSvc)
+
+	user, err := s.userRepository.FindUserById(ctx, userID)
+	if err != nil {
+		logger.Error(errCallRepositoy, err, stacktraceChangePasswordSvc)
+		return err
+	}
+
+	if !user.ComparePassword(currentPassword) {
+		logger.Error(errInvalidCurrentPassword, nil, zap.String("user_id", userID), stacktraceChangePasswordSvc)
+		return resterrors.NewBadRequestError(errInvalidCurrentPassword)
+	}
+
+	if user.ComparePassword(newPassword) {
+		logger.Error(errSamePassword, nil, zap.String("user_id", userID), stacktraceChangePasswordSvc)
+		return resterrors.NewBadRequestError(errSamePassword)
+	}
+
+	if err := s.passwordPolicy.Check("NewPassword", newPassword, user.Name, user.Email); err != nil {
+		logger.Error(err.Message, err, zap.String("user_id", userID), stacktraceChangePasswordSvc)
+		return err
+	}
+
+	if err := checkPasswordReuse(user, "NewPassword", newPassword, s.passwordHistorySize); err != nil {
+		logger.Error(err.Message, err, zap.String("user_id", userID), stacktraceChangePasswordSvc)
+		return err
+	}
+
+	user.Password = newPassword
+	if encryptErr := user.EncryptPassword(s.passwordHasher); encryptErr != nil {
+		logger.Error(errEncryptPassword, encryptErr, stacktraceChangePasswordSvc)
+		return resterrors.NewInternalServerError(errEncryptPassword)
+	}
+
+	if err := s.userRepository.UpdatePassword(ctx, userID, user.Password, s.passwordHistorySize); err != nil {
+		logger.Error(errCallRepositoy, err, stacktraceChangePasswordSvc)
+		return err
+	}
+
+	if err := s.revokeOtherSessions(ctx, userID); err != nil {
+		logger.Error("Error when trying revoke user sessions", err, stacktraceChangePasswordSvc)
+		return err
+	}
+
+	logger.Info("ChangePassword executed successfully", zap.String("user_id", userID), auth.ActorField(ctx), stacktraceChangePasswordSvc)
 	return nil
 }
 
-func (s *userSvc) checkIfEmailIsAlreadyRegistered(ctx context.Context, email, userID string) *resterrors.RestErr {
+// ChangeStatus moves the user to the status when the transition is allowed. Every token
+// of an user leaving the active status is revoked, so it is signed out right away. The
+// status is saved first so no login gets new tokens meanwhile, and restored when the
+// tokens can't be revoked: the change fails as a whole instead of leaving a suspended
+// user with working tokens.
+func (s *userSvc) ChangeStatus(ctx context.Context, userID, status, reason string) (*domain.User, *resterrors.RestErr) {
+	logger.Info("Starting ChangeStatus", stacktraceChangeStatusService)
+
+	if principal, ok := auth.PrincipalFromContext(ctx); ok && principal.IsOwner(userID) {
+		logger.Error(errChangeOwnStatus, nil, zap.String("user_id", userID), stacktraceChangeStatusService)
+		return nil, resterrors.NewBadRequestError(errChangeOwnStatus)
+	}
+
+	user, err := s.userRepository.FindUserById(ctx, userID)
+	if err != nil {
+		logger.Error(errCallRepositoy, err, stacktraceChangeStatusService)
+		return nil, err
+	}
+
+	currentStatus := user.GetStatus()
+	if !user.CanTransitionTo(status) {
+		errMsg := fmt.Sprintf(errInvalidStatusChange, currentStatus, status)
+		logger.Error(errMsg, nil, zap.String("user_id", userID), stacktraceChangeStatusService)
+		return nil, resterrors.NewConflictError(errMsg).WithCode(ErrCodeInvalidStatusTransition)
+	}
+
+	changedAt := time.Now()
+	if err := s.userRepository.UpdateStatus(ctx, userID, currentStatus, status, reason, changedAt); err != nil {
+		if err.HttpStatusCode == http.StatusNotFound {
+			logger.Error(errStatusChangedMeanwhile, err, zap.String("user_id", userID), stacktraceChangeStatusService)
+			return nil, resterrors.NewConflictError(errStatusChangedMeanwhile).WithCode(ErrCodeInvalidStatusTransition)
+		}
+
+		logger.Error(errCallRepositoy, err, stacktraceChangeStatusService)
+		return nil, err
+	}
+
+	previousReason := user.StatusReason
+	user.Status = status
+	user.StatusReason = reason
+	user.StatusChangedAt = &changedAt
+
+	if !user.IsActive() {
+		if err := s.revokeUserTokens(ctx, userID); err != nil {
+			logger.Error("Error when trying revoke user tokens", err, zap.String("user_id", userID), stacktraceChangeStatusService)
+			s.restoreStatus(ctx, userID, status, currentStatus, previousReason)
+			return nil, err
+		}
+	}
+
+	logger.Info("ChangeStatus executed successfully",
+		zap.String("user_id", userID),
+		zap.String("from_status", currentStatus),
+		zap.String("to_status", status),
+		zap.String("reason", reason),
+		auth.ActorField(ctx),
+		stacktraceChangeStatusService,
+	)
+	return user, nil
+}
+
+// revokeOtherSessions revokes the refresh and access tokens of every session of the
+// user but the one of the authenticated principal.
+func (s *userSvc) revokeOtherSessions(ctx context.Context, userID string) *resterrors.RestErr {
+	var currentSessionID string
+	if principal, ok := auth.PrincipalFromContext(ctx); ok && principal.IsOwner(userID) {
+		currentSessionID = principal.SessionID
+	}
+
+	sessionIDs, err := s.refreshTokenRepository.FindUserSessionIDs(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	var otherSessionIDs []string
+	for _, sessionID := range sessionIDs {
+		if sessionID == currentSessionID {
+			continue
+		}
+
+		if err := s.refreshTokenRepository.RevokeRefreshTokenFamily(ctx, sessionID); err != nil {
+			return err
+		}
+		otherSessionIDs = append(otherSessionIDs, sessionID)
+	}
+
+	return s.jwtAuth.RevokeSessions(ctx, otherSessionIDs...)
+}
+
+// restoreStatus moves the user back to the status it had before a change that could
+// not be completed. A failure is only logged, the error of the change is the one returned.
+func (s *userSvc) restoreStatus(ctx context.Context, userID, fromStatus, toStatus, reason string) {
+	if err := s.userRepository.UpdateStatus(ctx, userID, fromStatus, toStatus, reason, time.Now()); err != nil {
+		logger.Error("Error when trying restore user status", err,
+			zap.String("user_id", userID),
+			zap.String("status", fromStatus),
+			stacktraceChangeStatusService,
+		)
+		return
+	}
+
+	logger.Info("User status was restored", zap.String("user_id", userID), zap.String("status", toStatus), stacktraceChangeStatusService)
+}
+
+// revokeUserTokens invalidates every access and refresh token issued to the user.
+func (s *userSvc) revokeUserTokens(ctx context.Context, userID string) *resterrors.RestErr {
+	if err := s.jwtAuth.RevokeUserTokens(ctx, userID); err != nil {
+		return err
+	}
+	return s.refreshTokenRepository.RevokeUserRefreshTokens(ctx, userID)
+}
+
+// checkIfEmailIsAlreadyRegistered refuses an email registered to another user. It also
+// reports whether the email is already the one of the user, so a change can be detected.
+func (s *userSvc) checkIfEmailIsAlreadyRegistered(ctx context.Context, email, userID string) (bool, *resterrors.RestErr) {
 	resultUser, err := s.userRepository.FindUserByEmail(ctx, email)
 	if err != nil && err.HttpStatusCode != http.StatusNotFound {
-		return err
+		return false, err
 	}
 
 	if resultUser != nil && resultUser.ID != userID {
 		errMsg := errors.New(errEmailAlreadyRegistered)
 		logger.Error(errMsg.Error(), errMsg)
-		return resterrors.NewBadRequestError(errMsg.Error())
+		return false, resterrors.NewBadRequestError(errMsg.Error())
+	}
+
+	return resultUser != nil, nil
+}
+
+// setUsersCursors signs the cursors of the pages around the page. Read forward there is
+// a next page when the repository found more users, and a previous one when the page
+// is not the first. Read backward it is the other way around.
+func (s *userSvc) setUsersCursors(query *domain.UserQuery, page *domain.UserPage) error {
+	if len(page.Users) == 0 {
+		return nil
+	}
+
+	hasNext, hasPrev := page.HasMore, query.From != nil || query.Page > 1
+	if query.From != nil && query.From.Backward {
+		hasNext, hasPrev = true, page.HasMore
+	}
+
+	var err error
+	if hasNext {
+		if page.NextCursor, err = s.signUsersCursor(query, page.Users[len(page.Users)-1], false); err != nil {
+			return err
+		}
+	}
+	if hasPrev {
+		page.PrevCursor, err = s.signUsersCursor(query, page.Users[0], true)
+	}
+	return err
+}
+
+func (s *userSvc) signUsersCursor(query *domain.UserQuery, user *domain.User, backward bool) (string, error) {
+	data := usersCursorData{ID: user.ID, Sort: query.SortKey(), Backward: backward, Purpose: usersCursorPurpose}
+	for _, sortField := range query.Sort {
+		data.Values = append(data.Values, user.SortValue(sortField.Field))
+	}
+
+	// the cursors expire after usersCursorExpTime hours, long enough to walk through
+	// every user but a leaked cursor can't be used forever
+	return s.cursorSigner.Sign(data, time.Now().Add(time.Hour*usersCursorExpTime))
+}
+
+func (s *userSvc) decodeUsersCursor(query *domain.UserQuery) (*domain.UserCursor, error) {
+	var data usersCursorData
+	if err := s.cursorSigner.Verify(query.Cursor, &data); err != nil {
+		return nil, err
 	}
 
+	if data.Purpose != usersCursorPurpose || data.Sort != query.SortKey() || len(data.Values) != len(query.Sort) {
+		return nil, errors.New(errInvalidUsersCursor)
+	}
+
+	return &domain.UserCursor{ID: data.ID, SortValues: data.Values, Backward: data.Backward}, nil
+}
+
+// checkEmailChange refuses the email changes that would let the caller take over the
+// account through a password reset: an admin impersonating the user, or an OAuth
+// client changing the email of an admin.
+func (s *userSvc) checkEmailChange(ctx context.Context, userID string) *resterrors.RestErr {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		return nil
+	}
+
+	if principal.IsImpersonated() {
+		return resterrors.NewForbiddenError(errImpersonatedEmail)
+	}
+
+	if !principal.IsClient() {
+		return nil
+	}
+
+	user, err := s.userRepository.FindUserById(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.HasRole(domain.RoleAdmin) {
+		return resterrors.NewForbiddenError(errChangeAdminEmail)
+	}
 	return nil
 }
+
+// sendVerificationEmail doesn't fail the request, the user was already saved and
+// can ask for a new link.
+func (s *userSvc) sendVerificationEmail(ctx context.Context, user *domain.User) {
+	if user.EmailVerified {
+		return
+	}
+
+	if err := s.emailVerificationService.SendVerificationEmail(ctx, user); err != nil {
+		logger.Error("Error when trying send verification email", err, zap.String("user_id", user.ID))
+	}
+}
@@ -4,13 +4,20 @@ import (
 	"context"
 	"reflect"
 	"testing"
+	"time"
 
+	"github.com/WalterPaes/go-rest-api-crud/internal/auth"
 	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
 	"github.com/WalterPaes/go-rest-api-crud/internal/repositories"
 	"github.com/WalterPaes/go-rest-api-crud/mocks"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/hasher"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/jwt"
 	"github.com/WalterPaes/go-rest-api-crud/pkg/logger"
 	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/validation"
+	"github.com/stretchr/testify/mock"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/crypto/bcrypt"
 )
 
 func init() {
@@ -37,13 +44,24 @@ var (
 
 	internalServerError = resterrors.NewInternalServerError("error")
 
+	passwordHasher, _ = hasher.NewBcrypt(bcrypt.MinCost)
+	passwordPolicy    = &validation.PasswordPolicy{MinLength: 6}
+
+	passwordHistorySize = 3
+
+	cursorSigner = newSigner("secret")
+
 	currentPage  = 1
 	itemsPerPage = 10
 )
 
 func Test_userSvc_CreateUser(t *testing.T) {
+	// CreateUser hashes the password of the user received, the shared one keeps the plain password
+	inputUser := &domain.User{Name: inputUser.Name, Email: inputUser.Email, Password: inputUser.Password}
+
 	type fields struct {
-		userRepository repositories.UserRepository
+		userRepository           repositories.UserRepository
+		emailVerificationService EmailVerificationService
 	}
 	type args struct {
 		ctx  context.Context
@@ -68,6 +86,38 @@ func Test_userSvc_CreateUser(t *testing.T) {
 						Return(responseUser, nil)
 					return m
 				}(),
+				emailVerificationService: func() EmailVerificationService {
+					m := mocks.NewEmailVerificationService(t)
+					m.On("SendVerificationEmail", ctx, responseUser).
+						Return(nil)
+					return m
+				}(),
+			},
+			args: args{
+				ctx:  ctx,
+				user: inputUser,
+			},
+			want:    responseUser,
+			wantErr: nil,
+		},
+		{
+			name: "Should create an user even when the verification email can't be sent",
+			fields: fields{
+				userRepository: func() repositories.UserRepository {
+					m := mocks.NewUserRepository(t)
+					m.On("FindUserByEmail", ctx, inputUser.Email).
+						Return(nil, nil)
+
+					m.On("CreateUser", ctx, inputUser).
+						Return(responseUser, nil)
+					return m
+				}(),
+				emailVerificationService: func() EmailVerificationService {
+					m := mocks.NewEmailVerificationService(t)
+					m.On("SendVerificationEmail", ctx, responseUser).
+						Return(internalServerError)
+					return m
+				}(),
 			},
 			args: args{
 				ctx:  ctx,
@@ -113,6 +163,18 @@ func Test_userSvc_CreateUser(t *testing.T) {
 			want:    nil,
 			wantErr: internalServerError,
 		},
+		{
+			name: "Should return an error when the password breaks the password policy",
+			fields: fields{
+				userRepository: mocks.NewUserRepository(t),
+			},
+			args: args{
+				ctx:  ctx,
+				user: &domain.User{Name: "First User", Email: inputUser.Email, Password: "first@user"},
+			},
+			want:    nil,
+			wantErr: passwordPolicy.Check("Password", "first@user", "First User", inputUser.Email),
+		},
 		{
 			name: "Should return an error when user is already registered",
 			fields: fields{
@@ -133,7 +195,15 @@ func Test_userSvc_CreateUser(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			s := NewUserService(tt.fields.userRepository)
+			if tt.fields.emailVerificationService == nil {
+				tt.fields.emailVerificationService = mocks.NewEmailVerificationService(t)
+			}
+			s := NewUserService(tt.fields.userRepository, mocks.NewRefreshTokenRepository(t), mocks.NewJwtAuth(t), tt.fields.emailVerificationService, passwordHasher, passwordPolicy, passwordHistorySize, cursorSigner)
+
+			// CreateUser sets the default role on the user received, which the cases share
+			if tt.args.user == inputUser {
+				inputUser.Roles = nil
+			}
 
 			got, err := s.CreateUser(tt.args.ctx, tt.args.user)
 			if !reflect.DeepEqual(got, tt.want) {
@@ -146,6 +216,63 @@ func Test_userSvc_CreateUser(t *testing.T) {
 	}
 }
 
+func Test_userSvc_CreateUser_Roles(t *testing.T) {
+	adminUser := func() *domain.User {
+		return &domain.User{Name: inputUser.Name, Email: inputUser.Email, Password: inputUser.Password, Roles: []string{domain.RoleAdmin}}
+	}
+
+	t.Run("Should let an admin create an user with roles", func(t *testing.T) {
+		userRepository := mocks.NewUserRepository(t)
+		userRepository.On("FindUserByEmail", mock.Anything, inputUser.Email).
+			Return(nil, nil)
+		userRepository.On("CreateUser", mock.Anything, mock.AnythingOfType("*domain.User")).
+			Return(&domain.User{ID: userID, Roles: []string{domain.RoleAdmin}, EmailVerified: true}, nil)
+
+		s := NewUserService(userRepository, mocks.NewRefreshTokenRepository(t), mocks.NewJwtAuth(t), mocks.NewEmailVerificationService(t), passwordHasher, passwordPolicy, passwordHistorySize, cursorSigner)
+		adminCtx := auth.WithPrincipal(ctx, &domain.Principal{UserID: "admin_id", Roles: []string{domain.RoleAdmin}})
+
+		_, err := s.CreateUser(adminCtx, adminUser())
+		if err != nil {
+			t.Errorf("userSvc.CreateUser() err = %v, want nil", err)
+		}
+	})
+
+	for name, principal := range map[string]*domain.Principal{
+		"an OAuth client": {ClientID: "client_id", Scopes: []string{domain.ScopeUsersWrite}},
+		"a regular user":  {UserID: "user_id", Roles: []string{domain.RoleUser}},
+	} {
+		t.Run("Should refuse "+name+" creating an user with roles", func(t *testing.T) {
+			s := NewUserService(mocks.NewUserRepository(t), mocks.NewRefreshTokenRepository(t), mocks.NewJwtAuth(t), mocks.NewEmailVerificationService(t), passwordHasher, passwordPolicy, passwordHistorySize, cursorSigner)
+
+			_, err := s.CreateUser(auth.WithPrincipal(ctx, principal), adminUser())
+			if !reflect.DeepEqual(err, resterrors.NewForbiddenError(errChangeRoles)) {
+				t.Errorf("userSvc.CreateUser() err = %v, want %v", err, resterrors.NewForbiddenError(errChangeRoles))
+			}
+		})
+	}
+}
+
+func Test_userSvc_CreateUser_HashesPassword(t *testing.T) {
+	user := &domain.User{Name: inputUser.Name, Email: inputUser.Email, Password: inputUser.Password}
+
+	userRepository := mocks.NewUserRepository(t)
+	userRepository.On("FindUserByEmail", ctx, user.Email).
+		Return(nil, nil)
+	userRepository.On("CreateUser", ctx, mock.MatchedBy(func(u *domain.User) bool {
+		return u.Password != inputUser.Password && u.ComparePassword(inputUser.Password)
+	})).Return(responseUser, nil)
+
+	emailVerificationService := mocks.NewEmailVerificationService(t)
+	emailVerificationService.On("SendVerificationEmail", ctx, responseUser).
+		Return(nil)
+
+	s := NewUserService(userRepository, mocks.NewRefreshTokenRepository(t), mocks.NewJwtAuth(t), emailVerificationService, passwordHasher, passwordPolicy, passwordHistorySize, cursorSigner)
+
+	if _, err := s.CreateUser(ctx, user); err != nil {
+		t.Errorf("userSvc.CreateUser() err = %v, want nil", err)
+	}
+}
+
 func Test_userSvc_FindUserById(t *testing.T) {
 	type fields struct {
 		userRepository repositories.UserRepository
@@ -153,6 +280,7 @@ func Test_userSvc_FindUserById(t *testing.T) {
 	type args struct {
 		ctx    context.Context
 		userID string
+		fields []string
 	}
 	tests := []struct {
 		name    string
@@ -178,6 +306,24 @@ func Test_userSvc_FindUserById(t *testing.T) {
 			want:    responseUser,
 			wantErr: nil,
 		},
+		{
+			name: "Should find only the fields of an user",
+			fields: fields{
+				userRepository: func() repositories.UserRepository {
+					m := mocks.NewUserRepository(t)
+					m.On("FindUserById", ctx, userID, "id", "name").
+						Return(&domain.User{ID: userID, Name: responseUser.Name}, nil)
+					return m
+				}(),
+			},
+			args: args{
+				ctx:    ctx,
+				userID: userID,
+				fields: []string{"id", "name"},
+			},
+			want:    &domain.User{ID: userID, Name: responseUser.Name},
+			wantErr: nil,
+		},
 		{
 			name: "Should return an error when try find user by id",
 			fields: fields{
@@ -198,9 +344,9 @@ func Test_userSvc_FindUserById(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			s := NewUserService(tt.fields.userRepository)
+			s := NewUserService(tt.fields.userRepository, mocks.NewRefreshTokenRepository(t), mocks.NewJwtAuth(t), mocks.NewEmailVerificationService(t), passwordHasher, passwordPolicy, passwordHistorySize, cursorSigner)
 
-			got, err := s.FindUserById(tt.args.ctx, tt.args.userID)
+			got, err := s.FindUserById(tt.args.ctx, tt.args.userID, tt.args.fields...)
 			if !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("userSvc.FindUserById() got = %v, want %v", got, tt.want)
 			}
@@ -211,74 +357,154 @@ func Test_userSvc_FindUserById(t *testing.T) {
 	}
 }
 
-func Test_userSvc_FindAll(t *testing.T) {
-	type fields struct {
-		userRepository repositories.UserRepository
-	}
-	type args struct {
-		ctx          context.Context
-		itemsPerPage int
-		currentPage  int
+func usersCursor(t *testing.T, data usersCursorData) string {
+	t.Helper()
+	return usersCursorExpiring(t, data, time.Now().Add(time.Hour))
+}
+
+func usersCursorExpiring(t *testing.T, data usersCursorData, expiresAt time.Time) string {
+	t.Helper()
+	data.Purpose = usersCursorPurpose
+	cursor, err := cursorSigner.Sign(data, expiresAt)
+	if err != nil {
+		t.Fatal(err)
 	}
+	return cursor
+}
+
+func Test_userSvc_FindAll(t *testing.T) {
+	firstUser := &domain.User{ID: primitive.NewObjectID().Hex(), Name: "Ana"}
+	lastUser := &domain.User{ID: primitive.NewObjectID().Hex(), Name: "Bia"}
+	byName := []domain.SortField{{Field: domain.UserSortName}}
+
 	tests := []struct {
-		name    string
-		fields  fields
-		args    args
-		want    []*domain.User
-		wantErr *resterrors.RestErr
+		name           string
+		query          *domain.UserQuery
+		repositoryPage *domain.UserPage
+		repositoryErr  *resterrors.RestErr
+		countErr       *resterrors.RestErr
+		wantFrom       *domain.UserCursor
+		wantNext       *usersCursorData
+		wantPrev       *usersCursorData
+		wantErr        *resterrors.RestErr
 	}{
 		{
-			name: "Should find all users without errors",
-			fields: fields{
-				userRepository: func() repositories.UserRepository {
-					m := mocks.NewUserRepository(t)
-					m.On("FindAll", ctx, itemsPerPage, currentPage).
-						Return([]*domain.User{
-							responseUser,
-						}, nil)
-					return m
-				}(),
-			},
-			args: args{
-				ctx:          ctx,
-				itemsPerPage: itemsPerPage,
-				currentPage:  currentPage,
+			name:           "Should find the first page with the cursor of the next one",
+			query:          &domain.UserQuery{Sort: byName, Page: currentPage, PerPage: 2},
+			repositoryPage: &domain.UserPage{Users: []*domain.User{firstUser, lastUser}, HasMore: true},
+			wantNext:       &usersCursorData{ID: lastUser.ID, Values: []string{"Bia"}, Sort: "name", Purpose: usersCursorPurpose},
+		},
+		{
+			name:           "Should find a page by number with the cursor of the previous one",
+			query:          &domain.UserQuery{Page: 3, PerPage: 2},
+			repositoryPage: &domain.UserPage{Users: []*domain.User{firstUser, lastUser}},
+			wantPrev:       &usersCursorData{ID: firstUser.ID, Backward: true, Purpose: usersCursorPurpose},
+		},
+		{
+			name: "Should find the page after the cursor",
+			query: &domain.UserQuery{
+				Sort:    byName,
+				Cursor:  usersCursor(t, usersCursorData{ID: "cursor_id", Values: []string{"Al"}, Sort: "name"}),
+				PerPage: 2,
 			},
-			want: []*domain.User{
-				responseUser,
+			repositoryPage: &domain.UserPage{Users: []*domain.User{firstUser, lastUser}},
+			wantFrom:       &domain.UserCursor{ID: "cursor_id", SortValues: []string{"Al"}},
+			wantPrev:       &usersCursorData{ID: firstUser.ID, Values: []string{"Ana"}, Sort: "name", Backward: true, Purpose: usersCursorPurpose},
+		},
+		{
+			name: "Should find the page before the cursor",
+			query: &domain.UserQuery{
+				Sort:    byName,
+				Cursor:  usersCursor(t, usersCursorData{ID: "cursor_id", Values: []string{"Bob"}, Sort: "name", Backward: true}),
+				PerPage: 2,
 			},
-			wantErr: nil,
+			repositoryPage: &domain.UserPage{Users: []*domain.User{firstUser, lastUser}, HasMore: true},
+			wantFrom:       &domain.UserCursor{ID: "cursor_id", SortValues: []string{"Bob"}, Backward: true},
+			wantNext:       &usersCursorData{ID: lastUser.ID, Values: []string{"Bia"}, Sort: "name", Purpose: usersCursorPurpose},
+			wantPrev:       &usersCursorData{ID: firstUser.ID, Values: []string{"Ana"}, Sort: "name", Backward: true, Purpose: usersCursorPurpose},
 		},
 		{
-			name: "Should return an error when try find all users",
-			fields: fields{
-				userRepository: func() repositories.UserRepository {
-					m := mocks.NewUserRepository(t)
-					m.On("FindAll", ctx, itemsPerPage, currentPage).
-						Return(nil, internalServerError)
-					return m
-				}(),
+			name: "Should refuse a cursor of another sort",
+			query: &domain.UserQuery{
+				Sort:    []domain.SortField{{Field: domain.UserSortName, Descending: true}},
+				Cursor:  usersCursor(t, usersCursorData{ID: "cursor_id", Values: []string{"Al"}, Sort: "name"}),
+				PerPage: 2,
 			},
-			args: args{
-				ctx:          ctx,
-				itemsPerPage: itemsPerPage,
-				currentPage:  currentPage,
+			wantErr: resterrors.NewBadRequestError(errInvalidUsersCursor),
+		},
+		{
+			name: "Should refuse an expired cursor",
+			query: &domain.UserQuery{
+				Sort:    []domain.SortField{{Field: domain.UserSortName}},
+				Cursor:  usersCursorExpiring(t, usersCursorData{ID: "cursor_id", Values: []string{"Al"}, Sort: "name"}, time.Now().Add(-time.Minute)),
+				PerPage: 2,
 			},
-			want:    nil,
-			wantErr: internalServerError,
+			wantErr: resterrors.NewBadRequestError(errExpiredUsersCursor),
+		},
+		{
+			name:    "Should refuse a cursor not signed by the service",
+			query:   &domain.UserQuery{Cursor: "invalid.cursor", PerPage: 2},
+			wantErr: resterrors.NewBadRequestError(errInvalidUsersCursor),
+		},
+		{
+			name:           "Should count the users when the query asks",
+			query:          &domain.UserQuery{Page: currentPage, PerPage: 2, Count: true},
+			repositoryPage: &domain.UserPage{Users: []*domain.User{firstUser}},
+		},
+		{
+			name:           "Should return an error when try count the users",
+			query:          &domain.UserQuery{Page: currentPage, PerPage: 2, Count: true},
+			repositoryPage: &domain.UserPage{Users: []*domain.User{firstUser}},
+			countErr:       internalServerError,
+			wantErr:        internalServerError,
+		},
+		{
+			name:          "Should return an error when try find all users",
+			query:         &domain.UserQuery{Page: currentPage, PerPage: itemsPerPage},
+			repositoryErr: internalServerError,
+			wantErr:       internalServerError,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			s := &userSvc{
-				userRepository: tt.fields.userRepository,
+			userRepository := mocks.NewUserRepository(t)
+			if tt.repositoryPage != nil || tt.repositoryErr != nil {
+				userRepository.On("FindAll", ctx, mock.MatchedBy(func(query *domain.UserQuery) bool {
+					return reflect.DeepEqual(query.From, tt.wantFrom)
+				})).
+					Return(tt.repositoryPage, tt.repositoryErr)
 			}
-			got, err := s.FindAll(tt.args.ctx, tt.args.itemsPerPage, tt.args.currentPage)
-			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("userSvc.FindAll() got = %v, want %v", got, tt.want)
+			if tt.query.Count {
+				userRepository.On("CountUsers", ctx, tt.query).
+					Return(int64(7), tt.countErr)
 			}
+
+			s := NewUserService(userRepository, mocks.NewRefreshTokenRepository(t), mocks.NewJwtAuth(t), mocks.NewEmailVerificationService(t), passwordHasher, passwordPolicy, passwordHistorySize, cursorSigner)
+			got, err := s.FindAll(ctx, tt.query)
 			if !reflect.DeepEqual(err, tt.wantErr) {
-				t.Errorf("userSvc.FindAll() err = %v, want %v", err, tt.wantErr)
+				t.Fatalf("userSvc.FindAll() err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			if tt.query.Count != (got.TotalItems != nil) || (got.TotalItems != nil && *got.TotalItems != 7) {
+				t.Errorf("userSvc.FindAll() total = %v, want counted %v", got.TotalItems, tt.query.Count)
+			}
+			if !reflect.DeepEqual(got.Users, tt.repositoryPage.Users) {
+				t.Errorf("userSvc.FindAll() users = %v, want %v", got.Users, tt.repositoryPage.Users)
+			}
+			for cursor, want := range map[string]*usersCursorData{got.NextCursor: tt.wantNext, got.PrevCursor: tt.wantPrev} {
+				if want == nil {
+					continue
+				}
+				var data usersCursorData
+				if err := cursorSigner.Verify(cursor, &data); err != nil || !reflect.DeepEqual(&data, want) {
+					t.Errorf("userSvc.FindAll() cursor = %+v, want %+v", data, want)
+				}
+			}
+			if (got.NextCursor != "") != (tt.wantNext != nil) || (got.PrevCursor != "") != (tt.wantPrev != nil) {
+				t.Errorf("userSvc.FindAll() next = %q, prev = %q", got.NextCursor, got.PrevCursor)
 			}
 		})
 	}
@@ -286,7 +512,9 @@ func Test_userSvc_FindAll(t *testing.T) {
 
 func Test_userSvc_DeleteUser(t *testing.T) {
 	type fields struct {
-		userRepository repositories.UserRepository
+		userRepository         repositories.UserRepository
+		refreshTokenRepository repositories.RefreshTokenRepository
+		jwtAuth                jwt.JwtAuth
 	}
 	type args struct {
 		ctx    context.Context
@@ -299,7 +527,7 @@ func Test_userSvc_DeleteUser(t *testing.T) {
 		wantErr *resterrors.RestErr
 	}{
 		{
-			name: "Should delete an user without errors",
+			name: "Should delete an user and revoke its tokens without errors",
 			fields: fields{
 				userRepository: func() repositories.UserRepository {
 					m := mocks.NewUserRepository(t)
@@ -307,6 +535,18 @@ func Test_userSvc_DeleteUser(t *testing.T) {
 						Return(nil)
 					return m
 				}(),
+				refreshTokenRepository: func() repositories.RefreshTokenRepository {
+					m := mocks.NewRefreshTokenRepository(t)
+					m.On("RevokeUserRefreshTokens", ctx, userID).
+						Return(nil)
+					return m
+				}(),
+				jwtAuth: func() jwt.JwtAuth {
+					m := mocks.NewJwtAuth(t)
+					m.On("RevokeUserTokens", ctx, userID).
+						Return(nil)
+					return m
+				}(),
 			},
 			args: args{
 				ctx:    ctx,
@@ -323,6 +563,31 @@ func Test_userSvc_DeleteUser(t *testing.T) {
 						Return(internalServerError)
 					return m
 				}(),
+				refreshTokenRepository: mocks.NewRefreshTokenRepository(t),
+				jwtAuth:                mocks.NewJwtAuth(t),
+			},
+			args: args{
+				ctx:    ctx,
+				userID: userID,
+			},
+			wantErr: internalServerError,
+		},
+		{
+			name: "Should return an error when try revoke the user tokens",
+			fields: fields{
+				userRepository: func() repositories.UserRepository {
+					m := mocks.NewUserRepository(t)
+					m.On("DeleteUser", ctx, userID).
+						Return(nil)
+					return m
+				}(),
+				refreshTokenRepository: mocks.NewRefreshTokenRepository(t),
+				jwtAuth: func() jwt.JwtAuth {
+					m := mocks.NewJwtAuth(t)
+					m.On("RevokeUserTokens", ctx, userID).
+						Return(internalServerError)
+					return m
+				}(),
 			},
 			args: args{
 				ctx:    ctx,
@@ -333,9 +598,7 @@ func Test_userSvc_DeleteUser(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			s := &userSvc{
-				userRepository: tt.fields.userRepository,
-			}
+			s := NewUserService(tt.fields.userRepository, tt.fields.refreshTokenRepository, tt.fields.jwtAuth, mocks.NewEmailVerificationService(t), passwordHasher, passwordPolicy, passwordHistorySize, cursorSigner)
 			if got := s.DeleteUser(tt.args.ctx, tt.args.userID); !reflect.DeepEqual(got, tt.wantErr) {
 				t.Errorf("userSvc.DeleteUser() = %v, want %v", got, tt.wantErr)
 			}
@@ -343,14 +606,19 @@ func Test_userSvc_DeleteUser(t *testing.T) {
 	}
 }
 
-func Test_userSvc_UpdateUser(t *testing.T) {
+func Test_userSvc_PatchUser(t *testing.T) {
+	name := "Patched User"
+	email := "patched@email.com"
+	impersonatedCtx := auth.WithPrincipal(ctx, &domain.Principal{UserID: userID, ActorID: "admin_id", Roles: []string{domain.RoleUser}})
+
 	type fields struct {
-		userRepository repositories.UserRepository
+		userRepository           repositories.UserRepository
+		emailVerificationService EmailVerificationService
 	}
 	type args struct {
 		ctx    context.Context
 		userID string
-		user   *domain.User
+		patch  *domain.UserPatch
 	}
 	tests := []struct {
 		name    string
@@ -360,60 +628,152 @@ func Test_userSvc_UpdateUser(t *testing.T) {
 		wantErr *resterrors.RestErr
 	}{
 		{
-			name: "Should update an user without errors",
+			name: "Should patch only the name of an user",
 			fields: fields{
 				userRepository: func() repositories.UserRepository {
 					m := mocks.NewUserRepository(t)
-					m.On("FindUserByEmail", ctx, inputUser.Email).
-						Return(nil, nil)
+					m.On("PatchUser", ctx, userID, &domain.UserPatch{Name: &name}).
+						Return(responseUser, nil)
+					return m
+				}(),
+			},
+			args: args{
+				ctx:    ctx,
+				userID: userID,
+				patch:  &domain.UserPatch{Name: &name},
+			},
+			want:    responseUser,
+			wantErr: nil,
+		},
+		{
+			name: "Should check the email before patching it and send a verification email",
+			fields: fields{
+				userRepository: func() repositories.UserRepository {
+					m := mocks.NewUserRepository(t)
+					m.On("FindUserByEmail", ctx, email).
+						Return(nil, resterrors.NewNotFoundError("not found"))
 
-					m.On("UpdateUser", ctx, userID, inputUser).
+					m.On("PatchUser", ctx, userID, &domain.UserPatch{Email: &email}).
 						Return(responseUser, nil)
 					return m
 				}(),
+				emailVerificationService: func() EmailVerificationService {
+					m := mocks.NewEmailVerificationService(t)
+					m.On("SendVerificationEmail", ctx, responseUser).
+						Return(nil)
+					return m
+				}(),
 			},
 			args: args{
 				ctx:    ctx,
 				userID: userID,
-				user:   inputUser,
+				patch:  &domain.UserPatch{Email: &email},
 			},
 			want:    responseUser,
 			wantErr: nil,
 		},
 		{
-			name: "Should return an error when try call repository to update an user",
+			name: "Should refuse an admin impersonating the user changing the email",
 			fields: fields{
 				userRepository: func() repositories.UserRepository {
 					m := mocks.NewUserRepository(t)
-					m.On("FindUserByEmail", ctx, inputUser.Email).
-						Return(nil, nil)
+					m.On("FindUserByEmail", impersonatedCtx, email).
+						Return(nil, resterrors.NewNotFoundError("not found"))
+					return m
+				}(),
+			},
+			args: args{
+				ctx:    impersonatedCtx,
+				userID: userID,
+				patch:  &domain.UserPatch{Email: &email},
+			},
+			want:    nil,
+			wantErr: resterrors.NewForbiddenError(errImpersonatedEmail),
+		},
+		{
+			name: "Should let an admin impersonating the user change the name",
+			fields: fields{
+				userRepository: func() repositories.UserRepository {
+					m := mocks.NewUserRepository(t)
+					m.On("PatchUser", impersonatedCtx, userID, &domain.UserPatch{Name: &name}).
+						Return(responseUser, nil)
+					return m
+				}(),
+			},
+			args: args{
+				ctx:    impersonatedCtx,
+				userID: userID,
+				patch:  &domain.UserPatch{Name: &name},
+			},
+			want:    responseUser,
+			wantErr: nil,
+		},
+		{
+			name: "Should not send a verification email when the email didn't change",
+			fields: fields{
+				userRepository: func() repositories.UserRepository {
+					m := mocks.NewUserRepository(t)
+					m.On("FindUserByEmail", ctx, email).
+						Return(responseUser, nil)
 
-					m.On("UpdateUser", ctx, userID, inputUser).
-						Return(nil, internalServerError)
+					m.On("PatchUser", ctx, userID, &domain.UserPatch{Email: &email}).
+						Return(responseUser, nil)
+					return m
+				}(),
+			},
+			args: args{
+				ctx:    ctx,
+				userID: userID,
+				patch:  &domain.UserPatch{Email: &email},
+			},
+			want:    responseUser,
+			wantErr: nil,
+		},
+		{
+			name: "Should return an error when the email belongs to another user",
+			fields: fields{
+				userRepository: func() repositories.UserRepository {
+					m := mocks.NewUserRepository(t)
+					m.On("FindUserByEmail", ctx, email).
+						Return(&domain.User{ID: primitive.NewObjectID().Hex()}, nil)
 					return m
 				}(),
 			},
 			args: args{
 				ctx:    ctx,
 				userID: userID,
-				user:   inputUser,
+				patch:  &domain.UserPatch{Email: &email},
 			},
 			want:    nil,
-			wantErr: internalServerError,
+			wantErr: resterrors.NewBadRequestError(errEmailAlreadyRegistered),
 		},
 		{
-			name: "Should return an error when try call repository to find an user by email",
+			name: "Should return an error when no field is sent",
+			fields: fields{
+				userRepository: mocks.NewUserRepository(t),
+			},
+			args: args{
+				ctx:    ctx,
+				userID: userID,
+				patch:  &domain.UserPatch{},
+			},
+			want:    nil,
+			wantErr: resterrors.NewBadRequestError(errEmptyPatch),
+		},
+		{
+			name: "Should return an error when try call repository to patch an user",
 			fields: fields{
 				userRepository: func() repositories.UserRepository {
 					m := mocks.NewUserRepository(t)
-					m.On("FindUserByEmail", ctx, inputUser.Email).
+					m.On("PatchUser", ctx, userID, &domain.UserPatch{Name: &name}).
 						Return(nil, internalServerError)
 					return m
 				}(),
 			},
 			args: args{
-				ctx:  ctx,
-				user: inputUser,
+				ctx:    ctx,
+				userID: userID,
+				patch:  &domain.UserPatch{Name: &name},
 			},
 			want:    nil,
 			wantErr: internalServerError,
@@ -421,15 +781,717 @@ func Test_userSvc_UpdateUser(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			if tt.fields.emailVerificationService == nil {
+				tt.fields.emailVerificationService = mocks.NewEmailVerificationService(t)
+			}
 			s := &userSvc{
-				userRepository: tt.fields.userRepository,
+				userRepository:           tt.fields.userRepository,
+				emailVerificationService: tt.fields.emailVerificationService,
 			}
-			got, err := s.UpdateUser(tt.args.ctx, tt.args.userID, tt.args.user)
+			got, err := s.PatchUser(tt.args.ctx, tt.args.userID, tt.args.patch)
 			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("userSvc.UpdateUser() got = %v, want %v", got, tt.want)
+				t.Errorf("userSvc.PatchUser() got = %v, want %v", got, tt.want)
 			}
 			if !reflect.DeepEqual(err, tt.wantErr) {
-				t.Errorf("userSvc.UpdateUser() err = %v, want %v", err, tt.wantErr)
+				t.Errorf("userSvc.PatchUser() err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_userSvc_UpdateUser(t *testing.T) {
+	updateUser := &domain.User{Name: "First User", Email: "firstuser@email.com"}
+	adminCtx := auth.WithPrincipal(ctx, &domain.Principal{UserID: primitive.NewObjectID().Hex(), Roles: []string{domain.RoleAdmin}})
+	adminUpdate := &domain.User{Email: updateUser.Email, Roles: []string{domain.RoleAdmin}}
+	clientCtx := auth.WithPrincipal(ctx, &domain.Principal{ClientID: "client_id", Scopes: []string{domain.ScopeUsersWrite}})
+	impersonatedCtx := auth.WithPrincipal(ctx, &domain.Principal{UserID: userID, ActorID: "admin_id", Roles: []string{domain.RoleUser}})
+
+	type fields struct {
+		userRepository           repositories.UserRepository
+		emailVerificationService EmailVerificationService
+	}
+	type args struct {
+		ctx    context.Context
+		userID string
+		user   *domain.User
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    *domain.User
+		wantErr *resterrors.RestErr
+	}{
+		{
+			name: "Should update an user without errors",
+			fields: fields{
+				userRepository: func() repositories.UserRepository {
+					m := mocks.NewUserRepository(t)
+					m.On("FindUserByEmail", ctx, updateUser.Email).
+						Return(nil, nil)
+
+					m.On("UpdateUser", ctx, userID, updateUser).
+						Return(responseUser, nil)
+					return m
+				}(),
+				emailVerificationService: func() EmailVerificationService {
+					m := mocks.NewEmailVerificationService(t)
+					m.On("SendVerificationEmail", ctx, responseUser).
+						Return(nil)
+					return m
+				}(),
+			},
+			args: args{
+				ctx:    ctx,
+				userID: userID,
+				user:   updateUser,
+			},
+			want:    responseUser,
+			wantErr: nil,
+		},
+		{
+			name: "Should refuse an admin impersonating the user changing the email",
+			fields: fields{
+				userRepository: func() repositories.UserRepository {
+					m := mocks.NewUserRepository(t)
+					m.On("FindUserByEmail", impersonatedCtx, updateUser.Email).
+						Return(nil, nil)
+					return m
+				}(),
+			},
+			args: args{
+				ctx:    impersonatedCtx,
+				userID: userID,
+				user:   updateUser,
+			},
+			want:    nil,
+			wantErr: resterrors.NewForbiddenError(errImpersonatedEmail),
+		},
+		{
+			name: "Should refuse an OAuth client changing the email of an admin",
+			fields: fields{
+				userRepository: func() repositories.UserRepository {
+					m := mocks.NewUserRepository(t)
+					m.On("FindUserByEmail", clientCtx, updateUser.Email).
+						Return(nil, nil)
+
+					m.On("FindUserById", clientCtx, userID).
+						Return(&domain.User{ID: userID, Roles: []string{domain.RoleAdmin}}, nil)
+					return m
+				}(),
+			},
+			args: args{
+				ctx:    clientCtx,
+				userID: userID,
+				user:   updateUser,
+			},
+			want:    nil,
+			wantErr: resterrors.NewForbiddenError(errChangeAdminEmail),
+		},
+		{
+			name: "Should let an OAuth client change the email of a regular user",
+			fields: fields{
+				userRepository: func() repositories.UserRepository {
+					m := mocks.NewUserRepository(t)
+					m.On("FindUserByEmail", clientCtx, updateUser.Email).
+						Return(nil, nil)
+
+					m.On("FindUserById", clientCtx, userID).
+						Return(&domain.User{ID: userID, Roles: []string{domain.RoleUser}}, nil)
+
+					m.On("UpdateUser", clientCtx, userID, updateUser).
+						Return(responseUser, nil)
+					return m
+				}(),
+				emailVerificationService: func() EmailVerificationService {
+					m := mocks.NewEmailVerificationService(t)
+					m.On("SendVerificationEmail", clientCtx, responseUser).
+						Return(nil)
+					return m
+				}(),
+			},
+			args: args{
+				ctx:    clientCtx,
+				userID: userID,
+				user:   updateUser,
+			},
+			want:    responseUser,
+			wantErr: nil,
+		},
+		{
+			name: "Should not send a verification email when the email didn't change",
+			fields: fields{
+				userRepository: func() repositories.UserRepository {
+					m := mocks.NewUserRepository(t)
+					m.On("FindUserByEmail", ctx, updateUser.Email).
+						Return(responseUser, nil)
+
+					m.On("UpdateUser", ctx, userID, updateUser).
+						Return(responseUser, nil)
+					return m
+				}(),
+			},
+			args: args{
+				ctx:    ctx,
+				userID: userID,
+				user:   updateUser,
+			},
+			want:    responseUser,
+			wantErr: nil,
+		},
+		{
+			name: "Should return an error when try call repository to update an user",
+			fields: fields{
+				userRepository: func() repositories.UserRepository {
+					m := mocks.NewUserRepository(t)
+					m.On("FindUserByEmail", ctx, updateUser.Email).
+						Return(nil, nil)
+
+					m.On("UpdateUser", ctx, userID, updateUser).
+						Return(nil, internalServerError)
+					return m
+				}(),
+			},
+			args: args{
+				ctx:    ctx,
+				userID: userID,
+				user:   updateUser,
+			},
+			want:    nil,
+			wantErr: internalServerError,
+		},
+		{
+			name: "Should return an error when try call repository to find an user by email",
+			fields: fields{
+				userRepository: func() repositories.UserRepository {
+					m := mocks.NewUserRepository(t)
+					m.On("FindUserByEmail", ctx, updateUser.Email).
+						Return(nil, internalServerError)
+					return m
+				}(),
+			},
+			args: args{
+				ctx:  ctx,
+				user: updateUser,
+			},
+			want:    nil,
+			wantErr: internalServerError,
+		},
+		{
+			name: "Should let an admin change the roles of an user",
+			fields: fields{
+				userRepository: func() repositories.UserRepository {
+					m := mocks.NewUserRepository(t)
+					m.On("FindUserByEmail", adminCtx, adminUpdate.Email).
+						Return(nil, nil)
+
+					m.On("UpdateUser", adminCtx, userID, adminUpdate).
+						Return(responseUser, nil)
+					return m
+				}(),
+				emailVerificationService: func() EmailVerificationService {
+					m := mocks.NewEmailVerificationService(t)
+					m.On("SendVerificationEmail", adminCtx, responseUser).
+						Return(nil)
+					return m
+				}(),
+			},
+			args: args{
+				ctx:    adminCtx,
+				userID: userID,
+				user:   adminUpdate,
+			},
+			want:    responseUser,
+			wantErr: nil,
+		},
+		{
+			name: "Should forbid an user without the admin role to change roles",
+			fields: fields{
+				userRepository: mocks.NewUserRepository(t),
+			},
+			args: args{
+				ctx:    auth.WithPrincipal(ctx, &domain.Principal{UserID: userID, Roles: []string{domain.RoleUser}}),
+				userID: userID,
+				user:   &domain.User{Email: updateUser.Email, Roles: []string{domain.RoleAdmin}},
+			},
+			want:    nil,
+			wantErr: resterrors.NewForbiddenError(errChangeRoles),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.fields.emailVerificationService == nil {
+				tt.fields.emailVerificationService = mocks.NewEmailVerificationService(t)
+			}
+			s := &userSvc{
+				userRepository:           tt.fields.userRepository,
+				emailVerificationService: tt.fields.emailVerificationService,
+			}
+			got, err := s.UpdateUser(tt.args.ctx, tt.args.userID, tt.args.user)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("userSvc.UpdateUser() got = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(err, tt.wantErr) {
+				t.Errorf("userSvc.UpdateUser() err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_userSvc_ChangePassword(t *testing.T) {
+	currentPassword := "current@123"
+	newPassword := "new@123"
+
+	storedUser := &domain.User{ID: userID, Password: currentPassword}
+	if err := storedUser.EncryptPassword(passwordHasher); err != nil {
+		t.Fatal(err)
+	}
+
+	previousPassword := "previous@123"
+	previousUser := &domain.User{Password: previousPassword}
+	if err := previousUser.EncryptPassword(passwordHasher); err != nil {
+		t.Fatal(err)
+	}
+	previousPasswordHash := previousUser.Password
+
+	authCtx := auth.WithPrincipal(ctx, &domain.Principal{UserID: userID, SessionID: "current_session"})
+
+	type fields struct {
+		userRepository         repositories.UserRepository
+		refreshTokenRepository repositories.RefreshTokenRepository
+		jwtAuth                jwt.JwtAuth
+	}
+	type args struct {
+		currentPassword string
+		newPassword     string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		wantErr *resterrors.RestErr
+	}{
+		{
+			name: "Should change the password and revoke the other sessions",
+			fields: fields{
+				userRepository: func() repositories.UserRepository {
+					m := mocks.NewUserRepository(t)
+					m.On("FindUserById", authCtx, userID).
+						Return(&domain.User{ID: userID, Password: storedUser.Password}, nil)
+
+					m.On("UpdatePassword", authCtx, userID, mock.MatchedBy(func(hash string) bool {
+						return (&domain.User{Password: hash}).ComparePassword(newPassword)
+					}), passwordHistorySize).Return(nil)
+					return m
+				}(),
+				refreshTokenRepository: func() repositories.RefreshTokenRepository {
+					m := mocks.NewRefreshTokenRepository(t)
+					m.On("FindUserSessionIDs", authCtx, userID).
+						Return([]string{"current_session", "other_session"}, nil)
+
+					m.On("RevokeRefreshTokenFamily", authCtx, "other_session").
+						Return(nil)
+					return m
+				}(),
+				jwtAuth: func() jwt.JwtAuth {
+					m := mocks.NewJwtAuth(t)
+					m.On("RevokeSessions", authCtx, "other_session").
+						Return(nil)
+					return m
+				}(),
+			},
+			args: args{
+				currentPassword: currentPassword,
+				newPassword:     newPassword,
+			},
+			wantErr: nil,
+		},
+		{
+			name: "Should return an error when the current password is wrong",
+			fields: fields{
+				userRepository: func() repositories.UserRepository {
+					m := mocks.NewUserRepository(t)
+					m.On("FindUserById", authCtx, userID).
+						Return(&domain.User{ID: userID, Password: storedUser.Password}, nil)
+					return m
+				}(),
+				refreshTokenRepository: mocks.NewRefreshTokenRepository(t),
+				jwtAuth:                mocks.NewJwtAuth(t),
+			},
+			args: args{
+				currentPassword: "wrong@123",
+				newPassword:     newPassword,
+			},
+			wantErr: resterrors.NewBadRequestError(errInvalidCurrentPassword),
+		},
+		{
+			name: "Should return an error when the new password is the current one",
+			fields: fields{
+				userRepository: func() repositories.UserRepository {
+					m := mocks.NewUserRepository(t)
+					m.On("FindUserById", authCtx, userID).
+						Return(&domain.User{ID: userID, Password: storedUser.Password}, nil)
+					return m
+				}(),
+				refreshTokenRepository: mocks.NewRefreshTokenRepository(t),
+				jwtAuth:                mocks.NewJwtAuth(t),
+			},
+			args: args{
+				currentPassword: currentPassword,
+				newPassword:     currentPassword,
+			},
+			wantErr: resterrors.NewBadRequestError(errSamePassword),
+		},
+		{
+			name: "Should return an error when the new password is one of the previous ones",
+			fields: fields{
+				userRepository: func() repositories.UserRepository {
+					m := mocks.NewUserRepository(t)
+					m.On("FindUserById", authCtx, userID).
+						Return(&domain.User{ID: userID, Password: storedUser.Password, PasswordHistory: []string{previousPasswordHash}}, nil)
+					return m
+				}(),
+				refreshTokenRepository: mocks.NewRefreshTokenRepository(t),
+				jwtAuth:                mocks.NewJwtAuth(t),
+			},
+			args: args{
+				currentPassword: currentPassword,
+				newPassword:     previousPassword,
+			},
+			wantErr: checkPasswordReuse(&domain.User{Password: previousPasswordHash}, "NewPassword", previousPassword, passwordHistorySize),
+		},
+		{
+			name: "Should return an error when the new password breaks the password policy",
+			fields: fields{
+				userRepository: func() repositories.UserRepository {
+					m := mocks.NewUserRepository(t)
+					m.On("FindUserById", authCtx, userID).
+						Return(&domain.User{ID: userID, Name: responseUser.Name, Password: storedUser.Password}, nil)
+					return m
+				}(),
+				refreshTokenRepository: mocks.NewRefreshTokenRepository(t),
+				jwtAuth:                mocks.NewJwtAuth(t),
+			},
+			args: args{
+				currentPassword: currentPassword,
+				newPassword:     "abc",
+			},
+			wantErr: passwordPolicy.Check("NewPassword", "abc"),
+		},
+		{
+			name: "Should return an error when try call repository to update the password",
+			fields: fields{
+				userRepository: func() repositories.UserRepository {
+					m := mocks.NewUserRepository(t)
+					m.On("FindUserById", authCtx, userID).
+						Return(&domain.User{ID: userID, Password: storedUser.Password}, nil)
+
+					m.On("UpdatePassword", authCtx, userID, mock.Anything, passwordHistorySize).
+						Return(internalServerError)
+					return m
+				}(),
+				refreshTokenRepository: mocks.NewRefreshTokenRepository(t),
+				jwtAuth:                mocks.NewJwtAuth(t),
+			},
+			args: args{
+				currentPassword: currentPassword,
+				newPassword:     newPassword,
+			},
+			wantErr: internalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewUserService(tt.fields.userRepository, tt.fields.refreshTokenRepository, tt.fields.jwtAuth, mocks.NewEmailVerificationService(t), passwordHasher, passwordPolicy, passwordHistorySize, cursorSigner)
+			if err := s.ChangePassword(authCtx, userID, tt.args.currentPassword, tt.args.newPassword); !reflect.DeepEqual(err, tt.wantErr) {
+				t.Errorf("userSvc.ChangePassword() err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_userSvc_ChangeStatus(t *testing.T) {
+	findUser := func(status string) repositories.UserRepository {
+		m := mocks.NewUserRepository(t)
+		m.On("FindUserById", ctx, userID).
+			Return(&domain.User{ID: userID, Status: status}, nil)
+		return m
+	}
+
+	type fields struct {
+		userRepository         repositories.UserRepository
+		refreshTokenRepository repositories.RefreshTokenRepository
+		jwtAuth                jwt.JwtAuth
+	}
+	type args struct {
+		ctx    context.Context
+		status string
+	}
+	tests := []struct {
+		name       string
+		fields     fields
+		args       args
+		wantStatus string
+		wantErr    *resterrors.RestErr
+	}{
+		{
+			name: "Should suspend an active user and revoke its tokens",
+			fields: fields{
+				userRepository: func() repositories.UserRepository {
+					m := findUser("").(*mocks.UserRepository)
+					m.On("UpdateStatus", ctx, userID, domain.UserStatusActive, domain.UserStatusSuspended, "abuse report", mock.AnythingOfType("time.Time")).
+						Return(nil)
+					return m
+				}(),
+				refreshTokenRepository: func() repositories.RefreshTokenRepository {
+					m := mocks.NewRefreshTokenRepository(t)
+					m.On("RevokeUserRefreshTokens", ctx, userID).
+						Return(nil)
+					return m
+				}(),
+				jwtAuth: func() jwt.JwtAuth {
+					m := mocks.NewJwtAuth(t)
+					m.On("RevokeUserTokens", ctx, userID).
+						Return(nil)
+					return m
+				}(),
+			},
+			args: args{
+				ctx:    ctx,
+				status: domain.UserStatusSuspended,
+			},
+			wantStatus: domain.UserStatusSuspended,
+			wantErr:    nil,
+		},
+		{
+			name: "Should reactivate a suspended user",
+			fields: fields{
+				userRepository: func() repositories.UserRepository {
+					m := findUser(domain.UserStatusSuspended).(*mocks.UserRepository)
+					m.On("UpdateStatus", ctx, userID, domain.UserStatusSuspended, domain.UserStatusActive, "abuse report", mock.AnythingOfType("time.Time")).
+						Return(nil)
+					return m
+				}(),
+				refreshTokenRepository: mocks.NewRefreshTokenRepository(t),
+				jwtAuth:                mocks.NewJwtAuth(t),
+			},
+			args: args{
+				ctx:    ctx,
+				status: domain.UserStatusActive,
+			},
+			wantStatus: domain.UserStatusActive,
+			wantErr:    nil,
+		},
+		{
+			name: "Should refuse a transition that is not allowed",
+			fields: fields{
+				userRepository:         findUser(domain.UserStatusDeactivated),
+				refreshTokenRepository: mocks.NewRefreshTokenRepository(t),
+				jwtAuth:                mocks.NewJwtAuth(t),
+			},
+			args: args{
+				ctx:    ctx,
+				status: domain.UserStatusSuspended,
+			},
+			wantErr: resterrors.NewConflictError("User status can't change from deactivated to suspended").WithCode(ErrCodeInvalidStatusTransition),
+		},
+		{
+			name: "Should refuse a status changed by another request",
+			fields: fields{
+				userRepository: func() repositories.UserRepository {
+					m := findUser(domain.UserStatusSuspended).(*mocks.UserRepository)
+					m.On("UpdateStatus", ctx, userID, domain.UserStatusSuspended, domain.UserStatusActive, "abuse report", mock.AnythingOfType("time.Time")).
+						Return(resterrors.NewNotFoundError("not found"))
+					return m
+				}(),
+				refreshTokenRepository: mocks.NewRefreshTokenRepository(t),
+				jwtAuth:                mocks.NewJwtAuth(t),
+			},
+			args: args{
+				ctx:    ctx,
+				status: domain.UserStatusActive,
+			},
+			wantErr: resterrors.NewConflictError(errStatusChangedMeanwhile).WithCode(ErrCodeInvalidStatusTransition),
+		},
+		{
+			name: "Should restore the status when the tokens can't be revoked",
+			fields: fields{
+				userRepository: func() repositories.UserRepository {
+					m := mocks.NewUserRepository(t)
+					m.On("FindUserById", ctx, userID).
+						Return(&domain.User{ID: userID, StatusReason: "previous reason"}, nil)
+
+					m.On("UpdateStatus", ctx, userID, domain.UserStatusActive, domain.UserStatusSuspended, "abuse report", mock.AnythingOfType("time.Time")).
+						Return(nil).Once()
+
+					m.On("UpdateStatus", ctx, userID, domain.UserStatusSuspended, domain.UserStatusActive, "previous reason", mock.AnythingOfType("time.Time")).
+						Return(nil).Once()
+					return m
+				}(),
+				refreshTokenRepository: mocks.NewRefreshTokenRepository(t),
+				jwtAuth: func() jwt.JwtAuth {
+					m := mocks.NewJwtAuth(t)
+					m.On("RevokeUserTokens", ctx, userID).
+						Return(internalServerError)
+					return m
+				}(),
+			},
+			args: args{
+				ctx:    ctx,
+				status: domain.UserStatusSuspended,
+			},
+			wantErr: internalServerError,
+		},
+		{
+			name: "Should return the revocation error even when the status can't be restored",
+			fields: fields{
+				userRepository: func() repositories.UserRepository {
+					m := findUser("").(*mocks.UserRepository)
+					m.On("UpdateStatus", ctx, userID, domain.UserStatusActive, domain.UserStatusSuspended, "abuse report", mock.AnythingOfType("time.Time")).
+						Return(nil).Once()
+
+					m.On("UpdateStatus", ctx, userID, domain.UserStatusSuspended, domain.UserStatusActive, "", mock.AnythingOfType("time.Time")).
+						Return(resterrors.NewInternalServerError("restore error")).Once()
+					return m
+				}(),
+				refreshTokenRepository: func() repositories.RefreshTokenRepository {
+					m := mocks.NewRefreshTokenRepository(t)
+					m.On("RevokeUserRefreshTokens", ctx, userID).
+						Return(internalServerError)
+					return m
+				}(),
+				jwtAuth: func() jwt.JwtAuth {
+					m := mocks.NewJwtAuth(t)
+					m.On("RevokeUserTokens", ctx, userID).
+						Return(nil)
+					return m
+				}(),
+			},
+			args: args{
+				ctx:    ctx,
+				status: domain.UserStatusSuspended,
+			},
+			wantErr: internalServerError,
+		},
+		{
+			name: "Should refuse an admin changing its own status",
+			fields: fields{
+				userRepository:         mocks.NewUserRepository(t),
+				refreshTokenRepository: mocks.NewRefreshTokenRepository(t),
+				jwtAuth:                mocks.NewJwtAuth(t),
+			},
+			args: args{
+				ctx:    auth.WithPrincipal(ctx, &domain.Principal{UserID: userID, Roles: []string{domain.RoleAdmin}}),
+				status: domain.UserStatusSuspended,
+			},
+			wantErr: resterrors.NewBadRequestError(errChangeOwnStatus),
+		},
+		{
+			name: "Should return an error when try find the user",
+			fields: fields{
+				userRepository: func() repositories.UserRepository {
+					m := mocks.NewUserRepository(t)
+					m.On("FindUserById", ctx, userID).
+						Return(nil, internalServerError)
+					return m
+				}(),
+				refreshTokenRepository: mocks.NewRefreshTokenRepository(t),
+				jwtAuth:                mocks.NewJwtAuth(t),
+			},
+			args: args{
+				ctx:    ctx,
+				status: domain.UserStatusSuspended,
+			},
+			wantErr: internalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewUserService(tt.fields.userRepository, tt.fields.refreshTokenRepository, tt.fields.jwtAuth, mocks.NewEmailVerificationService(t), passwordHasher, passwordPolicy, passwordHistorySize, cursorSigner)
+			got, err := s.ChangeStatus(tt.args.ctx, userID, tt.args.status, "abuse report")
+			if !reflect.DeepEqual(err, tt.wantErr) {
+				t.Errorf("userSvc.ChangeStatus() err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && (got.Status != tt.wantStatus || got.StatusReason != "abuse report" || got.StatusChangedAt == nil) {
+				t.Errorf("userSvc.ChangeStatus() got = %+v, want status %v", got, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func Test_userSvc_RestoreUser(t *testing.T) {
+	deletedAt := time.Now()
+
+	tests := []struct {
+		name           string
+		userRepository repositories.UserRepository
+		want           *domain.User
+		wantErr        *resterrors.RestErr
+	}{
+		{
+			name: "Should restore a deleted user without errors",
+			userRepository: func() repositories.UserRepository {
+				m := mocks.NewUserRepository(t)
+				m.On("FindDeletedUserById", ctx, userID).
+					Return(&domain.User{ID: userID, Email: "test@email.com", DeletedAt: &deletedAt}, nil)
+				m.On("FindUserByEmail", ctx, "test@email.com").
+					Return(nil, resterrors.NewNotFoundError("user not found"))
+				m.On("RestoreUser", ctx, userID).
+					Return(&domain.User{ID: userID, Email: "test@email.com"}, nil)
+				return m
+			}(),
+			want:    &domain.User{ID: userID, Email: "test@email.com"},
+			wantErr: nil,
+		},
+		{
+			name: "Should refuse when the email was registered by another user meanwhile",
+			userRepository: func() repositories.UserRepository {
+				m := mocks.NewUserRepository(t)
+				m.On("FindDeletedUserById", ctx, userID).
+					Return(&domain.User{ID: userID, Email: "test@email.com", DeletedAt: &deletedAt}, nil)
+				m.On("FindUserByEmail", ctx, "test@email.com").
+					Return(&domain.User{ID: "another-user-id", Email: "test@email.com"}, nil)
+				return m
+			}(),
+			want:    nil,
+			wantErr: resterrors.NewBadRequestError(errEmailAlreadyRegistered),
+		},
+		{
+			name: "Should return not found when the user is not deleted",
+			userRepository: func() repositories.UserRepository {
+				m := mocks.NewUserRepository(t)
+				m.On("FindDeletedUserById", ctx, userID).
+					Return(nil, resterrors.NewNotFoundError("user not found"))
+				return m
+			}(),
+			want:    nil,
+			wantErr: resterrors.NewNotFoundError("user not found"),
+		},
+		{
+			name: "Should return an error when try restore the user",
+			userRepository: func() repositories.UserRepository {
+				m := mocks.NewUserRepository(t)
+				m.On("FindDeletedUserById", ctx, userID).
+					Return(&domain.User{ID: userID, Email: "test@email.com", DeletedAt: &deletedAt}, nil)
+				m.On("FindUserByEmail", ctx, "test@email.com").
+					Return(nil, resterrors.NewNotFoundError("user not found"))
+				m.On("RestoreUser", ctx, userID).
+					Return(nil, internalServerError)
+				return m
+			}(),
+			want:    nil,
+			wantErr: internalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewUserService(tt.userRepository, mocks.NewRefreshTokenRepository(t), mocks.NewJwtAuth(t), mocks.NewEmailVerificationService(t), passwordHasher, passwordPolicy, passwordHistorySize, cursorSigner)
+			got, err := s.RestoreUser(ctx, userID)
+			if !reflect.DeepEqual(err, tt.wantErr) {
+				t.Errorf("userSvc.RestoreUser() err = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("userSvc.RestoreUser() got = %v, want %v", got, tt.want)
 			}
 		})
 	}
@@ -0,0 +1,71 @@
+// Code generated by mockery v2.41.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	domain "github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	mock "github.com/stretchr/testify/mock"
+
+	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
+)
+
+// EmailVerificationService is an autogenerated mock type for the EmailVerificationService type
+type EmailVerificationService struct {
+	mock.Mock
+}
+
+// SendVerificationEmail provides a mock function with given fields: ctx, user
+func (_m *EmailVerificationService) SendVerificationEmail(ctx context.Context, user *domain.User) *resterrors.RestErr {
+	ret := _m.Called(ctx, user)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SendVerificationEmail")
+	}
+
+	var r0 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.User) *resterrors.RestErr); ok {
+		r0 = rf(ctx, user)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*resterrors.RestErr)
+		}
+	}
+
+	return r0
+}
+
+// VerifyEmail provides a mock function with given fields: ctx, verificationToken
+func (_m *EmailVerificationService) VerifyEmail(ctx context.Context, verificationToken string) *resterrors.RestErr {
+	ret := _m.Called(ctx, verificationToken)
+
+	if len(ret) == 0 {
+		panic("no return value specified for VerifyEmail")
+	}
+
+	var r0 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context, string) *resterrors.RestErr); ok {
+		r0 = rf(ctx, verificationToken)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*resterrors.RestErr)
+		}
+	}
+
+	return r0
+}
+
+// NewEmailVerificationService creates a new instance of EmailVerificationService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewEmailVerificationService(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *EmailVerificationService {
+	mock := &EmailVerificationService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
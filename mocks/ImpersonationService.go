@@ -0,0 +1,63 @@
+// Code generated by mockery v2.41.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	domain "github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	mock "github.com/stretchr/testify/mock"
+
+	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
+)
+
+// ImpersonationService is an autogenerated mock type for the ImpersonationService type
+type ImpersonationService struct {
+	mock.Mock
+}
+
+// Impersonate provides a mock function with given fields: ctx, actorID, userID, scopes, reason
+func (_m *ImpersonationService) Impersonate(ctx context.Context, actorID string, userID string, scopes []string, reason string) (*domain.Impersonation, *resterrors.RestErr) {
+	ret := _m.Called(ctx, actorID, userID, scopes, reason)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Impersonate")
+	}
+
+	var r0 *domain.Impersonation
+	var r1 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, []string, string) (*domain.Impersonation, *resterrors.RestErr)); ok {
+		return rf(ctx, actorID, userID, scopes, reason)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, []string, string) *domain.Impersonation); ok {
+		r0 = rf(ctx, actorID, userID, scopes, reason)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Impersonation)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, []string, string) *resterrors.RestErr); ok {
+		r1 = rf(ctx, actorID, userID, scopes, reason)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*resterrors.RestErr)
+		}
+	}
+
+	return r0, r1
+}
+
+// NewImpersonationService creates a new instance of ImpersonationService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewImpersonationService(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *ImpersonationService {
+	mock := &ImpersonationService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -3,11 +3,16 @@
 package mocks
 
 import (
+	context "context"
+
+	jwt "github.com/WalterPaes/go-rest-api-crud/pkg/jwt"
 	gin "github.com/gin-gonic/gin"
 
 	mock "github.com/stretchr/testify/mock"
 
 	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
+
+	time "time"
 )
 
 // JwtAuth is an autogenerated mock type for the JwtAuth type
@@ -45,6 +50,110 @@ func (_m *JwtAuth) GenerateToken(claims map[string]interface{}) (string, *rester
 	return r0, r1
 }
 
+// JWKSHandler provides a mock function with given fields: c
+func (_m *JwtAuth) JWKSHandler(c *gin.Context) {
+	_m.Called(c)
+}
+
+// ParseToken provides a mock function with given fields: ctx, tokenValue
+func (_m *JwtAuth) ParseToken(ctx context.Context, tokenValue string) (*jwt.Claims, *resterrors.RestErr) {
+	ret := _m.Called(ctx, tokenValue)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ParseToken")
+	}
+
+	var r0 *jwt.Claims
+	var r1 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*jwt.Claims, *resterrors.RestErr)); ok {
+		return rf(ctx, tokenValue)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *jwt.Claims); ok {
+		r0 = rf(ctx, tokenValue)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*jwt.Claims)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) *resterrors.RestErr); ok {
+		r1 = rf(ctx, tokenValue)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*resterrors.RestErr)
+		}
+	}
+
+	return r0, r1
+}
+
+// RevokeSessions provides a mock function with given fields: ctx, sessionIDs
+func (_m *JwtAuth) RevokeSessions(ctx context.Context, sessionIDs ...string) *resterrors.RestErr {
+	_va := make([]interface{}, len(sessionIDs))
+	for _i := range sessionIDs {
+		_va[_i] = sessionIDs[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeSessions")
+	}
+
+	var r0 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context, ...string) *resterrors.RestErr); ok {
+		r0 = rf(ctx, sessionIDs...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*resterrors.RestErr)
+		}
+	}
+
+	return r0
+}
+
+// RevokeToken provides a mock function with given fields: ctx, tokenID, expiresAt
+func (_m *JwtAuth) RevokeToken(ctx context.Context, tokenID string, expiresAt time.Time) *resterrors.RestErr {
+	ret := _m.Called(ctx, tokenID, expiresAt)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeToken")
+	}
+
+	var r0 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time) *resterrors.RestErr); ok {
+		r0 = rf(ctx, tokenID, expiresAt)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*resterrors.RestErr)
+		}
+	}
+
+	return r0
+}
+
+// RevokeUserTokens provides a mock function with given fields: ctx, userID
+func (_m *JwtAuth) RevokeUserTokens(ctx context.Context, userID string) *resterrors.RestErr {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeUserTokens")
+	}
+
+	var r0 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context, string) *resterrors.RestErr); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*resterrors.RestErr)
+		}
+	}
+
+	return r0
+}
+
 // VerifyTokenMiddleware provides a mock function with given fields: c
 func (_m *JwtAuth) VerifyTokenMiddleware(c *gin.Context) {
 	_m.Called(c)
@@ -16,23 +16,89 @@ type LoginService struct {
 	mock.Mock
 }
 
+// LoginExternalUser provides a mock function with given fields: ctx, user
+func (_m *LoginService) LoginExternalUser(ctx context.Context, user *domain.User) (*domain.AuthTokens, *resterrors.RestErr) {
+	ret := _m.Called(ctx, user)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LoginExternalUser")
+	}
+
+	var r0 *domain.AuthTokens
+	var r1 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.User) (*domain.AuthTokens, *resterrors.RestErr)); ok {
+		return rf(ctx, user)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.User) *domain.AuthTokens); ok {
+		r0 = rf(ctx, user)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.AuthTokens)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.User) *resterrors.RestErr); ok {
+		r1 = rf(ctx, user)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*resterrors.RestErr)
+		}
+	}
+
+	return r0, r1
+}
+
+// LoginMFA provides a mock function with given fields: ctx, mfaToken, code
+func (_m *LoginService) LoginMFA(ctx context.Context, mfaToken string, code string) (*domain.AuthTokens, *resterrors.RestErr) {
+	ret := _m.Called(ctx, mfaToken, code)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LoginMFA")
+	}
+
+	var r0 *domain.AuthTokens
+	var r1 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (*domain.AuthTokens, *resterrors.RestErr)); ok {
+		return rf(ctx, mfaToken, code)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *domain.AuthTokens); ok {
+		r0 = rf(ctx, mfaToken, code)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.AuthTokens)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) *resterrors.RestErr); ok {
+		r1 = rf(ctx, mfaToken, code)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*resterrors.RestErr)
+		}
+	}
+
+	return r0, r1
+}
+
 // LoginUser provides a mock function with given fields: ctx, user
-func (_m *LoginService) LoginUser(ctx context.Context, user *domain.User) (string, *resterrors.RestErr) {
+func (_m *LoginService) LoginUser(ctx context.Context, user *domain.User) (*domain.AuthTokens, *resterrors.RestErr) {
 	ret := _m.Called(ctx, user)
 
 	if len(ret) == 0 {
 		panic("no return value specified for LoginUser")
 	}
 
-	var r0 string
+	var r0 *domain.AuthTokens
 	var r1 *resterrors.RestErr
-	if rf, ok := ret.Get(0).(func(context.Context, *domain.User) (string, *resterrors.RestErr)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.User) (*domain.AuthTokens, *resterrors.RestErr)); ok {
 		return rf(ctx, user)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, *domain.User) string); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.User) *domain.AuthTokens); ok {
 		r0 = rf(ctx, user)
 	} else {
-		r0 = ret.Get(0).(string)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.AuthTokens)
+		}
 	}
 
 	if rf, ok := ret.Get(1).(func(context.Context, *domain.User) *resterrors.RestErr); ok {
@@ -46,6 +112,78 @@ func (_m *LoginService) LoginUser(ctx context.Context, user *domain.User) (strin
 	return r0, r1
 }
 
+// LogoutUser provides a mock function with given fields: ctx
+func (_m *LoginService) LogoutUser(ctx context.Context) *resterrors.RestErr {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LogoutUser")
+	}
+
+	var r0 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context) *resterrors.RestErr); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*resterrors.RestErr)
+		}
+	}
+
+	return r0
+}
+
+// RefreshToken provides a mock function with given fields: ctx, refreshToken
+func (_m *LoginService) RefreshToken(ctx context.Context, refreshToken string) (*domain.AuthTokens, *resterrors.RestErr) {
+	ret := _m.Called(ctx, refreshToken)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RefreshToken")
+	}
+
+	var r0 *domain.AuthTokens
+	var r1 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*domain.AuthTokens, *resterrors.RestErr)); ok {
+		return rf(ctx, refreshToken)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.AuthTokens); ok {
+		r0 = rf(ctx, refreshToken)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.AuthTokens)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) *resterrors.RestErr); ok {
+		r1 = rf(ctx, refreshToken)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*resterrors.RestErr)
+		}
+	}
+
+	return r0, r1
+}
+
+// UnlockUser provides a mock function with given fields: ctx, userID
+func (_m *LoginService) UnlockUser(ctx context.Context, userID string) *resterrors.RestErr {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UnlockUser")
+	}
+
+	var r0 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context, string) *resterrors.RestErr); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*resterrors.RestErr)
+		}
+	}
+
+	return r0
+}
+
 // NewLoginService creates a new instance of LoginService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewLoginService(t interface {
@@ -0,0 +1,157 @@
+// Code generated by mockery v2.41.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	domain "github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	mock "github.com/stretchr/testify/mock"
+
+	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
+)
+
+// MFAService is an autogenerated mock type for the MFAService type
+type MFAService struct {
+	mock.Mock
+}
+
+// Confirm provides a mock function with given fields: ctx, userID, code
+func (_m *MFAService) Confirm(ctx context.Context, userID string, code string) ([]string, *resterrors.RestErr) {
+	ret := _m.Called(ctx, userID, code)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Confirm")
+	}
+
+	var r0 []string
+	var r1 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) ([]string, *resterrors.RestErr)); ok {
+		return rf(ctx, userID, code)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) []string); ok {
+		r0 = rf(ctx, userID, code)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) *resterrors.RestErr); ok {
+		r1 = rf(ctx, userID, code)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*resterrors.RestErr)
+		}
+	}
+
+	return r0, r1
+}
+
+// CreateChallenge provides a mock function with given fields: ctx, user
+func (_m *MFAService) CreateChallenge(ctx context.Context, user *domain.User) (string, *resterrors.RestErr) {
+	ret := _m.Called(ctx, user)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateChallenge")
+	}
+
+	var r0 string
+	var r1 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.User) (string, *resterrors.RestErr)); ok {
+		return rf(ctx, user)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.User) string); ok {
+		r0 = rf(ctx, user)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.User) *resterrors.RestErr); ok {
+		r1 = rf(ctx, user)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*resterrors.RestErr)
+		}
+	}
+
+	return r0, r1
+}
+
+// Enroll provides a mock function with given fields: ctx, userID
+func (_m *MFAService) Enroll(ctx context.Context, userID string) (*domain.MFAEnrollment, *resterrors.RestErr) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Enroll")
+	}
+
+	var r0 *domain.MFAEnrollment
+	var r1 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*domain.MFAEnrollment, *resterrors.RestErr)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.MFAEnrollment); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.MFAEnrollment)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) *resterrors.RestErr); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*resterrors.RestErr)
+		}
+	}
+
+	return r0, r1
+}
+
+// VerifyChallenge provides a mock function with given fields: ctx, mfaToken, code
+func (_m *MFAService) VerifyChallenge(ctx context.Context, mfaToken string, code string) (*domain.User, *resterrors.RestErr) {
+	ret := _m.Called(ctx, mfaToken, code)
+
+	if len(ret) == 0 {
+		panic("no return value specified for VerifyChallenge")
+	}
+
+	var r0 *domain.User
+	var r1 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (*domain.User, *resterrors.RestErr)); ok {
+		return rf(ctx, mfaToken, code)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *domain.User); ok {
+		r0 = rf(ctx, mfaToken, code)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) *resterrors.RestErr); ok {
+		r1 = rf(ctx, mfaToken, code)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*resterrors.RestErr)
+		}
+	}
+
+	return r0, r1
+}
+
+// NewMFAService creates a new instance of MFAService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMFAService(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MFAService {
+	mock := &MFAService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
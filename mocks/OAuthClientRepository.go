@@ -0,0 +1,165 @@
+// Code generated by mockery v2.41.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	domain "github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	mock "github.com/stretchr/testify/mock"
+
+	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
+)
+
+// OAuthClientRepository is an autogenerated mock type for the OAuthClientRepository type
+type OAuthClientRepository struct {
+	mock.Mock
+}
+
+// CreateOAuthClient provides a mock function with given fields: ctx, client
+func (_m *OAuthClientRepository) CreateOAuthClient(ctx context.Context, client *domain.OAuthClient) (*domain.OAuthClient, *resterrors.RestErr) {
+	ret := _m.Called(ctx, client)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateOAuthClient")
+	}
+
+	var r0 *domain.OAuthClient
+	var r1 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.OAuthClient) (*domain.OAuthClient, *resterrors.RestErr)); ok {
+		return rf(ctx, client)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.OAuthClient) *domain.OAuthClient); ok {
+		r0 = rf(ctx, client)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.OAuthClient)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.OAuthClient) *resterrors.RestErr); ok {
+		r1 = rf(ctx, client)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*resterrors.RestErr)
+		}
+	}
+
+	return r0, r1
+}
+
+// DeleteOAuthClient provides a mock function with given fields: ctx, clientID
+func (_m *OAuthClientRepository) DeleteOAuthClient(ctx context.Context, clientID string) *resterrors.RestErr {
+	ret := _m.Called(ctx, clientID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteOAuthClient")
+	}
+
+	var r0 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context, string) *resterrors.RestErr); ok {
+		r0 = rf(ctx, clientID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*resterrors.RestErr)
+		}
+	}
+
+	return r0
+}
+
+// EnsureIndexes provides a mock function with given fields: ctx
+func (_m *OAuthClientRepository) EnsureIndexes(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for EnsureIndexes")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// FindOAuthClientByClientID provides a mock function with given fields: ctx, clientID
+func (_m *OAuthClientRepository) FindOAuthClientByClientID(ctx context.Context, clientID string) (*domain.OAuthClient, *resterrors.RestErr) {
+	ret := _m.Called(ctx, clientID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindOAuthClientByClientID")
+	}
+
+	var r0 *domain.OAuthClient
+	var r1 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*domain.OAuthClient, *resterrors.RestErr)); ok {
+		return rf(ctx, clientID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.OAuthClient); ok {
+		r0 = rf(ctx, clientID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.OAuthClient)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) *resterrors.RestErr); ok {
+		r1 = rf(ctx, clientID)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*resterrors.RestErr)
+		}
+	}
+
+	return r0, r1
+}
+
+// FindOAuthClients provides a mock function with given fields: ctx
+func (_m *OAuthClientRepository) FindOAuthClients(ctx context.Context) ([]*domain.OAuthClient, *resterrors.RestErr) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindOAuthClients")
+	}
+
+	var r0 []*domain.OAuthClient
+	var r1 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context) ([]*domain.OAuthClient, *resterrors.RestErr)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []*domain.OAuthClient); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.OAuthClient)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) *resterrors.RestErr); ok {
+		r1 = rf(ctx)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*resterrors.RestErr)
+		}
+	}
+
+	return r0, r1
+}
+
+// NewOAuthClientRepository creates a new instance of OAuthClientRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewOAuthClientRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *OAuthClientRepository {
+	mock := &OAuthClientRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
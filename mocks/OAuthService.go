@@ -0,0 +1,154 @@
+// Code generated by mockery v2.41.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	domain "github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	mock "github.com/stretchr/testify/mock"
+
+	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
+)
+
+// OAuthService is an autogenerated mock type for the OAuthService type
+type OAuthService struct {
+	mock.Mock
+}
+
+// ClientCredentialsToken provides a mock function with given fields: ctx, clientID, clientSecret, scope
+func (_m *OAuthService) ClientCredentialsToken(ctx context.Context, clientID string, clientSecret string, scope string) (*domain.OAuthToken, *resterrors.RestErr) {
+	ret := _m.Called(ctx, clientID, clientSecret, scope)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ClientCredentialsToken")
+	}
+
+	var r0 *domain.OAuthToken
+	var r1 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) (*domain.OAuthToken, *resterrors.RestErr)); ok {
+		return rf(ctx, clientID, clientSecret, scope)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) *domain.OAuthToken); ok {
+		r0 = rf(ctx, clientID, clientSecret, scope)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.OAuthToken)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) *resterrors.RestErr); ok {
+		r1 = rf(ctx, clientID, clientSecret, scope)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*resterrors.RestErr)
+		}
+	}
+
+	return r0, r1
+}
+
+// DeleteClient provides a mock function with given fields: ctx, clientID
+func (_m *OAuthService) DeleteClient(ctx context.Context, clientID string) *resterrors.RestErr {
+	ret := _m.Called(ctx, clientID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteClient")
+	}
+
+	var r0 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context, string) *resterrors.RestErr); ok {
+		r0 = rf(ctx, clientID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*resterrors.RestErr)
+		}
+	}
+
+	return r0
+}
+
+// ListClients provides a mock function with given fields: ctx
+func (_m *OAuthService) ListClients(ctx context.Context) ([]*domain.OAuthClient, *resterrors.RestErr) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListClients")
+	}
+
+	var r0 []*domain.OAuthClient
+	var r1 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context) ([]*domain.OAuthClient, *resterrors.RestErr)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []*domain.OAuthClient); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.OAuthClient)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) *resterrors.RestErr); ok {
+		r1 = rf(ctx)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*resterrors.RestErr)
+		}
+	}
+
+	return r0, r1
+}
+
+// RegisterClient provides a mock function with given fields: ctx, name, scopes
+func (_m *OAuthService) RegisterClient(ctx context.Context, name string, scopes []string) (*domain.OAuthClient, string, *resterrors.RestErr) {
+	ret := _m.Called(ctx, name, scopes)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RegisterClient")
+	}
+
+	var r0 *domain.OAuthClient
+	var r1 string
+	var r2 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context, string, []string) (*domain.OAuthClient, string, *resterrors.RestErr)); ok {
+		return rf(ctx, name, scopes)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, []string) *domain.OAuthClient); ok {
+		r0 = rf(ctx, name, scopes)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.OAuthClient)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, []string) string); ok {
+		r1 = rf(ctx, name, scopes)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, string, []string) *resterrors.RestErr); ok {
+		r2 = rf(ctx, name, scopes)
+	} else {
+		if ret.Get(2) != nil {
+			r2 = ret.Get(2).(*resterrors.RestErr)
+		}
+	}
+
+	return r0, r1, r2
+}
+
+// NewOAuthService creates a new instance of OAuthService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewOAuthService(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *OAuthService {
+	mock := &OAuthService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,105 @@
+// Code generated by mockery v2.41.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	oidc "github.com/WalterPaes/go-rest-api-crud/pkg/oidc"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// OIDCProvider is an autogenerated mock type for the Provider type
+type OIDCProvider struct {
+	mock.Mock
+}
+
+// AuthCodeURL provides a mock function with given fields: state, nonce, codeVerifier
+func (_m *OIDCProvider) AuthCodeURL(state string, nonce string, codeVerifier string) string {
+	ret := _m.Called(state, nonce, codeVerifier)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AuthCodeURL")
+	}
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(string, string, string) string); ok {
+		r0 = rf(state, nonce, codeVerifier)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	return r0
+}
+
+// Exchange provides a mock function with given fields: ctx, code, codeVerifier
+func (_m *OIDCProvider) Exchange(ctx context.Context, code string, codeVerifier string) (string, error) {
+	ret := _m.Called(ctx, code, codeVerifier)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Exchange")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (string, error)); ok {
+		return rf(ctx, code, codeVerifier)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) string); ok {
+		r0 = rf(ctx, code, codeVerifier)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, code, codeVerifier)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// VerifyIDToken provides a mock function with given fields: ctx, rawIDToken, nonce
+func (_m *OIDCProvider) VerifyIDToken(ctx context.Context, rawIDToken string, nonce string) (*oidc.IDToken, error) {
+	ret := _m.Called(ctx, rawIDToken, nonce)
+
+	if len(ret) == 0 {
+		panic("no return value specified for VerifyIDToken")
+	}
+
+	var r0 *oidc.IDToken
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (*oidc.IDToken, error)); ok {
+		return rf(ctx, rawIDToken, nonce)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *oidc.IDToken); ok {
+		r0 = rf(ctx, rawIDToken, nonce)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*oidc.IDToken)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, rawIDToken, nonce)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewOIDCProvider creates a new instance of OIDCProvider. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewOIDCProvider(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *OIDCProvider {
+	mock := &OIDCProvider{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
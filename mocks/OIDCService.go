@@ -0,0 +1,100 @@
+// Code generated by mockery v2.41.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	domain "github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	mock "github.com/stretchr/testify/mock"
+
+	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
+)
+
+// OIDCService is an autogenerated mock type for the OIDCService type
+type OIDCService struct {
+	mock.Mock
+}
+
+// FinishLogin provides a mock function with given fields: ctx, flowToken, state, code
+func (_m *OIDCService) FinishLogin(ctx context.Context, flowToken string, state string, code string) (*domain.AuthTokens, *resterrors.RestErr) {
+	ret := _m.Called(ctx, flowToken, state, code)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FinishLogin")
+	}
+
+	var r0 *domain.AuthTokens
+	var r1 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) (*domain.AuthTokens, *resterrors.RestErr)); ok {
+		return rf(ctx, flowToken, state, code)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) *domain.AuthTokens); ok {
+		r0 = rf(ctx, flowToken, state, code)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.AuthTokens)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) *resterrors.RestErr); ok {
+		r1 = rf(ctx, flowToken, state, code)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*resterrors.RestErr)
+		}
+	}
+
+	return r0, r1
+}
+
+// StartLogin provides a mock function with given fields: ctx
+func (_m *OIDCService) StartLogin(ctx context.Context) (string, string, *resterrors.RestErr) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for StartLogin")
+	}
+
+	var r0 string
+	var r1 string
+	var r2 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context) (string, string, *resterrors.RestErr)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) string); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) string); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context) *resterrors.RestErr); ok {
+		r2 = rf(ctx)
+	} else {
+		if ret.Get(2) != nil {
+			r2 = ret.Get(2).(*resterrors.RestErr)
+		}
+	}
+
+	return r0, r1, r2
+}
+
+// NewOIDCService creates a new instance of OIDCService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewOIDCService(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *OIDCService {
+	mock := &OIDCService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
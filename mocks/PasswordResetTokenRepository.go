@@ -0,0 +1,153 @@
+// Code generated by mockery v2.41.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	domain "github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	mock "github.com/stretchr/testify/mock"
+
+	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
+)
+
+// PasswordResetTokenRepository is an autogenerated mock type for the PasswordResetTokenRepository type
+type PasswordResetTokenRepository struct {
+	mock.Mock
+}
+
+// CreatePasswordResetToken provides a mock function with given fields: ctx, token
+func (_m *PasswordResetTokenRepository) CreatePasswordResetToken(ctx context.Context, token *domain.PasswordResetToken) (*domain.PasswordResetToken, *resterrors.RestErr) {
+	ret := _m.Called(ctx, token)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreatePasswordResetToken")
+	}
+
+	var r0 *domain.PasswordResetToken
+	var r1 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.PasswordResetToken) (*domain.PasswordResetToken, *resterrors.RestErr)); ok {
+		return rf(ctx, token)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.PasswordResetToken) *domain.PasswordResetToken); ok {
+		r0 = rf(ctx, token)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.PasswordResetToken)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.PasswordResetToken) *resterrors.RestErr); ok {
+		r1 = rf(ctx, token)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*resterrors.RestErr)
+		}
+	}
+
+	return r0, r1
+}
+
+// EnsureIndexes provides a mock function with given fields: ctx
+func (_m *PasswordResetTokenRepository) EnsureIndexes(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for EnsureIndexes")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// FindPasswordResetTokenByHash provides a mock function with given fields: ctx, tokenHash
+func (_m *PasswordResetTokenRepository) FindPasswordResetTokenByHash(ctx context.Context, tokenHash string) (*domain.PasswordResetToken, *resterrors.RestErr) {
+	ret := _m.Called(ctx, tokenHash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindPasswordResetTokenByHash")
+	}
+
+	var r0 *domain.PasswordResetToken
+	var r1 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*domain.PasswordResetToken, *resterrors.RestErr)); ok {
+		return rf(ctx, tokenHash)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.PasswordResetToken); ok {
+		r0 = rf(ctx, tokenHash)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.PasswordResetToken)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) *resterrors.RestErr); ok {
+		r1 = rf(ctx, tokenHash)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*resterrors.RestErr)
+		}
+	}
+
+	return r0, r1
+}
+
+// UsePasswordResetToken provides a mock function with given fields: ctx, tokenID
+func (_m *PasswordResetTokenRepository) UsePasswordResetToken(ctx context.Context, tokenID string) *resterrors.RestErr {
+	ret := _m.Called(ctx, tokenID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UsePasswordResetToken")
+	}
+
+	var r0 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context, string) *resterrors.RestErr); ok {
+		r0 = rf(ctx, tokenID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*resterrors.RestErr)
+		}
+	}
+
+	return r0
+}
+
+// UseUserPasswordResetTokens provides a mock function with given fields: ctx, userID
+func (_m *PasswordResetTokenRepository) UseUserPasswordResetTokens(ctx context.Context, userID string) *resterrors.RestErr {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UseUserPasswordResetTokens")
+	}
+
+	var r0 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context, string) *resterrors.RestErr); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*resterrors.RestErr)
+		}
+	}
+
+	return r0
+}
+
+// NewPasswordResetTokenRepository creates a new instance of PasswordResetTokenRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewPasswordResetTokenRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *PasswordResetTokenRepository {
+	mock := &PasswordResetTokenRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
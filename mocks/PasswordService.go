@@ -0,0 +1,69 @@
+// Code generated by mockery v2.41.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// PasswordService is an autogenerated mock type for the PasswordService type
+type PasswordService struct {
+	mock.Mock
+}
+
+// ForgotPassword provides a mock function with given fields: ctx, email
+func (_m *PasswordService) ForgotPassword(ctx context.Context, email string) *resterrors.RestErr {
+	ret := _m.Called(ctx, email)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ForgotPassword")
+	}
+
+	var r0 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context, string) *resterrors.RestErr); ok {
+		r0 = rf(ctx, email)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*resterrors.RestErr)
+		}
+	}
+
+	return r0
+}
+
+// ResetPassword provides a mock function with given fields: ctx, resetToken, newPassword
+func (_m *PasswordService) ResetPassword(ctx context.Context, resetToken string, newPassword string) *resterrors.RestErr {
+	ret := _m.Called(ctx, resetToken, newPassword)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ResetPassword")
+	}
+
+	var r0 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *resterrors.RestErr); ok {
+		r0 = rf(ctx, resetToken, newPassword)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*resterrors.RestErr)
+		}
+	}
+
+	return r0
+}
+
+// NewPasswordService creates a new instance of PasswordService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewPasswordService(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *PasswordService {
+	mock := &PasswordService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,187 @@
+// Code generated by mockery v2.41.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	domain "github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	mock "github.com/stretchr/testify/mock"
+
+	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
+
+	time "time"
+)
+
+// PersonalAccessTokenRepository is an autogenerated mock type for the PersonalAccessTokenRepository type
+type PersonalAccessTokenRepository struct {
+	mock.Mock
+}
+
+// CreatePersonalAccessToken provides a mock function with given fields: ctx, token
+func (_m *PersonalAccessTokenRepository) CreatePersonalAccessToken(ctx context.Context, token *domain.PersonalAccessToken) (*domain.PersonalAccessToken, *resterrors.RestErr) {
+	ret := _m.Called(ctx, token)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreatePersonalAccessToken")
+	}
+
+	var r0 *domain.PersonalAccessToken
+	var r1 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.PersonalAccessToken) (*domain.PersonalAccessToken, *resterrors.RestErr)); ok {
+		return rf(ctx, token)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.PersonalAccessToken) *domain.PersonalAccessToken); ok {
+		r0 = rf(ctx, token)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.PersonalAccessToken)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.PersonalAccessToken) *resterrors.RestErr); ok {
+		r1 = rf(ctx, token)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*resterrors.RestErr)
+		}
+	}
+
+	return r0, r1
+}
+
+// EnsureIndexes provides a mock function with given fields: ctx
+func (_m *PersonalAccessTokenRepository) EnsureIndexes(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for EnsureIndexes")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// FindPersonalAccessTokenByHash provides a mock function with given fields: ctx, tokenHash
+func (_m *PersonalAccessTokenRepository) FindPersonalAccessTokenByHash(ctx context.Context, tokenHash string) (*domain.PersonalAccessToken, *resterrors.RestErr) {
+	ret := _m.Called(ctx, tokenHash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindPersonalAccessTokenByHash")
+	}
+
+	var r0 *domain.PersonalAccessToken
+	var r1 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*domain.PersonalAccessToken, *resterrors.RestErr)); ok {
+		return rf(ctx, tokenHash)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.PersonalAccessToken); ok {
+		r0 = rf(ctx, tokenHash)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.PersonalAccessToken)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) *resterrors.RestErr); ok {
+		r1 = rf(ctx, tokenHash)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*resterrors.RestErr)
+		}
+	}
+
+	return r0, r1
+}
+
+// FindUserPersonalAccessTokens provides a mock function with given fields: ctx, userID
+func (_m *PersonalAccessTokenRepository) FindUserPersonalAccessTokens(ctx context.Context, userID string) ([]*domain.PersonalAccessToken, *resterrors.RestErr) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindUserPersonalAccessTokens")
+	}
+
+	var r0 []*domain.PersonalAccessToken
+	var r1 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]*domain.PersonalAccessToken, *resterrors.RestErr)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []*domain.PersonalAccessToken); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.PersonalAccessToken)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) *resterrors.RestErr); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*resterrors.RestErr)
+		}
+	}
+
+	return r0, r1
+}
+
+// RevokePersonalAccessToken provides a mock function with given fields: ctx, userID, tokenID
+func (_m *PersonalAccessTokenRepository) RevokePersonalAccessToken(ctx context.Context, userID string, tokenID string) *resterrors.RestErr {
+	ret := _m.Called(ctx, userID, tokenID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokePersonalAccessToken")
+	}
+
+	var r0 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *resterrors.RestErr); ok {
+		r0 = rf(ctx, userID, tokenID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*resterrors.RestErr)
+		}
+	}
+
+	return r0
+}
+
+// TouchPersonalAccessToken provides a mock function with given fields: ctx, tokenID, usedAt
+func (_m *PersonalAccessTokenRepository) TouchPersonalAccessToken(ctx context.Context, tokenID string, usedAt time.Time) *resterrors.RestErr {
+	ret := _m.Called(ctx, tokenID, usedAt)
+
+	if len(ret) == 0 {
+		panic("no return value specified for TouchPersonalAccessToken")
+	}
+
+	var r0 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time) *resterrors.RestErr); ok {
+		r0 = rf(ctx, tokenID, usedAt)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*resterrors.RestErr)
+		}
+	}
+
+	return r0
+}
+
+// NewPersonalAccessTokenRepository creates a new instance of PersonalAccessTokenRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewPersonalAccessTokenRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *PersonalAccessTokenRepository {
+	mock := &PersonalAccessTokenRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,154 @@
+// Code generated by mockery v2.41.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	domain "github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	mock "github.com/stretchr/testify/mock"
+
+	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
+)
+
+// PersonalAccessTokenService is an autogenerated mock type for the PersonalAccessTokenService type
+type PersonalAccessTokenService struct {
+	mock.Mock
+}
+
+// Authenticate provides a mock function with given fields: ctx, token
+func (_m *PersonalAccessTokenService) Authenticate(ctx context.Context, token string) (*domain.Principal, *resterrors.RestErr) {
+	ret := _m.Called(ctx, token)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Authenticate")
+	}
+
+	var r0 *domain.Principal
+	var r1 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*domain.Principal, *resterrors.RestErr)); ok {
+		return rf(ctx, token)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.Principal); ok {
+		r0 = rf(ctx, token)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Principal)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) *resterrors.RestErr); ok {
+		r1 = rf(ctx, token)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*resterrors.RestErr)
+		}
+	}
+
+	return r0, r1
+}
+
+// CreateToken provides a mock function with given fields: ctx, userID, name, scopes, expiresInDays
+func (_m *PersonalAccessTokenService) CreateToken(ctx context.Context, userID string, name string, scopes []string, expiresInDays int) (*domain.PersonalAccessToken, string, *resterrors.RestErr) {
+	ret := _m.Called(ctx, userID, name, scopes, expiresInDays)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateToken")
+	}
+
+	var r0 *domain.PersonalAccessToken
+	var r1 string
+	var r2 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, []string, int) (*domain.PersonalAccessToken, string, *resterrors.RestErr)); ok {
+		return rf(ctx, userID, name, scopes, expiresInDays)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, []string, int) *domain.PersonalAccessToken); ok {
+		r0 = rf(ctx, userID, name, scopes, expiresInDays)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.PersonalAccessToken)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, []string, int) string); ok {
+		r1 = rf(ctx, userID, name, scopes, expiresInDays)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, string, string, []string, int) *resterrors.RestErr); ok {
+		r2 = rf(ctx, userID, name, scopes, expiresInDays)
+	} else {
+		if ret.Get(2) != nil {
+			r2 = ret.Get(2).(*resterrors.RestErr)
+		}
+	}
+
+	return r0, r1, r2
+}
+
+// ListTokens provides a mock function with given fields: ctx, userID
+func (_m *PersonalAccessTokenService) ListTokens(ctx context.Context, userID string) ([]*domain.PersonalAccessToken, *resterrors.RestErr) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListTokens")
+	}
+
+	var r0 []*domain.PersonalAccessToken
+	var r1 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]*domain.PersonalAccessToken, *resterrors.RestErr)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []*domain.PersonalAccessToken); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.PersonalAccessToken)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) *resterrors.RestErr); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*resterrors.RestErr)
+		}
+	}
+
+	return r0, r1
+}
+
+// RevokeToken provides a mock function with given fields: ctx, userID, tokenID
+func (_m *PersonalAccessTokenService) RevokeToken(ctx context.Context, userID string, tokenID string) *resterrors.RestErr {
+	ret := _m.Called(ctx, userID, tokenID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeToken")
+	}
+
+	var r0 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *resterrors.RestErr); ok {
+		r0 = rf(ctx, userID, tokenID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*resterrors.RestErr)
+		}
+	}
+
+	return r0
+}
+
+// NewPersonalAccessTokenService creates a new instance of PersonalAccessTokenService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewPersonalAccessTokenService(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *PersonalAccessTokenService {
+	mock := &PersonalAccessTokenService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,135 @@
+// Code generated by mockery v2.41.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	domain "github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	mock "github.com/stretchr/testify/mock"
+
+	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
+
+	time "time"
+)
+
+// SessionRepository is an autogenerated mock type for the SessionRepository type
+type SessionRepository struct {
+	mock.Mock
+}
+
+// CreateSession provides a mock function with given fields: ctx, session
+func (_m *SessionRepository) CreateSession(ctx context.Context, session *domain.Session) (*domain.Session, *resterrors.RestErr) {
+	ret := _m.Called(ctx, session)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateSession")
+	}
+
+	var r0 *domain.Session
+	var r1 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.Session) (*domain.Session, *resterrors.RestErr)); ok {
+		return rf(ctx, session)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.Session) *domain.Session); ok {
+		r0 = rf(ctx, session)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Session)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.Session) *resterrors.RestErr); ok {
+		r1 = rf(ctx, session)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*resterrors.RestErr)
+		}
+	}
+
+	return r0, r1
+}
+
+// EnsureIndexes provides a mock function with given fields: ctx
+func (_m *SessionRepository) EnsureIndexes(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for EnsureIndexes")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// FindUserSessions provides a mock function with given fields: ctx, userID, sessionIDs
+func (_m *SessionRepository) FindUserSessions(ctx context.Context, userID string, sessionIDs []string) ([]*domain.Session, *resterrors.RestErr) {
+	ret := _m.Called(ctx, userID, sessionIDs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindUserSessions")
+	}
+
+	var r0 []*domain.Session
+	var r1 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context, string, []string) ([]*domain.Session, *resterrors.RestErr)); ok {
+		return rf(ctx, userID, sessionIDs)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, []string) []*domain.Session); ok {
+		r0 = rf(ctx, userID, sessionIDs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.Session)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, []string) *resterrors.RestErr); ok {
+		r1 = rf(ctx, userID, sessionIDs)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*resterrors.RestErr)
+		}
+	}
+
+	return r0, r1
+}
+
+// TouchSession provides a mock function with given fields: ctx, sessionID, tokenID, lastSeenAt, expiresAt
+func (_m *SessionRepository) TouchSession(ctx context.Context, sessionID string, tokenID string, lastSeenAt time.Time, expiresAt time.Time) *resterrors.RestErr {
+	ret := _m.Called(ctx, sessionID, tokenID, lastSeenAt, expiresAt)
+
+	if len(ret) == 0 {
+		panic("no return value specified for TouchSession")
+	}
+
+	var r0 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, time.Time, time.Time) *resterrors.RestErr); ok {
+		r0 = rf(ctx, sessionID, tokenID, lastSeenAt, expiresAt)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*resterrors.RestErr)
+		}
+	}
+
+	return r0
+}
+
+// NewSessionRepository creates a new instance of SessionRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewSessionRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *SessionRepository {
+	mock := &SessionRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -9,6 +9,8 @@ import (
 	mock "github.com/stretchr/testify/mock"
 
 	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
+
+	time "time"
 )
 
 // UserRepository is an autogenerated mock type for the UserRepository type
@@ -16,6 +18,36 @@ type UserRepository struct {
 	mock.Mock
 }
 
+// CountUsers provides a mock function with given fields: ctx, query
+func (_m *UserRepository) CountUsers(ctx context.Context, query *domain.UserQuery) (int64, *resterrors.RestErr) {
+	ret := _m.Called(ctx, query)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CountUsers")
+	}
+
+	var r0 int64
+	var r1 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.UserQuery) (int64, *resterrors.RestErr)); ok {
+		return rf(ctx, query)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.UserQuery) int64); ok {
+		r0 = rf(ctx, query)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.UserQuery) *resterrors.RestErr); ok {
+		r1 = rf(ctx, query)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*resterrors.RestErr)
+		}
+	}
+
+	return r0, r1
+}
+
 // CreateUser provides a mock function with given fields: _a0, _a1
 func (_m *UserRepository) CreateUser(_a0 context.Context, _a1 *domain.User) (*domain.User, *resterrors.RestErr) {
 	ret := _m.Called(_a0, _a1)
@@ -68,29 +100,121 @@ func (_m *UserRepository) DeleteUser(ctx context.Context, userID string) *rester
 	return r0
 }
 
-// FindAll provides a mock function with given fields: parentCtx, itemsPerPage, currentPage
-func (_m *UserRepository) FindAll(parentCtx context.Context, itemsPerPage int, currentPage int) ([]*domain.User, *resterrors.RestErr) {
-	ret := _m.Called(parentCtx, itemsPerPage, currentPage)
+// EnableMFA provides a mock function with given fields: ctx, userID, counter, recoveryCodeHashes
+func (_m *UserRepository) EnableMFA(ctx context.Context, userID string, counter int64, recoveryCodeHashes []string) *resterrors.RestErr {
+	ret := _m.Called(ctx, userID, counter, recoveryCodeHashes)
+
+	if len(ret) == 0 {
+		panic("no return value specified for EnableMFA")
+	}
+
+	var r0 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64, []string) *resterrors.RestErr); ok {
+		r0 = rf(ctx, userID, counter, recoveryCodeHashes)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*resterrors.RestErr)
+		}
+	}
+
+	return r0
+}
+
+// EndMFAChallenge provides a mock function with given fields: ctx, userID, challengeHash
+func (_m *UserRepository) EndMFAChallenge(ctx context.Context, userID string, challengeHash string) *resterrors.RestErr {
+	ret := _m.Called(ctx, userID, challengeHash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for EndMFAChallenge")
+	}
+
+	var r0 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *resterrors.RestErr); ok {
+		r0 = rf(ctx, userID, challengeHash)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*resterrors.RestErr)
+		}
+	}
+
+	return r0
+}
+
+// FailMFAChallenge provides a mock function with given fields: ctx, userID, challengeHash, maxFailures
+func (_m *UserRepository) FailMFAChallenge(ctx context.Context, userID string, challengeHash string, maxFailures int) *resterrors.RestErr {
+	ret := _m.Called(ctx, userID, challengeHash, maxFailures)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FailMFAChallenge")
+	}
+
+	var r0 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int) *resterrors.RestErr); ok {
+		r0 = rf(ctx, userID, challengeHash, maxFailures)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*resterrors.RestErr)
+		}
+	}
+
+	return r0
+}
+
+// FindAll provides a mock function with given fields: parentCtx, query
+func (_m *UserRepository) FindAll(parentCtx context.Context, query *domain.UserQuery) (*domain.UserPage, *resterrors.RestErr) {
+	ret := _m.Called(parentCtx, query)
 
 	if len(ret) == 0 {
 		panic("no return value specified for FindAll")
 	}
 
-	var r0 []*domain.User
+	var r0 *domain.UserPage
+	var r1 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.UserQuery) (*domain.UserPage, *resterrors.RestErr)); ok {
+		return rf(parentCtx, query)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.UserQuery) *domain.UserPage); ok {
+		r0 = rf(parentCtx, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.UserPage)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.UserQuery) *resterrors.RestErr); ok {
+		r1 = rf(parentCtx, query)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*resterrors.RestErr)
+		}
+	}
+
+	return r0, r1
+}
+
+// FindDeletedUserById provides a mock function with given fields: ctx, userID
+func (_m *UserRepository) FindDeletedUserById(ctx context.Context, userID string) (*domain.User, *resterrors.RestErr) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindDeletedUserById")
+	}
+
+	var r0 *domain.User
 	var r1 *resterrors.RestErr
-	if rf, ok := ret.Get(0).(func(context.Context, int, int) ([]*domain.User, *resterrors.RestErr)); ok {
-		return rf(parentCtx, itemsPerPage, currentPage)
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*domain.User, *resterrors.RestErr)); ok {
+		return rf(ctx, userID)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, int, int) []*domain.User); ok {
-		r0 = rf(parentCtx, itemsPerPage, currentPage)
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.User); ok {
+		r0 = rf(ctx, userID)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*domain.User)
+			r0 = ret.Get(0).(*domain.User)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, int, int) *resterrors.RestErr); ok {
-		r1 = rf(parentCtx, itemsPerPage, currentPage)
+	if rf, ok := ret.Get(1).(func(context.Context, string) *resterrors.RestErr); ok {
+		r1 = rf(ctx, userID)
 	} else {
 		if ret.Get(1) != nil {
 			r1 = ret.Get(1).(*resterrors.RestErr)
@@ -132,21 +256,122 @@ func (_m *UserRepository) FindUserByEmail(parentCtx context.Context, email strin
 	return r0, r1
 }
 
-// FindUserById provides a mock function with given fields: parentCtx, userID
-func (_m *UserRepository) FindUserById(parentCtx context.Context, userID string) (*domain.User, *resterrors.RestErr) {
-	ret := _m.Called(parentCtx, userID)
+// FindUserById provides a mock function with given fields: parentCtx, userID, fields
+func (_m *UserRepository) FindUserById(parentCtx context.Context, userID string, fields ...string) (*domain.User, *resterrors.RestErr) {
+	_va := make([]interface{}, len(fields))
+	for _i := range fields {
+		_va[_i] = fields[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, parentCtx, userID)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
 
 	if len(ret) == 0 {
 		panic("no return value specified for FindUserById")
 	}
 
+	var r0 *domain.User
+	var r1 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context, string, ...string) (*domain.User, *resterrors.RestErr)); ok {
+		return rf(parentCtx, userID, fields...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, ...string) *domain.User); ok {
+		r0 = rf(parentCtx, userID, fields...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, ...string) *resterrors.RestErr); ok {
+		r1 = rf(parentCtx, userID, fields...)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*resterrors.RestErr)
+		}
+	}
+
+	return r0, r1
+}
+
+// PatchUser provides a mock function with given fields: ctx, userID, patch
+func (_m *UserRepository) PatchUser(ctx context.Context, userID string, patch *domain.UserPatch) (*domain.User, *resterrors.RestErr) {
+	ret := _m.Called(ctx, userID, patch)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PatchUser")
+	}
+
+	var r0 *domain.User
+	var r1 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context, string, *domain.UserPatch) (*domain.User, *resterrors.RestErr)); ok {
+		return rf(ctx, userID, patch)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, *domain.UserPatch) *domain.User); ok {
+		r0 = rf(ctx, userID, patch)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, *domain.UserPatch) *resterrors.RestErr); ok {
+		r1 = rf(ctx, userID, patch)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*resterrors.RestErr)
+		}
+	}
+
+	return r0, r1
+}
+
+// PurgeDeletedUsers provides a mock function with given fields: ctx, deletedBefore
+func (_m *UserRepository) PurgeDeletedUsers(ctx context.Context, deletedBefore time.Time) (int64, *resterrors.RestErr) {
+	ret := _m.Called(ctx, deletedBefore)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PurgeDeletedUsers")
+	}
+
+	var r0 int64
+	var r1 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) (int64, *resterrors.RestErr)); ok {
+		return rf(ctx, deletedBefore)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) int64); ok {
+		r0 = rf(ctx, deletedBefore)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) *resterrors.RestErr); ok {
+		r1 = rf(ctx, deletedBefore)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*resterrors.RestErr)
+		}
+	}
+
+	return r0, r1
+}
+
+// RestoreUser provides a mock function with given fields: ctx, userID
+func (_m *UserRepository) RestoreUser(ctx context.Context, userID string) (*domain.User, *resterrors.RestErr) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RestoreUser")
+	}
+
 	var r0 *domain.User
 	var r1 *resterrors.RestErr
 	if rf, ok := ret.Get(0).(func(context.Context, string) (*domain.User, *resterrors.RestErr)); ok {
-		return rf(parentCtx, userID)
+		return rf(ctx, userID)
 	}
 	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.User); ok {
-		r0 = rf(parentCtx, userID)
+		r0 = rf(ctx, userID)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*domain.User)
@@ -154,7 +379,7 @@ func (_m *UserRepository) FindUserById(parentCtx context.Context, userID string)
 	}
 
 	if rf, ok := ret.Get(1).(func(context.Context, string) *resterrors.RestErr); ok {
-		r1 = rf(parentCtx, userID)
+		r1 = rf(ctx, userID)
 	} else {
 		if ret.Get(1) != nil {
 			r1 = ret.Get(1).(*resterrors.RestErr)
@@ -164,6 +389,86 @@ func (_m *UserRepository) FindUserById(parentCtx context.Context, userID string)
 	return r0, r1
 }
 
+// SetMFASecret provides a mock function with given fields: ctx, userID, secret
+func (_m *UserRepository) SetMFASecret(ctx context.Context, userID string, secret string) *resterrors.RestErr {
+	ret := _m.Called(ctx, userID, secret)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetMFASecret")
+	}
+
+	var r0 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *resterrors.RestErr); ok {
+		r0 = rf(ctx, userID, secret)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*resterrors.RestErr)
+		}
+	}
+
+	return r0
+}
+
+// StartMFAChallenge provides a mock function with given fields: ctx, userID, challengeHash
+func (_m *UserRepository) StartMFAChallenge(ctx context.Context, userID string, challengeHash string) *resterrors.RestErr {
+	ret := _m.Called(ctx, userID, challengeHash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for StartMFAChallenge")
+	}
+
+	var r0 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *resterrors.RestErr); ok {
+		r0 = rf(ctx, userID, challengeHash)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*resterrors.RestErr)
+		}
+	}
+
+	return r0
+}
+
+// UpdatePassword provides a mock function with given fields: ctx, userID, passwordHash, historySize
+func (_m *UserRepository) UpdatePassword(ctx context.Context, userID string, passwordHash string, historySize int) *resterrors.RestErr {
+	ret := _m.Called(ctx, userID, passwordHash, historySize)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdatePassword")
+	}
+
+	var r0 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int) *resterrors.RestErr); ok {
+		r0 = rf(ctx, userID, passwordHash, historySize)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*resterrors.RestErr)
+		}
+	}
+
+	return r0
+}
+
+// UpdateStatus provides a mock function with given fields: ctx, userID, fromStatus, toStatus, reason, changedAt
+func (_m *UserRepository) UpdateStatus(ctx context.Context, userID string, fromStatus string, toStatus string, reason string, changedAt time.Time) *resterrors.RestErr {
+	ret := _m.Called(ctx, userID, fromStatus, toStatus, reason, changedAt)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateStatus")
+	}
+
+	var r0 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, string, time.Time) *resterrors.RestErr); ok {
+		r0 = rf(ctx, userID, fromStatus, toStatus, reason, changedAt)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*resterrors.RestErr)
+		}
+	}
+
+	return r0
+}
+
 // UpdateUser provides a mock function with given fields: ctx, userID, user
 func (_m *UserRepository) UpdateUser(ctx context.Context, userID string, user *domain.User) (*domain.User, *resterrors.RestErr) {
 	ret := _m.Called(ctx, userID, user)
@@ -196,6 +501,66 @@ func (_m *UserRepository) UpdateUser(ctx context.Context, userID string, user *d
 	return r0, r1
 }
 
+// UseMFACounter provides a mock function with given fields: ctx, userID, counter
+func (_m *UserRepository) UseMFACounter(ctx context.Context, userID string, counter int64) *resterrors.RestErr {
+	ret := _m.Called(ctx, userID, counter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UseMFACounter")
+	}
+
+	var r0 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64) *resterrors.RestErr); ok {
+		r0 = rf(ctx, userID, counter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*resterrors.RestErr)
+		}
+	}
+
+	return r0
+}
+
+// UseRecoveryCode provides a mock function with given fields: ctx, userID, recoveryCodeHash
+func (_m *UserRepository) UseRecoveryCode(ctx context.Context, userID string, recoveryCodeHash string) *resterrors.RestErr {
+	ret := _m.Called(ctx, userID, recoveryCodeHash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UseRecoveryCode")
+	}
+
+	var r0 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *resterrors.RestErr); ok {
+		r0 = rf(ctx, userID, recoveryCodeHash)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*resterrors.RestErr)
+		}
+	}
+
+	return r0
+}
+
+// VerifyEmail provides a mock function with given fields: ctx, userID, email, verifiedAt
+func (_m *UserRepository) VerifyEmail(ctx context.Context, userID string, email string, verifiedAt time.Time) *resterrors.RestErr {
+	ret := _m.Called(ctx, userID, email, verifiedAt)
+
+	if len(ret) == 0 {
+		panic("no return value specified for VerifyEmail")
+	}
+
+	var r0 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, time.Time) *resterrors.RestErr); ok {
+		r0 = rf(ctx, userID, email, verifiedAt)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*resterrors.RestErr)
+		}
+	}
+
+	return r0
+}
+
 // NewUserRepository creates a new instance of UserRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewUserRepository(t interface {
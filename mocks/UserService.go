@@ -16,6 +16,58 @@ type UserService struct {
 	mock.Mock
 }
 
+// ChangePassword provides a mock function with given fields: ctx, userID, currentPassword, newPassword
+func (_m *UserService) ChangePassword(ctx context.Context, userID string, currentPassword string, newPassword string) *resterrors.RestErr {
+	ret := _m.Called(ctx, userID, currentPassword, newPassword)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ChangePassword")
+	}
+
+	var r0 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) *resterrors.RestErr); ok {
+		r0 = rf(ctx, userID, currentPassword, newPassword)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*resterrors.RestErr)
+		}
+	}
+
+	return r0
+}
+
+// ChangeStatus provides a mock function with given fields: ctx, userID, status, reason
+func (_m *UserService) ChangeStatus(ctx context.Context, userID string, status string, reason string) (*domain.User, *resterrors.RestErr) {
+	ret := _m.Called(ctx, userID, status, reason)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ChangeStatus")
+	}
+
+	var r0 *domain.User
+	var r1 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) (*domain.User, *resterrors.RestErr)); ok {
+		return rf(ctx, userID, status, reason)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) *domain.User); ok {
+		r0 = rf(ctx, userID, status, reason)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) *resterrors.RestErr); ok {
+		r1 = rf(ctx, userID, status, reason)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*resterrors.RestErr)
+		}
+	}
+
+	return r0, r1
+}
+
 // CreateUser provides a mock function with given fields: _a0, _a1
 func (_m *UserService) CreateUser(_a0 context.Context, _a1 *domain.User) (*domain.User, *resterrors.RestErr) {
 	ret := _m.Called(_a0, _a1)
@@ -68,29 +120,29 @@ func (_m *UserService) DeleteUser(ctx context.Context, userID string) *resterror
 	return r0
 }
 
-// FindAll provides a mock function with given fields: ctx, itemsPerPage, currentPage
-func (_m *UserService) FindAll(ctx context.Context, itemsPerPage int, currentPage int) ([]*domain.User, *resterrors.RestErr) {
-	ret := _m.Called(ctx, itemsPerPage, currentPage)
+// FindAll provides a mock function with given fields: ctx, query
+func (_m *UserService) FindAll(ctx context.Context, query *domain.UserQuery) (*domain.UserPage, *resterrors.RestErr) {
+	ret := _m.Called(ctx, query)
 
 	if len(ret) == 0 {
 		panic("no return value specified for FindAll")
 	}
 
-	var r0 []*domain.User
+	var r0 *domain.UserPage
 	var r1 *resterrors.RestErr
-	if rf, ok := ret.Get(0).(func(context.Context, int, int) ([]*domain.User, *resterrors.RestErr)); ok {
-		return rf(ctx, itemsPerPage, currentPage)
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.UserQuery) (*domain.UserPage, *resterrors.RestErr)); ok {
+		return rf(ctx, query)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, int, int) []*domain.User); ok {
-		r0 = rf(ctx, itemsPerPage, currentPage)
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.UserQuery) *domain.UserPage); ok {
+		r0 = rf(ctx, query)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*domain.User)
+			r0 = ret.Get(0).(*domain.UserPage)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, int, int) *resterrors.RestErr); ok {
-		r1 = rf(ctx, itemsPerPage, currentPage)
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.UserQuery) *resterrors.RestErr); ok {
+		r1 = rf(ctx, query)
 	} else {
 		if ret.Get(1) != nil {
 			r1 = ret.Get(1).(*resterrors.RestErr)
@@ -100,14 +152,85 @@ func (_m *UserService) FindAll(ctx context.Context, itemsPerPage int, currentPag
 	return r0, r1
 }
 
-// FindUserById provides a mock function with given fields: ctx, userID
-func (_m *UserService) FindUserById(ctx context.Context, userID string) (*domain.User, *resterrors.RestErr) {
-	ret := _m.Called(ctx, userID)
+// FindUserById provides a mock function with given fields: ctx, userID, fields
+func (_m *UserService) FindUserById(ctx context.Context, userID string, fields ...string) (*domain.User, *resterrors.RestErr) {
+	_va := make([]interface{}, len(fields))
+	for _i := range fields {
+		_va[_i] = fields[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, userID)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
 
 	if len(ret) == 0 {
 		panic("no return value specified for FindUserById")
 	}
 
+	var r0 *domain.User
+	var r1 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context, string, ...string) (*domain.User, *resterrors.RestErr)); ok {
+		return rf(ctx, userID, fields...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, ...string) *domain.User); ok {
+		r0 = rf(ctx, userID, fields...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, ...string) *resterrors.RestErr); ok {
+		r1 = rf(ctx, userID, fields...)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*resterrors.RestErr)
+		}
+	}
+
+	return r0, r1
+}
+
+// PatchUser provides a mock function with given fields: ctx, userID, patch
+func (_m *UserService) PatchUser(ctx context.Context, userID string, patch *domain.UserPatch) (*domain.User, *resterrors.RestErr) {
+	ret := _m.Called(ctx, userID, patch)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PatchUser")
+	}
+
+	var r0 *domain.User
+	var r1 *resterrors.RestErr
+	if rf, ok := ret.Get(0).(func(context.Context, string, *domain.UserPatch) (*domain.User, *resterrors.RestErr)); ok {
+		return rf(ctx, userID, patch)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, *domain.UserPatch) *domain.User); ok {
+		r0 = rf(ctx, userID, patch)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, *domain.UserPatch) *resterrors.RestErr); ok {
+		r1 = rf(ctx, userID, patch)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*resterrors.RestErr)
+		}
+	}
+
+	return r0, r1
+}
+
+// RestoreUser provides a mock function with given fields: ctx, userID
+func (_m *UserService) RestoreUser(ctx context.Context, userID string) (*domain.User, *resterrors.RestErr) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RestoreUser")
+	}
+
 	var r0 *domain.User
 	var r1 *resterrors.RestErr
 	if rf, ok := ret.Get(0).(func(context.Context, string) (*domain.User, *resterrors.RestErr)); ok {
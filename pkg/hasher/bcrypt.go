@@ -0,0 +1,51 @@
+package hasher
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var errInvalidBcryptCost = "bcrypt cost must be between %d and %d"
+
+type bcryptHasher struct {
+	cost int
+}
+
+func NewBcrypt(cost int) (*bcryptHasher, error) {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return nil, fmt.Errorf(errInvalidBcryptCost, bcrypt.MinCost, bcrypt.MaxCost)
+	}
+	return &bcryptHasher{cost: cost}, nil
+}
+
+// Hash returns the modular crypt format used by bcrypt, like $2a$10$...
+func (h *bcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+func (h *bcryptHasher) Verify(hash, password string) bool {
+	return Verify(hash, password)
+}
+
+func (h *bcryptHasher) NeedsRehash(hash string) bool {
+	if !isBcrypt(hash) {
+		return true
+	}
+
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost != h.cost
+}
+
+func isBcrypt(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
+
+func verifyBcrypt(hash, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
@@ -0,0 +1,63 @@
+package hasher
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	AlgorithmBcrypt   = "bcrypt"
+	AlgorithmArgon2id = "argon2id"
+)
+
+var errUnsupportedAlgorithm = "unsupported password hash algorithm '%s'"
+
+// PasswordHasher hashes the passwords with the configured algorithm. Hashes of
+// any supported algorithm are verified, so the algorithm can be changed without
+// locking out the users, NeedsRehash tells which hashes should be upgraded.
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	Verify(hash, password string) bool
+	NeedsRehash(hash string) bool
+}
+
+type Config struct {
+	Algorithm  string
+	BcryptCost int
+	Argon2id   Argon2idParams
+}
+
+// New returns the hasher of the configured algorithm.
+func New(cfg Config) (PasswordHasher, error) {
+	var (
+		h   PasswordHasher
+		err error
+	)
+
+	switch cfg.Algorithm {
+	case AlgorithmBcrypt:
+		h, err = NewBcrypt(cfg.BcryptCost)
+	case AlgorithmArgon2id:
+		h, err = NewArgon2id(cfg.Argon2id)
+	default:
+		err = fmt.Errorf(errUnsupportedAlgorithm, cfg.Algorithm)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// Verify checks the password against a hash of any supported algorithm, which is
+// detected from the hash prefix.
+func Verify(hash, password string) bool {
+	switch {
+	case isBcrypt(hash):
+		return verifyBcrypt(hash, password)
+	case strings.HasPrefix(hash, argon2idPrefix):
+		return verifyArgon2id(hash, password)
+	default:
+		return false
+	}
+}
@@ -0,0 +1,104 @@
+package hasher
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var testArgon2idParams = Argon2idParams{
+	Memory:      64,
+	Iterations:  1,
+	Parallelism: 1,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+func Test_Hashers(t *testing.T) {
+	bcryptHasher, _ := NewBcrypt(bcrypt.MinCost)
+	argon2idHasher, _ := NewArgon2id(testArgon2idParams)
+
+	hashers := map[string]PasswordHasher{
+		AlgorithmBcrypt:   bcryptHasher,
+		AlgorithmArgon2id: argon2idHasher,
+	}
+
+	for name, h := range hashers {
+		t.Run("Should verify the password hashed with "+name, func(t *testing.T) {
+			hash, err := h.Hash("secret@123")
+			assert.Nil(t, err)
+
+			assert.True(t, h.Verify(hash, "secret@123"))
+			assert.False(t, h.Verify(hash, "wrong@123"))
+			assert.False(t, h.NeedsRehash(hash))
+		})
+	}
+
+	t.Run("Should verify the hashes of every algorithm", func(t *testing.T) {
+		bcryptHash, _ := bcryptHasher.Hash("secret@123")
+		argon2idHash, _ := argon2idHasher.Hash("secret@123")
+
+		assert.True(t, argon2idHasher.Verify(bcryptHash, "secret@123"))
+		assert.True(t, bcryptHasher.Verify(argon2idHash, "secret@123"))
+	})
+
+	t.Run("Should hash argon2id in the PHC string format", func(t *testing.T) {
+		hash, _ := argon2idHasher.Hash("secret@123")
+
+		assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=64,t=1,p=1$"))
+		assert.Len(t, strings.Split(hash, "$"), 6)
+	})
+
+	t.Run("Should rehash the hashes of another algorithm", func(t *testing.T) {
+		bcryptHash, _ := bcryptHasher.Hash("secret@123")
+		argon2idHash, _ := argon2idHasher.Hash("secret@123")
+
+		assert.True(t, argon2idHasher.NeedsRehash(bcryptHash))
+		assert.True(t, bcryptHasher.NeedsRehash(argon2idHash))
+	})
+
+	t.Run("Should rehash the hashes with outdated parameters", func(t *testing.T) {
+		bcryptHash, _ := bcryptHasher.Hash("secret@123")
+		argon2idHash, _ := argon2idHasher.Hash("secret@123")
+
+		strongerBcrypt, _ := NewBcrypt(bcrypt.MinCost + 1)
+		strongerParams := testArgon2idParams
+		strongerParams.Iterations = 2
+		strongerArgon2id, _ := NewArgon2id(strongerParams)
+
+		assert.True(t, strongerBcrypt.NeedsRehash(bcryptHash))
+		assert.True(t, strongerArgon2id.NeedsRehash(argon2idHash))
+	})
+
+	t.Run("Should not verify malformed hashes", func(t *testing.T) {
+		for _, hash := range []string{"", "plain", "$argon2id$v=19$m=64,t=1,p=1$salt", "$argon2id$v=18$m=64,t=1,p=1$c2FsdA$a2V5", "$2a$invalid"} {
+			assert.False(t, Verify(hash, "secret@123"), hash)
+			assert.True(t, argon2idHasher.NeedsRehash(hash), hash)
+		}
+	})
+}
+
+func Test_New(t *testing.T) {
+	t.Run("Should return the hasher of the algorithm", func(t *testing.T) {
+		h, err := New(Config{Algorithm: AlgorithmArgon2id, Argon2id: testArgon2idParams})
+		assert.Nil(t, err)
+		assert.IsType(t, &argon2idHasher{}, h)
+
+		h, err = New(Config{Algorithm: AlgorithmBcrypt, BcryptCost: bcrypt.DefaultCost})
+		assert.Nil(t, err)
+		assert.IsType(t, &bcryptHasher{}, h)
+	})
+
+	t.Run("Should return an error for invalid configs", func(t *testing.T) {
+		for _, cfg := range []Config{
+			{Algorithm: "md5"},
+			{Algorithm: AlgorithmBcrypt, BcryptCost: bcrypt.MaxCost + 1},
+			{Algorithm: AlgorithmArgon2id},
+		} {
+			_, err := New(cfg)
+			assert.NotNil(t, err, cfg.Algorithm)
+		}
+	})
+}
@@ -0,0 +1,104 @@
+package jwt
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	"github.com/golang-jwt/jwt"
+)
+
+var (
+	errMissingClaims       = errors.New("token is missing the id, name or email claims")
+	errInvalidClientClaims = errors.New("token of a client must have the client as subject")
+	errInvalidActorClaims  = errors.New("token of an impersonation must have another user as actor")
+)
+
+// Claims are the claims of the access tokens issued by GenerateToken. The tokens of
+// an OAuth client carry the client_id, as the sub, and the scope claims instead of
+// the user ones. The tokens of an impersonation carry the admin in the act claim,
+// as RFC 8693 describes, and the scope claim limiting them.
+type Claims struct {
+	UserID    string   `json:"id"`
+	Name      string   `json:"name"`
+	Email     string   `json:"email"`
+	Roles     []string `json:"roles,omitempty"`
+	SessionID string   `json:"sid,omitempty"`
+	ClientID  string   `json:"client_id,omitempty"`
+	Scope     string   `json:"scope,omitempty"`
+	Actor     *Actor   `json:"act,omitempty"`
+
+	// IssuedAtNano is the iat in nanoseconds. The iat only has seconds, which can't
+	// tell the tokens issued right before a revocation from the ones issued right
+	// after it in the same second.
+	IssuedAtNano int64 `json:"iat_ns,omitempty"`
+	jwt.StandardClaims
+}
+
+// Actor is the party acting on behalf of the subject of the token.
+type Actor struct {
+	Subject string `json:"sub"`
+}
+
+// Valid checks the expiration of the token and that it identifies an user or a
+// client, a token lacking these claims is refused instead of producing an empty principal.
+func (c *Claims) Valid() error {
+	if err := c.StandardClaims.Valid(); err != nil {
+		return err
+	}
+
+	if c.ClientID != "" {
+		if c.Subject != c.ClientID || c.UserID != "" || c.Actor != nil {
+			return errInvalidClientClaims
+		}
+		return nil
+	}
+
+	if c.Actor != nil && (c.Actor.Subject == "" || c.Actor.Subject == c.UserID) {
+		return errInvalidActorClaims
+	}
+
+	if c.UserID == "" || c.Name == "" || c.Email == "" {
+		return errMissingClaims
+	}
+
+	return nil
+}
+
+// IssuedTime returns when the token was issued, to the second for the tokens issued
+// before the iat_ns claim existed.
+func (c *Claims) IssuedTime() time.Time {
+	if c.IssuedAtNano != 0 {
+		return time.Unix(0, c.IssuedAtNano)
+	}
+	return time.Unix(c.IssuedAt, 0)
+}
+
+func (c *Claims) Principal() *domain.Principal {
+	if c.ClientID != "" {
+		return &domain.Principal{
+			ClientID:  c.ClientID,
+			TokenID:   c.Id,
+			ExpiresAt: time.Unix(c.ExpiresAt, 0),
+			Scopes:    append([]string{}, strings.Fields(c.Scope)...),
+		}
+	}
+
+	principal := &domain.Principal{
+		UserID:    c.UserID,
+		Name:      c.Name,
+		Email:     c.Email,
+		Roles:     c.Roles,
+		TokenID:   c.Id,
+		SessionID: c.SessionID,
+		ExpiresAt: time.Unix(c.ExpiresAt, 0),
+	}
+
+	if c.Actor != nil {
+		principal.ActorID = c.Actor.Subject
+		principal.Scopes = append([]string{}, strings.Fields(c.Scope)...)
+	}
+
+	return principal
+}
@@ -1,46 +1,77 @@
 package jwt
 
 import (
+	"context"
 	"fmt"
+	"net/http"
 	"strings"
 	"time"
 
-	"github.com/WalterPaes/go-rest-api-crud/internal/domain"
+	"github.com/WalterPaes/go-rest-api-crud/internal/auth"
 	"github.com/WalterPaes/go-rest-api-crud/pkg/logger"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/randtoken"
 	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt"
+	"go.uber.org/zap"
 )
 
-const errInvalidToken = "Invalid Token"
+const (
+	errInvalidToken     = "Invalid Token"
+	errVerifyRevocation = "Error when trying verify token revocation"
+	errGenerateTokenID  = "Error trying to generate jwt token id"
+)
 
 type JwtAuth interface {
 	GenerateToken(claims map[string]any) (string, *resterrors.RestErr)
 	VerifyTokenMiddleware(c *gin.Context)
+	ParseToken(ctx context.Context, tokenValue string) (*Claims, *resterrors.RestErr)
+	JWKSHandler(c *gin.Context)
+	RevokeToken(ctx context.Context, tokenID string, expiresAt time.Time) *resterrors.RestErr
+	RevokeSessions(ctx context.Context, sessionIDs ...string) *resterrors.RestErr
+	RevokeUserTokens(ctx context.Context, userID string) *resterrors.RestErr
 }
 
 type jwtAuth struct {
-	secret  string
-	expTime int
+	keySet          *KeySet
+	expTime         int
+	revocationStore RevocationStore
 }
 
-func NewJwtAuth(secret string, expTime int) *jwtAuth {
+func NewJwtAuth(keySet *KeySet, expTime int, revocationStore RevocationStore) *jwtAuth {
 	return &jwtAuth{
-		secret:  secret,
-		expTime: expTime,
+		keySet:          keySet,
+		expTime:         expTime,
+		revocationStore: revocationStore,
 	}
 }
 
 func (a *jwtAuth) GenerateToken(claims map[string]any) (string, *resterrors.RestErr) {
-	jwtClaims := jwt.MapClaims{"exp": time.Now().Add(time.Hour * time.Duration(a.expTime)).Unix()}
+	tokenID, err := randtoken.Generate(16)
+	if err != nil {
+		return "", resterrors.NewInternalServerError(errGenerateTokenID)
+	}
+
+	now := time.Now()
+	jwtClaims := jwt.MapClaims{
+		"jti":    tokenID,
+		"iat":    now.Unix(),
+		"iat_ns": now.UnixNano(),
+		"exp":    now.Add(a.tokenDuration()).Unix(),
+	}
 
 	for k, v := range claims {
 		jwtClaims[k] = v
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwtClaims)
+	signingKey := a.keySet.SigningKey()
 
-	tokenString, err := token.SignedString([]byte(a.secret))
+	token := jwt.NewWithClaims(signingKey.Method, jwtClaims)
+	if signingKey.ID != "" {
+		token.Header["kid"] = signingKey.ID
+	}
+
+	tokenString, err := token.SignedString(signingKey.SigningKey)
 	if err != nil {
 		return "", resterrors.NewInternalServerError(
 			fmt.Sprintf("Error trying to generate jwt token: %s", err.Error()))
@@ -52,30 +83,88 @@ func (a *jwtAuth) GenerateToken(claims map[string]any) (string, *resterrors.Rest
 func (a *jwtAuth) VerifyTokenMiddleware(c *gin.Context) {
 	tokenValue := strings.TrimPrefix(c.Request.Header.Get("Authorization"), "Bearer ")
 
-	token, err := jwt.Parse(tokenValue, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
-			return []byte(a.secret), nil
-		}
-		return nil, resterrors.NewBadRequestError(errInvalidToken)
-	})
-	if err != nil {
-		errRest := resterrors.NewUnauthorizedError(errInvalidToken)
+	claims, errRest := a.ParseToken(c.Request.Context(), tokenValue)
+	if errRest != nil {
 		c.JSON(errRest.HttpStatusCode, errRest)
 		c.Abort()
 		return
 	}
 
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok || !token.Valid {
-		errRest := resterrors.NewUnauthorizedError(errInvalidToken)
-		c.JSON(errRest.HttpStatusCode, errRest)
-		c.Abort()
+	principal := claims.Principal()
+	auth.SetPrincipal(c, principal)
+
+	if principal.IsClient() {
+		logger.Info("Client authenticated", zap.String("client_id", principal.ClientID), zap.String("token_id", principal.TokenID))
+		return
+	}
+
+	if principal.IsImpersonated() {
+		logger.Info("User authenticated by impersonation", zap.String("user_id", principal.UserID), zap.String("actor_id", principal.ActorID), zap.String("token_id", principal.TokenID))
 		return
 	}
 
-	logger.Info(fmt.Sprintf("User authenticated: %+v", domain.User{
-		ID:    claims["id"].(string),
-		Name:  claims["name"].(string),
-		Email: claims["email"].(string),
-	}))
+	logger.Info("User authenticated", zap.String("user_id", principal.UserID), zap.String("token_id", principal.TokenID))
+}
+
+// ParseToken verifies the signature, the claims and the revocation of the token.
+func (a *jwtAuth) ParseToken(ctx context.Context, tokenValue string) (*Claims, *resterrors.RestErr) {
+	claims := &Claims{}
+
+	token, err := jwt.ParseWithClaims(tokenValue, claims, func(token *jwt.Token) (interface{}, error) {
+		keyID, _ := token.Header["kid"].(string)
+		if key, ok := a.keySet.VerificationKey(keyID, token.Method.Alg()); ok {
+			return key.VerificationKey, nil
+		}
+		return nil, resterrors.NewBadRequestError(errInvalidToken)
+	})
+	if err != nil || !token.Valid {
+		logger.Error(errInvalidToken, err)
+		return nil, resterrors.NewUnauthorizedError(errInvalidToken)
+	}
+
+	revoked, errRest := a.revocationStore.IsRevoked(ctx, claims.Id, claims.UserID, claims.SessionID, claims.IssuedTime())
+	if errRest != nil {
+		logger.Error(errVerifyRevocation, errRest)
+		return nil, errRest
+	}
+
+	if revoked {
+		return nil, resterrors.NewUnauthorizedError(errInvalidToken)
+	}
+
+	return claims, nil
+}
+
+// JWKSHandler publishes the public keys that verify the tokens issued by this API.
+func (a *jwtAuth) JWKSHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, a.keySet.JWKS())
+}
+
+// RevokeToken refuses the token with the given jti until it would have expired anyway.
+func (a *jwtAuth) RevokeToken(ctx context.Context, tokenID string, expiresAt time.Time) *resterrors.RestErr {
+	return a.revocationStore.RevokeToken(ctx, tokenID, expiresAt)
+}
+
+// RevokeSessions refuses every token carrying one of the sids, whenever it was issued.
+func (a *jwtAuth) RevokeSessions(ctx context.Context, sessionIDs ...string) *resterrors.RestErr {
+	expiresAt := time.Now().Add(a.tokenDuration())
+	for _, sessionID := range sessionIDs {
+		if err := a.revocationStore.RevokeSession(ctx, sessionID, expiresAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RevokeUserTokens refuses every token issued to the user until now. The tokens are
+// compared by their iat_ns claim, so the ones issued earlier in the same second are
+// refused and the ones issued right after are not. The entry is kept only while a
+// token issued right now could still be valid.
+func (a *jwtAuth) RevokeUserTokens(ctx context.Context, userID string) *resterrors.RestErr {
+	now := time.Now()
+	return a.revocationStore.RevokeUserTokens(ctx, userID, now, now.Add(a.tokenDuration()))
+}
+
+func (a *jwtAuth) tokenDuration() time.Duration {
+	return time.Hour * time.Duration(a.expTime)
 }
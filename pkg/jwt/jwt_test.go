@@ -0,0 +1,266 @@
+package jwt
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/WalterPaes/go-rest-api-crud/internal/auth"
+	"github.com/WalterPaes/go-rest-api-crud/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	logger.Init("debug", "stdout")
+}
+
+var (
+	ctx = context.Background()
+
+	claims = map[string]any{
+		"id":    "user_id",
+		"name":  "First User",
+		"email": "firstuser@email.com",
+		"roles": []string{"admin"},
+		"sid":   "session_id",
+	}
+)
+
+func newHMACJwtAuth(secret string) *jwtAuth {
+	keySet, _ := NewKeySet(NewHMACKey("", secret))
+	return NewJwtAuth(keySet, 1, NewMemoryRevocationStore())
+}
+
+func verifyToken(a *jwtAuth, token string) (*httptest.ResponseRecorder, *gin.Context) {
+	gin.SetMode(gin.TestMode)
+
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	c.Request.Header.Set("Authorization", "Bearer "+token)
+
+	a.VerifyTokenMiddleware(c)
+	return recorder, c
+}
+
+func Test_jwtAuth_VerifyTokenMiddleware(t *testing.T) {
+	t.Run("Should accept a valid token and expose its id", func(t *testing.T) {
+		a := newHMACJwtAuth("secret")
+
+		token, err := a.GenerateToken(claims)
+		assert.Nil(t, err)
+
+		recorder, c := verifyToken(a, token)
+
+		assert.False(t, c.IsAborted())
+		assert.EqualValues(t, http.StatusOK, recorder.Code)
+
+		principal, ok := auth.PrincipalFromGin(c)
+		assert.True(t, ok)
+		assert.NotEmpty(t, principal.TokenID)
+		assert.Equal(t, "session_id", principal.SessionID)
+		assert.True(t, principal.ExpiresAt.After(time.Now()))
+		assert.Equal(t, "user_id", principal.UserID)
+		assert.Equal(t, "First User", principal.Name)
+		assert.Equal(t, "firstuser@email.com", principal.Email)
+		assert.Equal(t, []string{"admin"}, principal.Roles)
+
+		ctxPrincipal, ok := auth.PrincipalFromContext(c.Request.Context())
+		assert.True(t, ok)
+		assert.Equal(t, principal, ctxPrincipal)
+	})
+
+	t.Run("Should reject a token without the user claims", func(t *testing.T) {
+		a := newHMACJwtAuth("secret")
+
+		token, err := a.GenerateToken(map[string]any{"id": "user_id"})
+		assert.Nil(t, err)
+
+		recorder, c := verifyToken(a, token)
+
+		assert.True(t, c.IsAborted())
+		assert.EqualValues(t, http.StatusUnauthorized, recorder.Code)
+		_, ok := auth.PrincipalFromGin(c)
+		assert.False(t, ok)
+	})
+
+	t.Run("Should accept the token of a client and expose its scopes", func(t *testing.T) {
+		a := newHMACJwtAuth("secret")
+
+		token, err := a.GenerateToken(map[string]any{"sub": "client_id", "client_id": "client_id", "scope": "users:read users:write"})
+		assert.Nil(t, err)
+
+		recorder, c := verifyToken(a, token)
+
+		assert.False(t, c.IsAborted())
+		assert.EqualValues(t, http.StatusOK, recorder.Code)
+
+		principal, ok := auth.PrincipalFromGin(c)
+		assert.True(t, ok)
+		assert.True(t, principal.IsClient())
+		assert.Equal(t, "client_id", principal.ClientID)
+		assert.Empty(t, principal.UserID)
+		assert.Nil(t, principal.Roles)
+		assert.Equal(t, []string{"users:read", "users:write"}, principal.Scopes)
+	})
+
+	t.Run("Should reject the token of a client with another subject", func(t *testing.T) {
+		a := newHMACJwtAuth("secret")
+
+		token, err := a.GenerateToken(map[string]any{"sub": "other_client_id", "client_id": "client_id", "scope": "users:read"})
+		assert.Nil(t, err)
+
+		recorder, c := verifyToken(a, token)
+
+		assert.True(t, c.IsAborted())
+		assert.EqualValues(t, http.StatusUnauthorized, recorder.Code)
+	})
+
+	t.Run("Should accept the token of an impersonation and expose both identities", func(t *testing.T) {
+		a := newHMACJwtAuth("secret")
+
+		token, err := a.GenerateToken(map[string]any{
+			"id":    "user_id",
+			"name":  "First User",
+			"email": "firstuser@email.com",
+			"roles": []string{"user"},
+			"act":   map[string]any{"sub": "admin_id"},
+			"scope": "users:read",
+		})
+		assert.Nil(t, err)
+
+		recorder, c := verifyToken(a, token)
+
+		assert.False(t, c.IsAborted())
+		assert.EqualValues(t, http.StatusOK, recorder.Code)
+
+		principal, ok := auth.PrincipalFromGin(c)
+		assert.True(t, ok)
+		assert.True(t, principal.IsImpersonated())
+		assert.Equal(t, "user_id", principal.UserID)
+		assert.Equal(t, "admin_id", principal.ActorID)
+		assert.Equal(t, []string{"users:read"}, principal.Scopes)
+	})
+
+	t.Run("Should reject the token of an user impersonating itself", func(t *testing.T) {
+		a := newHMACJwtAuth("secret")
+
+		token, err := a.GenerateToken(map[string]any{
+			"id":    "user_id",
+			"name":  "First User",
+			"email": "firstuser@email.com",
+			"act":   map[string]any{"sub": "user_id"},
+		})
+		assert.Nil(t, err)
+
+		recorder, c := verifyToken(a, token)
+
+		assert.True(t, c.IsAborted())
+		assert.EqualValues(t, http.StatusUnauthorized, recorder.Code)
+	})
+
+	t.Run("Should reject a token with claims of unexpected types", func(t *testing.T) {
+		a := newHMACJwtAuth("secret")
+
+		token, err := a.GenerateToken(map[string]any{"id": 10, "name": "First User", "email": "firstuser@email.com"})
+		assert.Nil(t, err)
+
+		recorder, c := verifyToken(a, token)
+
+		assert.True(t, c.IsAborted())
+		assert.EqualValues(t, http.StatusUnauthorized, recorder.Code)
+	})
+
+	t.Run("Should reject a token signed with another secret", func(t *testing.T) {
+		a := newHMACJwtAuth("secret")
+		token, _ := newHMACJwtAuth("other").GenerateToken(claims)
+
+		recorder, c := verifyToken(a, token)
+
+		assert.True(t, c.IsAborted())
+		assert.EqualValues(t, http.StatusUnauthorized, recorder.Code)
+	})
+
+	t.Run("Should reject a revoked token", func(t *testing.T) {
+		a := newHMACJwtAuth("secret")
+
+		token, _ := a.GenerateToken(claims)
+		_, c := verifyToken(a, token)
+		principal, _ := auth.PrincipalFromGin(c)
+
+		err := a.RevokeToken(ctx, principal.TokenID, principal.ExpiresAt)
+		assert.Nil(t, err)
+
+		recorder, c := verifyToken(a, token)
+
+		assert.True(t, c.IsAborted())
+		assert.EqualValues(t, http.StatusUnauthorized, recorder.Code)
+	})
+
+	t.Run("Should reject the tokens of a revoked session", func(t *testing.T) {
+		a := newHMACJwtAuth("secret")
+
+		token, _ := a.GenerateToken(claims)
+		otherSessionClaims := map[string]any{"id": "user_id", "name": "First User", "email": "firstuser@email.com", "sid": "other_session_id"}
+		otherSessionToken, _ := a.GenerateToken(otherSessionClaims)
+
+		err := a.RevokeSessions(ctx, "session_id")
+		assert.Nil(t, err)
+
+		recorder, c := verifyToken(a, token)
+		assert.True(t, c.IsAborted())
+		assert.EqualValues(t, http.StatusUnauthorized, recorder.Code)
+
+		_, c = verifyToken(a, otherSessionToken)
+		assert.False(t, c.IsAborted())
+	})
+
+	t.Run("Should reject every token issued to an user before its revocation, in the same second too", func(t *testing.T) {
+		a := newHMACJwtAuth("secret")
+
+		token, _ := a.GenerateToken(claims)
+		otherUserClaims := map[string]any{"id": "other_user_id", "name": "Other", "email": "other@email.com"}
+		otherUserToken, _ := a.GenerateToken(otherUserClaims)
+
+		err := a.RevokeUserTokens(ctx, "user_id")
+		assert.Nil(t, err)
+
+		recorder, c := verifyToken(a, token)
+		assert.True(t, c.IsAborted())
+		assert.EqualValues(t, http.StatusUnauthorized, recorder.Code)
+
+		_, c = verifyToken(a, otherUserToken)
+		assert.False(t, c.IsAborted())
+	})
+
+	t.Run("Should accept the tokens issued right after the revocation", func(t *testing.T) {
+		a := newHMACJwtAuth("secret")
+
+		err := a.RevokeUserTokens(ctx, "user_id")
+		assert.Nil(t, err)
+
+		token, _ := a.GenerateToken(claims)
+
+		_, c := verifyToken(a, token)
+		assert.False(t, c.IsAborted())
+	})
+
+	t.Run("Should compare the tokens without iat_ns by their iat", func(t *testing.T) {
+		a := newHMACJwtAuth("secret")
+
+		legacyClaims := map[string]any{"iat_ns": 0, "iat": time.Now().Add(-time.Minute).Unix()}
+		for k, v := range claims {
+			legacyClaims[k] = v
+		}
+		token, _ := a.GenerateToken(legacyClaims)
+
+		err := a.RevokeUserTokens(ctx, "user_id")
+		assert.Nil(t, err)
+
+		_, c := verifyToken(a, token)
+		assert.True(t, c.IsAborted())
+	})
+}
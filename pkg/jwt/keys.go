@@ -0,0 +1,233 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/golang-jwt/jwt"
+)
+
+const (
+	SigningMethodHS256 = "HS256"
+	SigningMethodRS256 = "RS256"
+	SigningMethodEdDSA = "EdDSA"
+)
+
+var (
+	errInvalidPEM           = errors.New("invalid PEM data")
+	errUnsupportedKey       = errors.New("unsupported key type, expected RSA or Ed25519")
+	errUnsupportedMethod    = "unsupported jwt signing method '%s'"
+	errKeyMethodMismatch    = "key '%s' can't be used with signing method '%s'"
+	errDuplicatedKeyID      = "duplicated jwt key id '%s'"
+	errInvalidPublicKeyFile = "invalid jwt public key file entry '%s', expected kid=path"
+)
+
+// Key is a key able to verify tokens and, when it holds the private part, to sign them.
+type Key struct {
+	ID              string
+	Method          jwt.SigningMethod
+	SigningKey      any
+	VerificationKey any
+}
+
+// KeySet holds the key used to sign new tokens and every key accepted to verify them.
+// Keeping the previous public keys in the set allows rotating the signing key without
+// invalidating the tokens already issued.
+type KeySet struct {
+	signing      *Key
+	verification map[string]*Key
+}
+
+type KeySetConfig struct {
+	SigningMethod  string
+	Secret         string
+	KeyID          string
+	PrivateKeyFile string
+	// PublicKeyFiles is a comma separated list of kid=path entries with the
+	// extra public keys accepted for verification.
+	PublicKeyFiles string
+}
+
+func NewKeySet(signing *Key, verification ...*Key) (*KeySet, error) {
+	ks := &KeySet{
+		signing:      signing,
+		verification: map[string]*Key{signing.ID: signing},
+	}
+
+	for _, key := range verification {
+		if _, exists := ks.verification[key.ID]; exists {
+			return nil, fmt.Errorf(errDuplicatedKeyID, key.ID)
+		}
+		ks.verification[key.ID] = key
+	}
+
+	return ks, nil
+}
+
+// LoadKeySet builds the KeySet described by the config, reading the PEM files from disk.
+func LoadKeySet(cfg KeySetConfig) (*KeySet, error) {
+	var (
+		signing *Key
+		err     error
+	)
+
+	switch cfg.SigningMethod {
+	case "", SigningMethodHS256:
+		signing = NewHMACKey(cfg.KeyID, cfg.Secret)
+	case SigningMethodRS256, SigningMethodEdDSA:
+		signing, err = readPrivateKey(cfg.KeyID, cfg.PrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+
+		if signing.Method.Alg() != cfg.SigningMethod {
+			return nil, fmt.Errorf(errKeyMethodMismatch, cfg.KeyID, cfg.SigningMethod)
+		}
+	default:
+		return nil, fmt.Errorf(errUnsupportedMethod, cfg.SigningMethod)
+	}
+
+	var verification []*Key
+	for _, entry := range strings.Split(cfg.PublicKeyFiles, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		keyID, path, found := strings.Cut(entry, "=")
+		if !found || keyID == "" || path == "" {
+			return nil, fmt.Errorf(errInvalidPublicKeyFile, entry)
+		}
+
+		key, err := readPublicKey(keyID, path)
+		if err != nil {
+			return nil, err
+		}
+		verification = append(verification, key)
+	}
+
+	return NewKeySet(signing, verification...)
+}
+
+func NewHMACKey(keyID, secret string) *Key {
+	return &Key{
+		ID:              keyID,
+		Method:          jwt.SigningMethodHS256,
+		SigningKey:      []byte(secret),
+		VerificationKey: []byte(secret),
+	}
+}
+
+// ParsePrivateKeyPEM reads a PKCS#1 or PKCS#8 RSA key, or a PKCS#8 Ed25519 key.
+// RSA keys sign with RS256 and Ed25519 keys with EdDSA.
+func ParsePrivateKeyPEM(keyID string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errInvalidPEM
+	}
+
+	var (
+		privateKey any
+		err        error
+	)
+
+	if block.Type == "RSA PRIVATE KEY" {
+		privateKey, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	} else {
+		privateKey, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	signer, ok := privateKey.(crypto.Signer)
+	if !ok {
+		return nil, errUnsupportedKey
+	}
+
+	method, err := methodForKey(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	return &Key{
+		ID:              keyID,
+		Method:          method,
+		SigningKey:      privateKey,
+		VerificationKey: signer.Public(),
+	}, nil
+}
+
+// ParsePublicKeyPEM reads a PKIX RSA or Ed25519 public key, used only for verification.
+func ParsePublicKeyPEM(keyID string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errInvalidPEM
+	}
+
+	publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	method, err := methodForKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Key{
+		ID:              keyID,
+		Method:          method,
+		VerificationKey: publicKey,
+	}, nil
+}
+
+func (ks *KeySet) SigningKey() *Key {
+	return ks.signing
+}
+
+// VerificationKey returns the key registered with the kid of the token. Tokens
+// without kid were issued before keys had ids, so they can only match an
+// unnamed key. The algorithm of the token must be the one of the key, otherwise
+// a public key could be abused as an HMAC secret.
+func (ks *KeySet) VerificationKey(keyID, alg string) (*Key, bool) {
+	key, ok := ks.verification[keyID]
+	if !ok || key.Method.Alg() != alg {
+		return nil, false
+	}
+	return key, true
+}
+
+func readPrivateKey(keyID, path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePrivateKeyPEM(keyID, data)
+}
+
+func readPublicKey(keyID, path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePublicKeyPEM(keyID, data)
+}
+
+func methodForKey(publicKey any) (jwt.SigningMethod, error) {
+	switch publicKey.(type) {
+	case *rsa.PublicKey:
+		return jwt.SigningMethodRS256, nil
+	case ed25519.PublicKey:
+		return jwt.SigningMethodEdDSA, nil
+	default:
+		return nil, errUnsupportedKey
+	}
+}
@@ -0,0 +1,159 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+func rsaPrivateKeyPEM(t *testing.T) []byte {
+	t.Helper()
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)})
+}
+
+func ed25519PrivateKeyPEM(t *testing.T) []byte {
+	t.Helper()
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	assert.Nil(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+}
+
+func publicKeyPEM(t *testing.T, key *Key) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(key.VerificationKey)
+	assert.Nil(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+func writeFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	assert.Nil(t, os.WriteFile(path, data, 0o600))
+	return path
+}
+
+func Test_LoadKeySet(t *testing.T) {
+	t.Run("Should sign and verify tokens with RS256", func(t *testing.T) {
+		keySet, err := LoadKeySet(KeySetConfig{
+			SigningMethod:  SigningMethodRS256,
+			KeyID:          "rsa-key",
+			PrivateKeyFile: writeFile(t, "rsa.pem", rsaPrivateKeyPEM(t)),
+		})
+		assert.Nil(t, err)
+
+		a := NewJwtAuth(keySet, 1, NewMemoryRevocationStore())
+		token, restErr := a.GenerateToken(claims)
+		assert.Nil(t, restErr)
+
+		parsed, _ := jwt.Parse(token, nil)
+		assert.Equal(t, "rsa-key", parsed.Header["kid"])
+		assert.Equal(t, SigningMethodRS256, parsed.Header["alg"])
+
+		recorder, c := verifyToken(a, token)
+		assert.False(t, c.IsAborted())
+		assert.EqualValues(t, http.StatusOK, recorder.Code)
+	})
+
+	t.Run("Should sign and verify tokens with EdDSA", func(t *testing.T) {
+		keySet, err := LoadKeySet(KeySetConfig{
+			SigningMethod:  SigningMethodEdDSA,
+			KeyID:          "ed-key",
+			PrivateKeyFile: writeFile(t, "ed.pem", ed25519PrivateKeyPEM(t)),
+		})
+		assert.Nil(t, err)
+
+		a := NewJwtAuth(keySet, 1, NewMemoryRevocationStore())
+		token, restErr := a.GenerateToken(claims)
+		assert.Nil(t, restErr)
+
+		_, c := verifyToken(a, token)
+		assert.False(t, c.IsAborted())
+	})
+
+	t.Run("Should keep accepting tokens of the previous key after a rotation", func(t *testing.T) {
+		oldKey, err := ParsePrivateKeyPEM("old-key", ed25519PrivateKeyPEM(t))
+		assert.Nil(t, err)
+		oldKeySet, _ := NewKeySet(oldKey)
+		oldToken, _ := NewJwtAuth(oldKeySet, 1, NewMemoryRevocationStore()).GenerateToken(claims)
+
+		keySet, err := LoadKeySet(KeySetConfig{
+			SigningMethod:  SigningMethodRS256,
+			KeyID:          "new-key",
+			PrivateKeyFile: writeFile(t, "rsa.pem", rsaPrivateKeyPEM(t)),
+			PublicKeyFiles: "old-key=" + writeFile(t, "old.pub.pem", publicKeyPEM(t, oldKey)),
+		})
+		assert.Nil(t, err)
+
+		_, c := verifyToken(NewJwtAuth(keySet, 1, NewMemoryRevocationStore()), oldToken)
+		assert.False(t, c.IsAborted())
+	})
+
+	t.Run("Should reject a token signed with HS256 using the public key as secret", func(t *testing.T) {
+		key, err := ParsePrivateKeyPEM("rsa-key", rsaPrivateKeyPEM(t))
+		assert.Nil(t, err)
+		keySet, _ := NewKeySet(key)
+
+		forged := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims(claims))
+		forged.Header["kid"] = "rsa-key"
+		token, _ := forged.SignedString(publicKeyPEM(t, key))
+
+		recorder, c := verifyToken(NewJwtAuth(keySet, 1, NewMemoryRevocationStore()), token)
+		assert.True(t, c.IsAborted())
+		assert.EqualValues(t, http.StatusUnauthorized, recorder.Code)
+	})
+
+	t.Run("Should return an error when the key doesn't match the signing method", func(t *testing.T) {
+		_, err := LoadKeySet(KeySetConfig{
+			SigningMethod:  SigningMethodEdDSA,
+			KeyID:          "rsa-key",
+			PrivateKeyFile: writeFile(t, "rsa.pem", rsaPrivateKeyPEM(t)),
+		})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Should return an error when the signing method is unknown", func(t *testing.T) {
+		_, err := LoadKeySet(KeySetConfig{SigningMethod: "none"})
+		assert.NotNil(t, err)
+	})
+}
+
+func Test_jwtAuth_JWKSHandler(t *testing.T) {
+	rsaKey, _ := ParsePrivateKeyPEM("rsa-key", rsaPrivateKeyPEM(t))
+	edKey, _ := ParsePrivateKeyPEM("ed-key", ed25519PrivateKeyPEM(t))
+	keySet, _ := NewKeySet(rsaKey, &Key{ID: "ed-key", Method: edKey.Method, VerificationKey: edKey.VerificationKey})
+
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+
+	NewJwtAuth(keySet, 1, NewMemoryRevocationStore()).JWKSHandler(c)
+
+	var jwks JSONWebKeySet
+	assert.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &jwks))
+	assert.Len(t, jwks.Keys, 2)
+
+	assert.Equal(t, "ed-key", jwks.Keys[0].KeyID)
+	assert.Equal(t, "OKP", jwks.Keys[0].KeyType)
+	assert.Equal(t, "Ed25519", jwks.Keys[0].Curve)
+	assert.NotEmpty(t, jwks.Keys[0].X)
+
+	assert.Equal(t, "rsa-key", jwks.Keys[1].KeyID)
+	assert.Equal(t, "RSA", jwks.Keys[1].KeyType)
+	assert.Equal(t, "AQAB", jwks.Keys[1].E)
+	assert.NotEmpty(t, jwks.Keys[1].N)
+}
@@ -0,0 +1,94 @@
+package jwt
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
+)
+
+// RevocationStore keeps the tokens that must be refused before they expire.
+// A single token is revoked by its jti, the tokens of a session by its sid, and
+// every token of an user by storing the instant before which its tokens are no
+// longer accepted. The instant has nanoseconds, as the iat_ns claim of the tokens.
+type RevocationStore interface {
+	RevokeToken(ctx context.Context, tokenID string, expiresAt time.Time) *resterrors.RestErr
+	RevokeSession(ctx context.Context, sessionID string, expiresAt time.Time) *resterrors.RestErr
+	RevokeUserTokens(ctx context.Context, userID string, issuedBefore, expiresAt time.Time) *resterrors.RestErr
+	IsRevoked(ctx context.Context, tokenID, userID, sessionID string, issuedAt time.Time) (bool, *resterrors.RestErr)
+}
+
+type memoryRevocationStore struct {
+	mu       sync.RWMutex
+	tokens   map[string]time.Time
+	sessions map[string]time.Time
+	users    map[string]revokedUser
+}
+
+type revokedUser struct {
+	issuedBefore time.Time
+	expiresAt    time.Time
+}
+
+// NewMemoryRevocationStore returns a RevocationStore that lives in the process
+// memory, meant for tests and single instance deployments.
+func NewMemoryRevocationStore() *memoryRevocationStore {
+	return &memoryRevocationStore{
+		tokens:   map[string]time.Time{},
+		sessions: map[string]time.Time{},
+		users:    map[string]revokedUser{},
+	}
+}
+
+func (s *memoryRevocationStore) RevokeToken(_ context.Context, tokenID string, expiresAt time.Time) *resterrors.RestErr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tokens[tokenID] = expiresAt
+	return nil
+}
+
+func (s *memoryRevocationStore) RevokeSession(_ context.Context, sessionID string, expiresAt time.Time) *resterrors.RestErr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sessions[sessionID] = expiresAt
+	return nil
+}
+
+func (s *memoryRevocationStore) RevokeUserTokens(_ context.Context, userID string, issuedBefore, expiresAt time.Time) *resterrors.RestErr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current := s.users[userID]
+	if issuedBefore.After(current.issuedBefore) {
+		current.issuedBefore = issuedBefore
+	}
+	if expiresAt.After(current.expiresAt) {
+		current.expiresAt = expiresAt
+	}
+	s.users[userID] = current
+	return nil
+}
+
+func (s *memoryRevocationStore) IsRevoked(_ context.Context, tokenID, userID, sessionID string, issuedAt time.Time) (bool, *resterrors.RestErr) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now()
+
+	if expiresAt, ok := s.tokens[tokenID]; ok && now.Before(expiresAt) {
+		return true, nil
+	}
+
+	if expiresAt, ok := s.sessions[sessionID]; ok && sessionID != "" && now.Before(expiresAt) {
+		return true, nil
+	}
+
+	if user, ok := s.users[userID]; ok && now.Before(user.expiresAt) && issuedAt.Before(user.issuedBefore) {
+		return true, nil
+	}
+
+	return false, nil
+}
@@ -0,0 +1,109 @@
+// Package lockout throttles an action by key after repeated failures, first with
+// an exponential backoff and then with a temporary lockout.
+package lockout
+
+import (
+	"context"
+	"time"
+
+	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
+)
+
+// Attempts are the failures of a key since its last success.
+type Attempts struct {
+	Failures      int
+	LastFailureAt time.Time
+}
+
+// Store keeps the attempts by key. The attempts of a key are forgotten once its
+// expiresAt passes without a new failure, a missing key has zero attempts.
+type Store interface {
+	GetAttempts(ctx context.Context, key string) (*Attempts, *resterrors.RestErr)
+	RegisterFailure(ctx context.Context, key string, failedAt, expiresAt time.Time) (*Attempts, *resterrors.RestErr)
+	ResetAttempts(ctx context.Context, key string) *resterrors.RestErr
+}
+
+// Policy tells how long a key waits after its failures. The first FreeAttempts
+// failures don't delay anything, the next ones wait BaseDelay doubled at every
+// failure, and MaxFailures locks the key for LockoutDuration.
+type Policy struct {
+	FreeAttempts    int
+	MaxFailures     int
+	BaseDelay       time.Duration
+	LockoutDuration time.Duration
+}
+
+// LockedUntil returns the instant the key can try again, zero when it isn't delayed.
+func (p Policy) LockedUntil(attempts *Attempts) time.Time {
+	if attempts == nil || attempts.Failures == 0 || attempts.Failures <= p.FreeAttempts {
+		return time.Time{}
+	}
+
+	if attempts.Failures >= p.MaxFailures {
+		return attempts.LastFailureAt.Add(p.LockoutDuration)
+	}
+
+	delay := p.BaseDelay
+	for i := p.FreeAttempts + 1; i < attempts.Failures && delay < p.LockoutDuration; i++ {
+		delay *= 2
+	}
+	if delay > p.LockoutDuration {
+		delay = p.LockoutDuration
+	}
+
+	return attempts.LastFailureAt.Add(delay)
+}
+
+// Limiter applies a policy to the keys of a store. The prefix lets limiters of
+// different kinds of key, like emails and ips, share the same store.
+type Limiter struct {
+	store  Store
+	prefix string
+	policy Policy
+	now    func() time.Time
+}
+
+func NewLimiter(store Store, prefix string, policy Policy) *Limiter {
+	return &Limiter{
+		store:  store,
+		prefix: prefix,
+		policy: policy,
+		now:    time.Now,
+	}
+}
+
+// RetryAfter returns how long the key must wait before trying again, zero when
+// it can try now.
+func (l *Limiter) RetryAfter(ctx context.Context, key string) (time.Duration, *resterrors.RestErr) {
+	attempts, err := l.store.GetAttempts(ctx, l.prefix+key)
+	if err != nil {
+		return 0, err
+	}
+
+	return l.retryAfter(attempts), nil
+}
+
+// RegisterFailure counts a failure of the key and returns how long it must wait
+// before trying again.
+func (l *Limiter) RegisterFailure(ctx context.Context, key string) (time.Duration, *resterrors.RestErr) {
+	now := l.now()
+
+	attempts, err := l.store.RegisterFailure(ctx, l.prefix+key, now, now.Add(l.policy.LockoutDuration))
+	if err != nil {
+		return 0, err
+	}
+
+	return l.retryAfter(attempts), nil
+}
+
+// Reset forgets the failures of the key, after a success or to unlock it.
+func (l *Limiter) Reset(ctx context.Context, key string) *resterrors.RestErr {
+	return l.store.ResetAttempts(ctx, l.prefix+key)
+}
+
+func (l *Limiter) retryAfter(attempts *Attempts) time.Duration {
+	if wait := l.policy.LockedUntil(attempts).Sub(l.now()); wait > 0 {
+		return wait
+	}
+	return 0
+}
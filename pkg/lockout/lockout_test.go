@@ -0,0 +1,110 @@
+package lockout
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	ctx = context.Background()
+
+	policy = Policy{
+		FreeAttempts:    3,
+		MaxFailures:     6,
+		BaseDelay:       time.Second,
+		LockoutDuration: time.Minute,
+	}
+)
+
+func TestPolicy_LockedUntil(t *testing.T) {
+	lastFailureAt := time.Now()
+
+	tests := []struct {
+		name     string
+		failures int
+		want     time.Duration
+	}{
+		{name: "Should not delay a key without failures", failures: 0, want: 0},
+		{name: "Should not delay the free attempts", failures: 2, want: 0},
+		{name: "Should not delay the last free attempt", failures: 3, want: 0},
+		{name: "Should delay the first failure after the free attempts", failures: 4, want: time.Second},
+		{name: "Should double the delay at every failure", failures: 5, want: 2 * time.Second},
+		{name: "Should lock the key after the max failures", failures: 6, want: time.Minute},
+		{name: "Should keep the key locked after more failures", failures: 20, want: time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := policy.LockedUntil(&Attempts{Failures: tt.failures, LastFailureAt: lastFailureAt})
+			if tt.want == 0 {
+				assert.True(t, got.IsZero())
+				return
+			}
+			assert.Equal(t, lastFailureAt.Add(tt.want), got)
+		})
+	}
+
+	t.Run("Should never delay more than the lockout", func(t *testing.T) {
+		longBackoff := Policy{FreeAttempts: 1, MaxFailures: 100, BaseDelay: time.Second, LockoutDuration: time.Minute}
+		got := longBackoff.LockedUntil(&Attempts{Failures: 50, LastFailureAt: lastFailureAt})
+		assert.Equal(t, lastFailureAt.Add(time.Minute), got)
+	})
+}
+
+func TestLimiter(t *testing.T) {
+	t.Run("Should delay a key after its free attempts and until its reset", func(t *testing.T) {
+		limiter := NewLimiter(NewMemoryStore(), "email:", policy)
+
+		for i := 0; i < policy.FreeAttempts; i++ {
+			retryAfter, err := limiter.RegisterFailure(ctx, "user@email.com")
+			assert.Nil(t, err)
+			assert.Zero(t, retryAfter)
+		}
+
+		retryAfter, err := limiter.RegisterFailure(ctx, "user@email.com")
+		assert.Nil(t, err)
+		assert.InDelta(t, time.Second, retryAfter, float64(100*time.Millisecond))
+
+		retryAfter, err = limiter.RetryAfter(ctx, "user@email.com")
+		assert.Nil(t, err)
+		assert.Greater(t, retryAfter, time.Duration(0))
+
+		assert.Nil(t, limiter.Reset(ctx, "user@email.com"))
+
+		retryAfter, err = limiter.RetryAfter(ctx, "user@email.com")
+		assert.Nil(t, err)
+		assert.Zero(t, retryAfter)
+	})
+
+	t.Run("Should keep the keys of limiters with different prefixes apart", func(t *testing.T) {
+		store := NewMemoryStore()
+		emailLimiter := NewLimiter(store, "email:", Policy{FreeAttempts: 0, MaxFailures: 1, BaseDelay: time.Second, LockoutDuration: time.Minute})
+		ipLimiter := NewLimiter(store, "ip:", policy)
+
+		retryAfter, _ := emailLimiter.RegisterFailure(ctx, "key")
+		assert.Greater(t, retryAfter, time.Duration(0))
+
+		retryAfter, _ = ipLimiter.RetryAfter(ctx, "key")
+		assert.Zero(t, retryAfter)
+	})
+
+	t.Run("Should forget the failures once they expire", func(t *testing.T) {
+		store := NewMemoryStore()
+		limiter := NewLimiter(store, "email:", policy)
+
+		past := time.Now().Add(-2 * policy.LockoutDuration)
+		limiter.now = func() time.Time { return past }
+		for i := 0; i < policy.MaxFailures; i++ {
+			limiter.RegisterFailure(ctx, "user@email.com")
+		}
+
+		limiter.now = time.Now
+		attempts, _ := store.GetAttempts(ctx, "email:user@email.com")
+		assert.Zero(t, attempts.Failures)
+
+		retryAfter, _ := limiter.RegisterFailure(ctx, "user@email.com")
+		assert.Zero(t, retryAfter)
+	})
+}
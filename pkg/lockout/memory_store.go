@@ -0,0 +1,66 @@
+package lockout
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
+)
+
+type memoryStore struct {
+	mu       sync.Mutex
+	attempts map[string]memoryAttempts
+}
+
+type memoryAttempts struct {
+	Attempts
+	expiresAt time.Time
+}
+
+// NewMemoryStore returns a Store that lives in the process memory, meant for
+// tests and single instance deployments.
+func NewMemoryStore() *memoryStore {
+	return &memoryStore{
+		attempts: map[string]memoryAttempts{},
+	}
+}
+
+func (s *memoryStore) GetAttempts(_ context.Context, key string) (*Attempts, *resterrors.RestErr) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, ok := s.attempts[key]
+	if !ok || !time.Now().Before(current.expiresAt) {
+		return &Attempts{}, nil
+	}
+
+	attempts := current.Attempts
+	return &attempts, nil
+}
+
+func (s *memoryStore) RegisterFailure(_ context.Context, key string, failedAt, expiresAt time.Time) (*Attempts, *resterrors.RestErr) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, ok := s.attempts[key]
+	if !ok || !failedAt.Before(current.expiresAt) {
+		current = memoryAttempts{}
+	}
+
+	current.Failures++
+	current.LastFailureAt = failedAt
+	current.expiresAt = expiresAt
+	s.attempts[key] = current
+
+	attempts := current.Attempts
+	return &attempts, nil
+}
+
+func (s *memoryStore) ResetAttempts(_ context.Context, key string) *resterrors.RestErr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.attempts, key)
+	return nil
+}
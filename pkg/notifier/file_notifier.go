@@ -0,0 +1,43 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+type fileNotifier struct {
+	mu   sync.Mutex
+	path string
+}
+
+type fileEntry struct {
+	Message
+	SentAt time.Time `json:"sent_at"`
+}
+
+// NewFileNotifier returns a Notifier that appends every message as a JSON line to the file.
+func NewFileNotifier(path string) *fileNotifier {
+	return &fileNotifier{path: path}
+}
+
+func (n *fileNotifier) Notify(_ context.Context, message Message) error {
+	line, err := json.Marshal(fileEntry{Message: message, SentAt: time.Now()})
+	if err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	file, err := os.OpenFile(n.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.Write(append(line, '\n'))
+	return err
+}
@@ -0,0 +1,26 @@
+package notifier
+
+import (
+	"context"
+
+	"github.com/WalterPaes/go-rest-api-crud/pkg/logger"
+	"go.uber.org/zap"
+)
+
+type logNotifier struct{}
+
+// NewLogNotifier returns a Notifier that writes the messages to the application log.
+func NewLogNotifier() *logNotifier {
+	return &logNotifier{}
+}
+
+func (*logNotifier) Notify(_ context.Context, message Message) error {
+	logger.Info(
+		"Notification sent",
+		zap.String("to", message.To),
+		zap.String("subject", message.Subject),
+		zap.String("body", message.Body),
+		zap.String("stacktrace", "log-notifier"),
+	)
+	return nil
+}
@@ -0,0 +1,39 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	TypeLog  = "log"
+	TypeFile = "file"
+)
+
+var errUnsupportedType = "unsupported notifier type '%s'"
+
+// Message is a notification addressed to an user, like an email.
+type Message struct {
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+// Notifier delivers messages to the users. The log and file implementations are
+// meant for local development, a real deployment plugs an email provider here.
+type Notifier interface {
+	Notify(ctx context.Context, message Message) error
+}
+
+// New returns the notifier of the given type, the file notifier appends the
+// messages to filePath.
+func New(notifierType, filePath string) (Notifier, error) {
+	switch notifierType {
+	case "", TypeLog:
+		return NewLogNotifier(), nil
+	case TypeFile:
+		return NewFileNotifier(filePath), nil
+	default:
+		return nil, fmt.Errorf(errUnsupportedType, notifierType)
+	}
+}
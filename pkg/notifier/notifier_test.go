@@ -0,0 +1,52 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/WalterPaes/go-rest-api-crud/pkg/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	logger.Init("debug", "stdout")
+}
+
+func Test_New(t *testing.T) {
+	t.Run("Should return the log notifier by default", func(t *testing.T) {
+		n, err := New("", "")
+		assert.Nil(t, err)
+		assert.IsType(t, &logNotifier{}, n)
+		assert.Nil(t, n.Notify(context.Background(), Message{To: "user@email.com"}))
+	})
+
+	t.Run("Should return an error when the type is unknown", func(t *testing.T) {
+		_, err := New("smtp", "")
+		assert.NotNil(t, err)
+	})
+}
+
+func Test_fileNotifier_Notify(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notifications.log")
+	n, err := New(TypeFile, path)
+	assert.Nil(t, err)
+
+	assert.Nil(t, n.Notify(context.Background(), Message{To: "first@email.com", Subject: "First", Body: "body"}))
+	assert.Nil(t, n.Notify(context.Background(), Message{To: "second@email.com", Subject: "Second", Body: "body"}))
+
+	data, err := os.ReadFile(path)
+	assert.Nil(t, err)
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	assert.Len(t, lines, 2)
+
+	var entry fileEntry
+	assert.Nil(t, json.Unmarshal([]byte(lines[1]), &entry))
+	assert.Equal(t, "second@email.com", entry.To)
+	assert.Equal(t, "Second", entry.Subject)
+	assert.False(t, entry.SentAt.IsZero())
+}
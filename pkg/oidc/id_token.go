@@ -0,0 +1,149 @@
+package oidc
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// leeway tolerates the clock drift between this API and the provider.
+const leeway = time.Minute
+
+var (
+	ErrInvalidIDToken = errors.New("oidc: invalid ID token")
+	ErrNonceMismatch  = errors.New("oidc: ID token nonce doesn't match the login")
+
+	// the shared secret methods and none are never accepted, the ID token must be
+	// signed by a key the provider publishes
+	validMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+)
+
+// IDToken has the claims of a verified ID token identifying the user.
+type IDToken struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+type idTokenClaims struct {
+	Issuer          string       `json:"iss"`
+	Subject         string       `json:"sub"`
+	Audience        audience     `json:"aud"`
+	AuthorizedParty string       `json:"azp"`
+	ExpiresAt       int64        `json:"exp"`
+	IssuedAt        int64        `json:"iat"`
+	Nonce           string       `json:"nonce"`
+	Email           string       `json:"email"`
+	EmailVerified   flexibleBool `json:"email_verified"`
+	Name            string       `json:"name"`
+}
+
+func (c *idTokenClaims) Valid() error {
+	now := time.Now()
+	if c.ExpiresAt == 0 || now.After(time.Unix(c.ExpiresAt, 0).Add(leeway)) {
+		return errors.New("oidc: ID token is expired")
+	}
+	if c.IssuedAt != 0 && now.Add(leeway).Before(time.Unix(c.IssuedAt, 0)) {
+		return errors.New("oidc: ID token issued in the future")
+	}
+	return nil
+}
+
+// VerifyIDToken checks the signature of the ID token against the provider keys,
+// its issuer, audience, expiration and that its nonce is the one of the login.
+func (p *provider) VerifyIDToken(ctx context.Context, rawIDToken, nonce string) (*IDToken, error) {
+	claims := &idTokenClaims{}
+
+	parser := &jwt.Parser{ValidMethods: validMethods}
+	token, err := parser.ParseWithClaims(rawIDToken, claims, func(token *jwt.Token) (interface{}, error) {
+		keyID, _ := token.Header["kid"].(string)
+		return p.key(ctx, keyID)
+	})
+	if err != nil || !token.Valid {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	if strings.TrimSuffix(claims.Issuer, "/") != strings.TrimSuffix(p.metadata.Issuer, "/") {
+		return nil, fmt.Errorf("%w: unexpected issuer", ErrInvalidIDToken)
+	}
+
+	if !claims.Audience.contains(p.config.ClientID) {
+		return nil, fmt.Errorf("%w: token is not meant for this client", ErrInvalidIDToken)
+	}
+
+	if len(claims.Audience) > 1 && claims.AuthorizedParty != p.config.ClientID {
+		return nil, fmt.Errorf("%w: token authorized to another party", ErrInvalidIDToken)
+	}
+
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: token has no subject", ErrInvalidIDToken)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(nonce)) != 1 {
+		return nil, ErrNonceMismatch
+	}
+
+	return &IDToken{
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: bool(claims.EmailVerified),
+		Name:          claims.Name,
+	}, nil
+}
+
+// audience is the aud claim, which can be a single string or a list of them.
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+func (a audience) contains(clientID string) bool {
+	for _, aud := range a {
+		if aud == clientID {
+			return true
+		}
+	}
+	return false
+}
+
+// flexibleBool accepts the email_verified sent as a string, as some providers do.
+type flexibleBool bool
+
+func (b *flexibleBool) UnmarshalJSON(data []byte) error {
+	var value bool
+	if err := json.Unmarshal(data, &value); err == nil {
+		*b = flexibleBool(value)
+		return nil
+	}
+
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return err
+	}
+	value, err := strconv.ParseBool(text)
+	if err != nil {
+		return err
+	}
+	*b = flexibleBool(value)
+	return nil
+}
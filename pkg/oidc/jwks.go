@@ -0,0 +1,132 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// keysRefreshInterval limits how often an unknown kid makes the keys be fetched
+// again, so tokens with made up kids can't flood the provider.
+const keysRefreshInterval = time.Minute
+
+var ErrUnknownKey = errors.New("oidc: ID token signed with an unknown key")
+
+type jsonWebKey struct {
+	KeyType string `json:"kty"`
+	KeyID   string `json:"kid"`
+	Use     string `json:"use"`
+	Curve   string `json:"crv"`
+	N       string `json:"n"`
+	E       string `json:"e"`
+	X       string `json:"x"`
+	Y       string `json:"y"`
+}
+
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+// key returns the verification key of the kid, fetching the keys of the provider
+// again when the kid is unknown, as happens after the provider rotates its keys.
+func (p *provider) key(ctx context.Context, keyID string) (any, error) {
+	p.mu.RLock()
+	key, ok := p.keys[keyID]
+	fetchedRecently := time.Since(p.keysFetched) < keysRefreshInterval
+	p.mu.RUnlock()
+
+	if ok {
+		return key, nil
+	}
+	if fetchedRecently {
+		return nil, ErrUnknownKey
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.keys[keyID]; ok {
+		return key, nil
+	}
+
+	var jwks jsonWebKeySet
+	if err := getJSON(ctx, p.httpClient, p.metadata.JWKSURI, &jwks); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]any, len(jwks.Keys))
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		// keys the package can't use, like symmetric ones, are skipped
+		if publicKey, err := jwk.publicKey(); err == nil {
+			keys[jwk.KeyID] = publicKey
+		}
+	}
+	p.keys = keys
+	p.keysFetched = time.Now()
+
+	if key, ok := p.keys[keyID]; ok {
+		return key, nil
+	}
+	return nil, ErrUnknownKey
+}
+
+func (k jsonWebKey) publicKey() (any, error) {
+	switch k.KeyType {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() {
+			return nil, fmt.Errorf("oidc: invalid RSA exponent of key %q", k.KeyID)
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Curve {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("oidc: unsupported curve %q of key %q", k.Curve, k.KeyID)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("oidc: point of key %q is not on the curve", k.KeyID)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("oidc: unsupported key type %q of key %q", k.KeyType, k.KeyID)
+	}
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(b) == 0 {
+		return nil, errors.New("oidc: invalid key parameter")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,170 @@
+// Package oidc implements the relying party side of the OpenID Connect authorization
+// code flow with PKCE: the provider discovery, the code exchange and the ID token
+// verification against the keys the provider publishes.
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const discoveryPath = "/.well-known/openid-configuration"
+
+var (
+	ErrIssuerMismatch = errors.New("oidc: discovered issuer doesn't match the configured one")
+	ErrMissingIDToken = errors.New("oidc: token response has no id_token")
+)
+
+// Config identifies this API at the provider. Scopes must have openid, RedirectURL
+// is the callback registered at the provider.
+type Config struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// Metadata is the part of the provider configuration used by the relying party.
+type Metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type Provider interface {
+	// AuthCodeURL is the url of the provider login page, the state and the nonce
+	// must be kept to check the callback and the ID token.
+	AuthCodeURL(state, nonce, codeVerifier string) string
+	// Exchange redeems the code of the callback and returns the raw ID token.
+	Exchange(ctx context.Context, code, codeVerifier string) (string, error)
+	VerifyIDToken(ctx context.Context, rawIDToken, nonce string) (*IDToken, error)
+}
+
+type provider struct {
+	config     Config
+	metadata   Metadata
+	httpClient *http.Client
+
+	mu          sync.RWMutex
+	keys        map[string]any
+	keysFetched time.Time
+}
+
+// Discover reads the provider metadata from its well-known configuration document.
+func Discover(ctx context.Context, config Config, httpClient *http.Client) (*provider, error) {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	issuer := strings.TrimSuffix(config.Issuer, "/")
+
+	var metadata Metadata
+	if err := getJSON(ctx, httpClient, issuer+discoveryPath, &metadata); err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSuffix(metadata.Issuer, "/") != issuer {
+		return nil, ErrIssuerMismatch
+	}
+
+	return &provider{
+		config:     config,
+		metadata:   metadata,
+		httpClient: httpClient,
+	}, nil
+}
+
+func (p *provider) AuthCodeURL(state, nonce, codeVerifier string) string {
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.config.ClientID},
+		"redirect_uri":          {p.config.RedirectURL},
+		"scope":                 {strings.Join(p.config.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {CodeChallenge(codeVerifier)},
+		"code_challenge_method": {"S256"},
+	}
+
+	separator := "?"
+	if strings.Contains(p.metadata.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return p.metadata.AuthorizationEndpoint + separator + query.Encode()
+}
+
+type tokenResponse struct {
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+func (p *provider) Exchange(ctx context.Context, code, codeVerifier string) (string, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.config.RedirectURL},
+		"code_verifier": {codeVerifier},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+
+	res, err := p.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	var token tokenResponse
+	if err := json.NewDecoder(io.LimitReader(res.Body, 1<<20)).Decode(&token); err != nil {
+		return "", fmt.Errorf("oidc: decoding token response: %w", err)
+	}
+
+	if res.StatusCode != http.StatusOK || token.Error != "" {
+		return "", fmt.Errorf("oidc: token endpoint returned %d: %s %s", res.StatusCode, token.Error, token.ErrorDescription)
+	}
+
+	if token.IDToken == "" {
+		return "", ErrMissingIDToken
+	}
+	return token.IDToken, nil
+}
+
+func getJSON(ctx context.Context, httpClient *http.Client, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("oidc: GET %s returned %d", url, res.StatusCode)
+	}
+
+	if err := json.NewDecoder(io.LimitReader(res.Body, 1<<20)).Decode(v); err != nil {
+		return fmt.Errorf("oidc: decoding %s: %w", url, err)
+	}
+	return nil
+}
@@ -0,0 +1,297 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+var ctx = context.Background()
+
+// mockIssuer is a local OpenID provider issuing the ID token of a single code.
+type mockIssuer struct {
+	server        *httptest.Server
+	key           *rsa.PrivateKey
+	keyID         string
+	code          string
+	codeChallenge string
+	idTokenClaims jwt.MapClaims
+}
+
+func newMockIssuer(t *testing.T) *mockIssuer {
+	t.Helper()
+
+	issuer := &mockIssuer{code: "code", keyID: "key-1"}
+	issuer.rotateKey(t, "key-1")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(discoveryPath, func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(Metadata{
+			Issuer:                issuer.server.URL,
+			AuthorizationEndpoint: issuer.server.URL + "/authorize",
+			TokenEndpoint:         issuer.server.URL + "/token",
+			JWKSURI:               issuer.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": issuer.keyID,
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(issuer.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(issuer.key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		clientID, clientSecret, _ := r.BasicAuth()
+		if clientID != "client-id" || clientSecret != "client-secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_client"})
+			return
+		}
+
+		if r.PostFormValue("code") != issuer.code || CodeChallenge(r.PostFormValue("code_verifier")) != issuer.codeChallenge {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]string{"id_token": issuer.sign(t, issuer.idTokenClaims), "token_type": "Bearer"})
+	})
+
+	issuer.server = httptest.NewServer(mux)
+	t.Cleanup(issuer.server.Close)
+	return issuer
+}
+
+func (i *mockIssuer) rotateKey(t *testing.T, keyID string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	i.key = key
+	i.keyID = keyID
+}
+
+func (i *mockIssuer) claims(nonce string) jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":            i.server.URL,
+		"sub":            "subject",
+		"aud":            "client-id",
+		"exp":            time.Now().Add(time.Hour).Unix(),
+		"iat":            time.Now().Unix(),
+		"nonce":          nonce,
+		"email":          "first@email.com",
+		"email_verified": true,
+		"name":           "First User",
+	}
+}
+
+func (i *mockIssuer) sign(t *testing.T, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = i.keyID
+
+	signed, err := token.SignedString(i.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func (i *mockIssuer) config() Config {
+	return Config{
+		Issuer:       i.server.URL,
+		ClientID:     "client-id",
+		ClientSecret: "client-secret",
+		RedirectURL:  "http://localhost:8080/auth/oidc/callback",
+		Scopes:       []string{"openid", "email", "profile"},
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	issuer := newMockIssuer(t)
+
+	t.Run("Should read the provider metadata", func(t *testing.T) {
+		p, err := Discover(ctx, issuer.config(), issuer.server.Client())
+
+		assert.Nil(t, err)
+		assert.Equal(t, issuer.server.URL+"/token", p.metadata.TokenEndpoint)
+		assert.Equal(t, issuer.server.URL+"/jwks", p.metadata.JWKSURI)
+	})
+
+	t.Run("Should refuse a provider claiming another issuer", func(t *testing.T) {
+		config := issuer.config()
+		config.Issuer = issuer.server.URL + "/tenant"
+
+		_, err := Discover(ctx, config, issuer.server.Client())
+
+		assert.NotNil(t, err)
+	})
+}
+
+func Test_provider_AuthCodeURL(t *testing.T) {
+	issuer := newMockIssuer(t)
+	p, _ := Discover(ctx, issuer.config(), issuer.server.Client())
+
+	authURL, err := url.Parse(p.AuthCodeURL("state", "nonce", "verifier"))
+	assert.Nil(t, err)
+
+	query := authURL.Query()
+	assert.Equal(t, issuer.server.URL+"/authorize", authURL.Scheme+"://"+authURL.Host+authURL.Path)
+	assert.Equal(t, "code", query.Get("response_type"))
+	assert.Equal(t, "client-id", query.Get("client_id"))
+	assert.Equal(t, "openid email profile", query.Get("scope"))
+	assert.Equal(t, "state", query.Get("state"))
+	assert.Equal(t, "nonce", query.Get("nonce"))
+	assert.Equal(t, CodeChallenge("verifier"), query.Get("code_challenge"))
+	assert.Equal(t, "S256", query.Get("code_challenge_method"))
+}
+
+func Test_provider_Exchange(t *testing.T) {
+	issuer := newMockIssuer(t)
+	p, _ := Discover(ctx, issuer.config(), issuer.server.Client())
+
+	codeVerifier, _ := NewCodeVerifier()
+	issuer.codeChallenge = CodeChallenge(codeVerifier)
+	issuer.idTokenClaims = issuer.claims("nonce")
+
+	t.Run("Should redeem the code with the code verifier", func(t *testing.T) {
+		rawIDToken, err := p.Exchange(ctx, "code", codeVerifier)
+
+		assert.Nil(t, err)
+		assert.NotEmpty(t, rawIDToken)
+	})
+
+	t.Run("Should fail when the code verifier is not the one of the challenge", func(t *testing.T) {
+		_, err := p.Exchange(ctx, "code", "other-verifier")
+
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "invalid_grant")
+	})
+}
+
+func Test_provider_VerifyIDToken(t *testing.T) {
+	issuer := newMockIssuer(t)
+
+	tests := []struct {
+		name    string
+		claims  func() jwt.MapClaims
+		wantErr error
+	}{
+		{
+			name:   "Should accept a valid ID token",
+			claims: func() jwt.MapClaims { return issuer.claims("nonce") },
+		},
+		{
+			name: "Should accept a list of audiences authorized to the client",
+			claims: func() jwt.MapClaims {
+				claims := issuer.claims("nonce")
+				claims["aud"] = []string{"client-id", "other-client"}
+				claims["azp"] = "client-id"
+				claims["email_verified"] = "true"
+				return claims
+			},
+		},
+		{
+			name:    "Should refuse a token of another login",
+			claims:  func() jwt.MapClaims { return issuer.claims("other-nonce") },
+			wantErr: ErrNonceMismatch,
+		},
+		{
+			name: "Should refuse a token meant for another client",
+			claims: func() jwt.MapClaims {
+				claims := issuer.claims("nonce")
+				claims["aud"] = "other-client"
+				return claims
+			},
+			wantErr: ErrInvalidIDToken,
+		},
+		{
+			name: "Should refuse a token of another issuer",
+			claims: func() jwt.MapClaims {
+				claims := issuer.claims("nonce")
+				claims["iss"] = "https://other-issuer"
+				return claims
+			},
+			wantErr: ErrInvalidIDToken,
+		},
+		{
+			name: "Should refuse an expired token",
+			claims: func() jwt.MapClaims {
+				claims := issuer.claims("nonce")
+				claims["exp"] = time.Now().Add(-time.Hour).Unix()
+				return claims
+			},
+			wantErr: ErrInvalidIDToken,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := Discover(ctx, issuer.config(), issuer.server.Client())
+
+			idToken, err := p.VerifyIDToken(ctx, issuer.sign(t, tt.claims()), "nonce")
+
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), err)
+				assert.Nil(t, idToken)
+				return
+			}
+
+			assert.Nil(t, err)
+			assert.Equal(t, &IDToken{Subject: "subject", Email: "first@email.com", EmailVerified: true, Name: "First User"}, idToken)
+		})
+	}
+
+	t.Run("Should refuse a token signed with a shared secret", func(t *testing.T) {
+		p, _ := Discover(ctx, issuer.config(), issuer.server.Client())
+
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, issuer.claims("nonce"))
+		token.Header["kid"] = issuer.keyID
+		rawIDToken, _ := token.SignedString([]byte("client-secret"))
+
+		_, err := p.VerifyIDToken(ctx, rawIDToken, "nonce")
+
+		assert.True(t, errors.Is(err, ErrInvalidIDToken))
+	})
+
+	t.Run("Should fetch the keys again after the provider rotates them", func(t *testing.T) {
+		p, _ := Discover(ctx, issuer.config(), issuer.server.Client())
+
+		_, err := p.VerifyIDToken(ctx, issuer.sign(t, issuer.claims("nonce")), "nonce")
+		assert.Nil(t, err)
+
+		issuer.rotateKey(t, "key-2")
+		rawIDToken := issuer.sign(t, issuer.claims("nonce"))
+
+		_, err = p.VerifyIDToken(ctx, rawIDToken, "nonce")
+		assert.True(t, errors.Is(err, ErrInvalidIDToken), "keys are not fetched again right away")
+
+		p.keysFetched = time.Now().Add(-keysRefreshInterval)
+
+		_, err = p.VerifyIDToken(ctx, rawIDToken, "nonce")
+		assert.Nil(t, err)
+	})
+}
+
+func TestCodeChallenge(t *testing.T) {
+	// example of RFC 7636 appendix B
+	assert.Equal(t, "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM", CodeChallenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"))
+
+	verifier, err := NewCodeVerifier()
+	assert.Nil(t, err)
+	assert.Len(t, verifier, 43)
+	assert.False(t, strings.ContainsAny(verifier, "+/="))
+}
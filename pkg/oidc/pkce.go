@@ -0,0 +1,19 @@
+package oidc
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+
+	"github.com/WalterPaes/go-rest-api-crud/pkg/randtoken"
+)
+
+// NewCodeVerifier returns a PKCE code verifier, 43 characters long as RFC 7636 asks.
+func NewCodeVerifier() (string, error) {
+	return randtoken.Generate(32)
+}
+
+// CodeChallenge is the S256 challenge of the verifier, sent on the authorization request.
+func CodeChallenge(codeVerifier string) string {
+	sum := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,30 @@
+package randtoken
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+const defaultSize = 32
+
+// Generate returns a random url-safe token with size bytes of entropy.
+func Generate(size int) (string, error) {
+	if size <= 0 {
+		size = defaultSize
+	}
+
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Hash returns the hex encoded sha256 of the token, the value stored server-side.
+func Hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -1,7 +1,9 @@
 package resterrors
 
 import (
+	"math"
 	"net/http"
+	"time"
 )
 
 const (
@@ -10,12 +12,16 @@ const (
 	notFound            = "Not Found"
 	forbidden           = "Forbidden"
 	unathorized         = "Unauthorized"
+	tooManyRequests     = "Too Many Requests"
+	conflict            = "Conflict"
 )
 
 type RestErr struct {
 	Message        string  `json:"message"`
 	HttpErr        string  `json:"http_error"`
 	HttpStatusCode int     `json:"status_code"`
+	Code           string  `json:"code,omitempty"`
+	RetryAfter     int     `json:"retry_after,omitempty"`
 	Errors         []error `json:"errors,omitempty"`
 }
 
@@ -23,6 +29,13 @@ func (r *RestErr) Error() string {
 	return r.Message
 }
 
+// WithCode sets a machine readable code, letting clients tell apart errors
+// sharing the same status code.
+func (r *RestErr) WithCode(code string) *RestErr {
+	r.Code = code
+	return r
+}
+
 func NewRestErr(message, err string, code int, errors []error) *RestErr {
 	return &RestErr{
 		Message:        message,
@@ -80,3 +93,22 @@ func NewForbiddenError(message string) *RestErr {
 		HttpStatusCode: http.StatusForbidden,
 	}
 }
+
+func NewConflictError(message string) *RestErr {
+	return &RestErr{
+		Message:        message,
+		HttpErr:        conflict,
+		HttpStatusCode: http.StatusConflict,
+	}
+}
+
+// NewTooManyRequestsError builds the error of a throttled request, retryAfter is
+// rounded up to seconds to be sent in the Retry-After header.
+func NewTooManyRequestsError(message string, retryAfter time.Duration) *RestErr {
+	return &RestErr{
+		Message:        message,
+		HttpErr:        tooManyRequests,
+		HttpStatusCode: http.StatusTooManyRequests,
+		RetryAfter:     int(math.Ceil(retryAfter.Seconds())),
+	}
+}
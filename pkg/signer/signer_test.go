@@ -0,0 +1,86 @@
+package signer
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type payload struct {
+	UserID string `json:"uid"`
+	Email  string `json:"email"`
+}
+
+func newSigner(t *testing.T, secret string) *Signer {
+	s, err := New(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func Test_New(t *testing.T) {
+	t.Run("Should refuse an empty secret", func(t *testing.T) {
+		s, err := New("")
+
+		assert.ErrorIs(t, err, ErrEmptySecret)
+		assert.Nil(t, s)
+	})
+}
+
+func Test_Signer(t *testing.T) {
+	data := payload{UserID: "user_id", Email: "user@email.com"}
+
+	t.Run("Should verify a signed token and decode its data", func(t *testing.T) {
+		s := newSigner(t, "secret")
+
+		token, err := s.Sign(data, time.Now().Add(time.Hour))
+		assert.Nil(t, err)
+
+		var got payload
+		assert.Nil(t, s.Verify(token, &got))
+		assert.Equal(t, data, got)
+	})
+
+	t.Run("Should verify a token without expiration", func(t *testing.T) {
+		s := newSigner(t, "secret")
+
+		token, err := s.Sign(data, time.Time{})
+		assert.Nil(t, err)
+
+		var got payload
+		assert.Nil(t, s.Verify(token, &got))
+	})
+
+	t.Run("Should reject a token signed with another secret", func(t *testing.T) {
+		token, _ := newSigner(t, "other").Sign(data, time.Now().Add(time.Hour))
+
+		assert.ErrorIs(t, newSigner(t, "secret").Verify(token, &payload{}), ErrInvalidSignature)
+	})
+
+	t.Run("Should reject a token with a tampered payload", func(t *testing.T) {
+		s := newSigner(t, "secret")
+
+		token, _ := s.Sign(data, time.Now().Add(time.Hour))
+		other, _ := s.Sign(payload{UserID: "other_user_id"}, time.Now().Add(time.Hour))
+
+		_, signature, _ := strings.Cut(token, ".")
+		encoded, _, _ := strings.Cut(other, ".")
+
+		assert.ErrorIs(t, s.Verify(encoded+"."+signature, &payload{}), ErrInvalidSignature)
+	})
+
+	t.Run("Should reject an expired token", func(t *testing.T) {
+		s := newSigner(t, "secret")
+
+		token, _ := s.Sign(data, time.Now().Add(-time.Second))
+
+		assert.ErrorIs(t, s.Verify(token, &payload{}), ErrExpired)
+	})
+
+	t.Run("Should reject a malformed token", func(t *testing.T) {
+		assert.ErrorIs(t, newSigner(t, "secret").Verify("malformed", &payload{}), ErrMalformed)
+	})
+}
@@ -0,0 +1,82 @@
+package totp
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// rfcSecret is the base32 of the ascii secret "12345678901234567890" used by the
+// test vectors of RFC 6238.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCode(t *testing.T) {
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "287082"},
+		{unix: 1111111109, want: "081804"},
+		{unix: 1111111111, want: "050471"},
+		{unix: 1234567890, want: "005924"},
+		{unix: 2000000000, want: "279037"},
+	}
+	for _, tt := range tests {
+		got, err := Code(rfcSecret, Counter(time.Unix(tt.unix, 0)))
+		assert.Nil(t, err)
+		assert.Equal(t, tt.want, got)
+	}
+
+	_, err := Code("not base32!", 1)
+	assert.ErrorIs(t, err, ErrInvalidSecret)
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+
+	t.Run("Should accept the code of the current step", func(t *testing.T) {
+		counter, ok := Validate(rfcSecret, "081804", now)
+		assert.True(t, ok)
+		assert.Equal(t, Counter(now), counter)
+	})
+
+	t.Run("Should accept the code of the previous step", func(t *testing.T) {
+		counter, ok := Validate(rfcSecret, "081804", now.Add(Period*time.Second))
+		assert.True(t, ok)
+		assert.Equal(t, Counter(now), counter)
+	})
+
+	t.Run("Should refuse the code of an old step", func(t *testing.T) {
+		_, ok := Validate(rfcSecret, "081804", now.Add(3*Period*time.Second))
+		assert.False(t, ok)
+	})
+
+	t.Run("Should refuse a code with the wrong size", func(t *testing.T) {
+		_, ok := Validate(rfcSecret, "81804", now)
+		assert.False(t, ok)
+	})
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	assert.Nil(t, err)
+
+	code, err := Code(secret, Counter(time.Now()))
+	assert.Nil(t, err)
+
+	_, ok := Validate(secret, code, time.Now())
+	assert.True(t, ok)
+}
+
+func TestURI(t *testing.T) {
+	uri, err := url.Parse(URI("Go Users", "user@email.com", rfcSecret))
+	assert.Nil(t, err)
+
+	assert.Equal(t, "otpauth", uri.Scheme)
+	assert.Equal(t, "totp", uri.Host)
+	assert.Equal(t, "/Go Users:user@email.com", uri.Path)
+	assert.Equal(t, rfcSecret, uri.Query().Get("secret"))
+	assert.Equal(t, "Go Users", uri.Query().Get("issuer"))
+}
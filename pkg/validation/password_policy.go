@@ -0,0 +1,145 @@
+package validation
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	resterrors "github.com/WalterPaes/go-rest-api-crud/pkg/rest_errors"
+)
+
+const (
+	errPasswordPolicy       = "Some fields are invalid"
+	errPasswordMinLength    = "%s must be at least %d characters in length"
+	errPasswordMaxLength    = "%s must be a maximum of %d characters in length"
+	errPasswordUpper        = "%s must contain an uppercase letter"
+	errPasswordLower        = "%s must contain a lowercase letter"
+	errPasswordDigit        = "%s must contain a digit"
+	errPasswordSymbol       = "%s must contain a symbol"
+	errPasswordPersonalInfo = "%s must not contain the name or the email of the user"
+	errPasswordCommon       = "%s is too common"
+
+	// personalInfoMinLength ignores short parts of the name, like "de" or "da".
+	personalInfoMinLength = 3
+)
+
+// PasswordPolicy checks the strength of the new passwords. It is applied when a
+// password is set, never on login, so changing the rules doesn't lock anyone out.
+// A zero value rule is not checked.
+type PasswordPolicy struct {
+	MinLength     int
+	MaxLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+
+	denyList map[string]struct{}
+}
+
+// LoadDenyList reads the common passwords refused by the policy, one per line.
+// Empty lines and lines starting with # are skipped, the match ignores the case.
+func (p *PasswordPolicy) LoadDenyList(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	denyList := make(map[string]struct{})
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		denyList[strings.ToLower(line)] = struct{}{}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	p.denyList = denyList
+	return nil
+}
+
+// Check returns a validation error with every rule broken by the password. The
+// field is the key of the errors and personalInfo, like the name and the email of
+// the user, must not be found in the password.
+func (p *PasswordPolicy) Check(field, password string, personalInfo ...string) *resterrors.RestErr {
+	var causes []error
+
+	addCause := func(message string, args ...any) {
+		causes = append(causes, &ValidationError{
+			Key:     field,
+			Message: fmt.Sprintf(message, append([]any{field}, args...)...),
+		})
+	}
+
+	length := utf8.RuneCountInString(password)
+	if p.MinLength > 0 && length < p.MinLength {
+		addCause(errPasswordMinLength, p.MinLength)
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		addCause(errPasswordMaxLength, p.MaxLength)
+	}
+
+	if p.RequireUpper && !containsFunc(password, unicode.IsUpper) {
+		addCause(errPasswordUpper)
+	}
+	if p.RequireLower && !containsFunc(password, unicode.IsLower) {
+		addCause(errPasswordLower)
+	}
+	if p.RequireDigit && !containsFunc(password, unicode.IsDigit) {
+		addCause(errPasswordDigit)
+	}
+	if p.RequireSymbol && !containsFunc(password, isSymbol) {
+		addCause(errPasswordSymbol)
+	}
+
+	if containsPersonalInfo(password, personalInfo) {
+		addCause(errPasswordPersonalInfo)
+	}
+
+	if _, denied := p.denyList[strings.ToLower(password)]; denied {
+		addCause(errPasswordCommon)
+	}
+
+	if len(causes) > 0 {
+		return resterrors.NewBadRequestValidationError(errPasswordPolicy, causes)
+	}
+	return nil
+}
+
+func containsFunc(s string, f func(rune) bool) bool {
+	return strings.IndexFunc(s, f) >= 0
+}
+
+func isSymbol(r rune) bool {
+	return unicode.IsPunct(r) || unicode.IsSymbol(r)
+}
+
+// containsPersonalInfo looks for the words of the personal info in the password,
+// only the local part of an email is checked, the domains are shared by many users.
+func containsPersonalInfo(password string, personalInfo []string) bool {
+	password = strings.ToLower(password)
+
+	for _, info := range personalInfo {
+		localPart, _, _ := strings.Cut(strings.ToLower(info), "@")
+		words := strings.FieldsFunc(localPart, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+
+		for _, word := range words {
+			if utf8.RuneCountInString(word) >= personalInfoMinLength && strings.Contains(password, word) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package validation
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func causesMessages(t *testing.T, causes []error) []string {
+	t.Helper()
+
+	messages := make([]string, 0, len(causes))
+	for _, cause := range causes {
+		validationErr, ok := cause.(*ValidationError)
+		assert.True(t, ok)
+		assert.Equal(t, "NewPassword", validationErr.Key)
+		messages = append(messages, validationErr.Message)
+	}
+	return messages
+}
+
+func Test_PasswordPolicy_Check(t *testing.T) {
+	policy := &PasswordPolicy{
+		MinLength:     8,
+		MaxLength:     16,
+		RequireUpper:  true,
+		RequireLower:  true,
+		RequireDigit:  true,
+		RequireSymbol: true,
+	}
+
+	t.Run("Should accept a password following every rule", func(t *testing.T) {
+		assert.Nil(t, policy.Check("NewPassword", "Str0ng!pass", "First User", "firstuser@email.com"))
+	})
+
+	t.Run("Should return a message for every rule broken", func(t *testing.T) {
+		err := policy.Check("NewPassword", "abc")
+
+		assert.Equal(t, http.StatusBadRequest, err.HttpStatusCode)
+		assert.Equal(t, []string{
+			"NewPassword must be at least 8 characters in length",
+			"NewPassword must contain an uppercase letter",
+			"NewPassword must contain a digit",
+			"NewPassword must contain a symbol",
+		}, causesMessages(t, err.Errors))
+	})
+
+	t.Run("Should refuse a password too long", func(t *testing.T) {
+		err := policy.Check("NewPassword", "Str0ng!password-too-long")
+
+		assert.Equal(t, []string{"NewPassword must be a maximum of 16 characters in length"}, causesMessages(t, err.Errors))
+	})
+
+	t.Run("Should refuse a password with the name or the email of the user", func(t *testing.T) {
+		for _, password := range []string{"Walter!2024", "w4lter.PAES!", "Jsilva#1990"} {
+			err := policy.Check("NewPassword", password, "Walter Paes", "j.silva@email.com")
+
+			if assert.NotNil(t, err, password) {
+				assert.Equal(t, []string{"NewPassword must not contain the name or the email of the user"}, causesMessages(t, err.Errors))
+			}
+		}
+	})
+
+	t.Run("Should ignore the short words and the domain of the email", func(t *testing.T) {
+		assert.Nil(t, policy.Check("NewPassword", "Email.c0m!de", "Jo de Souza", "jo@email.com"))
+	})
+
+	t.Run("Should not check the rules with zero values", func(t *testing.T) {
+		assert.Nil(t, (&PasswordPolicy{}).Check("NewPassword", "a"))
+	})
+}
+
+func Test_PasswordPolicy_LoadDenyList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "common_passwords.txt")
+	if err := os.WriteFile(path, []byte("# comment\n\nPassword123\nqwerty\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	policy := &PasswordPolicy{}
+
+	t.Run("Should refuse the passwords of the deny list ignoring the case", func(t *testing.T) {
+		assert.Nil(t, policy.LoadDenyList(path))
+
+		err := policy.Check("NewPassword", "PASSWORD123")
+		if assert.NotNil(t, err) {
+			assert.Equal(t, []string{"NewPassword is too common"}, causesMessages(t, err.Errors))
+		}
+
+		assert.Nil(t, policy.Check("NewPassword", "# comment"))
+	})
+
+	t.Run("Should return an error when the file can't be read", func(t *testing.T) {
+		assert.NotNil(t, policy.LoadDenyList(filepath.Join(t.TempDir(), "missing.txt")))
+	})
+
+	t.Run("Should load the deny list shipped with the configs", func(t *testing.T) {
+		assert.Nil(t, (&PasswordPolicy{}).LoadDenyList("../../configs/common_passwords.txt"))
+	})
+}